@@ -114,7 +114,7 @@ func buildRouter() *gin.Engine {
 		log.Fatalf("trusted proxies: %v", err)
 	}
 	r.Use(security.SecurityHeaders())
-	r.Use(security.MaxBodyBytes(security.MaxRequestBodyBytes))
+	r.Use(security.MaxBodyBytes(security.MaxRequestBodyBytes()))
 	r.Use(security.OriginGuard())
 	// Dev CORS allowlist: only installed in non-production mode so that a
 	// developer can `npm run dev` the frontend against a running desktop
@@ -125,7 +125,7 @@ func buildRouter() *gin.Engine {
 		r.Use(cors.New(cors.Config{
 			AllowOrigins:     security.DevOrigins(),
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Claude-Session-ID"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Claude-Session-ID", "X-Confirm-Production"},
 			ExposeHeaders:    []string{"Content-Length"},
 			AllowCredentials: false,
 			MaxAge:           12 * time.Hour,
@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -19,8 +23,15 @@ var (
 	backendURL   string
 	sessionID    string
 	sessionToken string
+	// backendTimeout bounds each backend call; PGVOYAGER_MCP_TIMEOUT
+	// raises it for databases where execute_query is slow.
+	backendTimeout = defaultBackendTimeout
 )
 
+const defaultBackendTimeout = 30 * time.Second
+
+var httpClient = &http.Client{}
+
 func main() {
 	// Get backend URL and session ID from environment
 	backendURL = os.Getenv("PGVOYAGER_BACKEND_URL")
@@ -43,6 +54,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if value := os.Getenv("PGVOYAGER_MCP_TIMEOUT"); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "PGVOYAGER_MCP_TIMEOUT: %v\n", err)
+			os.Exit(1)
+		}
+		backendTimeout = timeout
+	}
+
 	// Create MCP server
 	s := server.NewMCPServer(
 		"PgVoyager Database Tools",
@@ -61,7 +81,86 @@ func main() {
 	}
 }
 
-// callBackendAPI makes a request to the PgVoyager backend
+// parseTimeout reads a timeout as a Go duration ("2m", "90s") or a whole
+// number of seconds.
+func parseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.Atoi(value); err == nil {
+		value += "s"
+		if secs <= 0 {
+			return 0, fmt.Errorf("must be positive, got %q", value)
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a duration like 90s or 2m, got %q", value)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %q", value)
+	}
+	return d, nil
+}
+
+// Kinds of backendError, telling the model whether retrying, waiting for
+// the app or changing the request is the way forward.
+const (
+	errorKindTimeout     = "timeout"            // no response within backendTimeout
+	errorKindCanceled    = "canceled"           // the tool call was canceled
+	errorKindRefused     = "connection_refused" // PgVoyager isn't listening
+	errorKindUnreachable = "unreachable"        // any other network failure
+	errorKindAPI         = "api_error"          // the backend answered with an error
+)
+
+// backendError is a failed backend call, reported to the model as JSON.
+type backendError struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	Status  int    `json:"status,omitempty"`
+}
+
+func (e *backendError) Error() string {
+	if e.Status != 0 {
+		return fmt.Sprintf("%s (%d): %s", e.Kind, e.Status, e.Message)
+	}
+	return e.Kind + ": " + e.Message
+}
+
+// classifyRequestError turns a failed HTTP round trip into a backendError.
+// parent is the tool call's context, to tell its cancellation apart from
+// the call timing out.
+func classifyRequestError(parent context.Context, err error) *backendError {
+	switch {
+	case parent.Err() != nil:
+		return &backendError{Kind: errorKindCanceled, Message: "the request was canceled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &backendError{Kind: errorKindTimeout, Message: fmt.Sprintf(
+			"PgVoyager did not respond within %s; the query may still be running. Narrow it or ask the user to raise PGVOYAGER_MCP_TIMEOUT", backendTimeout)}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return &backendError{Kind: errorKindRefused, Message: fmt.Sprintf(
+			"PgVoyager is not accepting connections at %s; it may have been closed or restarted", backendURL)}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &backendError{Kind: errorKindTimeout, Message: err.Error()}
+	}
+	return &backendError{Kind: errorKindUnreachable, Message: err.Error()}
+}
+
+// toolError reports a failed tool call. Backend errors are sent as JSON
+// ({"kind", "message", "status"}) after what failed, so the model can tell
+// a timeout from the app being gone or a bad query.
+func toolError(what string, err error) *mcp.CallToolResult {
+	var be *backendError
+	if errors.As(err, &be) {
+		if b, jerr := json.Marshal(be); jerr == nil {
+			return mcp.NewToolResultError(what + ": " + string(b))
+		}
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("%s: %v", what, err))
+}
+
+// callBackendAPI makes a request to the PgVoyager backend, giving up after
+// backendTimeout or when ctx is canceled. Failures are *backendError.
 func callBackendAPI(ctx context.Context, method, endpoint string, body interface{}) ([]byte, error) {
 	var reqBody io.Reader
 	if body != nil {
@@ -73,7 +172,9 @@ func callBackendAPI(ctx context.Context, method, endpoint string, body interface
 	}
 
 	url := fmt.Sprintf("%s%s", backendURL, endpoint)
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	reqCtx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -82,25 +183,36 @@ func callBackendAPI(ctx context.Context, method, endpoint string, body interface
 	req.Header.Set("X-Claude-Session-ID", sessionID)
 	req.Header.Set("Authorization", "Bearer "+sessionToken)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, classifyRequestError(ctx, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, classifyRequestError(ctx, err)
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(respBody))
+		return nil, &backendError{Kind: errorKindAPI, Status: resp.StatusCode, Message: apiErrorMessage(respBody)}
 	}
 
 	return respBody, nil
 }
 
+// apiErrorMessage pulls the message out of the backend's {"error": ...}
+// responses, falling back to the raw body.
+func apiErrorMessage(body []byte) string {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		return payload.Error
+	}
+	return strings.TrimSpace(string(body))
+}
+
 func registerDatabaseTools(s *server.MCPServer) {
 	// Editor tools
 	getEditorContent := mcp.NewTool("get_editor_content",
@@ -119,6 +231,7 @@ func registerDatabaseTools(s *server.MCPServer) {
 	replaceEditorContent := mcp.NewTool("replace_editor_content",
 		mcp.WithDescription("Replace the entire content of the SQL query editor. Use this when you want to provide a complete new query."),
 		mcp.WithString("content", mcp.Required(), mcp.Description("The new content for the editor")),
+		mcp.WithBoolean("preview", mcp.Description("Show the change to the user as a diff and only apply it if they accept. Use for large or destructive rewrites.")),
 	)
 	s.AddTool(replaceEditorContent, handleReplaceEditorContent)
 
@@ -153,12 +266,28 @@ func registerDatabaseTools(s *server.MCPServer) {
 
 	// Execute query tool
 	executeQuery := mcp.NewTool("execute_query",
-		mcp.WithDescription("Execute a SQL query on the currently connected database and return the results. Use this to run SELECT queries to explore data. Be careful with INSERT/UPDATE/DELETE queries."),
+		mcp.WithDescription("Execute a SQL query on the currently connected database and return the results. Use this to run SELECT queries to explore data. Statements that write (INSERT/UPDATE/DELETE/DDL) are rejected unless the user has enabled writes for this session, and always on a connection tagged production."),
 		mcp.WithString("sql", mcp.Required(), mcp.Description("The SQL query to execute")),
-		mcp.WithNumber("limit", mcp.Description("Maximum number of rows to return (default 100, max 1000)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of rows to return (default 100; PgVoyager caps it at the mcp.max_query_rows preference, 1000 unless changed)")),
 	)
 	s.AddTool(executeQuery, handleExecuteQuery)
 
+	// Saved query tools
+	listSavedQueries := mcp.NewTool("list_saved_queries",
+		mcp.WithDescription("List the user's saved queries that apply to the current connection, with their SQL and parameters. Optionally filter by tag or folder."),
+		mcp.WithString("tag", mcp.Description("Optional tag to filter by (case-insensitive)")),
+		mcp.WithString("folder", mcp.Description("Optional folder to filter by")),
+	)
+	s.AddTool(listSavedQueries, handleListSavedQueries)
+
+	runSavedQuery := mcp.NewTool("run_saved_query",
+		mcp.WithDescription("Run one of the user's saved queries by ID (see list_saved_queries) and return the results. Writes are rejected unless the user has enabled them for this session, as with execute_query."),
+		mcp.WithString("id", mcp.Required(), mcp.Description("The saved query ID")),
+		mcp.WithObject("params", mcp.Description("Values for the query's parameters, by name; parameters with a default may be left out")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of rows to return (default 100; capped like execute_query)")),
+	)
+	s.AddTool(runSavedQuery, handleRunSavedQuery)
+
 	// List views tool
 	listViews := mcp.NewTool("list_views",
 		mcp.WithDescription("List database views in the currently connected database. Optionally filter by schema."),
@@ -189,6 +318,15 @@ func registerDatabaseTools(s *server.MCPServer) {
 	)
 	s.AddTool(getIndexes, handleGetIndexes)
 
+	// Sample table rows
+	sampleTable := mcp.NewTool("sample_table",
+		mcp.WithDescription("Fetch the first rows of a table to see what its data looks like. Read-only."),
+		mcp.WithString("schema", mcp.Required(), mcp.Description("The schema containing the table")),
+		mcp.WithString("table", mcp.Required(), mcp.Description("The table name")),
+		mcp.WithNumber("rows", mcp.Description("Number of rows to return (default: 10, max: 100)")),
+	)
+	s.AddTool(sampleTable, handleSampleTable)
+
 	// Get current connection info
 	getConnectionInfo := mcp.NewTool("get_connection_info",
 		mcp.WithDescription("Get information about the currently active database connection."),
@@ -199,7 +337,7 @@ func registerDatabaseTools(s *server.MCPServer) {
 func handleListSchemas(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	resp, err := callBackendAPI(ctx, "GET", "/api/mcp/schemas", nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to list schemas: %v", err)), nil
+		return toolError("Failed to list schemas", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -214,7 +352,7 @@ func handleListTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to list tables: %v", err)), nil
+		return toolError("Failed to list tables", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -232,7 +370,7 @@ func handleGetColumns(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	endpoint := fmt.Sprintf("/api/mcp/tables/%s/%s/columns", url.PathEscape(schema), url.PathEscape(table))
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get columns: %v", err)), nil
+		return toolError("Failed to get columns", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -250,7 +388,7 @@ func handleGetTableInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	endpoint := fmt.Sprintf("/api/mcp/tables/%s/%s", url.PathEscape(schema), url.PathEscape(table))
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get table info: %v", err)), nil
+		return toolError("Failed to get table info", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -265,22 +403,69 @@ func handleExecuteQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	args := request.GetArguments()
 	if limitVal, ok := args["limit"].(float64); ok {
 		limit = int(limitVal)
-		if limit > 1000 {
-			limit = 1000
-		}
 		if limit < 1 {
 			limit = 1
 		}
 	}
 
 	body := map[string]interface{}{
-		"sql":   sql,
-		"limit": limit,
+		"sql":       sql,
+		"limit":     limit,
+		"timeoutMs": backendTimeout.Milliseconds(),
 	}
 
 	resp, err := callBackendAPI(ctx, "POST", "/api/mcp/query", body)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Query failed: %v", err)), nil
+		return toolError("Query failed", err), nil
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+func handleListSavedQueries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := url.Values{}
+	if tag := request.GetString("tag", ""); tag != "" {
+		query.Set("tag", tag)
+	}
+	if folder := request.GetString("folder", ""); folder != "" {
+		query.Set("folder", folder)
+	}
+
+	endpoint := "/api/mcp/queries"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return toolError("Failed to list saved queries", err), nil
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+func handleRunSavedQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError("id parameter is required"), nil
+	}
+
+	limit := 100
+	args := request.GetArguments()
+	if limitVal, ok := args["limit"].(float64); ok {
+		limit = max(int(limitVal), 1)
+	}
+
+	body := map[string]interface{}{
+		"limit":     limit,
+		"timeoutMs": backendTimeout.Milliseconds(),
+	}
+	if params, ok := args["params"].(map[string]interface{}); ok {
+		body["params"] = params
+	}
+
+	endpoint := fmt.Sprintf("/api/mcp/queries/%s/run", url.PathEscape(id))
+	resp, err := callBackendAPI(ctx, "POST", endpoint, body)
+	if err != nil {
+		return toolError("Saved query failed", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -295,7 +480,7 @@ func handleListViews(ctx context.Context, request mcp.CallToolRequest) (*mcp.Cal
 
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to list views: %v", err)), nil
+		return toolError("Failed to list views", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -310,7 +495,7 @@ func handleListFunctions(ctx context.Context, request mcp.CallToolRequest) (*mcp
 
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to list functions: %v", err)), nil
+		return toolError("Failed to list functions", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -328,7 +513,7 @@ func handleGetForeignKeys(ctx context.Context, request mcp.CallToolRequest) (*mc
 	endpoint := fmt.Sprintf("/api/mcp/tables/%s/%s/foreign-keys", url.PathEscape(schema), url.PathEscape(table))
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get foreign keys: %v", err)), nil
+		return toolError("Failed to get foreign keys", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -346,7 +531,30 @@ func handleGetIndexes(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	endpoint := fmt.Sprintf("/api/mcp/tables/%s/%s/indexes", url.PathEscape(schema), url.PathEscape(table))
 	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get indexes: %v", err)), nil
+		return toolError("Failed to get indexes", err), nil
+	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
+
+func handleSampleTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	schema, err := request.RequireString("schema")
+	if err != nil {
+		return mcp.NewToolResultError("schema parameter is required"), nil
+	}
+	table, err := request.RequireString("table")
+	if err != nil {
+		return mcp.NewToolResultError("table parameter is required"), nil
+	}
+	rows := 10
+	args := request.GetArguments()
+	if rowsVal, ok := args["rows"].(float64); ok {
+		rows = min(max(int(rowsVal), 1), 100)
+	}
+
+	endpoint := fmt.Sprintf("/api/mcp/tables/%s/%s/sample?limit=%d", url.PathEscape(schema), url.PathEscape(table), rows)
+	resp, err := callBackendAPI(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return toolError("Failed to sample table", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -354,7 +562,7 @@ func handleGetIndexes(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 func handleGetConnectionInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	resp, err := callBackendAPI(ctx, "GET", "/api/mcp/connection", nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get connection info: %v", err)), nil
+		return toolError("Failed to get connection info", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -362,7 +570,7 @@ func handleGetConnectionInfo(ctx context.Context, request mcp.CallToolRequest) (
 func handleGetEditorContent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	resp, err := callBackendAPI(ctx, "GET", "/api/mcp/editor", nil)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get editor content: %v", err)), nil
+		return toolError("Failed to get editor content", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -389,7 +597,7 @@ func handleInsertToEditor(ctx context.Context, request mcp.CallToolRequest) (*mc
 
 	resp, err := callBackendAPI(ctx, "POST", "/api/mcp/editor/insert", body)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert to editor: %v", err)), nil
+		return toolError("Failed to insert to editor", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -402,11 +610,12 @@ func handleReplaceEditorContent(ctx context.Context, request mcp.CallToolRequest
 
 	body := map[string]interface{}{
 		"content": content,
+		"preview": request.GetBool("preview", false),
 	}
 
 	resp, err := callBackendAPI(ctx, "POST", "/api/mcp/editor/replace", body)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to replace editor content: %v", err)), nil
+		return toolError("Failed to replace editor content", err), nil
 	}
 	return mcp.NewToolResultText(string(resp)), nil
 }
@@ -54,7 +54,7 @@ func main() {
 		log.Fatalf("failed to clear trusted proxies: %v", err)
 	}
 	r.Use(security.SecurityHeaders())
-	r.Use(security.MaxBodyBytes(security.MaxRequestBodyBytes))
+	r.Use(security.MaxBodyBytes(security.MaxRequestBodyBytes()))
 	// OriginGuard blocks browser-mediated CSRF from any page the user
 	// visits — even with bind-localhost, a malicious site can `fetch()`
 	// to http://localhost:5137 and Origin would be the attacker. Empty
@@ -73,7 +73,7 @@ func main() {
 		r.Use(cors.New(cors.Config{
 			AllowOrigins:     security.DevOrigins(),
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Claude-Session-ID"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Claude-Session-ID", "X-Confirm-Production"},
 			ExposeHeaders:    []string{"Content-Length"},
 			AllowCredentials: false,
 			MaxAge:           12 * time.Hour,
@@ -14,16 +14,28 @@ func RegisterRoutes(r *gin.Engine) {
 			connections.GET("", handlers.ListConnections)
 			connections.POST("", handlers.CreateConnection)
 			connections.POST("/test", handlers.TestConnection)
+			connections.GET("/export", handlers.ExportConnections)
+			connections.POST("/import", handlers.ImportConnections)
 			connections.GET("/:id", handlers.GetConnection)
 			connections.PUT("/:id", handlers.UpdateConnection)
 			connections.DELETE("/:id", handlers.DeleteConnection)
+			connections.POST("/:id/duplicate", handlers.DuplicateConnection)
+			connections.POST("/:id/favorite", handlers.SetConnectionFavorite)
 			connections.POST("/:id/connect", handlers.Connect)
 			connections.POST("/:id/disconnect", handlers.Disconnect)
+			connections.GET("/:id/pool-stats", handlers.GetPoolStats)
+			connections.GET("/:id/health", handlers.GetConnectionHealth)
 			connections.POST("/:id/switch-database", handlers.SwitchDatabase)
-			connections.POST("/:id/databases", handlers.CreateDatabase)
-			connections.DELETE("/:id/databases/:name", handlers.DropDatabase)
+			connections.POST("/:id/databases", handlers.RequireProductionConfirmation(), handlers.CreateDatabase)
+			connections.DELETE("/:id/databases/:name", handlers.RequireProductionConfirmation(), handlers.DropDatabase)
 		}
 
+		// Schema comparison between two active connections
+		api.POST("/schema/diff", handlers.DiffSchemas)
+
+		// SQL formatting for the editor; needs no connection
+		api.POST("/query/format", handlers.FormatSQL)
+
 		// Schema browsing (requires active connection)
 		schema := api.Group("/schema/:connId")
 		{
@@ -32,28 +44,51 @@ func RegisterRoutes(r *gin.Engine) {
 			schema.GET("/schemas", handlers.ListSchemas)
 			schema.GET("/tables", handlers.ListTables)
 			schema.GET("/tables/:schema/:table", handlers.GetTableInfo)
+			schema.GET("/tables/:schema/:table/describe", handlers.DescribeTable)
+			schema.GET("/tables/:schema/:table/partitions", handlers.GetTablePartitions)
 			schema.GET("/tables/:schema/:table/columns", handlers.GetTableColumns)
+			schema.PUT("/tables/:schema/:table/comment", handlers.RequireProductionConfirmation(), handlers.SetTableComment)
+			schema.PUT("/tables/:schema/:table/columns/:column/comment", handlers.RequireProductionConfirmation(), handlers.SetColumnComment)
+			schema.POST("/tables/:schema/:table/columns/:column/rename", handlers.RequireProductionConfirmation(), handlers.RenameColumn)
+			schema.POST("/tables/:schema/:table/columns/:column/change-type", handlers.RequireProductionConfirmation(), handlers.ChangeColumnType)
 			schema.GET("/all-columns", handlers.GetAllColumns)
 			schema.GET("/tables/:schema/:table/constraints", handlers.GetTableConstraints)
 			schema.GET("/tables/:schema/:table/indexes", handlers.GetTableIndexes)
+			schema.POST("/indexes", handlers.RequireProductionConfirmation(), handlers.CreateIndex)
+			schema.DELETE("/indexes/:schema/:name", handlers.RequireProductionConfirmation(), handlers.DropIndex)
 			schema.GET("/tables/:schema/:table/foreign-keys", handlers.GetForeignKeys)
+			schema.GET("/tables/:schema/:table/triggers", handlers.GetTableTriggers)
 			schema.GET("/schemas/:schema/relationships", handlers.GetSchemaRelationships)
+			schema.GET("/erd/:schema", handlers.GetERDiagram)
 			schema.GET("/views", handlers.ListViews)
+			schema.GET("/views/:schema/:view/dependencies", handlers.GetViewDependencies)
+			schema.GET("/materialized-views", handlers.ListMaterializedViews)
+			schema.POST("/materialized-views/:schema/:name/refresh", handlers.RequireProductionConfirmation(), handlers.RefreshMaterializedView)
 			schema.GET("/functions", handlers.ListFunctions)
 			schema.GET("/sequences", handlers.ListSequences)
+			schema.POST("/sequences/:schema/:name/setval", handlers.RequireProductionConfirmation(), handlers.SetSequenceValue)
+			schema.POST("/sequences/:schema/:name/alter", handlers.RequireProductionConfirmation(), handlers.AlterSequence)
 			schema.GET("/types", handlers.ListTypes)
+			schema.GET("/triggers", handlers.ListTriggers)
+			schema.GET("/roles", handlers.ListRoles)
+			schema.GET("/extensions", handlers.ListExtensions)
+			schema.GET("/objects/:schema/:name/dependencies", handlers.GetObjectDependencies)
 		}
 
 		// Data operations
-		data := api.Group("/data/:connId")
+		data := api.Group("/data/:connId", handlers.RequireProductionConfirmation())
 		{
 			data.GET("/tables/:schema/:table", handlers.GetTableData)
 			data.GET("/tables/:schema/:table/count", handlers.GetTableRowCount)
+			data.GET("/tables/:schema/:table/export", handlers.ExportTableData)
+			data.GET("/tables/:schema/:table/columns/:column/stats", handlers.GetColumnStats)
 			data.GET("/fk-preview/:schema/:table/:column/:value", handlers.GetForeignKeyPreview)
 			// CRUD operations
 			data.POST("/tables/:schema/:table/rows", handlers.InsertRow)
+			data.POST("/tables/:schema/:table/rows/bulk", handlers.BulkInsertRows)
 			data.PUT("/tables/:schema/:table/rows", handlers.UpdateRow)
 			data.DELETE("/tables/:schema/:table/rows", handlers.DeleteRow)
+			data.POST("/tables/:schema/:table/rows/delete-by-filter", handlers.DeleteRowsByFilter)
 			// Table operations
 			data.DELETE("/tables/:schema/:table", handlers.DropTable)
 			// Schema DDL operations
@@ -61,17 +96,72 @@ func RegisterRoutes(r *gin.Engine) {
 			data.DELETE("/schemas/:schema", handlers.DropSchema)
 			data.POST("/tables/:schema", handlers.CreateTable)
 			data.POST("/tables/:schema/:table/constraints", handlers.AddConstraint)
+			// Import helpers
+			data.POST("/infer-types", handlers.InferColumnTypes)
+			data.POST("/tables/:schema/:table/import-ndjson", handlers.ImportNDJSON)
+			data.POST("/tables/:schema/:table/import", handlers.ImportCSV)
+			data.POST("/search", handlers.SearchData)
+			data.GET("/recent-tables", handlers.ListRecentTables)
 		}
 
 		// Query execution
-		query := api.Group("/query/:connId")
+		query := api.Group("/query/:connId", handlers.RequireProductionConfirmation())
 		{
 			query.POST("/execute", handlers.ExecuteQuery)
+			query.POST("/cancel", handlers.CancelQuery)
 			query.POST("/explain", handlers.ExplainQuery)
+			query.POST("/lint", handlers.LintQuery)
+			query.POST("/diff", handlers.DiffQueries)
+			query.POST("/export", handlers.ExportQuery)
+			query.GET("/stream", handlers.StreamQuery)
+		}
+
+		// Interactive transactions
+		tx := api.Group("/tx/:connId", handlers.RequireProductionConfirmation())
+		{
+			tx.GET("", handlers.ListTransactions)
+			tx.POST("/begin", handlers.BeginTransaction)
+			tx.POST("/:txId/execute", handlers.ExecuteInTransaction)
+			tx.POST("/:txId/commit", handlers.CommitTransaction)
+			tx.POST("/:txId/rollback", handlers.RollbackTransaction)
+			tx.POST("/:txId/savepoint", handlers.CreateSavepoint)
+			tx.POST("/:txId/release", handlers.ReleaseSavepoint)
+			tx.POST("/:txId/rollback-to", handlers.RollbackToSavepoint)
+		}
+
+		// Table maintenance (VACUUM / ANALYZE)
+		maintenance := api.Group("/maintenance/:connId", handlers.RequireProductionConfirmation())
+		{
+			maintenance.POST("/tables/:schema/:table/vacuum", handlers.VacuumTable)
+			maintenance.POST("/tables/:schema/:table/analyze", handlers.AnalyzeTable)
+			maintenance.GET("/progress/:queryId", handlers.GetMaintenanceProgress)
+		}
+
+		// Server monitoring
+		monitor := api.Group("/monitor/:connId")
+		{
+			monitor.GET("/prepared-transactions", handlers.ListPreparedTransactions)
+			monitor.POST("/rollback-prepared/*gid", handlers.RequireProductionConfirmation(), handlers.RollbackPrepared)
 		}
 
 		// Database analysis
 		api.GET("/analysis/:connId", handlers.RunAnalysis)
+		api.GET("/analysis/:connId/slow-queries", handlers.GetSlowQueries)
+		api.GET("/analysis/:connId/activity", handlers.ListActivity)
+		api.POST("/analysis/:connId/activity/:pid/terminate", handlers.RequireProductionConfirmation(), handlers.TerminateSession)
+		api.GET("/analysis/:connId/locks", handlers.ListLocks)
+		api.GET("/analysis/:connId/size-history", handlers.GetSizeHistory)
+		api.POST("/analysis/:connId/size-history/sample", handlers.SampleSize)
+
+		// Custom analysis rules (user-defined SQL health checks)
+		rules := api.Group("/analysis-rules")
+		{
+			rules.GET("", handlers.ListAnalysisRules)
+			rules.POST("", handlers.CreateAnalysisRule)
+			rules.GET("/:id", handlers.GetAnalysisRule)
+			rules.PUT("/:id", handlers.UpdateAnalysisRule)
+			rules.DELETE("/:id", handlers.DeleteAnalysisRule)
+		}
 
 		// Query history
 		history := api.Group("/history")
@@ -96,19 +186,36 @@ func RegisterRoutes(r *gin.Engine) {
 		{
 			queries.GET("", handlers.ListSavedQueries)
 			queries.POST("", handlers.CreateSavedQuery)
+			queries.GET("/tags", handlers.ListSavedQueryTags)
 			queries.GET("/:id", handlers.GetSavedQuery)
 			queries.PUT("/:id", handlers.UpdateSavedQuery)
 			queries.DELETE("/:id", handlers.DeleteSavedQuery)
+			queries.POST("/:id/run", handlers.RunSavedQuery)
+			queries.POST("/:id/favorite", handlers.SetSavedQueryFavorite)
+		}
+
+		// Macros (replayable sequences of saved queries / statements)
+		macros := api.Group("/macros")
+		{
+			macros.GET("", handlers.ListMacros)
+			macros.POST("", handlers.CreateMacro)
+			macros.GET("/:id", handlers.GetMacro)
+			macros.PUT("/:id", handlers.UpdateMacro)
+			macros.DELETE("/:id", handlers.DeleteMacro)
+			macros.POST("/:id/run/:connId", handlers.RequireProductionConfirmation(), handlers.RunMacro)
 		}
 
 		// Claude Code terminal
 		claude := api.Group("/claude")
 		{
 			claude.POST("/sessions", handlers.CreateClaudeSession)
+			claude.GET("/sessions", handlers.ListClaudeSessions)
+			claude.GET("/sessions/:id/transcript", handlers.GetClaudeSessionTranscript)
 			claude.DELETE("/sessions/:id", handlers.DestroyClaudeSession)
 			claude.POST("/sessions/:id/destroy", handlers.DestroyClaudeSessionPost) // For sendBeacon on page close
 			claude.GET("/terminal/:id", handlers.ClaudeTerminalWebSocket)
 			claude.PUT("/sessions/:id/connection", handlers.UpdateClaudeSessionConnection)
+			claude.PUT("/sessions/:id/writes", handlers.UpdateClaudeSessionWrites)
 		}
 
 		// Version and updates
@@ -126,7 +233,10 @@ func RegisterRoutes(r *gin.Engine) {
 			mcp.GET("/tables/:schema/:table/columns", handlers.MCPGetColumns)
 			mcp.GET("/tables/:schema/:table/foreign-keys", handlers.MCPGetForeignKeys)
 			mcp.GET("/tables/:schema/:table/indexes", handlers.MCPGetIndexes)
+			mcp.GET("/tables/:schema/:table/sample", handlers.MCPSampleTable)
 			mcp.POST("/query", handlers.MCPExecuteQuery)
+			mcp.GET("/queries", handlers.MCPListSavedQueries)
+			mcp.POST("/queries/:id/run", handlers.MCPRunSavedQuery)
 			mcp.GET("/views", handlers.MCPListViews)
 			mcp.GET("/functions", handlers.MCPListFunctions)
 			// Editor integration
@@ -135,4 +245,11 @@ func RegisterRoutes(r *gin.Engine) {
 			mcp.POST("/editor/replace", handlers.MCPReplaceEditorContent)
 		}
 	}
+
+	// WebSockets outside /api
+	ws := r.Group("/ws")
+	{
+		ws.GET("/listen/:connId", handlers.ListenNotifications)
+		ws.GET("/progress/:operationId", handlers.WatchProgress)
+	}
 }
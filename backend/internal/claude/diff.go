@@ -0,0 +1,84 @@
+package claude
+
+import "strings"
+
+// Line diff operations in DiffLine.Op
+const (
+	DiffEqual  = "equal"
+	DiffAdd    = "add"
+	DiffRemove = "remove"
+)
+
+// maxDiffCells bounds the LCS table; larger inputs are diffed as a full
+// removal followed by a full addition.
+const maxDiffCells = 4_000_000
+
+// DiffLine is one line of a line diff from the editor's content to a
+// proposed replacement.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// lineDiff returns the lines of b against a: unchanged lines are equal,
+// lines only in a are removed and lines only in b added, in order, based
+// on a longest common subsequence.
+func lineDiff(a, b string) []DiffLine {
+	x, y := splitLines(a), splitLines(b)
+	diff := make([]DiffLine, 0, len(x)+len(y))
+
+	if len(x)*len(y) > maxDiffCells {
+		for _, line := range x {
+			diff = append(diff, DiffLine{Op: DiffRemove, Text: line})
+		}
+		for _, line := range y {
+			diff = append(diff, DiffLine{Op: DiffAdd, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of x[i:] and y[j:].
+	lcs := make([][]int32, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffRemove, Text: x[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffAdd, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, DiffLine{Op: DiffRemove, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, DiffLine{Op: DiffAdd, Text: y[j]})
+	}
+	return diff
+}
@@ -0,0 +1,59 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderDiff(diff []DiffLine) string {
+	var b strings.Builder
+	for _, d := range diff {
+		switch d.Op {
+		case DiffEqual:
+			b.WriteString(" ")
+		case DiffAdd:
+			b.WriteString("+")
+		case DiffRemove:
+			b.WriteString("-")
+		}
+		b.WriteString(d.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestLineDiff(t *testing.T) {
+	cases := []struct {
+		name, a, b, want string
+	}{
+		{"empty to content", "", "SELECT 1;", "+SELECT 1;\n"},
+		{"unchanged", "SELECT 1;", "SELECT 1;", " SELECT 1;\n"},
+		{
+			"changed middle line",
+			"SELECT id\nFROM users\nWHERE active;",
+			"SELECT id\nFROM accounts\nWHERE active;",
+			" SELECT id\n-FROM users\n+FROM accounts\n WHERE active;\n",
+		},
+		{"cleared", "a\nb", "", "-a\n-b\n"},
+	}
+	for _, tc := range cases {
+		if got := renderDiff(lineDiff(tc.a, tc.b)); got != tc.want {
+			t.Errorf("%s: lineDiff =\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestConfirmReplacePreviewIgnoresStaleID(t *testing.T) {
+	m := &Manager{sessions: map[string]*Session{}}
+	s := &Session{ID: "abc", preview: &replacePreview{id: "p2", content: "SELECT 2;"}}
+	m.sessions["abc"] = s
+
+	if err := m.ConfirmReplacePreview("abc", "p1", true); err == nil {
+		t.Error("answering a superseded preview should fail")
+	}
+	if err := m.ConfirmReplacePreview("abc", "p2", false); err != nil {
+		t.Errorf("rejecting the pending preview: %v", err)
+	}
+	if s.preview != nil {
+		t.Error("a rejected preview should be cleared")
+	}
+}
@@ -118,6 +118,7 @@ func GetManager() *Manager {
 		manager = &Manager{
 			sessions: make(map[string]*Session),
 		}
+		go manager.reapIdleSessions()
 	})
 	return manager
 }
@@ -343,7 +344,9 @@ func buildSystemPrompt(dbContext *DatabaseContext) string {
 	sb.WriteString("- list_views: List database views\n")
 	sb.WriteString("- list_functions: List database functions\n")
 	sb.WriteString("- get_foreign_keys: Get FK relationships\n")
-	sb.WriteString("- get_indexes: Get index information\n\n")
+	sb.WriteString("- get_indexes: Get index information\n")
+	sb.WriteString("- list_saved_queries: List the user's saved queries\n")
+	sb.WriteString("- run_saved_query: Run a saved query by ID with parameter values\n\n")
 	sb.WriteString("Editor tools (to interact with the SQL query editor):\n")
 	sb.WriteString("- get_editor_content: Get the current content of the SQL editor\n")
 	sb.WriteString("- insert_to_editor: Insert SQL text into the editor\n")
@@ -405,15 +408,19 @@ func (m *Manager) CreateSession(connectionID string) (*Session, error) {
 
 	// Create MCP configuration as JSON string
 	// MCP server calls backend API using session ID - no direct DB connection
+	mcpEnv := map[string]string{
+		"PGVOYAGER_SESSION_ID":    sessionID,
+		"PGVOYAGER_SESSION_TOKEN": token,
+		"PGVOYAGER_BACKEND_URL":   getBackendURL(),
+	}
+	if timeout := os.Getenv("PGVOYAGER_MCP_TIMEOUT"); timeout != "" {
+		mcpEnv["PGVOYAGER_MCP_TIMEOUT"] = timeout
+	}
 	mcpConfig := MCPConfig{
 		McpServers: map[string]MCPServerConfig{
 			"pgvoyager": {
 				Command: mcpServerPath,
-				Env: map[string]string{
-					"PGVOYAGER_SESSION_ID":    sessionID,
-					"PGVOYAGER_SESSION_TOKEN": token,
-					"PGVOYAGER_BACKEND_URL":   getBackendURL(),
-				},
+				Env:     mcpEnv,
 			},
 		},
 	}
@@ -455,6 +462,8 @@ func (m *Manager) CreateSession(connectionID string) (*Session, error) {
 		"mcp__pgvoyager__list_functions",
 		"mcp__pgvoyager__get_foreign_keys",
 		"mcp__pgvoyager__get_indexes",
+		"mcp__pgvoyager__list_saved_queries",
+		"mcp__pgvoyager__run_saved_query",
 		// Editor tools
 		"mcp__pgvoyager__get_editor_content",
 		"mcp__pgvoyager__insert_to_editor",
@@ -488,6 +497,7 @@ func (m *Manager) CreateSession(connectionID string) (*Session, error) {
 		return nil, fmt.Errorf("failed to start PTY: %w", err)
 	}
 
+	now := time.Now()
 	session := &Session{
 		ID:           sessionID,
 		Token:        token,
@@ -496,6 +506,9 @@ func (m *Manager) CreateSession(connectionID string) (*Session, error) {
 		Cmd:          cmd,
 		EditorState:  &EditorState{Content: ""},
 		TempDir:      tempDir,
+		CreatedAt:    now,
+		lastActivity: now,
+		transcript:   newRingBuffer(transcriptSize()),
 	}
 
 	m.mu.Lock()
@@ -623,6 +636,10 @@ func (m *Manager) UpdateSessionConnection(sessionID, connectionID string) error
 	}
 
 	session.mu.Lock()
+	if session.ConnectionID != connectionID {
+		// Permission to write was given for the old database only
+		session.allowWrites = false
+	}
 	session.ConnectionID = connectionID
 	session.mu.Unlock()
 
@@ -654,3 +671,62 @@ func (m *Manager) SendEditorAction(sessionID string, action *EditorActionData) e
 
 	return conn.WriteJSON(msg)
 }
+
+// PreviewReplace proposes replacing the editor content: the frontend gets
+// a replace_preview action with a diff against the current content and
+// nothing changes until the user accepts it (see ConfirmReplacePreview).
+// A newer preview supersedes an unanswered one. Returns the preview ID.
+func (m *Manager) PreviewReplace(sessionID, content string) (string, error) {
+	session, ok := m.GetSession(sessionID)
+	if !ok {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	id := uuid.New().String()
+	session.mu.Lock()
+	current := ""
+	if session.EditorState != nil {
+		current = session.EditorState.Content
+	}
+	session.preview = &replacePreview{id: id, content: content}
+	session.mu.Unlock()
+
+	action := &EditorActionData{
+		Action:    "replace_preview",
+		Text:      content,
+		PreviewID: id,
+		Diff:      lineDiff(current, content),
+	}
+	if err := m.SendEditorAction(sessionID, action); err != nil {
+		session.mu.Lock()
+		if session.preview != nil && session.preview.id == id {
+			session.preview = nil
+		}
+		session.mu.Unlock()
+		return "", err
+	}
+	return id, nil
+}
+
+// ConfirmReplacePreview applies (accept) or discards the pending preview.
+// Answers to a superseded or unknown preview are ignored.
+func (m *Manager) ConfirmReplacePreview(sessionID, previewID string, accept bool) error {
+	session, ok := m.GetSession(sessionID)
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	session.mu.Lock()
+	preview := session.preview
+	if preview == nil || preview.id != previewID {
+		session.mu.Unlock()
+		return fmt.Errorf("no pending preview %s", previewID)
+	}
+	session.preview = nil
+	session.mu.Unlock()
+
+	if !accept {
+		return nil
+	}
+	return m.SendEditorAction(sessionID, &EditorActionData{Action: "replace", Text: preview.content})
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestGenerateSessionTokenIsRandomAndUrlSafe(t *testing.T) {
@@ -201,3 +204,62 @@ func contains(haystack []string, needle string) bool {
 	}
 	return false
 }
+
+func TestParseIdleTimeout(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"60", time.Minute, false},
+		{" 900 ", 15 * time.Minute, false},
+		{"30", 0, true},
+		{"100000", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tc := range cases {
+		got, err := ParseIdleTimeout(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseIdleTimeout(%q) = %v, %v; want %v, err=%v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestIdleSessionIDs(t *testing.T) {
+	now := time.Now()
+	m := &Manager{sessions: map[string]*Session{
+		"fresh":    {ID: "fresh", CreatedAt: now.Add(-time.Hour), lastActivity: now.Add(-time.Minute)},
+		"stale":    {ID: "stale", CreatedAt: now.Add(-2 * time.Hour), lastActivity: now.Add(-time.Hour)},
+		"attached": {ID: "attached", CreatedAt: now.Add(-3 * time.Hour), lastActivity: now.Add(-time.Hour), WSConn: &websocket.Conn{}},
+	}}
+
+	ids := m.idleSessionIDs(now, 15*time.Minute)
+	if len(ids) != 1 || ids[0] != "stale" {
+		t.Errorf("idleSessionIDs = %v, want [stale]", ids)
+	}
+
+	list := m.ListSessions()
+	if len(list) != 3 || list[0].ID != "attached" || !list[0].Attached || list[2].ID != "fresh" {
+		t.Errorf("ListSessions not ordered by creation or missing attachment: %+v", list)
+	}
+}
+
+func TestSetAllowWrites(t *testing.T) {
+	m := &Manager{sessions: map[string]*Session{}}
+	s := &Session{ID: "abc"}
+	m.sessions["abc"] = s
+
+	if s.AllowsWrites() {
+		t.Fatal("writes should be off by default")
+	}
+	if err := m.SetAllowWrites("abc", true); err != nil {
+		t.Fatalf("SetAllowWrites: %v", err)
+	}
+	if !s.AllowsWrites() || !s.info().AllowWrites {
+		t.Error("writes should be on after SetAllowWrites(true)")
+	}
+	if err := m.SetAllowWrites("missing", true); err == nil {
+		t.Error("expected an error for an unknown session")
+	}
+}
@@ -0,0 +1,166 @@
+package claude
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// IdleTimeoutPreference holds the seconds a session may go without an
+	// attached terminal before it is destroyed: 0 turns reaping off,
+	// otherwise MinIdleTimeout to a day.
+	IdleTimeoutPreference = "claude.idle_timeout"
+	MinIdleTimeout        = time.Minute
+	maxIdleTimeout        = 24 * time.Hour
+	defaultIdleTimeout    = 15 * time.Minute
+
+	reapInterval = 30 * time.Second
+)
+
+// ParseIdleTimeout validates an IdleTimeoutPreference value. Zero means
+// idle sessions are kept.
+func ParseIdleTimeout(value string) (time.Duration, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	d := time.Duration(n) * time.Second
+	if err != nil || (n != 0 && (d < MinIdleTimeout || d > maxIdleTimeout)) {
+		return 0, fmt.Errorf("must be 0 (never) or %d to %d seconds",
+			int(MinIdleTimeout.Seconds()), int(maxIdleTimeout.Seconds()))
+	}
+	return d, nil
+}
+
+// idleTimeout reads the configured timeout, falling back to the default
+// when the preference is unset or invalid.
+func idleTimeout() time.Duration {
+	value, err := storage.GetPreference(IdleTimeoutPreference)
+	if err != nil || value == "" {
+		return defaultIdleTimeout
+	}
+	d, err := ParseIdleTimeout(value)
+	if err != nil {
+		return defaultIdleTimeout
+	}
+	return d
+}
+
+// touch records activity on the session: terminal input or output, an
+// editor update, or the WebSocket attaching or detaching.
+func (s *Session) touch() {
+	s.mu.Lock()
+	s.lastActivity = time.Now()
+	s.mu.Unlock()
+}
+
+// recordOutput appends terminal output to the transcript. It only copies
+// into the buffer, so the PTY reader holds mu briefly.
+func (s *Session) recordOutput(p []byte) {
+	s.mu.Lock()
+	s.lastActivity = time.Now()
+	if s.transcript != nil {
+		s.transcript.Write(p)
+	}
+	s.mu.Unlock()
+}
+
+// Transcript returns the session's buffered terminal output.
+func (s *Session) Transcript() Transcript {
+	t := Transcript{SessionID: s.ID}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.transcript != nil {
+		out, truncated := s.transcript.Bytes()
+		t.Output = string(out)
+		t.Truncated = truncated
+		t.TotalBytes = s.transcript.written
+	}
+	return t
+}
+
+// AllowsWrites reports whether the user has let Claude run writing
+// statements through execute_query. Off by default and whenever the
+// session switches connection.
+func (s *Session) AllowsWrites() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.allowWrites
+}
+
+// SetAllowWrites turns writes through execute_query on or off.
+func (m *Manager) SetAllowWrites(sessionID string, allow bool) error {
+	session, ok := m.GetSession(sessionID)
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	session.mu.Lock()
+	session.allowWrites = allow
+	session.mu.Unlock()
+	return nil
+}
+
+func (s *Session) info() SessionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return SessionInfo{
+		ID:           s.ID,
+		ConnectionID: s.ConnectionID,
+		CreatedAt:    s.CreatedAt,
+		LastActivity: s.lastActivity,
+		Attached:     s.WSConn != nil,
+		AllowWrites:  s.allowWrites,
+	}
+}
+
+// ListSessions describes the live sessions, oldest first.
+func (m *Manager) ListSessions() []SessionInfo {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.RUnlock()
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, s.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CreatedAt.Before(infos[j].CreatedAt) })
+	return infos
+}
+
+// idleSessionIDs returns the sessions with no terminal attached and no
+// activity since before now-timeout.
+func (m *Manager) idleSessionIDs(now time.Time, timeout time.Duration) []string {
+	var ids []string
+	for _, info := range m.ListSessions() {
+		if !info.Attached && now.Sub(info.LastActivity) > timeout {
+			ids = append(ids, info.ID)
+		}
+	}
+	return ids
+}
+
+// reapIdleSessions destroys abandoned sessions, e.g. from a tab that was
+// closed without its destroy beacon arriving, so their PTYs and claude
+// processes don't pile up.
+func (m *Manager) reapIdleSessions() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		timeout := idleTimeout()
+		if timeout == 0 {
+			continue
+		}
+		for _, id := range m.idleSessionIDs(now, timeout) {
+			log.Printf("claude: destroying session %s after %s without a terminal attached", id, timeout)
+			if err := m.DestroySession(id); err != nil {
+				log.Printf("claude: destroying idle session %s: %v", id, err)
+			}
+		}
+	}
+}
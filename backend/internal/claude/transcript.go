@@ -0,0 +1,79 @@
+package claude
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// TranscriptSizePreference holds how many bytes of terminal output
+	// each session keeps for its transcript, read when the session starts.
+	TranscriptSizePreference = "claude.transcript_bytes"
+	minTranscriptSize        = 4 << 10
+	maxTranscriptSize        = 16 << 20
+	defaultTranscriptSize    = 256 << 10
+)
+
+// ParseTranscriptSize validates a TranscriptSizePreference value.
+func ParseTranscriptSize(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < minTranscriptSize || n > maxTranscriptSize {
+		return 0, fmt.Errorf("must be %d to %d bytes", minTranscriptSize, maxTranscriptSize)
+	}
+	return n, nil
+}
+
+// transcriptSize reads the configured size, falling back to the default
+// when the preference is unset or invalid.
+func transcriptSize() int {
+	value, err := storage.GetPreference(TranscriptSizePreference)
+	if err != nil || value == "" {
+		return defaultTranscriptSize
+	}
+	n, err := ParseTranscriptSize(value)
+	if err != nil {
+		return defaultTranscriptSize
+	}
+	return n
+}
+
+// ringBuffer keeps the last len(buf) bytes written to it. Not safe for
+// concurrent use; Session guards it with mu.
+type ringBuffer struct {
+	buf     []byte
+	next    int   // where the next byte goes
+	written int64 // total bytes ever written
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]byte, size)}
+}
+
+func (r *ringBuffer) Write(p []byte) {
+	r.written += int64(len(p))
+	if len(p) >= len(r.buf) {
+		copy(r.buf, p[len(p)-len(r.buf):])
+		r.next = 0
+		return
+	}
+	n := copy(r.buf[r.next:], p)
+	if n < len(p) {
+		copy(r.buf, p[n:])
+	}
+	r.next = (r.next + len(p)) % len(r.buf)
+}
+
+// Bytes returns a copy of the buffered bytes, oldest first, and whether
+// earlier output was dropped.
+func (r *ringBuffer) Bytes() ([]byte, bool) {
+	if r.written <= int64(len(r.buf)) {
+		return append([]byte(nil), r.buf[:r.written]...), false
+	}
+	out := make([]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	out = append(out, r.buf[:r.next]...)
+	return out, true
+}
@@ -0,0 +1,41 @@
+package claude
+
+import "testing"
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(8)
+	r.Write([]byte("abc"))
+	if got, truncated := r.Bytes(); string(got) != "abc" || truncated {
+		t.Errorf("Bytes() = %q, %v; want \"abc\", false", got, truncated)
+	}
+
+	r.Write([]byte("defgh"))
+	if got, truncated := r.Bytes(); string(got) != "abcdefgh" || truncated {
+		t.Errorf("Bytes() = %q, %v; want a full, untruncated buffer", got, truncated)
+	}
+
+	r.Write([]byte("ij"))
+	if got, truncated := r.Bytes(); string(got) != "cdefghij" || !truncated {
+		t.Errorf("Bytes() = %q, %v; want \"cdefghij\", true", got, truncated)
+	}
+
+	r.Write([]byte("0123456789"))
+	if got, _ := r.Bytes(); string(got) != "23456789" {
+		t.Errorf("Bytes() after an oversized write = %q, want \"23456789\"", got)
+	}
+	if r.written != 20 {
+		t.Errorf("written = %d, want 20", r.written)
+	}
+}
+
+func TestSessionTranscript(t *testing.T) {
+	s := &Session{ID: "abc", transcript: newRingBuffer(minTranscriptSize)}
+	s.recordOutput([]byte("SELECT 1;\r\n"))
+	tr := s.Transcript()
+	if tr.SessionID != "abc" || tr.Output != "SELECT 1;\r\n" || tr.Truncated || tr.TotalBytes != 11 {
+		t.Errorf("Transcript() = %+v", tr)
+	}
+	if s.lastActivity.IsZero() {
+		t.Error("recordOutput should update lastActivity")
+	}
+}
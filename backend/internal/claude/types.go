@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -18,10 +19,36 @@ type Session struct {
 	EditorState  *EditorState
 	TempDir      string // Temporary directory for MCP config
 	WSConn       *websocket.Conn // WebSocket connection to frontend
+	CreatedAt    time.Time
+	lastActivity time.Time // guarded by mu; see touch
+	transcript   *ringBuffer // terminal output, guarded by mu
+	preview      *replacePreview // awaiting the user's answer, guarded by mu
+	allowWrites  bool            // MCP execute_query may write, guarded by mu
 	mu           sync.RWMutex
 	wsMu         sync.Mutex // Mutex for WebSocket writes
 }
 
+// SessionInfo describes a live session for listing. It deliberately
+// omits the token.
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	ConnectionID string    `json:"connectionId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	LastActivity time.Time `json:"lastActivity"`
+	Attached     bool      `json:"attached"` // a terminal WebSocket is open
+	AllowWrites  bool      `json:"allowWrites"`
+}
+
+// Transcript is a session's recent terminal output.
+type Transcript struct {
+	SessionID string `json:"sessionId"`
+	Output    string `json:"output"`
+	// Truncated is set when older output no longer fits the buffer;
+	// TotalBytes counts everything the session has printed.
+	Truncated  bool  `json:"truncated"`
+	TotalBytes int64 `json:"totalBytes"`
+}
+
 // EditorState holds the current state of the SQL editor
 type EditorState struct {
 	Content   string     `json:"content"`
@@ -67,9 +94,26 @@ type EditorUpdateData struct {
 
 // EditorActionData for actions from Claude to editor
 type EditorActionData struct {
-	Action   string `json:"action"` // "insert", "replace"
-	Text     string `json:"text"`
+	Action   string    `json:"action"` // "insert", "replace", "replace_preview"
+	Text     string    `json:"text"`
 	Position *Position `json:"position,omitempty"`
+	// For replace_preview: the ID to answer with in a replace_confirm
+	// message, and Text diffed against the editor's current content.
+	PreviewID string     `json:"previewId,omitempty"`
+	Diff      []DiffLine `json:"diff,omitempty"`
+}
+
+// ReplaceConfirmData answers a replace_preview: accepted previews are
+// applied as a replace action, rejected ones dropped.
+type ReplaceConfirmData struct {
+	PreviewID string `json:"previewId"`
+	Accept    bool   `json:"accept"`
+}
+
+// replacePreview is a proposed replacement the user hasn't answered yet.
+type replacePreview struct {
+	id      string
+	content string
 }
 
 // CreateSessionRequest for creating a new session
@@ -6,22 +6,17 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/thelinuxer/pgvoyager/internal/security"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	// Reject cross-origin upgrades. Default gorilla CheckOrigin (and the
-	// prior `return true`) made the PTY WebSocket reachable from any web
-	// page the user visited — classic CSWSH into a full shell.
-	CheckOrigin: func(r *http.Request) bool {
-		return security.AllowedOrigin(r.Header.Get("Origin"), r.Host)
-	},
-}
+// Cross-origin upgrades are rejected: an open CheckOrigin made the PTY
+// WebSocket reachable from any web page the user visited — CSWSH into a
+// full shell.
+var upgrader = security.NewWebSocketUpgrader()
 
 // HandleTerminalWebSocket handles WebSocket connections for terminal I/O.
 // Authentication: the URL must carry `?token=<session-bearer>`. Browsers
@@ -48,12 +43,14 @@ func HandleTerminalWebSocket(c *gin.Context) {
 		conn.Close()
 		session.mu.Lock()
 		session.WSConn = nil
+		session.lastActivity = time.Now()
 		session.mu.Unlock()
 	}()
 
 	// Store WebSocket connection in session for sending editor actions
 	session.mu.Lock()
 	session.WSConn = conn
+	session.lastActivity = time.Now()
 	session.mu.Unlock()
 
 	// Channel to signal shutdown (use sync.Once to prevent double close)
@@ -82,6 +79,7 @@ func HandleTerminalWebSocket(c *gin.Context) {
 					return
 				}
 				if n > 0 {
+					session.recordOutput(buf[:n])
 					msg := WSMessage{
 						Type: "output",
 						Data: string(buf[:n]),
@@ -115,6 +113,7 @@ func HandleTerminalWebSocket(c *gin.Context) {
 				log.Printf("Failed to parse WebSocket message: %v", err)
 				continue
 			}
+			session.touch()
 
 			switch wsMsg.Type {
 			case "input":
@@ -123,6 +122,8 @@ func HandleTerminalWebSocket(c *gin.Context) {
 				handleResize(session, wsMsg.Data)
 			case "editor_update":
 				handleEditorUpdate(session, wsMsg.Data)
+			case "replace_confirm":
+				handleReplaceConfirm(session, wsMsg.Data)
 			}
 		}
 	}
@@ -196,3 +197,16 @@ func handleEditorUpdate(session *Session, data interface{}) {
 
 	GetManager().UpdateEditorState(session.ID, state)
 }
+
+func handleReplaceConfirm(session *Session, data interface{}) {
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	previewID, _ := dataMap["previewId"].(string)
+	accept, _ := dataMap["accept"].(bool)
+	if err := GetManager().ConfirmReplacePreview(session.ID, previewID, accept); err != nil {
+		log.Printf("Replace preview confirmation: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Stable codes for why a connection attempt failed. The UI switches on
+// these; the raw error text is still returned alongside for detail.
+const (
+	ConnectErrAuthFailed         = "auth_failed"
+	ConnectErrAccessDenied       = "access_denied"
+	ConnectErrDatabaseNotFound   = "database_not_found"
+	ConnectErrSSLRequired        = "ssl_required"
+	ConnectErrSSLUnsupported     = "ssl_unsupported"
+	ConnectErrRoleDenied         = "role_denied"
+	ConnectErrTooManyConnections = "too_many_connections"
+	ConnectErrServerStarting     = "server_starting"
+	ConnectErrHostUnreachable    = "host_unreachable"
+	ConnectErrHostNotFound       = "host_not_found"
+	ConnectErrTimeout            = "timeout"
+	ConnectErrSSHTunnel          = "ssh_tunnel"
+	ConnectErrSSLFile            = "ssl_file"
+	ConnectErrUnknown            = "unknown"
+)
+
+// ConnectErrorInfo classifies a failed connection attempt.
+type ConnectErrorInfo struct {
+	Code    string
+	Message string // short, user-facing explanation
+	// Transient errors may succeed if retried unchanged (server starting,
+	// connection refused); fatal ones need the user to fix something.
+	Transient bool
+}
+
+// ClassifyConnectError maps an error from Connect, TestConnection or
+// SwitchDatabase to a ConnectErrorInfo, using the Postgres SQLSTATE when
+// the server answered and the network error otherwise.
+func ClassifyConnectError(err error) ConnectErrorInfo {
+	if IsTunnelError(err) {
+		return ConnectErrorInfo{Code: ConnectErrSSHTunnel, Message: "Could not open the SSH tunnel to the bastion host."}
+	}
+
+	var fileErr *SSLFileError
+	if errors.As(err, &fileErr) {
+		return ConnectErrorInfo{Code: ConnectErrSSLFile, Message: "The SSL " + fileErr.Kind + " file could not be read."}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "28P01":
+			return ConnectErrorInfo{Code: ConnectErrAuthFailed, Message: "Wrong username or password."}
+		case "28000":
+			// invalid_authorization_specification is mostly pg_hba.conf
+			// rejecting the client; "no encryption" means a hostssl-only rule.
+			if strings.Contains(pgErr.Message, "no encryption") {
+				return ConnectErrorInfo{Code: ConnectErrSSLRequired, Message: "The server requires SSL; change the SSL mode from disable."}
+			}
+			return ConnectErrorInfo{Code: ConnectErrAccessDenied, Message: "The server's pg_hba.conf does not allow this user, database or host."}
+		case "3D000":
+			return ConnectErrorInfo{Code: ConnectErrDatabaseNotFound, Message: "The database does not exist."}
+		case "42704", "42501":
+			// From SET ROLE in AfterConnect, the role doesn't exist or the
+			// login isn't a member of it. Otherwise 42501 is the login
+			// lacking CONNECT on the database.
+			var roleErr *RoleError
+			if errors.As(err, &roleErr) {
+				return ConnectErrorInfo{Code: ConnectErrRoleDenied, Message: "The configured role does not exist or this user cannot assume it."}
+			}
+			if pgErr.Code == "42501" {
+				return ConnectErrorInfo{Code: ConnectErrAccessDenied, Message: "This user does not have permission to connect to the database."}
+			}
+		case "53300":
+			return ConnectErrorInfo{Code: ConnectErrTooManyConnections, Message: "The server has too many open connections.", Transient: true}
+		case "57P03":
+			return ConnectErrorInfo{Code: ConnectErrServerStarting, Message: "The database server is still starting up.", Transient: true}
+		}
+		return ConnectErrorInfo{Code: ConnectErrUnknown, Message: "The server rejected the connection."}
+	}
+
+	if err != nil && strings.Contains(err.Error(), "server refused TLS connection") {
+		return ConnectErrorInfo{Code: ConnectErrSSLUnsupported, Message: "The server does not support SSL; set the SSL mode to disable or prefer."}
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ConnectErrorInfo{Code: ConnectErrHostNotFound, Message: "The host name could not be resolved.", Transient: dnsErr.IsTemporary}
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ConnectErrorInfo{Code: ConnectErrHostUnreachable, Message: "Nothing accepted the connection at that host and port.", Transient: true}
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return ConnectErrorInfo{Code: ConnectErrHostUnreachable, Message: "The host is unreachable from this machine."}
+	case errors.Is(err, context.DeadlineExceeded):
+		return ConnectErrorInfo{Code: ConnectErrTimeout, Message: "Timed out waiting for the server.", Transient: true}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ConnectErrorInfo{Code: ConnectErrTimeout, Message: "Timed out waiting for the server.", Transient: true}
+	}
+
+	return ConnectErrorInfo{Code: ConnectErrUnknown, Message: "Could not connect to the server."}
+}
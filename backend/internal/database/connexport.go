@@ -0,0 +1,330 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	connectionExportVersion = 1
+
+	// MinExportPassphrase is the shortest passphrase accepted for
+	// encrypting an export.
+	MinExportPassphrase = 8
+
+	exportKDFIterations = 600_000
+	// maxExportKDFIterations bounds what an imported file can make us
+	// compute before its passphrase is even checked.
+	maxExportKDFIterations = 10_000_000
+)
+
+var (
+	ErrPassphraseTooShort = fmt.Errorf("passphrase must be at least %d characters", MinExportPassphrase)
+	ErrBadPassphrase      = errors.New("wrong passphrase, or the export is corrupted")
+	ErrPassphraseRequired = errors.New("this export's passwords are encrypted; the passphrase is required to import it")
+)
+
+// exportSecrets is what ExportedConnection.Secrets seals for one
+// connection.
+type exportSecrets struct {
+	Password         string `json:"password,omitempty"`
+	SSHPassword      string `json:"sshPassword,omitempty"`
+	ConnectionString string `json:"connectionString,omitempty"`
+}
+
+// exportCipher derives the AES-256-GCM cipher for a passphrase and salt.
+func exportCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSecrets encrypts secrets as base64(nonce || ciphertext).
+func sealSecrets(aead cipher.AEAD, secrets exportSecrets) (string, error) {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+// openSecrets reverses sealSecrets. Any failure, including a wrong key,
+// is ErrBadPassphrase.
+func openSecrets(aead cipher.AEAD, sealed string) (exportSecrets, error) {
+	var secrets exportSecrets
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return secrets, ErrBadPassphrase
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil || json.Unmarshal(plain, &secrets) != nil {
+		return secrets, ErrBadPassphrase
+	}
+	return secrets, nil
+}
+
+// exportConnections builds the export of conns, sorted by name. With a
+// passphrase each connection's secrets are sealed into Secrets; without
+// one they are dropped. Either way a connection string carries no password
+// in the clear.
+func exportConnections(conns []*models.Connection, passphrase string) (*models.ConnectionExport, error) {
+	doc := &models.ConnectionExport{
+		Version:     connectionExportVersion,
+		ExportedAt:  time.Now().UTC(),
+		Passwords:   models.ExportPasswordsExcluded,
+		Connections: []models.ExportedConnection{},
+	}
+
+	var aead cipher.AEAD
+	if passphrase != "" {
+		if len(passphrase) < MinExportPassphrase {
+			return nil, ErrPassphraseTooShort
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		var err error
+		if aead, err = exportCipher(passphrase, salt, exportKDFIterations); err != nil {
+			return nil, err
+		}
+		doc.Passwords = models.ExportPasswordsEncrypted
+		doc.Salt = base64.StdEncoding.EncodeToString(salt)
+		doc.Iterations = exportKDFIterations
+	}
+
+	sorted := append([]*models.Connection(nil), conns...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, c := range sorted {
+		e := models.ExportedConnection{
+			Name:             c.Name,
+			Host:             c.Host,
+			Port:             c.Port,
+			Database:         c.Database,
+			Username:         c.Username,
+			SSLMode:          c.SSLMode,
+			ConnectionString: stripConnStringPassword(c.ConnectionString),
+			MaxConnIdleTime:  c.MaxConnIdleTime,
+			MaxConns:         c.MaxConns,
+			MinConns:         c.MinConns,
+			MaxConnLifetime:  c.MaxConnLifetime,
+			IsReadOnly:       c.IsReadOnly,
+			Environment:      c.Environment,
+			Role:             c.Role,
+			SearchPath:       c.SearchPath,
+			SSLFiles:         c.SSLFiles,
+			SSHHost:          c.SSHHost,
+			SSHPort:          c.SSHPort,
+			SSHUser:          c.SSHUser,
+			SSHKeyPath:       c.SSHKeyPath,
+
+			DefaultQueryTimeoutMs: c.DefaultQueryTimeoutMs,
+		}
+		secrets := exportSecrets{Password: c.Password, SSHPassword: c.SSHPassword}
+		if e.ConnectionString != c.ConnectionString {
+			secrets.ConnectionString = c.ConnectionString
+		}
+		if aead != nil && secrets != (exportSecrets{}) {
+			sealed, err := sealSecrets(aead, secrets)
+			if err != nil {
+				return nil, err
+			}
+			e.Secrets = sealed
+		}
+		doc.Connections = append(doc.Connections, e)
+	}
+	return doc, nil
+}
+
+// importRequests turns an export back into connection requests, in file
+// order, restoring the secrets of an encrypted export. It fails as a whole
+// on a wrong passphrase, before anything is created.
+func importRequests(doc *models.ConnectionExport, passphrase string) ([]models.ConnectionRequest, error) {
+	if doc.Version != connectionExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", doc.Version)
+	}
+
+	var aead cipher.AEAD
+	switch doc.Passwords {
+	case models.ExportPasswordsExcluded:
+	case models.ExportPasswordsEncrypted:
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		salt, err := base64.StdEncoding.DecodeString(doc.Salt)
+		if err != nil || len(salt) == 0 {
+			return nil, errors.New("export has an invalid salt")
+		}
+		if doc.Iterations < 1 || doc.Iterations > maxExportKDFIterations {
+			return nil, fmt.Errorf("export has an invalid iteration count %d", doc.Iterations)
+		}
+		if aead, err = exportCipher(passphrase, salt, doc.Iterations); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown passwords mode %q", doc.Passwords)
+	}
+
+	reqs := make([]models.ConnectionRequest, 0, len(doc.Connections))
+	for _, e := range doc.Connections {
+		req := models.ConnectionRequest{
+			Name:             e.Name,
+			Host:             e.Host,
+			Port:             e.Port,
+			Database:         e.Database,
+			Username:         e.Username,
+			SSLMode:          e.SSLMode,
+			ConnectionString: e.ConnectionString,
+			MaxConnIdleTime:  e.MaxConnIdleTime,
+			MaxConns:         e.MaxConns,
+			MinConns:         e.MinConns,
+			MaxConnLifetime:  e.MaxConnLifetime,
+			IsReadOnly:       e.IsReadOnly,
+			Environment:      e.Environment,
+			Role:             e.Role,
+			SearchPath:       e.SearchPath,
+			SSLFiles:         e.SSLFiles,
+
+			DefaultQueryTimeoutMs: e.DefaultQueryTimeoutMs,
+			SSHTunnel: models.SSHTunnel{
+				SSHHost:    e.SSHHost,
+				SSHPort:    e.SSHPort,
+				SSHUser:    e.SSHUser,
+				SSHKeyPath: e.SSHKeyPath,
+			},
+		}
+		if aead != nil && e.Secrets != "" {
+			secrets, err := openSecrets(aead, e.Secrets)
+			if err != nil {
+				return nil, err
+			}
+			req.Password = secrets.Password
+			req.SSHPassword = secrets.SSHPassword
+			if secrets.ConnectionString != "" {
+				req.ConnectionString = secrets.ConnectionString
+			}
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// validateImported applies CreateConnection's checks to an imported
+// connection, which skips request binding.
+func validateImported(req *models.ConnectionRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	switch req.Environment {
+	case "", "development", "staging", models.EnvironmentProduction:
+	default:
+		return fmt.Errorf("unknown environment %q", req.Environment)
+	}
+	if req.MaxConnIdleTime < 0 || req.MaxConnLifetime < 0 {
+		return errors.New("connection lifetimes can't be negative")
+	}
+	if err := ValidateRole(req.Role); err != nil {
+		return err
+	}
+	if err := ValidateSearchPath(req.SearchPath); err != nil {
+		return err
+	}
+	if err := ValidatePoolSize(req.MaxConns, req.MinConns); err != nil {
+		return err
+	}
+	if err := ValidateConnectionTarget(req.ConnectionString, req.Host, req.Port, req.Username, req.Password); err != nil {
+		return err
+	}
+	return ValidateSSLFiles(req.SSLFiles, req.SSLMode, req.ConnectionString)
+}
+
+// importKey identifies a connection for duplicate detection.
+func importKey(name, host, database string) string {
+	return name + "\x00" + host + "\x00" + database
+}
+
+// Export returns every saved connection for moving to another machine.
+// With a passphrase the secrets are encrypted; without one they are left
+// out.
+func (m *ConnectionManager) Export(passphrase string) (*models.ConnectionExport, error) {
+	m.mu.RLock()
+	conns := make([]*models.Connection, 0, len(m.connections))
+	for _, c := range m.connections {
+		connCopy := *c
+		conns = append(conns, &connCopy)
+	}
+	m.mu.RUnlock()
+
+	return exportConnections(conns, passphrase)
+}
+
+// Import saves the connections in an export through Create, each under a
+// new ID. One with the name, host and database of a saved connection (or
+// of an earlier entry in the file) is skipped, as is one that fails
+// validation.
+func (m *ConnectionManager) Import(doc *models.ConnectionExport, passphrase string) (*models.ConnectionImportResult, error) {
+	reqs, err := importRequests(doc, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	m.mu.RLock()
+	for _, c := range m.connections {
+		seen[importKey(c.Name, c.Host, c.Database)] = true
+	}
+	m.mu.RUnlock()
+
+	result := &models.ConnectionImportResult{
+		Imported: []*models.Connection{},
+		Skipped:  []models.ConnectionImportSkip{},
+	}
+	for i := range reqs {
+		req := &reqs[i]
+		if err := validateImported(req); err != nil {
+			result.Skipped = append(result.Skipped, models.ConnectionImportSkip{Name: req.Name, Reason: err.Error()})
+			continue
+		}
+		database, err := requestDatabase(req.ConnectionString, req.Database)
+		if err != nil {
+			result.Skipped = append(result.Skipped, models.ConnectionImportSkip{Name: req.Name, Reason: err.Error()})
+			continue
+		}
+		key := importKey(req.Name, req.Host, database)
+		if seen[key] {
+			result.Skipped = append(result.Skipped, models.ConnectionImportSkip{Name: req.Name, Reason: "a connection with this name, host and database already exists"})
+			continue
+		}
+
+		conn, err := m.Create(req)
+		if err != nil {
+			return result, err
+		}
+		seen[key] = true
+		result.Imported = append(result.Imported, conn)
+	}
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func exportFixture() []*models.Connection {
+	return []*models.Connection{
+		{
+			ID: "2", Name: "prod", Host: "db.example.com", Port: 5432, Database: "app",
+			Username: "app", Password: "s3cret", SSLMode: "require",
+			SSHHost: "bastion", SSHPort: 22, SSHUser: "ops", SSHPassword: "hunter2",
+		},
+		{
+			ID: "1", Name: "analytics", Database: "warehouse",
+			ConnectionString: "postgres://etl:pw@wh.example.com/warehouse",
+		},
+	}
+}
+
+func TestExportExcludesSecrets(t *testing.T) {
+	doc, err := exportConnections(exportFixture(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Passwords != models.ExportPasswordsExcluded || doc.Salt != "" {
+		t.Errorf("passwords = %q, salt = %q; want excluded with no salt", doc.Passwords, doc.Salt)
+	}
+	raw, _ := json.Marshal(doc)
+	for _, secret := range []string{"s3cret", "hunter2", ":pw@"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("export contains %q:\n%s", secret, raw)
+		}
+	}
+	if doc.Connections[0].Name != "analytics" {
+		t.Errorf("connections not sorted by name: first is %q", doc.Connections[0].Name)
+	}
+	if got := doc.Connections[0].ConnectionString; got != "postgres://etl@wh.example.com/warehouse" {
+		t.Errorf("connection string = %q", got)
+	}
+
+	reqs, err := importRequests(doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reqs[1].Password != "" || reqs[1].SSHPassword != "" || reqs[1].Host != "db.example.com" {
+		t.Errorf("imported %+v", reqs[1])
+	}
+}
+
+func TestExportEncryptedRoundTrip(t *testing.T) {
+	if _, err := exportConnections(exportFixture(), "short"); !errors.Is(err, ErrPassphraseTooShort) {
+		t.Errorf("short passphrase: err = %v, want ErrPassphraseTooShort", err)
+	}
+
+	doc, err := exportConnections(exportFixture(), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(doc)
+	for _, secret := range []string{"s3cret", "hunter2", ":pw@"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("encrypted export contains %q in the clear", secret)
+		}
+	}
+
+	// Through JSON, as the file would travel.
+	var read models.ConnectionExport
+	if err := json.Unmarshal(raw, &read); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importRequests(&read, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("no passphrase: err = %v, want ErrPassphraseRequired", err)
+	}
+	if _, err := importRequests(&read, "wrong horse"); !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("wrong passphrase: err = %v, want ErrBadPassphrase", err)
+	}
+
+	reqs, err := importRequests(&read, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reqs[0].ConnectionString; got != "postgres://etl:pw@wh.example.com/warehouse" {
+		t.Errorf("connection string = %q, want the original", got)
+	}
+	if reqs[1].Password != "s3cret" || reqs[1].SSHPassword != "hunter2" || reqs[1].SSHHost != "bastion" {
+		t.Errorf("imported %+v", reqs[1])
+	}
+}
+
+func TestValidateImported(t *testing.T) {
+	ok := models.ConnectionRequest{Name: "x", Host: "h", Port: 5432, Username: "u"}
+	if err := validateImported(&ok); err != nil {
+		t.Errorf("valid connection rejected: %v", err)
+	}
+	bad := []models.ConnectionRequest{
+		{Host: "h", Port: 5432, Username: "u"},
+		{Name: "x", Host: "h", Port: 5432},
+		{Name: "x", Host: "h", Port: 5432, Username: "u", Environment: "prod"},
+		{Name: "x", Host: "h", Port: 5432, Username: "u", MaxConns: 1, MinConns: 2},
+	}
+	for _, req := range bad {
+		if err := validateImported(&req); err == nil {
+			t.Errorf("validateImported(%+v) accepted it", req)
+		}
+	}
+}
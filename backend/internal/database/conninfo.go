@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// reportedExtensions are the extensions a connection test reports on:
+// those PgVoyager features or common setups depend on. "vector" is
+// pgvector.
+var reportedExtensions = []string{"pg_stat_statements", "vector", "postgis"}
+
+// fetchConnectionInfo reads the server version, session user and database,
+// whether the session is encrypted, and which reportedExtensions are there.
+// SSL is read off the client side of the socket, which needs no
+// privileges and holds through an SSH tunnel.
+func fetchConnectionInfo(ctx context.Context, conn *pgx.Conn) (*models.ConnectionInfo, error) {
+	info := &models.ConnectionInfo{}
+	err := conn.QueryRow(ctx, `
+		SELECT current_setting('server_version'), current_setting('server_version_num')::int,
+			current_database(), current_user
+	`).Scan(&info.ServerVersion, &info.ServerVersionNum, &info.Database, &info.CurrentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConn, ok := conn.PgConn().Conn().(*tls.Conn); ok {
+		info.SSL = true
+		info.SSLVersion = tls.VersionName(tlsConn.ConnectionState().Version)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT name, COALESCE(installed_version, '')
+		FROM pg_available_extensions
+		WHERE name = ANY($1)
+	`, reportedExtensions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]string)
+	for rows.Next() {
+		var name, version string
+		if err := rows.Scan(&name, &version); err != nil {
+			return nil, err
+		}
+		found[name] = version
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	info.Extensions = extensionStatuses(reportedExtensions, found)
+	return info, nil
+}
+
+// extensionStatuses lists names in order, given the available ones mapped
+// to their installed version ("" when not installed).
+func extensionStatuses(names []string, available map[string]string) []models.ExtensionStatus {
+	statuses := make([]models.ExtensionStatus, len(names))
+	for i, name := range names {
+		version, ok := available[name]
+		statuses[i] = models.ExtensionStatus{
+			Name:             name,
+			Available:        ok,
+			Installed:        version != "",
+			InstalledVersion: version,
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// redactedPassword replaces passwords in connection strings handed to the UI.
+const redactedPassword = "xxxxx"
+
+var keywordPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// keywordPasswordSetting is a whole password= setting with the space
+// before it, for removing it.
+var keywordPasswordSetting = regexp.MustCompile(`\s*password\s*=\s*('(?:[^'\\]|\\.)*'|\S+)`)
+
+// ValidateConnectionTarget checks that a connection is described either by
+// a libpq connection string (URI or key=value) or by discrete host, port
+// and username fields, not a mix. A database may accompany a connection
+// string and overrides the one inside it.
+func ValidateConnectionTarget(connString, host string, port int, username, password string) error {
+	if strings.TrimSpace(connString) == "" {
+		if host == "" || port == 0 || username == "" {
+			return errors.New("host, port and username are required unless a connection string is given")
+		}
+		return nil
+	}
+	if host != "" || port != 0 || username != "" || password != "" {
+		return errors.New("give either a connection string or host, port, username and password, not both")
+	}
+	if _, err := pgxpool.ParseConfig(connString); err != nil {
+		return errors.New("invalid connection string: " + redactConnString(err.Error()))
+	}
+	return nil
+}
+
+// requestDatabase picks the database for a connection: the requested one,
+// else the one named in the connection string, else the default.
+func requestDatabase(connString, database string) (string, error) {
+	if database != "" {
+		return database, nil
+	}
+	if connString != "" {
+		config, err := pgxpool.ParseConfig(connString)
+		if err != nil {
+			return "", err
+		}
+		if config.ConnConfig.Database != "" {
+			return config.ConnConfig.Database, nil
+		}
+	}
+	return models.DefaultDatabase, nil
+}
+
+// redactConnString masks any password in a connection string, in either
+// the URI user info, a password query parameter or a password= keyword.
+func redactConnString(s string) string {
+	if s == "" {
+		return s
+	}
+	if strings.HasPrefix(s, "postgres://") || strings.HasPrefix(s, "postgresql://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			// Unparseable, so don't guess where the password is.
+			return redactedPassword
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedPassword)
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", redactedPassword)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return keywordPassword.ReplaceAllString(s, "${1}"+redactedPassword)
+}
+
+// stripConnStringPassword removes any password from a connection string,
+// wherever redactConnString would mask one.
+func stripConnStringPassword(s string) string {
+	if s == "" {
+		return s
+	}
+	if strings.HasPrefix(s, "postgres://") || strings.HasPrefix(s, "postgresql://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			// Unparseable, so there's no telling what part is the password.
+			return ""
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.User(u.User.Username())
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Del("password")
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return strings.TrimSpace(keywordPasswordSetting.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,67 @@
+package database
+
+import "testing"
+
+func TestRedactConnString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"postgres://app:s3cret@db:5432/prod?sslmode=require", "postgres://app:xxxxx@db:5432/prod?sslmode=require"},
+		{"postgresql://app@db/prod", "postgresql://app@db/prod"},
+		{"postgres://db/prod?password=s3cret&user=app", "postgres://db/prod?password=xxxxx&user=app"},
+		{"host=db user=app password=s3cret dbname=prod", "host=db user=app password=xxxxx dbname=prod"},
+		{"host=db password = 'it\\'s secret' dbname=prod", "host=db password = xxxxx dbname=prod"},
+		{"service=prod", "service=prod"},
+	}
+	for _, tt := range tests {
+		if got := redactConnString(tt.in); got != tt.want {
+			t.Errorf("redactConnString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStripConnStringPassword(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"postgres://app:s3cret@db:5432/prod?sslmode=require", "postgres://app@db:5432/prod?sslmode=require"},
+		{"postgresql://app@db/prod", "postgresql://app@db/prod"},
+		{"postgres://db/prod?password=s3cret&user=app", "postgres://db/prod?user=app"},
+		{"host=db user=app password=s3cret dbname=prod", "host=db user=app dbname=prod"},
+		{"password = 'it\\'s secret' host=db", "host=db"},
+		{"service=prod", "service=prod"},
+	}
+	for _, tt := range tests {
+		if got := stripConnStringPassword(tt.in); got != tt.want {
+			t.Errorf("stripConnStringPassword(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConnectionTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		connString string
+		host       string
+		port       int
+		username   string
+		password   string
+		wantErr    bool
+	}{
+		{"discrete fields", "", "localhost", 5432, "postgres", "", false},
+		{"missing host", "", "", 5432, "postgres", "", true},
+		{"uri", "postgres://app:pw@db/prod", "", 0, "", "", false},
+		{"key value", "host=db user=app dbname=prod", "", 0, "", "", false},
+		{"mixed", "postgres://db/prod", "localhost", 0, "", "", true},
+		{"password alongside", "postgres://app@db/prod", "", 0, "", "pw", true},
+		{"unparseable", "postgres://db:notaport/prod", "", 0, "", "", true},
+	}
+	for _, tt := range tests {
+		err := ValidateConnectionTarget(tt.connString, tt.host, tt.port, tt.username, tt.password)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// HealthCheckIntervalPreference holds the seconds between pings of each
+	// connected pool: 0 turns checking off, otherwise at least
+	// MinHealthCheckInterval. Changes apply from the next check.
+	HealthCheckIntervalPreference = "connections.health_check_interval"
+	MinHealthCheckInterval        = 5 * time.Second
+	maxHealthCheckInterval        = time.Hour
+	defaultHealthCheckInterval    = 30 * time.Second
+
+	healthPingTimeout = 5 * time.Second
+)
+
+// Health statuses reported in models.ConnectionHealth
+const (
+	HealthUnknown      = "unknown"      // not pinged yet
+	HealthHealthy      = "healthy"      // last ping succeeded
+	HealthReconnected  = "reconnected"  // ping failed, a fresh pool succeeded
+	HealthDisconnected = "disconnected" // ping and reconnect failed, or not connected
+)
+
+// ParseHealthCheckInterval validates a HealthCheckIntervalPreference value.
+// Zero means checking is off.
+func ParseHealthCheckInterval(value string) (time.Duration, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	d := time.Duration(n) * time.Second
+	if err != nil || (n != 0 && (d < MinHealthCheckInterval || d > maxHealthCheckInterval)) {
+		return 0, fmt.Errorf("must be 0 (off) or %d to %d seconds",
+			int(MinHealthCheckInterval.Seconds()), int(maxHealthCheckInterval.Seconds()))
+	}
+	return d, nil
+}
+
+// healthCheckInterval reads the configured interval, falling back to the
+// default when the preference is unset or invalid.
+func healthCheckInterval() time.Duration {
+	value, err := storage.GetPreference(HealthCheckIntervalPreference)
+	if err != nil || value == "" {
+		return defaultHealthCheckInterval
+	}
+	d, err := ParseHealthCheckInterval(value)
+	if err != nil {
+		return defaultHealthCheckInterval
+	}
+	return d
+}
+
+// healthMonitor pings one connection's pool in the background. It stays
+// in ConnectionManager.health after giving up so the last result can still
+// be read; Disconnect and Delete remove it.
+type healthMonitor struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	status     string
+	lastPingAt *time.Time
+	latency    time.Duration
+	lastError  string
+	failures   int
+	reconnects int
+}
+
+func (h *healthMonitor) halt() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+func (h *healthMonitor) stopped() bool {
+	select {
+	case <-h.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// startHealthCheck starts monitoring the connection, replacing any
+// previous monitor. Caller holds m.mu.
+func (m *ConnectionManager) startHealthCheck(id string) {
+	m.stopHealthCheck(id)
+	h := &healthMonitor{stop: make(chan struct{}), status: HealthUnknown}
+	m.health[id] = h
+	go m.runHealthCheck(id, h)
+}
+
+// stopHealthCheck stops and forgets the connection's monitor. It doesn't
+// wait for the goroutine, which exits at its next wake-up; a check already
+// in flight sees the monitor was replaced and leaves the pool alone.
+// Caller holds m.mu.
+func (m *ConnectionManager) stopHealthCheck(id string) {
+	if h, ok := m.health[id]; ok {
+		h.halt()
+		delete(m.health, id)
+	}
+}
+
+func (m *ConnectionManager) runHealthCheck(id string, h *healthMonitor) {
+	for {
+		interval := healthCheckInterval()
+		wait := interval
+		if wait == 0 {
+			// Off: look at the preference again later.
+			wait = defaultHealthCheckInterval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-h.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if interval == 0 {
+			continue
+		}
+		if !m.checkHealth(id, h) {
+			return
+		}
+	}
+}
+
+// checkHealth pings the pool once. On failure it tries a single reconnect
+// with a fresh pool; if that fails too the connection is marked
+// disconnected and false is returned to end monitoring.
+func (m *ConnectionManager) checkHealth(id string, h *healthMonitor) bool {
+	pool, err := m.GetPool(id)
+	if err != nil {
+		return false
+	}
+
+	// With every connection checked out the pool is plainly in use, and a
+	// ping would only queue behind the running queries and time out.
+	if stat := pool.Stat(); stat.AcquiredConns() >= stat.MaxConns() {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+	start := time.Now()
+	err = pool.Ping(ctx)
+	rtt := time.Since(start)
+	cancel()
+
+	if err == nil {
+		h.record(HealthHealthy, start, rtt, nil)
+		m.latencyMu.Lock()
+		m.latencies[id] = latencySample{rtt: rtt, measuredAt: time.Now()}
+		m.latencyMu.Unlock()
+		return true
+	}
+
+	log.Printf("health check: ping failed for connection %s: %v", id, err)
+	if reconnectErr := m.reconnect(id, h); reconnectErr != nil {
+		log.Printf("health check: reconnect failed for connection %s: %v", id, reconnectErr)
+		h.record(HealthDisconnected, start, 0, reconnectErr)
+		return false
+	}
+	h.record(HealthReconnected, start, 0, err)
+	return !h.stopped()
+}
+
+// reconnect replaces the connection's pool (and SSH tunnel) with a fresh
+// one. If that fails the connection is marked disconnected. Does nothing
+// once h has been stopped, so a Disconnect that raced the check wins.
+//
+// m.mu is only held to swap state: the new tunnel and pool are dialled
+// without it, and the old pool and tunnel are closed in the background,
+// since Close waits for any queries still using them.
+func (m *ConnectionManager) reconnect(id string, h *healthMonitor) error {
+	m.mu.Lock()
+	if h.stopped() || m.health[id] != h {
+		m.mu.Unlock()
+		return nil
+	}
+	conn, ok := m.connections[id]
+	if !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("connection not found: %s", id)
+	}
+
+	oldPool := m.pools[id]
+	oldTunnel := m.tunnels[id]
+	delete(m.pools, id)
+	delete(m.tunnels, id)
+	m.forgetLatency(id)
+	config, err := m.poolConfig(conn)
+	tunnel := tunnelSettings(conn)
+	m.mu.Unlock()
+
+	go func() {
+		if oldPool != nil {
+			oldPool.Close()
+		}
+		if oldTunnel != nil {
+			oldTunnel.Close()
+		}
+	}()
+
+	var pool *pgxpool.Pool
+	if err == nil {
+		var t *sshTunnel
+		if t, err = m.openTunnel(id, tunnel); err == nil {
+			if t != nil {
+				t.attach(config)
+			}
+			pool, err = openPoolWithRetry(config)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if h.stopped() || m.health[id] != h {
+		// Disconnected (or reconnected by hand) meanwhile.
+		if pool != nil {
+			pool.Close()
+		}
+		if _, ok := m.pools[id]; !ok {
+			m.closeTunnel(id)
+		}
+		return nil
+	}
+	if err != nil {
+		m.closeTunnel(id)
+		conn.IsConnected = false
+		h.halt()
+		return err
+	}
+	m.pools[id] = pool
+	conn.IsConnected = true
+	return nil
+}
+
+func (h *healthMonitor) record(status string, at time.Time, rtt time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.status = status
+	h.lastPingAt = &at
+	h.latency = rtt
+	h.lastError = ""
+	switch status {
+	case HealthHealthy:
+		h.failures = 0
+	case HealthReconnected:
+		h.failures++
+		h.reconnects++
+	default:
+		h.failures++
+	}
+	if err != nil {
+		h.lastError = err.Error()
+	}
+}
+
+// Health reports the connection's background health check results.
+func (m *ConnectionManager) Health(id string) (*models.ConnectionHealth, error) {
+	m.mu.RLock()
+	conn, ok := m.connections[id]
+	if !ok {
+		m.mu.RUnlock()
+		return nil, fmt.Errorf("connection not found: %s", id)
+	}
+	_, connected := m.pools[id]
+	h := m.health[id]
+	m.mu.RUnlock()
+
+	health := &models.ConnectionHealth{
+		ConnectionID:    conn.ID,
+		IsConnected:     connected,
+		Status:          HealthDisconnected,
+		IntervalSeconds: int(healthCheckInterval().Seconds()),
+	}
+	if h == nil {
+		return health, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if connected || h.status == HealthDisconnected {
+		health.Status = h.status
+	}
+	health.LastPingAt = h.lastPingAt
+	health.LatencyMs = h.latency.Seconds() * 1000
+	health.LastError = h.lastError
+	health.ConsecutiveFailures = h.failures
+	health.Reconnects = h.reconnects
+	return health, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseHealthCheckInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{" 30 ", 30 * time.Second, false},
+		{"5", 5 * time.Second, false},
+		{"3600", time.Hour, false},
+		{"4", 0, true},
+		{"3601", 0, true},
+		{"-1", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHealthCheckInterval(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseHealthCheckInterval(%q) = (%v, %v), want (%v, err=%v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHealthMonitorRecord(t *testing.T) {
+	h := &healthMonitor{stop: make(chan struct{}), status: HealthUnknown}
+	now := time.Now()
+
+	h.record(HealthReconnected, now, 0, errors.New("connection reset"))
+	h.record(HealthDisconnected, now, 0, errors.New("refused"))
+	if h.failures != 2 || h.reconnects != 1 || h.lastError != "refused" {
+		t.Errorf("after failures: failures=%d reconnects=%d lastError=%q", h.failures, h.reconnects, h.lastError)
+	}
+
+	h.record(HealthHealthy, now, 3*time.Millisecond, nil)
+	if h.status != HealthHealthy || h.failures != 0 || h.lastError != "" || h.latency != 3*time.Millisecond {
+		t.Errorf("after success: %+v", h)
+	}
+}
+
+func TestStopHealthCheck(t *testing.T) {
+	m := &ConnectionManager{health: make(map[string]*healthMonitor)}
+	h := &healthMonitor{stop: make(chan struct{})}
+	m.health["a"] = h
+
+	m.stopHealthCheck("a")
+	m.stopHealthCheck("a")
+	if !h.stopped() {
+		t.Error("monitor should be stopped")
+	}
+	if _, ok := m.health["a"]; ok {
+		t.Error("monitor should be forgotten")
+	}
+}
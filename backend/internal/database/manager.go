@@ -3,11 +3,15 @@ package database
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
 	"github.com/thelinuxer/pgvoyager/internal/models"
@@ -23,13 +27,33 @@ type ConnectionManager struct {
 	mu          sync.RWMutex
 	connections map[string]*models.Connection
 	pools       map[string]*pgxpool.Pool
+	tunnels     map[string]*sshTunnel // keyed like pools; only for SSH connections
+
+	latencyMu sync.Mutex
+	latencies map[string]latencySample
+
+	health       map[string]*healthMonitor // guarded by mu
+	sizeSamplers map[string]*sizeSampler   // guarded by mu
+}
+
+// latencySample is a cached round-trip measurement for a connection.
+type latencySample struct {
+	rtt        time.Duration
+	measuredAt time.Time
 }
 
+// latencyTTL bounds how often Latency actually pings the server.
+const latencyTTL = 15 * time.Second
+
 func GetManager() *ConnectionManager {
 	managerOnce.Do(func() {
 		manager = &ConnectionManager{
-			connections: make(map[string]*models.Connection),
-			pools:       make(map[string]*pgxpool.Pool),
+			connections:  make(map[string]*models.Connection),
+			pools:        make(map[string]*pgxpool.Pool),
+			tunnels:      make(map[string]*sshTunnel),
+			latencies:    make(map[string]latencySample),
+			health:       make(map[string]*healthMonitor),
+			sizeSamplers: make(map[string]*sizeSampler),
 		}
 		manager.loadConnections()
 	})
@@ -46,7 +70,8 @@ func (m *ConnectionManager) loadConnections() error {
 	}
 
 	rows, err := db.Query(`
-		SELECT id, name, host, port, database, username, password, ssl_mode, created_at
+		SELECT id, name, host, port, database, username, password, ssl_mode, connection_string, max_conn_idle_time, max_conns, min_conns, max_conn_lifetime, is_read_only, environment, role, search_path, default_query_timeout_ms,
+			ssl_cert, ssl_key, ssl_root_cert, ssh_host, ssh_port, ssh_user, ssh_key_path, ssh_password, is_favorite, created_at
 		FROM connections
 	`)
 	if err != nil {
@@ -65,6 +90,25 @@ func (m *ConnectionManager) loadConnections() error {
 			&conn.Username,
 			&conn.Password,
 			&conn.SSLMode,
+			&conn.ConnectionString,
+			&conn.MaxConnIdleTime,
+			&conn.MaxConns,
+			&conn.MinConns,
+			&conn.MaxConnLifetime,
+			&conn.IsReadOnly,
+			&conn.Environment,
+			&conn.Role,
+			&conn.SearchPath,
+			&conn.DefaultQueryTimeoutMs,
+			&conn.SSLCert,
+			&conn.SSLKey,
+			&conn.SSLRootCert,
+			&conn.SSHHost,
+			&conn.SSHPort,
+			&conn.SSHUser,
+			&conn.SSHKeyPath,
+			&conn.SSHPassword,
+			&conn.IsFavorite,
 			&conn.CreatedAt,
 		)
 		if err != nil {
@@ -85,11 +129,61 @@ func (m *ConnectionManager) List() []*models.Connection {
 	for _, conn := range m.connections {
 		connCopy := *conn
 		connCopy.Password = "" // Don't expose password
+		connCopy.SSHPassword = ""
+		connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 		result = append(result, &connCopy)
 	}
+	sortConnections(result)
 	return result
 }
 
+// sortConnections orders connections favorites first, then by name
+// (ignoring case), then by ID so equal names keep a stable order.
+func sortConnections(conns []*models.Connection) {
+	sort.Slice(conns, func(i, j int) bool {
+		a, b := conns[i], conns[j]
+		if a.IsFavorite != b.IsFavorite {
+			return a.IsFavorite
+		}
+		if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+			return an < bn
+		}
+		return a.ID < b.ID
+	})
+}
+
+// SetFavorite pins or unpins a connection, flipping it when favorite is
+// nil.
+func (m *ConnectionManager) SetFavorite(id string, favorite *bool) (*models.Connection, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn, ok := m.connections[id]
+	if !ok {
+		return nil, fmt.Errorf("connection not found: %s", id)
+	}
+
+	value := !conn.IsFavorite
+	if favorite != nil {
+		value = *favorite
+	}
+
+	db, err := storage.GetDB()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec("UPDATE connections SET is_favorite = ? WHERE id = ?", value, id); err != nil {
+		return nil, err
+	}
+	conn.IsFavorite = value
+
+	connCopy := *conn
+	connCopy.Password = ""
+	connCopy.SSHPassword = ""
+	connCopy.ConnectionString = redactConnString(conn.ConnectionString)
+	return &connCopy, nil
+}
+
 func (m *ConnectionManager) Get(id string) (*models.Connection, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -100,6 +194,8 @@ func (m *ConnectionManager) Get(id string) (*models.Connection, error) {
 	}
 	connCopy := *conn
 	connCopy.Password = ""
+	connCopy.SSHPassword = ""
+	connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 	return &connCopy, nil
 }
 
@@ -117,9 +213,9 @@ func (m *ConnectionManager) GetWithPassword(id string) (*models.Connection, erro
 }
 
 func (m *ConnectionManager) Create(req *models.ConnectionRequest) (*models.Connection, error) {
-	database := req.Database
-	if database == "" {
-		database = models.DefaultDatabase
+	database, err := requestDatabase(req.ConnectionString, req.Database)
+	if err != nil {
+		return nil, err
 	}
 
 	conn := &models.Connection{
@@ -133,9 +229,29 @@ func (m *ConnectionManager) Create(req *models.ConnectionRequest) (*models.Conne
 		SSLMode:   req.SSLMode,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		ConnectionString: req.ConnectionString,
+
+		MaxConnIdleTime: req.MaxConnIdleTime,
+		MaxConns:        req.MaxConns,
+		MinConns:        req.MinConns,
+		MaxConnLifetime: req.MaxConnLifetime,
+		IsReadOnly:      req.IsReadOnly,
+		Environment:     req.Environment,
+		Role:            strings.TrimSpace(req.Role),
+		SearchPath:      NormalizeSearchPath(req.SearchPath),
+		SSLFiles:        req.SSLFiles,
+
+		DefaultQueryTimeoutMs: req.DefaultQueryTimeoutMs,
+
+		SSHHost:     req.SSHHost,
+		SSHPort:     req.SSHPort,
+		SSHUser:     req.SSHUser,
+		SSHKeyPath:  req.SSHKeyPath,
+		SSHPassword: req.SSHPassword,
 	}
 
-	if conn.SSLMode == "" {
+	if conn.SSLMode == "" && conn.ConnectionString == "" {
 		conn.SSLMode = "prefer"
 	}
 
@@ -145,9 +261,11 @@ func (m *ConnectionManager) Create(req *models.ConnectionRequest) (*models.Conne
 	}
 
 	_, err = db.Exec(`
-		INSERT INTO connections (id, name, host, port, database, username, password, ssl_mode, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, conn.ID, conn.Name, conn.Host, conn.Port, conn.Database, conn.Username, conn.Password, conn.SSLMode, conn.CreatedAt)
+		INSERT INTO connections (id, name, host, port, database, username, password, ssl_mode, connection_string, max_conn_idle_time, max_conns, min_conns, max_conn_lifetime, is_read_only, environment, role, search_path, default_query_timeout_ms,
+			ssl_cert, ssl_key, ssl_root_cert, ssh_host, ssh_port, ssh_user, ssh_key_path, ssh_password, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, conn.ID, conn.Name, conn.Host, conn.Port, conn.Database, conn.Username, conn.Password, conn.SSLMode, conn.ConnectionString, conn.MaxConnIdleTime, conn.MaxConns, conn.MinConns, conn.MaxConnLifetime, conn.IsReadOnly, conn.Environment, conn.Role, conn.SearchPath, conn.DefaultQueryTimeoutMs,
+		conn.SSLCert, conn.SSLKey, conn.SSLRootCert, conn.SSHHost, conn.SSHPort, conn.SSHUser, conn.SSHKeyPath, conn.SSHPassword, conn.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -158,9 +276,49 @@ func (m *ConnectionManager) Create(req *models.ConnectionRequest) (*models.Conne
 
 	connCopy := *conn
 	connCopy.Password = ""
+	connCopy.SSHPassword = ""
+	connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 	return &connCopy, nil
 }
 
+// Duplicate saves a copy of a connection, passwords included, through
+// Create: a new ID, and the name with " (copy)" appended.
+func (m *ConnectionManager) Duplicate(id string) (*models.Connection, error) {
+	src, err := m.GetWithPassword(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Create(&models.ConnectionRequest{
+		Name:             src.Name + " (copy)",
+		Host:             src.Host,
+		Port:             src.Port,
+		Database:         src.Database,
+		Username:         src.Username,
+		Password:         src.Password,
+		SSLMode:          src.SSLMode,
+		ConnectionString: src.ConnectionString,
+		MaxConnIdleTime:  src.MaxConnIdleTime,
+		MaxConns:         src.MaxConns,
+		MinConns:         src.MinConns,
+		MaxConnLifetime:  src.MaxConnLifetime,
+		IsReadOnly:       src.IsReadOnly,
+		Environment:      src.Environment,
+		Role:             src.Role,
+		SearchPath:       src.SearchPath,
+		SSLFiles:         src.SSLFiles,
+
+		DefaultQueryTimeoutMs: src.DefaultQueryTimeoutMs,
+		SSHTunnel: models.SSHTunnel{
+			SSHHost:     src.SSHHost,
+			SSHPort:     src.SSHPort,
+			SSHUser:     src.SSHUser,
+			SSHKeyPath:  src.SSHKeyPath,
+			SSHPassword: src.SSHPassword,
+		},
+	})
+}
+
 func (m *ConnectionManager) Update(id string, req *models.ConnectionRequest) (*models.Connection, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -170,19 +328,54 @@ func (m *ConnectionManager) Update(id string, req *models.ConnectionRequest) (*m
 		return nil, fmt.Errorf("connection not found: %s", id)
 	}
 
+	// The UI sends back the redacted string it was given; that means
+	// "unchanged", not a password of "xxxxx".
+	connString := req.ConnectionString
+	if connString != "" && connString == redactConnString(conn.ConnectionString) {
+		connString = conn.ConnectionString
+	}
+
 	conn.Name = req.Name
 	conn.Host = req.Host
 	conn.Port = req.Port
-	if req.Database != "" {
+	if connString != "" {
+		database, err := requestDatabase(connString, req.Database)
+		if err != nil {
+			return nil, err
+		}
+		conn.Database = database
+	} else if req.Database != "" {
 		conn.Database = req.Database
-	} else if conn.Database == "" {
+	} else if conn.Database == "" || conn.ConnectionString != "" {
 		conn.Database = models.DefaultDatabase
 	}
 	conn.Username = req.Username
-	if req.Password != "" {
+	if connString != "" {
+		conn.Password = ""
+	} else if req.Password != "" {
 		conn.Password = req.Password
 	}
 	conn.SSLMode = req.SSLMode
+	conn.ConnectionString = connString
+	conn.MaxConnIdleTime = req.MaxConnIdleTime
+	conn.MaxConns = req.MaxConns
+	conn.MinConns = req.MinConns
+	conn.MaxConnLifetime = req.MaxConnLifetime
+	conn.IsReadOnly = req.IsReadOnly
+	conn.Environment = req.Environment
+	conn.Role = strings.TrimSpace(req.Role)
+	conn.SearchPath = NormalizeSearchPath(req.SearchPath)
+	conn.DefaultQueryTimeoutMs = req.DefaultQueryTimeoutMs
+	conn.SSLFiles = req.SSLFiles
+	conn.SSHHost = req.SSHHost
+	conn.SSHPort = req.SSHPort
+	conn.SSHUser = req.SSHUser
+	conn.SSHKeyPath = req.SSHKeyPath
+	if req.SSHPassword != "" {
+		conn.SSHPassword = req.SSHPassword
+	} else if req.SSHHost == "" {
+		conn.SSHPassword = ""
+	}
 	conn.UpdatedAt = time.Now()
 
 	db, err := storage.GetDB()
@@ -192,15 +385,19 @@ func (m *ConnectionManager) Update(id string, req *models.ConnectionRequest) (*m
 
 	_, err = db.Exec(`
 		UPDATE connections
-		SET name = ?, host = ?, port = ?, database = ?, username = ?, password = ?, ssl_mode = ?
+		SET name = ?, host = ?, port = ?, database = ?, username = ?, password = ?, ssl_mode = ?, connection_string = ?, max_conn_idle_time = ?, max_conns = ?, min_conns = ?, max_conn_lifetime = ?, is_read_only = ?, environment = ?, role = ?, search_path = ?, default_query_timeout_ms = ?,
+			ssl_cert = ?, ssl_key = ?, ssl_root_cert = ?, ssh_host = ?, ssh_port = ?, ssh_user = ?, ssh_key_path = ?, ssh_password = ?
 		WHERE id = ?
-	`, conn.Name, conn.Host, conn.Port, conn.Database, conn.Username, conn.Password, conn.SSLMode, id)
+	`, conn.Name, conn.Host, conn.Port, conn.Database, conn.Username, conn.Password, conn.SSLMode, conn.ConnectionString, conn.MaxConnIdleTime, conn.MaxConns, conn.MinConns, conn.MaxConnLifetime, conn.IsReadOnly, conn.Environment, conn.Role, conn.SearchPath, conn.DefaultQueryTimeoutMs,
+		conn.SSLCert, conn.SSLKey, conn.SSLRootCert, conn.SSHHost, conn.SSHPort, conn.SSHUser, conn.SSHKeyPath, conn.SSHPassword, id)
 	if err != nil {
 		return nil, err
 	}
 
 	connCopy := *conn
 	connCopy.Password = ""
+	connCopy.SSHPassword = ""
+	connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 	return &connCopy, nil
 }
 
@@ -217,6 +414,9 @@ func (m *ConnectionManager) Delete(id string) error {
 		pool.Close()
 		delete(m.pools, id)
 	}
+	m.closeTunnel(id)
+	m.stopHealthCheck(id)
+	m.stopSizeSampler(id)
 
 	db, err := storage.GetDB()
 	if err != nil {
@@ -227,17 +427,43 @@ func (m *ConnectionManager) Delete(id string) error {
 	if err != nil {
 		return err
 	}
+	if err := storage.ClearSizeHistory(id); err != nil {
+		log.Printf("could not clear size history of deleted connection %s: %v", id, err)
+	}
+	if err := storage.ClearTableAccess(id); err != nil {
+		log.Printf("could not clear table usage of deleted connection %s: %v", id, err)
+	}
 
 	delete(m.connections, id)
 	return nil
 }
 
 func (m *ConnectionManager) buildConnString(conn *models.Connection) string {
+	if conn.ConnectionString != "" {
+		return conn.ConnectionString
+	}
 	database := conn.Database
 	if database == "" {
 		database = models.DefaultDatabase
 	}
-	return buildPostgresURL(conn.Username, conn.Password, conn.Host, conn.Port, database, conn.SSLMode)
+	return buildPostgresURL(conn.Username, conn.Password, conn.Host, conn.Port, database, conn.SSLMode, conn.SSLFiles)
+}
+
+// parsePoolConfig parses the connection's settings. A connection string is
+// used as-is apart from the database, which follows conn.Database so
+// SwitchDatabase works for those connections too.
+func (m *ConnectionManager) parsePoolConfig(conn *models.Connection) (*pgxpool.Config, error) {
+	if err := checkSSLFiles(conn.SSLFiles); err != nil {
+		return nil, err
+	}
+	config, err := pgxpool.ParseConfig(m.buildConnString(conn))
+	if err != nil {
+		return nil, err
+	}
+	if conn.ConnectionString != "" && conn.Database != "" {
+		config.ConnConfig.Database = conn.Database
+	}
+	return config, nil
 }
 
 // buildPostgresURL composes a postgres:// connection URL with every
@@ -245,7 +471,7 @@ func (m *ConnectionManager) buildConnString(conn *models.Connection) string {
 // or `?` in a password or database name could redirect to a different host
 // or corrupt the connection string in ways that surface as confusing
 // (and potentially credential-leaking) pgx parse errors.
-func buildPostgresURL(user, password, host string, port int, database, sslMode string) string {
+func buildPostgresURL(user, password, host string, port int, database, sslMode string, files models.SSLFiles) string {
 	u := url.URL{
 		Scheme: "postgres",
 		User:   url.UserPassword(user, password),
@@ -256,83 +482,135 @@ func buildPostgresURL(user, password, host string, port int, database, sslMode s
 	if sslMode != "" {
 		q.Set("sslmode", sslMode)
 	}
+	setSSLFileParams(q, files)
 	u.RawQuery = q.Encode()
 	return u.String()
 }
 
-func (m *ConnectionManager) TestConnection(req *models.TestConnectionRequest) error {
-	database := req.Database
-	if database == "" {
-		database = models.DefaultDatabase
+// TestConnection opens a throwaway pool with the request's settings and
+// reports on the server it reaches.
+func (m *ConnectionManager) TestConnection(req *models.TestConnectionRequest) (*models.ConnectionInfo, error) {
+	connStr := req.ConnectionString
+	if connStr == "" {
+		database := req.Database
+		if database == "" {
+			database = models.DefaultDatabase
+		}
+		connStr = buildPostgresURL(req.Username, req.Password, req.Host, req.Port, database, req.SSLMode, req.SSLFiles)
+	}
+	if err := checkSSLFiles(req.SSLFiles); err != nil {
+		return nil, err
 	}
-	connStr := buildPostgresURL(req.Username, req.Password, req.Host, req.Port, database, req.SSLMode)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
 
 	// Use a minimal pool configuration for testing
 	config, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if req.ConnectionString != "" && req.Database != "" {
+		config.ConnConfig.Database = req.Database
 	}
 	config.MaxConns = 1 // Only need one connection for testing
 	config.MinConns = 0
+	applySessionSettings(config, strings.TrimSpace(req.Role), NormalizeSearchPath(req.SearchPath))
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if req.SSHHost != "" {
+		tunnel, err := openSSHTunnel(req.SSHTunnel)
+		if err != nil {
+			return nil, err
+		}
+		defer tunnel.Close()
+		tunnel.attach(config)
+	}
+
+	pool, err := openPoolWithRetry(config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer pool.Close()
 
-	return pool.Ping(ctx)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return fetchConnectionInfo(ctx, conn.Conn())
 }
 
+// Connect opens the connection's pool. The SSH tunnel, if any, and the
+// pool are dialled (with retries) without holding m.mu, so a slow or
+// unreachable server doesn't block every other connection; the lock is
+// only taken to prepare the config and to store the result.
 func (m *ConnectionManager) Connect(id string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	conn, ok := m.connections[id]
 	if !ok {
+		m.mu.Unlock()
 		return fmt.Errorf("connection not found: %s", id)
 	}
-
 	if _, ok := m.pools[id]; ok {
+		m.mu.Unlock()
 		return nil // Already connected
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Configure pool with limited connections to avoid exhausting PostgreSQL
-	config, err := pgxpool.ParseConfig(m.buildConnString(conn))
+	config, err := m.poolConfig(conn)
+	tunnel := tunnelSettings(conn)
+	m.mu.Unlock()
 	if err != nil {
 		return err
 	}
-	// PgVoyager is single-user and largely UI-driven; one or two
-	// concurrent server-side queries cover every realistic flow.
-	// Earlier `MaxConns = 5` exhausted Postgres' default 100-conn cap
-	// in the E2E suite once enough pools were open at once (5 conns
-	// per pool × N pools).
-	config.MaxConns = 2
-	config.MinConns = 0                       // No idle connections
-	config.MaxConnIdleTime = 1 * time.Minute  // Aggressive idle release
-	config.MaxConnLifetime = 30 * time.Minute // Recycle connections
+	if t, err := m.openTunnel(id, tunnel); err != nil {
+		return err
+	} else if t != nil {
+		t.attach(config)
+	}
 
-	pool, err := pgxpool.NewWithConfig(ctx, config)
+	pool, err := openPoolWithRetry(config)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, connected := m.pools[id]
 	if err != nil {
+		if !connected {
+			m.closeTunnel(id)
+		}
 		return err
 	}
-
-	if err := pool.Ping(ctx); err != nil {
+	if connected {
+		// A concurrent Connect got there first.
 		pool.Close()
-		return err
+		return nil
+	}
+	conn, ok = m.connections[id]
+	if !ok {
+		// Deleted while connecting.
+		pool.Close()
+		m.closeTunnel(id)
+		return fmt.Errorf("connection not found: %s", id)
 	}
 
 	m.pools[id] = pool
 	conn.IsConnected = true
+	m.startHealthCheck(id)
+	m.startSizeSampler(id)
 	return nil
 }
 
+// poolConfig builds the pool config for the connection, without its SSH
+// tunnel (see attachTunnel). Caller holds m.mu.
+func (m *ConnectionManager) poolConfig(conn *models.Connection) (*pgxpool.Config, error) {
+	// Configure pool with limited connections to avoid exhausting PostgreSQL
+	config, err := m.parsePoolConfig(conn)
+	if err != nil {
+		return nil, err
+	}
+	setPoolDefaults(config)
+	applyConnectionOptions(config, conn)
+	return config, nil
+}
+
 func (m *ConnectionManager) Disconnect(id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -346,6 +624,10 @@ func (m *ConnectionManager) Disconnect(id string) error {
 		pool.Close()
 		delete(m.pools, id)
 	}
+	m.closeTunnel(id)
+	m.forgetLatency(id)
+	m.stopHealthCheck(id)
+	m.stopSizeSampler(id)
 
 	conn.IsConnected = false
 	return nil
@@ -362,6 +644,231 @@ func (m *ConnectionManager) GetPool(id string) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
+// openTunnel returns the connection's SSH tunnel, opening it if there
+// isn't one yet, or nil for a direct connection. Caller must not hold
+// m.mu: the bastion is dialled and authenticated without it, and a tunnel
+// stored meanwhile by a concurrent caller wins over this one.
+func (m *ConnectionManager) openTunnel(id string, settings models.SSHTunnel) (*sshTunnel, error) {
+	if settings.SSHHost == "" {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	tunnel, ok := m.tunnels[id]
+	m.mu.RUnlock()
+	if ok {
+		return tunnel, nil
+	}
+
+	opened, err := openSSHTunnel(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.connections[id]; !ok {
+		opened.Close()
+		return nil, fmt.Errorf("connection not found: %s", id)
+	}
+	if tunnel, ok := m.tunnels[id]; ok {
+		opened.Close()
+		return tunnel, nil
+	}
+	m.tunnels[id] = opened
+	return opened, nil
+}
+
+// closeTunnel tears down the connection's SSH tunnel, if any. Caller holds m.mu.
+func (m *ConnectionManager) closeTunnel(id string) {
+	if tunnel, ok := m.tunnels[id]; ok {
+		tunnel.Close()
+		delete(m.tunnels, id)
+	}
+}
+
+// ValidatePoolSize checks a connection's pool bounds. Either may be zero
+// (the default); when both are set the minimum can't exceed the maximum.
+func ValidatePoolSize(maxConns, minConns int) error {
+	if maxConns > 0 && minConns > maxConns {
+		return fmt.Errorf("minConns (%d) must not exceed maxConns (%d)", minConns, maxConns)
+	}
+	return nil
+}
+
+// Pool defaults for a connection that leaves MaxConns, MinConns or
+// MaxConnLifetime at zero. PgVoyager is single-user and largely
+// UI-driven; one or two concurrent server-side queries cover every
+// realistic flow. Earlier `MaxConns = 5` exhausted Postgres' default
+// 100-conn cap in the E2E suite once enough pools were open at once
+// (5 conns per pool × N pools).
+const (
+	defaultPoolMaxConns        = 2
+	defaultPoolMinConns        = 0 // No idle connections
+	defaultPoolMaxConnLifetime = 30 * time.Minute
+)
+
+// setPoolDefaults applies the pool defaults above, before
+// applyConnectionOptions overrides them with the connection's own.
+func setPoolDefaults(config *pgxpool.Config) {
+	config.MaxConns = defaultPoolMaxConns
+	config.MinConns = defaultPoolMinConns
+	config.MaxConnLifetime = defaultPoolMaxConnLifetime
+}
+
+// applyConnectionOptions applies the connection's per-pool settings:
+//   - MaxConnIdleTime, MaxConns, MinConns and MaxConnLifetime; left at
+//     zero, the defaults already in config stand (setPoolDefaults, and
+//     pgx's for MaxConnIdleTime). A MinConns above the default maximum
+//     raises the maximum to match.
+//   - IsReadOnly sets default_transaction_read_only as a startup parameter,
+//     so even statements that slip past the handler checks can't write.
+//   - Role and SearchPath are set on every session (see
+//     applySessionSettings).
+func applyConnectionOptions(config *pgxpool.Config, conn *models.Connection) {
+	if conn.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = time.Duration(conn.MaxConnIdleTime) * time.Second
+	}
+	if conn.MaxConns > 0 {
+		config.MaxConns = int32(conn.MaxConns)
+	}
+	if conn.MinConns > 0 {
+		config.MinConns = int32(conn.MinConns)
+		config.MaxConns = max(config.MaxConns, config.MinConns)
+	}
+	if conn.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = time.Duration(conn.MaxConnLifetime) * time.Second
+	}
+	if conn.IsReadOnly {
+		config.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+	applySessionSettings(config, conn.Role, conn.SearchPath)
+}
+
+// Latency returns the round-trip time of a trivial query on the
+// connection's pool. Results are cached for latencyTTL so connection-info
+// polling doesn't turn into a ping loop. The first call after connecting
+// may include connection setup time if the pool has no idle connection.
+func (m *ConnectionManager) Latency(id string) (time.Duration, error) {
+	m.latencyMu.Lock()
+	sample, ok := m.latencies[id]
+	m.latencyMu.Unlock()
+	if ok && time.Since(sample.measuredAt) < latencyTTL {
+		return sample.rtt, nil
+	}
+
+	pool, err := m.GetPool(id)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	start := time.Now()
+	if _, err := conn.Exec(ctx, "SELECT 1"); err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+
+	m.latencyMu.Lock()
+	m.latencies[id] = latencySample{rtt: rtt, measuredAt: time.Now()}
+	m.latencyMu.Unlock()
+	return rtt, nil
+}
+
+// forgetLatency drops the cached sample, e.g. when the pool is replaced.
+func (m *ConnectionManager) forgetLatency(id string) {
+	m.latencyMu.Lock()
+	delete(m.latencies, id)
+	m.latencyMu.Unlock()
+}
+
+// IsReadOnly reports whether the connection is flagged read-only.
+func (m *ConnectionManager) IsReadOnly(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, ok := m.connections[id]
+	return ok && conn.IsReadOnly
+}
+
+// DefaultQueryTimeout returns the connection's DefaultQueryTimeoutMs, or
+// zero when it has none.
+func (m *ConnectionManager) DefaultQueryTimeout(id string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, ok := m.connections[id]
+	if !ok {
+		return 0
+	}
+	return time.Duration(conn.DefaultQueryTimeoutMs) * time.Millisecond
+}
+
+// IsProduction reports whether the connection is tagged production.
+func (m *ConnectionManager) IsProduction(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	conn, ok := m.connections[id]
+	return ok && conn.Environment == models.EnvironmentProduction
+}
+
+// PoolStats returns a snapshot of the connection's pool counters along with
+// the effective idle and lifetime limits it was configured with.
+func (m *ConnectionManager) PoolStats(id string) (*models.PoolStats, error) {
+	pool, err := m.GetPool(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stat := pool.Stat()
+	config := pool.Config()
+	return &models.PoolStats{
+		TotalConns:              stat.TotalConns(),
+		IdleConns:               stat.IdleConns(),
+		AcquiredConns:           stat.AcquiredConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		MaxConns:                stat.MaxConns(),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxConnIdleTime:         config.MaxConnIdleTime.Seconds(),
+		MaxConnLifetime:         config.MaxConnLifetime.Seconds(),
+	}, nil
+}
+
+// OpenDedicatedConn opens a connection outside the pool but configured
+// like it (tunnel, read-only flag, role), for sessions that hold one for
+// a long time: the pool is kept small and can't close while a connection
+// is checked out. The caller closes it.
+func (m *ConnectionManager) OpenDedicatedConn(ctx context.Context, id string) (*pgx.Conn, error) {
+	pool, err := m.GetPool(id)
+	if err != nil {
+		return nil, err
+	}
+
+	config := pool.Config()
+	conn, err := pgx.ConnectConfig(ctx, config.ConnConfig)
+	if err != nil {
+		return nil, err
+	}
+	if config.AfterConnect != nil {
+		if err := config.AfterConnect(ctx, conn); err != nil {
+			conn.Close(ctx)
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
 func (m *ConnectionManager) IsConnected(id string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -376,10 +883,26 @@ func (m *ConnectionManager) SwitchDatabase(id, dbName string) (*models.Connectio
 		return nil, fmt.Errorf("database name is required")
 	}
 
+	// The tunnel (if any) survives the pool swap, since it's the same
+	// server; make sure it's open before taking the lock.
+	m.mu.RLock()
+	conn, ok := m.connections[id]
+	var settings models.SSHTunnel
+	if ok {
+		settings = tunnelSettings(conn)
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("connection not found: %s", id)
+	}
+	if _, err := m.openTunnel(id, settings); err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	conn, ok := m.connections[id]
+	conn, ok = m.connections[id]
 	if !ok {
 		return nil, fmt.Errorf("connection not found: %s", id)
 	}
@@ -388,6 +911,8 @@ func (m *ConnectionManager) SwitchDatabase(id, dbName string) (*models.Connectio
 		if pool, ok := m.pools[id]; ok && pool != nil {
 			connCopy := *conn
 			connCopy.Password = ""
+			connCopy.SSHPassword = ""
+			connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 			return &connCopy, nil
 		}
 	}
@@ -405,24 +930,34 @@ func (m *ConnectionManager) SwitchDatabase(id, dbName string) (*models.Connectio
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	config, err := pgxpool.ParseConfig(m.buildConnString(conn))
+	config, err := m.parsePoolConfig(conn)
 	if err != nil {
 		conn.Database = previousDB
 		return nil, err
 	}
-	config.MaxConns = 5
-	config.MinConns = 0
-	config.MaxConnIdleTime = 5 * time.Minute
-	config.MaxConnLifetime = 30 * time.Minute
+	setPoolDefaults(config)
+	applyConnectionOptions(config, conn)
+
+	if conn.SSHHost != "" {
+		tunnel, ok := m.tunnels[id]
+		if !ok {
+			// Disconnected while the tunnel was opening.
+			conn.Database = previousDB
+			return nil, &TunnelError{Err: fmt.Errorf("tunnel closed while switching databases")}
+		}
+		tunnel.attach(config)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
+		m.closeTunnel(id)
 		conn.Database = previousDB
 		return nil, err
 	}
 
 	if err := pool.Ping(ctx); err != nil {
 		pool.Close()
+		m.closeTunnel(id)
 		conn.Database = previousDB
 		return nil, err
 	}
@@ -430,21 +965,26 @@ func (m *ConnectionManager) SwitchDatabase(id, dbName string) (*models.Connectio
 	db, err := storage.GetDB()
 	if err != nil {
 		pool.Close()
+		m.closeTunnel(id)
 		conn.Database = previousDB
 		return nil, err
 	}
 
 	if _, err := db.Exec(`UPDATE connections SET database = ? WHERE id = ?`, dbName, id); err != nil {
 		pool.Close()
+		m.closeTunnel(id)
 		conn.Database = previousDB
 		return nil, err
 	}
 
 	m.pools[id] = pool
 	conn.IsConnected = true
+	m.forgetLatency(id)
 
 	connCopy := *conn
 	connCopy.Password = ""
+	connCopy.SSHPassword = ""
+	connCopy.ConnectionString = redactConnString(conn.ConnectionString)
 	return &connCopy, nil
 }
 
@@ -1,9 +1,21 @@
 package database
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
 )
 
 func TestBuildPostgresURLEncodesCredentials(t *testing.T) {
@@ -16,6 +28,7 @@ func TestBuildPostgresURLEncodesCredentials(t *testing.T) {
 		5432,
 		"app db",
 		"disable",
+		models.SSLFiles{},
 	)
 
 	u, err := url.Parse(got)
@@ -48,8 +61,308 @@ func TestBuildPostgresURLEncodesCredentials(t *testing.T) {
 }
 
 func TestBuildPostgresURLOmitsSSLModeWhenEmpty(t *testing.T) {
-	got := buildPostgresURL("u", "p", "h", 5432, "d", "")
+	got := buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{})
 	if strings.Contains(got, "sslmode=") {
 		t.Errorf("empty sslMode should not emit query param: %s", got)
 	}
 }
+
+func TestApplyConnectionOptions(t *testing.T) {
+	config, err := pgxpool.ParseConfig(buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgxDefault := config.MaxConnIdleTime
+
+	applyConnectionOptions(config, &models.Connection{})
+	if config.MaxConnIdleTime != pgxDefault {
+		t.Errorf("zero MaxConnIdleTime changed pool idle time to %v, want pgx default %v", config.MaxConnIdleTime, pgxDefault)
+	}
+
+	if _, ok := config.ConnConfig.RuntimeParams["default_transaction_read_only"]; ok {
+		t.Error("read-write connection should not set default_transaction_read_only")
+	}
+
+	applyConnectionOptions(config, &models.Connection{MaxConnIdleTime: 45, IsReadOnly: true})
+	if config.MaxConnIdleTime != 45*time.Second {
+		t.Errorf("MaxConnIdleTime = %v, want 45s", config.MaxConnIdleTime)
+	}
+	if got := config.ConnConfig.RuntimeParams["default_transaction_read_only"]; got != "on" {
+		t.Errorf("default_transaction_read_only = %q, want on", got)
+	}
+}
+
+func TestApplyConnectionOptionsPoolSize(t *testing.T) {
+	config, err := pgxpool.ParseConfig(buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := *config
+
+	applyConnectionOptions(config, &models.Connection{})
+	if config.MaxConns != defaults.MaxConns || config.MinConns != defaults.MinConns || config.MaxConnLifetime != defaults.MaxConnLifetime {
+		t.Errorf("zero pool settings changed pgx defaults")
+	}
+
+	applyConnectionOptions(config, &models.Connection{MaxConns: 20, MinConns: 2, MaxConnLifetime: 600})
+	if config.MaxConns != 20 || config.MinConns != 2 || config.MaxConnLifetime != 10*time.Minute {
+		t.Errorf("got MaxConns=%d MinConns=%d MaxConnLifetime=%v", config.MaxConns, config.MinConns, config.MaxConnLifetime)
+	}
+
+	config, _ = pgxpool.ParseConfig(buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{}))
+	applyConnectionOptions(config, &models.Connection{MinConns: int(defaults.MaxConns) + 5})
+	if config.MaxConns != config.MinConns {
+		t.Errorf("MinConns above the default max should raise MaxConns, got max %d min %d", config.MaxConns, config.MinConns)
+	}
+}
+
+func TestValidatePoolSize(t *testing.T) {
+	if err := ValidatePoolSize(0, 5); err != nil {
+		t.Errorf("unset max should accept any min: %v", err)
+	}
+	if err := ValidatePoolSize(10, 10); err != nil {
+		t.Errorf("equal bounds should be valid: %v", err)
+	}
+	if err := ValidatePoolSize(4, 5); err == nil {
+		t.Error("min above max should be rejected")
+	}
+}
+
+func TestTunnelErrorIsDistinguishable(t *testing.T) {
+	_, err := openSSHTunnel(models.SSHTunnel{SSHHost: "bastion"})
+	if err == nil {
+		t.Fatal("expected error for tunnel without a user")
+	}
+	if !IsTunnelError(err) {
+		t.Errorf("missing-user error should be a TunnelError: %v", err)
+	}
+	if IsTunnelError(fmt.Errorf("password authentication failed")) {
+		t.Error("plain errors must not be classified as tunnel errors")
+	}
+}
+
+func TestSSHAuthMethodsRequiresCredential(t *testing.T) {
+	if _, err := sshAuthMethods(models.SSHTunnel{SSHHost: "h", SSHUser: "u"}); err == nil {
+		t.Error("expected error when neither key nor password is set")
+	}
+	methods, err := sshAuthMethods(models.SSHTunnel{SSHHost: "h", SSHUser: "u", SSHPassword: "pw"})
+	if err != nil || len(methods) != 1 {
+		t.Errorf("password-only auth: got %d methods, err %v", len(methods), err)
+	}
+}
+
+func TestConnectRetryDelay(t *testing.T) {
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i, w := range want {
+		if got := connectRetryDelay(i + 1); got != w {
+			t.Errorf("connectRetryDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := connectRetryDelay(100); got != connectRetryMaxDelay {
+		t.Errorf("connectRetryDelay(100) = %v, want cap %v", got, connectRetryMaxDelay)
+	}
+}
+
+func TestIsTransientConnectError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"starting up", &pgconn.PgError{Code: "57P03"}, true},
+		{"bad password", &pgconn.PgError{Code: "28P01"}, false},
+		{"missing database", &pgconn.PgError{Code: "3D000"}, false},
+		{"tunnel", &TunnelError{Err: syscall.ECONNREFUSED}, false},
+		{"timeout", context.DeadlineExceeded, false},
+	}
+	for _, tc := range cases {
+		if got := isTransientConnectError(tc.err); got != tc.want {
+			t.Errorf("%s: isTransientConnectError = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestConnectAttemptsEnv(t *testing.T) {
+	t.Setenv("PGVOYAGER_CONNECT_ATTEMPTS", "")
+	if got := ConnectAttempts(); got != DefaultConnectAttempts {
+		t.Errorf("default = %d, want %d", got, DefaultConnectAttempts)
+	}
+	t.Setenv("PGVOYAGER_CONNECT_ATTEMPTS", "1")
+	if got := ConnectAttempts(); got != 1 {
+		t.Errorf("override = %d, want 1", got)
+	}
+	t.Setenv("PGVOYAGER_CONNECT_ATTEMPTS", "0")
+	if got := ConnectAttempts(); got != DefaultConnectAttempts {
+		t.Errorf("zero should fall back to default, got %d", got)
+	}
+}
+
+func TestClassifyConnectError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"bad password", &pgconn.PgError{Code: "28P01"}, ConnectErrAuthFailed},
+		{"hba ssl", &pgconn.PgError{Code: "28000", Message: `no pg_hba.conf entry for host "10.0.0.1", user "app", database "app", no encryption`}, ConnectErrSSLRequired},
+		{"hba reject", &pgconn.PgError{Code: "28000", Message: `pg_hba.conf rejects connection`}, ConnectErrAccessDenied},
+		{"no database", fmt.Errorf("after retries: %w", &pgconn.PgError{Code: "3D000"}), ConnectErrDatabaseNotFound},
+		{"role denied", &RoleError{Role: "analyst", Err: &pgconn.PgError{Code: "42501"}}, ConnectErrRoleDenied},
+		{"no role", &RoleError{Role: "analyst", Err: &pgconn.PgError{Code: "42704"}}, ConnectErrRoleDenied},
+		{"no connect privilege", &pgconn.PgError{Code: "42501"}, ConnectErrAccessDenied},
+		{"too many", &pgconn.PgError{Code: "53300"}, ConnectErrTooManyConnections},
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), ConnectErrHostUnreachable},
+		{"dns", &net.DNSError{Err: "no such host", Name: "db.invalid", IsNotFound: true}, ConnectErrHostNotFound},
+		{"tls", fmt.Errorf("server refused TLS connection"), ConnectErrSSLUnsupported},
+		{"tunnel", &TunnelError{Err: fmt.Errorf("handshake failed")}, ConnectErrSSHTunnel},
+		{"other", fmt.Errorf("something odd"), ConnectErrUnknown},
+	}
+	for _, tc := range cases {
+		info := ClassifyConnectError(tc.err)
+		if info.Code != tc.want {
+			t.Errorf("%s: code = %q, want %q", tc.name, info.Code, tc.want)
+		}
+		if info.Message == "" {
+			t.Errorf("%s: empty message", tc.name)
+		}
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	for _, ok := range []string{"", "analyst", "app-readonly", "Mixed Case", `weird"quote`} {
+		if err := ValidateRole(ok); err != nil {
+			t.Errorf("ValidateRole(%q) = %v, want nil", ok, err)
+		}
+	}
+	for _, bad := range []string{"a\x00b", "line\nbreak", strings.Repeat("r", 64)} {
+		if err := ValidateRole(bad); err == nil {
+			t.Errorf("ValidateRole(%q) should fail", bad)
+		}
+	}
+	if got, _ := setRoleSQL(`weird"quote`); got != `SET ROLE "weird""quote"` {
+		t.Errorf("setRoleSQL = %s", got)
+	}
+}
+
+func TestApplyConnectionOptionsRole(t *testing.T) {
+	config, err := pgxpool.ParseConfig(buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyConnectionOptions(config, &models.Connection{})
+	if config.AfterConnect != nil || config.AfterRelease != nil {
+		t.Error("no role: hooks should stay unset")
+	}
+	applyConnectionOptions(config, &models.Connection{Role: "analyst"})
+	if config.AfterConnect == nil {
+		t.Error("role set: AfterConnect hook should be installed")
+	}
+	if config.AfterRelease != nil {
+		t.Error("role set: AfterRelease should stay unset; the role is restored only after a reset")
+	}
+
+	config, _ = pgxpool.ParseConfig(buildPostgresURL("u", "p", "h", 5432, "d", "", models.SSLFiles{}))
+	applyConnectionOptions(config, &models.Connection{SearchPath: "app, public"})
+	if got := config.ConnConfig.RuntimeParams["search_path"]; got != `"app", "public"` {
+		t.Errorf("search path set: search_path startup parameter = %q", got)
+	}
+	if config.AfterConnect != nil || config.AfterRelease != nil {
+		t.Error("search path set: hooks should stay unset")
+	}
+}
+
+func TestValidateSearchPath(t *testing.T) {
+	for _, path := range []string{"", "app", "app, public", `$user, Mixed Case, public`} {
+		if err := ValidateSearchPath(path); err != nil {
+			t.Errorf("ValidateSearchPath(%q) = %v", path, err)
+		}
+	}
+	for _, path := range []string{"app,,public", "app,", "bad\nname", strings.Repeat("x", 64)} {
+		if ValidateSearchPath(path) == nil {
+			t.Errorf("ValidateSearchPath(%q) should fail", path)
+		}
+	}
+
+	if got := NormalizeSearchPath("  app ,public  "); got != "app, public" {
+		t.Errorf("NormalizeSearchPath = %q", got)
+	}
+	if got, _ := searchPathValue(`app, $user, we"ird`); got != `"app", "$user", "we""ird"` {
+		t.Errorf("searchPathValue = %s", got)
+	}
+}
+
+func TestExtensionStatuses(t *testing.T) {
+	got := extensionStatuses([]string{"pg_stat_statements", "vector", "postgis"}, map[string]string{
+		"pg_stat_statements": "1.10",
+		"vector":             "",
+	})
+	want := []models.ExtensionStatus{
+		{Name: "pg_stat_statements", Installed: true, InstalledVersion: "1.10", Available: true},
+		{Name: "vector", Available: true},
+		{Name: "postgis"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extensionStatuses = %+v, want %+v", got, want)
+	}
+}
+
+func TestSortConnectionsFavoritesFirst(t *testing.T) {
+	conns := []*models.Connection{
+		{ID: "4", Name: "beta"},
+		{ID: "3", Name: "Zeta", IsFavorite: true},
+		{ID: "2", Name: "alpha"},
+		{ID: "1", Name: "Alpha"},
+		{ID: "5", Name: "gamma", IsFavorite: true},
+	}
+	sortConnections(conns)
+
+	var got []string
+	for _, c := range conns {
+		got = append(got, c.ID)
+	}
+	want := []string{"5", "3", "1", "2", "4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestSSHHandshakeTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bastion that accepts the connection and never says anything.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	defer func(d time.Duration) { sshTimeout = d }(sshTimeout)
+	sshTimeout = 200 * time.Millisecond
+
+	addr := ln.Addr().(*net.TCPAddr)
+	start := time.Now()
+	_, err = openSSHTunnel(models.SSHTunnel{SSHHost: "127.0.0.1", SSHPort: addr.Port, SSHUser: "u", SSHPassword: "p"})
+	if err == nil || !IsTunnelError(err) {
+		t.Fatalf("expected a tunnel error from a stalled bastion, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("handshake took %v, want it bounded by sshTimeout", elapsed)
+	}
+}
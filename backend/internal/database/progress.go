@@ -0,0 +1,163 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// progressRetention is how long a finished operation's last event is kept
+// for a client that subscribes late.
+const progressRetention = time.Minute
+
+var (
+	progressOps     *ProgressManager
+	progressOpsOnce sync.Once
+)
+
+// ProgressManager relays progress of long operations (imports, exports,
+// maintenance) to WebSocket subscribers, keyed by the operationId the
+// client tagged the operation with. A subscriber may arrive before the
+// operation starts.
+type ProgressManager struct {
+	mu  sync.Mutex
+	ops map[string]*progressOp
+}
+
+type progressOp struct {
+	running bool
+	last    *models.ProgressEvent
+	subs    map[chan models.ProgressEvent]struct{}
+	expiry  *time.Timer
+}
+
+func GetProgressManager() *ProgressManager {
+	progressOpsOnce.Do(func() {
+		progressOps = &ProgressManager{
+			ops: make(map[string]*progressOp),
+		}
+	})
+	return progressOps
+}
+
+// op returns the entry for id, creating it. Callers hold m.mu.
+func (m *ProgressManager) op(id string) *progressOp {
+	op, ok := m.ops[id]
+	if !ok {
+		op = &progressOp{subs: make(map[chan models.ProgressEvent]struct{})}
+		m.ops[id] = op
+	}
+	return op
+}
+
+// Begin starts tracking an operation. It returns false if one with this
+// operationId is still running.
+func (m *ProgressManager) Begin(id, operation string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op := m.op(id)
+	if op.running {
+		return false
+	}
+	if op.expiry != nil {
+		op.expiry.Stop()
+		op.expiry = nil
+	}
+	op.running = true
+	m.publish(op, models.ProgressEvent{Type: "progress", OperationID: id, Operation: operation})
+	return true
+}
+
+// Report sends a "progress" event. OperationID and Type are filled in.
+func (m *ProgressManager) Report(id string, event models.ProgressEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.ops[id]
+	if !ok || !op.running {
+		return
+	}
+	event.Type = "progress"
+	event.OperationID = id
+	m.publish(op, event)
+}
+
+// Finish sends the final "done" event, or "error" with err, on top of the
+// last progress reported. The event stays available to late subscribers
+// for progressRetention.
+func (m *ProgressManager) Finish(id string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op, ok := m.ops[id]
+	if !ok || !op.running {
+		return
+	}
+	event := *op.last
+	event.Type = "done"
+	if err != nil {
+		event.Type = "error"
+		event.Error = err.Error()
+	}
+	op.running = false
+	m.publish(op, event)
+	op.expiry = time.AfterFunc(progressRetention, func() { m.expire(id, op) })
+}
+
+// expire forgets a finished operation, unless it has been restarted.
+func (m *ProgressManager) expire(id string, op *progressOp) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ops[id] == op && !op.running {
+		delete(m.ops, id)
+	}
+}
+
+// publish records event and hands it to every subscriber. Each
+// subscriber's channel holds one event and a slow reader only gets the
+// latest, so a stalled socket never holds up the operation. Callers hold
+// m.mu.
+func (m *ProgressManager) publish(op *progressOp, event models.ProgressEvent) {
+	op.last = &event
+	for ch := range op.subs {
+		for sent := false; !sent; {
+			select {
+			case ch <- event:
+				sent = true
+			default:
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel of the operation's events, starting with the
+// latest if it has begun, and a function to stop receiving them.
+func (m *ProgressManager) Subscribe(id string) (<-chan models.ProgressEvent, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan models.ProgressEvent, 1)
+	op := m.op(id)
+	op.subs[ch] = struct{}{}
+	if op.last != nil {
+		ch <- *op.last
+	}
+
+	unsubscribe := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(op.subs, ch)
+		// Nothing to keep for a subscriber that gave up before the
+		// operation began.
+		if op.last == nil && len(op.subs) == 0 && m.ops[id] == op {
+			delete(m.ops, id)
+		}
+	}
+	return ch, unsubscribe
+}
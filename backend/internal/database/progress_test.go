@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestProgressSubscriberBeforeBegin(t *testing.T) {
+	m := &ProgressManager{ops: make(map[string]*progressOp)}
+	events, unsubscribe := m.Subscribe("op1")
+	defer unsubscribe()
+
+	if !m.Begin("op1", "import") {
+		t.Fatal("Begin refused a new operation")
+	}
+	if m.Begin("op1", "import") {
+		t.Error("Begin accepted an operationId that is running")
+	}
+	if got := <-events; got.Type != "progress" || got.Operation != "import" {
+		t.Errorf("first event = %+v", got)
+	}
+
+	// A slow subscriber only sees the latest event.
+	m.Report("op1", models.ProgressEvent{Operation: "import", Done: 10})
+	m.Report("op1", models.ProgressEvent{Operation: "import", Done: 20})
+	if got := <-events; got.Done != 20 || got.OperationID != "op1" {
+		t.Errorf("latest event = %+v", got)
+	}
+
+	m.Finish("op1", errors.New("boom"))
+	if got := <-events; got.Type != "error" || got.Error != "boom" || got.Done != 20 {
+		t.Errorf("final event = %+v", got)
+	}
+}
+
+func TestProgressLateSubscriberGetsFinalEvent(t *testing.T) {
+	m := &ProgressManager{ops: make(map[string]*progressOp)}
+	m.Begin("op2", "export")
+	m.Report("op2", models.ProgressEvent{Operation: "export", Done: 5})
+	m.Finish("op2", nil)
+
+	events, unsubscribe := m.Subscribe("op2")
+	defer unsubscribe()
+	if got := <-events; got.Type != "done" || got.Done != 5 {
+		t.Errorf("event = %+v", got)
+	}
+
+	// A finished operationId can be reused.
+	if !m.Begin("op2", "export") {
+		t.Error("Begin refused a finished operationId")
+	}
+}
+
+func TestProgressUnsubscribeBeforeBegin(t *testing.T) {
+	m := &ProgressManager{ops: make(map[string]*progressOp)}
+	_, unsubscribe := m.Subscribe("op3")
+	unsubscribe()
+	if len(m.ops) != 0 {
+		t.Errorf("ops = %v, want none", m.ops)
+	}
+}
@@ -1,16 +1,13 @@
 package database
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/thelinuxer/pgvoyager/internal/models"
-	"github.com/thelinuxer/pgvoyager/internal/secretstore"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
 )
 
 var (
@@ -18,95 +15,31 @@ var (
 	queryManagerOnce sync.Once
 )
 
-type SavedQueryManager struct {
-	mu         sync.RWMutex
-	queries    map[string]*models.SavedQuery
-	configPath string
-}
+// SavedQueryManager manages saved queries. They live in the SQLite store,
+// so concurrent saves are serialized by the database rather than racing
+// on a shared JSON file.
+type SavedQueryManager struct{}
 
 func GetQueryManager() *SavedQueryManager {
 	queryManagerOnce.Do(func() {
-		pgvoyagerDir, err := secretstore.Ensure()
-		if err != nil {
-			// Fall back to a temp dir if HOME / UserConfigDir blew up;
-			// saved queries are user-content, not credentials.
-			pgvoyagerDir = filepath.Join(os.TempDir(), "pgvoyager")
-			_ = os.MkdirAll(pgvoyagerDir, secretstore.DirPerm)
-		}
-
-		queryManager = &SavedQueryManager{
-			queries:    make(map[string]*models.SavedQuery),
-			configPath: filepath.Join(pgvoyagerDir, "queries.json"),
-		}
-		queryManager.loadQueries()
+		queryManager = &SavedQueryManager{}
 	})
 	return queryManager
 }
 
-func (m *SavedQueryManager) loadQueries() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	data, err := os.ReadFile(m.configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-
-	var queries []*models.SavedQuery
-	if err := json.Unmarshal(data, &queries); err != nil {
-		return err
-	}
-
-	for _, q := range queries {
-		m.queries[q.ID] = q
-	}
-	return nil
-}
-
-func (m *SavedQueryManager) saveQueries() error {
-	m.mu.RLock()
-	queries := make([]*models.SavedQuery, 0, len(m.queries))
-	for _, q := range m.queries {
-		queries = append(queries, q)
-	}
-	m.mu.RUnlock()
-
-	data, err := json.MarshalIndent(queries, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	if err := os.WriteFile(m.configPath, data, secretstore.FilePerm); err != nil {
-		return err
-	}
-	// Enforce the correct mode even if the file pre-existed with a looser
-	// permission (e.g. created before this policy was introduced).
-	return secretstore.SecureFile(m.configPath)
+// List returns the saved queries matching filter; see
+// models.SavedQueryFilter.
+func (m *SavedQueryManager) List(filter models.SavedQueryFilter) ([]*models.SavedQuery, error) {
+	return storage.ListSavedQueries(filter)
 }
 
-func (m *SavedQueryManager) List() []*models.SavedQuery {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	result := make([]*models.SavedQuery, 0, len(m.queries))
-	for _, q := range m.queries {
-		result = append(result, q)
-	}
-	return result
+// Tags returns the distinct tags in use with their query counts.
+func (m *SavedQueryManager) Tags() ([]models.TagCount, error) {
+	return storage.ListSavedQueryTags()
 }
 
 func (m *SavedQueryManager) Get(id string) (*models.SavedQuery, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	q, ok := m.queries[id]
-	if !ok {
-		return nil, fmt.Errorf("saved query not found: %s", id)
-	}
-	return q, nil
+	return storage.GetSavedQuery(id)
 }
 
 func (m *SavedQueryManager) Create(req *models.SavedQueryRequest) (*models.SavedQuery, error) {
@@ -116,51 +49,75 @@ func (m *SavedQueryManager) Create(req *models.SavedQueryRequest) (*models.Saved
 		SQL:          req.SQL,
 		ConnectionID: req.ConnectionID,
 		Description:  req.Description,
+		Tags:         normalizeTags(req.Tags),
+		Folder:       strings.TrimSpace(req.Folder),
+		Parameters:   req.Parameters,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
-	m.mu.Lock()
-	m.queries[q.ID] = q
-	m.mu.Unlock()
-
-	if err := m.saveQueries(); err != nil {
+	if err := storage.SaveSavedQuery(q); err != nil {
 		return nil, err
 	}
-
 	return q, nil
 }
 
 func (m *SavedQueryManager) Update(id string, req *models.SavedQueryRequest) (*models.SavedQuery, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	q, ok := m.queries[id]
-	if !ok {
-		return nil, fmt.Errorf("saved query not found: %s", id)
+	q, err := storage.GetSavedQuery(id)
+	if err != nil {
+		return nil, err
 	}
 
 	q.Name = req.Name
 	q.SQL = req.SQL
 	q.ConnectionID = req.ConnectionID
 	q.Description = req.Description
+	q.Tags = normalizeTags(req.Tags)
+	q.Folder = strings.TrimSpace(req.Folder)
+	q.Parameters = req.Parameters
 	q.UpdatedAt = time.Now()
 
-	if err := m.saveQueries(); err != nil {
+	if err := storage.SaveSavedQuery(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// SetFavorite pins or unpins a saved query, flipping it when favorite is
+// nil.
+func (m *SavedQueryManager) SetFavorite(id string, favorite *bool) (*models.SavedQuery, error) {
+	q, err := storage.GetSavedQuery(id)
+	if err != nil {
 		return nil, err
 	}
 
+	q.IsFavorite = !q.IsFavorite
+	if favorite != nil {
+		q.IsFavorite = *favorite
+	}
+	if err := storage.SetSavedQueryFavorite(id, q.IsFavorite); err != nil {
+		return nil, err
+	}
 	return q, nil
 }
 
 func (m *SavedQueryManager) Delete(id string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return storage.DeleteSavedQuery(id)
+}
 
-	if _, ok := m.queries[id]; !ok {
-		return fmt.Errorf("saved query not found: %s", id)
+// normalizeTags trims tags, drops empty ones and removes duplicates that
+// differ only in case, keeping the first spelling.
+func normalizeTags(tags []string) []string {
+	result := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
 	}
-
-	delete(m.queries, id)
-	return m.saveQueries()
+	return result
 }
@@ -0,0 +1,17 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Reports ", "ops", "", "reports", "OPS", "billing"})
+	want := []string{"Reports", "ops", "billing"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTags = %v, want %v", got, want)
+	}
+	if got := normalizeTags(nil); got == nil || len(got) != 0 {
+		t.Errorf("normalizeTags(nil) = %#v, want empty slice", got)
+	}
+}
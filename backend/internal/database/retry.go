@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// DefaultConnectAttempts is how many times Connect and TestConnection
+	// try to reach the server unless PGVOYAGER_CONNECT_ATTEMPTS says
+	// otherwise. With the backoff below four attempts wait ~3.5s in total,
+	// enough for a docker-compose Postgres to finish starting.
+	DefaultConnectAttempts = 4
+
+	connectAttemptTimeout = 10 * time.Second
+	connectRetryBaseDelay = 500 * time.Millisecond
+	connectRetryMaxDelay  = 4 * time.Second
+)
+
+// ConnectAttempts returns the configured number of connection attempts
+// (at least 1).
+func ConnectAttempts() int {
+	if v := strings.TrimSpace(os.Getenv("PGVOYAGER_CONNECT_ATTEMPTS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultConnectAttempts
+}
+
+// connectRetryDelay is the wait before retry number attempt (1-based):
+// 500ms, 1s, 2s, then capped at 4s.
+func connectRetryDelay(attempt int) time.Duration {
+	d := connectRetryBaseDelay << (attempt - 1)
+	if d > connectRetryMaxDelay || d <= 0 {
+		return connectRetryMaxDelay
+	}
+	return d
+}
+
+// isTransientConnectError reports whether a failed connection attempt is
+// worth retrying. Bad credentials, a missing database and SSH failures
+// fail fast — retrying them only delays the error. So do timeouts: the
+// attempt already waited connectAttemptTimeout, and retrying an
+// unreachable host would multiply that.
+func isTransientConnectError(err error) bool {
+	info := ClassifyConnectError(err)
+	return info.Transient && info.Code != ConnectErrTimeout
+}
+
+// openPoolWithRetry creates a pool from config and pings it, retrying
+// transient failures with exponential backoff. Each attempt gets its own
+// timeout. The returned error names the attempt count once retries are
+// exhausted and wraps the last failure, so IsTunnelError still works.
+func openPoolWithRetry(config *pgxpool.Config) (*pgxpool.Pool, error) {
+	attempts := ConnectAttempts()
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(connectRetryDelay(attempt - 1))
+		}
+
+		pool, err := pingNewPool(config)
+		if err == nil {
+			return pool, nil
+		}
+		lastErr = err
+		if !isTransientConnectError(err) {
+			return nil, err
+		}
+	}
+	if attempts == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("could not connect after %d attempts: %w", attempts, lastErr)
+}
+
+func pingNewPool(config *pgxpool.Config) (*pgxpool.Pool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectAttemptTimeout)
+	defer cancel()
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
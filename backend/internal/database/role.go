@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+)
+
+// maxRoleNameLen is Postgres' NAMEDATALEN - 1.
+const maxRoleNameLen = 63
+
+// ValidateRole checks a role name for SET ROLE. Role names are quoted, not
+// pattern-matched, so anything Postgres accepts as a name is allowed apart
+// from control characters. The empty string means "no role".
+func ValidateRole(role string) error {
+	role = strings.TrimSpace(role)
+	if len(role) > maxRoleNameLen {
+		return fmt.Errorf("role name is longer than %d bytes", maxRoleNameLen)
+	}
+	for _, r := range role {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("role name contains a control character")
+		}
+	}
+	return nil
+}
+
+// setRoleSQL builds the SET ROLE statement; role has passed ValidateRole.
+func setRoleSQL(role string) (string, error) {
+	quoted, err := dbsafe.QuoteIdent(role)
+	if err != nil {
+		return "", err
+	}
+	return "SET ROLE " + quoted, nil
+}
+
+// RoleError marks a failed SET ROLE on a new session, so ClassifyConnectError
+// can tell a missing role or membership from the same SQLSTATE raised for
+// other reasons, such as a login without CONNECT on the database.
+type RoleError struct {
+	Role string
+	Err  error
+}
+
+func (e *RoleError) Error() string { return "set role " + e.Role + ": " + e.Err.Error() }
+func (e *RoleError) Unwrap() error { return e.Err }
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	runningQueries     *RunningQueryManager
+	runningQueriesOnce sync.Once
+)
+
+// RunningQueryManager tracks each in-flight query that the client tagged
+// with a queryId: the backend PID serving it, and how to cancel it.
+type RunningQueryManager struct {
+	mu      sync.Mutex
+	queries map[string]runningQuery // connId + "/" + queryId
+}
+
+type runningQuery struct {
+	pid uint32
+	// cancel sends a cancel request for the query's backend, normally
+	// PgConn.CancelRequest, which goes over its own network connection
+	// rather than the (possibly exhausted) pool.
+	cancel func(context.Context) error
+}
+
+func GetRunningQueryManager() *RunningQueryManager {
+	runningQueriesOnce.Do(func() {
+		runningQueries = &RunningQueryManager{
+			queries: make(map[string]runningQuery),
+		}
+	})
+	return runningQueries
+}
+
+func runningKey(connId, queryId string) string {
+	return connId + "/" + queryId
+}
+
+// Register records the query's backend pid and canceller. It returns false
+// if the queryId is already in use on this connection.
+func (m *RunningQueryManager) Register(connId, queryId string, pid uint32, cancel func(context.Context) error) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := runningKey(connId, queryId)
+	if _, exists := m.queries[key]; exists {
+		return false
+	}
+	m.queries[key] = runningQuery{pid: pid, cancel: cancel}
+	return true
+}
+
+// Unregister forgets the query once it has finished (or failed).
+func (m *RunningQueryManager) Unregister(connId, queryId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.queries, runningKey(connId, queryId))
+}
+
+// Lookup returns the backend PID running the query, if it is still running.
+func (m *RunningQueryManager) Lookup(connId, queryId string) (uint32, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.queries[runningKey(connId, queryId)]
+	return q.pid, ok
+}
+
+// Cancel asks Postgres to cancel the query. It returns false if the query
+// isn't running.
+func (m *RunningQueryManager) Cancel(ctx context.Context, connId, queryId string) (bool, error) {
+	m.mu.Lock()
+	q, ok := m.queries[runningKey(connId, queryId)]
+	m.mu.Unlock()
+	if !ok || q.cancel == nil {
+		return false, nil
+	}
+	return true, q.cancel(ctx)
+}
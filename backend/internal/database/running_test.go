@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunningQueryManagerLifecycle(t *testing.T) {
+	m := &RunningQueryManager{queries: make(map[string]runningQuery)}
+
+	if !m.Register("conn", "q1", 4242, nil) {
+		t.Fatal("first Register should succeed")
+	}
+	if m.Register("conn", "q1", 1, nil) {
+		t.Error("duplicate queryId on the same connection should be rejected")
+	}
+	if !m.Register("other", "q1", 7, nil) {
+		t.Error("same queryId on another connection should be allowed")
+	}
+
+	if pid, ok := m.Lookup("conn", "q1"); !ok || pid != 4242 {
+		t.Errorf("Lookup = (%d, %v), want (4242, true)", pid, ok)
+	}
+
+	m.Unregister("conn", "q1")
+	if _, ok := m.Lookup("conn", "q1"); ok {
+		t.Error("query should be gone after Unregister")
+	}
+	if _, ok := m.Lookup("other", "q1"); !ok {
+		t.Error("Unregister must not affect other connections")
+	}
+}
+
+func TestRunningQueryManagerCancel(t *testing.T) {
+	m := &RunningQueryManager{queries: make(map[string]runningQuery)}
+	cancelled := false
+	m.Register("conn", "q1", 1, func(context.Context) error {
+		cancelled = true
+		return nil
+	})
+
+	if ok, err := m.Cancel(context.Background(), "conn", "q1"); !ok || err != nil || !cancelled {
+		t.Errorf("Cancel = (%v, %v), cancelled %v", ok, err, cancelled)
+	}
+	if ok, _ := m.Cancel(context.Background(), "conn", "missing"); ok {
+		t.Error("Cancel of an unknown queryId should report false")
+	}
+}
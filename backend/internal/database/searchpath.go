@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+)
+
+// maxSearchPathSchemas bounds how many schemas a connection's search_path
+// may list.
+const maxSearchPathSchemas = 32
+
+// splitSearchPath splits a comma-separated search path into its trimmed
+// schema names.
+func splitSearchPath(path string) []string {
+	if strings.TrimSpace(path) == "" {
+		return nil
+	}
+	parts := strings.Split(path, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+// NormalizeSearchPath tidies a search path for storage: names trimmed and
+// joined with ", ". The empty string means "server default".
+func NormalizeSearchPath(path string) string {
+	return strings.Join(splitSearchPath(path), ", ")
+}
+
+// ValidateSearchPath checks a comma-separated list of schemas for
+// search_path. Like role names, schema names are quoted rather than
+// pattern-matched, so any name works as written, unquoted ($user included);
+// empty entries and control characters are rejected. The schemas need not
+// exist, as with search_path itself.
+func ValidateSearchPath(path string) error {
+	schemas := splitSearchPath(path)
+	if len(schemas) > maxSearchPathSchemas {
+		return fmt.Errorf("search path lists more than %d schemas", maxSearchPathSchemas)
+	}
+	for _, schema := range schemas {
+		if schema == "" {
+			return fmt.Errorf("search path has an empty schema name")
+		}
+		if len(schema) > maxRoleNameLen {
+			return fmt.Errorf("schema name %q is longer than %d bytes", schema, maxRoleNameLen)
+		}
+		for _, r := range schema {
+			if unicode.IsControl(r) {
+				return fmt.Errorf("schema name contains a control character")
+			}
+		}
+	}
+	return nil
+}
+
+// searchPathValue builds the search_path setting, each schema quoted;
+// path has passed ValidateSearchPath.
+func searchPathValue(path string) (string, error) {
+	schemas := splitSearchPath(path)
+	quoted := make([]string, len(schemas))
+	for i, schema := range schemas {
+		q, err := dbsafe.QuoteIdent(schema)
+		if err != nil {
+			return "", err
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, ", "), nil
+}
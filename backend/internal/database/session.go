@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// applySessionSettings makes every pooled session run as role and with
+// searchPath, either of which may be empty. The search path is a startup
+// parameter, so it is the session default that RESET and DISCARD ALL
+// return to. The role is set in AfterConnect — a failure there (role
+// missing, login not a member) fails the connection attempt, so Connect
+// reports it — and restored by RestoreSessionRole after a session reset,
+// which returns it to the login role.
+func applySessionSettings(config *pgxpool.Config, role, searchPath string) {
+	if searchPath != "" {
+		value, err := searchPathValue(searchPath)
+		if err != nil {
+			config.AfterConnect = func(context.Context, *pgx.Conn) error { return err }
+			return
+		}
+		config.ConnConfig.RuntimeParams["search_path"] = value
+	}
+	if role == "" {
+		return
+	}
+
+	stmt, err := setRoleSQL(role)
+	if err != nil {
+		config.AfterConnect = func(context.Context, *pgx.Conn) error { return err }
+		return
+	}
+	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		if _, err := conn.Exec(ctx, stmt); err != nil {
+			return &RoleError{Role: role, Err: err}
+		}
+		return nil
+	}
+}
+
+// RestoreSessionRole sets the connection's configured role again on conn,
+// for callers that have just run DISCARD ALL on it. It does nothing when
+// the connection has no role.
+func (m *ConnectionManager) RestoreSessionRole(ctx context.Context, id string, conn *pgx.Conn) error {
+	m.mu.RLock()
+	c, ok := m.connections[id]
+	role := ""
+	if ok {
+		role = c.Role
+	}
+	m.mu.RUnlock()
+	if role == "" {
+		return nil
+	}
+
+	stmt, err := setRoleSQL(role)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, stmt); err != nil {
+		return &RoleError{Role: role, Err: err}
+	}
+	return nil
+}
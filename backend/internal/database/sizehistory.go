@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// SizeHistoryIntervalPreference holds the seconds between size samples
+	// of each connected database: 0 turns sampling off, otherwise
+	// MinSizeHistoryInterval to a day. Samples are kept for
+	// storage.SizeHistoryRetentionPreference days.
+	SizeHistoryIntervalPreference = "size_history.interval"
+	MinSizeHistoryInterval        = 5 * time.Minute
+	maxSizeHistoryInterval        = 24 * time.Hour
+	defaultSizeHistoryInterval    = time.Hour
+
+	// sizeSampleCheck is how often a sampler looks whether a sample is
+	// due. Samples are due by the last stored one, so reconnecting (or
+	// restarting PgVoyager) doesn't sample early.
+	sizeSampleCheck   = time.Minute
+	sizeSampleTimeout = 30 * time.Second
+	// sizeSampleTables is how many of the largest tables each sample
+	// records.
+	sizeSampleTables = 50
+)
+
+// ParseSizeHistoryInterval validates a SizeHistoryIntervalPreference value.
+// Zero means sampling is off.
+func ParseSizeHistoryInterval(value string) (time.Duration, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	d := time.Duration(n) * time.Second
+	if err != nil || (n != 0 && (d < MinSizeHistoryInterval || d > maxSizeHistoryInterval)) {
+		return 0, fmt.Errorf("must be 0 (off) or %d to %d seconds",
+			int(MinSizeHistoryInterval.Seconds()), int(maxSizeHistoryInterval.Seconds()))
+	}
+	return d, nil
+}
+
+// SizeHistoryInterval reads the configured interval, falling back to the
+// default when the preference is unset or invalid.
+func SizeHistoryInterval() time.Duration {
+	value, err := storage.GetPreference(SizeHistoryIntervalPreference)
+	if err != nil || value == "" {
+		return defaultSizeHistoryInterval
+	}
+	d, err := ParseSizeHistoryInterval(value)
+	if err != nil {
+		return defaultSizeHistoryInterval
+	}
+	return d
+}
+
+// sizeSampler records one connected database's size in the background
+// until stopped by Disconnect or Delete.
+type sizeSampler struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (s *sizeSampler) halt() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// startSizeSampler starts sampling the connection, replacing any previous
+// sampler. Caller holds m.mu.
+func (m *ConnectionManager) startSizeSampler(id string) {
+	m.stopSizeSampler(id)
+	s := &sizeSampler{stop: make(chan struct{})}
+	m.sizeSamplers[id] = s
+	go m.runSizeSampler(id, s)
+}
+
+// stopSizeSampler stops and forgets the connection's sampler. A sample in
+// flight still completes. Caller holds m.mu.
+func (m *ConnectionManager) stopSizeSampler(id string) {
+	if s, ok := m.sizeSamplers[id]; ok {
+		s.halt()
+		delete(m.sizeSamplers, id)
+	}
+}
+
+func (m *ConnectionManager) runSizeSampler(id string, s *sizeSampler) {
+	ticker := time.NewTicker(sizeSampleCheck)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !m.IsConnected(id) {
+			// The health check gave up on it; Connect starts a new sampler.
+			return
+		}
+		interval := SizeHistoryInterval()
+		if interval == 0 {
+			continue
+		}
+		last, err := storage.LastSizeSampleAt(id)
+		if err != nil {
+			log.Printf("size history: reading last sample for connection %s: %v", id, err)
+			continue
+		}
+		if time.Since(last) < interval {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sizeSampleTimeout)
+		_, err = m.SampleSize(ctx, id)
+		cancel()
+		if err != nil {
+			log.Printf("size history: sampling connection %s: %v", id, err)
+		}
+	}
+}
+
+// SampleSize measures the connection's database and its largest tables
+// now and stores the sample.
+func (m *ConnectionManager) SampleSize(ctx context.Context, id string) (*models.SizeSample, error) {
+	pool, err := m.GetPool(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &models.SizeSample{SampledAt: time.Now(), Tables: []models.TableSizeSample{}}
+	if err := pool.QueryRow(ctx, `SELECT pg_database_size(current_database())`).Scan(&sample.DatabaseBytes); err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT n.nspname, c.relname, pg_total_relation_size(c.oid)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'm')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND n.nspname NOT LIKE 'pg_temp%'
+		ORDER BY 3 DESC
+		LIMIT $1
+	`, sizeSampleTables)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.TableSizeSample
+		if err := rows.Scan(&t.Schema, &t.Table, &t.Bytes); err != nil {
+			return nil, err
+		}
+		sample.Tables = append(sample.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := storage.AddSizeSample(id, sample); err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSizeHistoryInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"300", 5 * time.Minute, false},
+		{" 3600 ", time.Hour, false},
+		{"86400", 24 * time.Hour, false},
+		{"299", 0, true},
+		{"86401", 0, true},
+		{"-1", 0, true},
+		{"hourly", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSizeHistoryInterval(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSizeHistoryInterval(%q) = (%v, %v), want (%v, err=%v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestStopSizeSampler(t *testing.T) {
+	m := &ConnectionManager{sizeSamplers: make(map[string]*sizeSampler)}
+	s := &sizeSampler{stop: make(chan struct{})}
+	m.sizeSamplers["a"] = s
+
+	m.stopSizeSampler("a")
+	m.stopSizeSampler("a")
+	select {
+	case <-s.stop:
+	default:
+		t.Error("sampler should be stopped")
+	}
+	if _, ok := m.sizeSamplers["a"]; ok {
+		t.Error("sampler should be forgotten")
+	}
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// SSLFileError marks a configured certificate or key file that can't be
+// read, caught before pgx turns it into a generic TLS failure.
+type SSLFileError struct {
+	Kind string // "client certificate", "client key" or "root certificate"
+	Err  error
+}
+
+func (e *SSLFileError) Error() string { return "ssl " + e.Kind + ": " + e.Err.Error() }
+func (e *SSLFileError) Unwrap() error { return e.Err }
+
+// ValidateSSLFiles checks a request's certificate settings: the client
+// certificate and key come as a pair, and no file goes with a connection
+// string (which names its own) or with sslmode=disable.
+func ValidateSSLFiles(files models.SSLFiles, sslMode, connString string) error {
+	if files == (models.SSLFiles{}) {
+		return nil
+	}
+	if connString != "" {
+		return errors.New("put sslcert, sslkey and sslrootcert in the connection string instead")
+	}
+	if sslMode == "disable" {
+		return errors.New("ssl certificate files need an SSL mode other than disable")
+	}
+	if (files.SSLCert == "") != (files.SSLKey == "") {
+		return errors.New("a client certificate needs its key, and a key its certificate")
+	}
+	return nil
+}
+
+// sslFiles lists the configured files with what each one is.
+func sslFiles(files models.SSLFiles) []struct{ kind, param, path string } {
+	return []struct{ kind, param, path string }{
+		{"client certificate", "sslcert", files.SSLCert},
+		{"client key", "sslkey", files.SSLKey},
+		{"root certificate", "sslrootcert", files.SSLRootCert},
+	}
+}
+
+// setSSLFileParams adds the configured files to a connection URL's query.
+func setSSLFileParams(q url.Values, files models.SSLFiles) {
+	for _, f := range sslFiles(files) {
+		if f.path != "" {
+			q.Set(f.param, expandHome(f.path))
+		}
+	}
+}
+
+// checkSSLFiles verifies at connect time that every configured file exists
+// and is readable.
+func checkSSLFiles(files models.SSLFiles) error {
+	for _, f := range sslFiles(files) {
+		if f.path == "" {
+			continue
+		}
+		path := expandHome(f.path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return &SSLFileError{Kind: f.kind, Err: err}
+		}
+		if info.IsDir() {
+			return &SSLFileError{Kind: f.kind, Err: fmt.Errorf("%s is a directory", path)}
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return &SSLFileError{Kind: f.kind, Err: err}
+		}
+		file.Close()
+	}
+	return nil
+}
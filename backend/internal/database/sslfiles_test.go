@@ -0,0 +1,76 @@
+package database
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestValidateSSLFiles(t *testing.T) {
+	pair := models.SSLFiles{SSLCert: "/c.pem", SSLKey: "/k.pem"}
+	tests := []struct {
+		name       string
+		files      models.SSLFiles
+		sslMode    string
+		connString string
+		wantErr    bool
+	}{
+		{"none", models.SSLFiles{}, "disable", "", false},
+		{"pair", pair, "require", "", false},
+		{"root only", models.SSLFiles{SSLRootCert: "/ca.pem"}, "verify-full", "", false},
+		{"cert without key", models.SSLFiles{SSLCert: "/c.pem"}, "require", "", true},
+		{"key without cert", models.SSLFiles{SSLKey: "/k.pem"}, "require", "", true},
+		{"ssl disabled", pair, "disable", "", true},
+		{"connection string", pair, "", "postgres://h/db", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSSLFiles(tt.files, tt.sslMode, tt.connString); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateSSLFiles error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckSSLFiles(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "client.crt")
+	if err := os.WriteFile(cert, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkSSLFiles(models.SSLFiles{SSLCert: cert}); err != nil {
+		t.Errorf("readable file: unexpected error %v", err)
+	}
+
+	err := checkSSLFiles(models.SSLFiles{SSLCert: cert, SSLKey: filepath.Join(dir, "missing.key")})
+	var fileErr *SSLFileError
+	if !errors.As(err, &fileErr) || fileErr.Kind != "client key" {
+		t.Errorf("missing key: got %v, want an SSLFileError for the client key", err)
+	}
+	if info := ClassifyConnectError(err); info.Code != ConnectErrSSLFile {
+		t.Errorf("ClassifyConnectError code = %q, want %q", info.Code, ConnectErrSSLFile)
+	}
+
+	if err := checkSSLFiles(models.SSLFiles{SSLRootCert: dir}); !errors.As(err, &fileErr) {
+		t.Errorf("directory: got %v, want an SSLFileError", err)
+	}
+}
+
+func TestBuildPostgresURLSSLFiles(t *testing.T) {
+	got := buildPostgresURL("u", "p", "h", 5432, "d", "verify-full", models.SSLFiles{
+		SSLCert:     "/certs/client.crt",
+		SSLKey:      "/certs/client.key",
+		SSLRootCert: "/certs/ca.crt",
+	})
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("sslcert") != "/certs/client.crt" || q.Get("sslkey") != "/certs/client.key" || q.Get("sslrootcert") != "/certs/ca.crt" {
+		t.Errorf("ssl file params missing from %s", got)
+	}
+}
@@ -0,0 +1,343 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// TransactionIdleTimeoutPreference holds the seconds an interactive
+	// transaction may go without a statement before it is rolled back,
+	// MinTransactionIdleTimeout to an hour. There is no "never": an
+	// abandoned transaction holds locks and a server connection.
+	TransactionIdleTimeoutPreference = "transactions.idle_timeout"
+	MinTransactionIdleTimeout        = 30 * time.Second
+	maxTransactionIdleTimeout        = time.Hour
+	defaultTransactionIdleTimeout    = 5 * time.Minute
+
+	// MaxOpenTransactions bounds the interactive transactions open at once
+	// on one connection, each holding its own server connection.
+	MaxOpenTransactions = 4
+
+	txReapInterval = 15 * time.Second
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found or already finished")
+	ErrTooManyTransactions = fmt.Errorf("at most %d open transactions per connection", MaxOpenTransactions)
+	ErrTransactionEnded    = errors.New("a statement ended the transaction itself, so it has been closed")
+	ErrInvalidSavepoint    = errors.New("savepoint name must be an identifier: a letter or underscore, then letters, digits or underscores, at most 63 characters")
+)
+
+// Savepoint operations on an interactive transaction, as the SQL that
+// precedes the savepoint name.
+const (
+	SavepointCreate   = "SAVEPOINT"
+	SavepointRelease  = "RELEASE SAVEPOINT"
+	SavepointRollback = "ROLLBACK TO SAVEPOINT"
+)
+
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// ValidateSavepointName checks that name is a plain identifier, so it can
+// go into the savepoint statements unquoted and matches a savepoint the
+// user created in their own SQL the way Postgres would.
+func ValidateSavepointName(name string) error {
+	if !savepointName.MatchString(name) {
+		return ErrInvalidSavepoint
+	}
+	return nil
+}
+
+// ParseTransactionIdleTimeout validates a TransactionIdleTimeoutPreference
+// value.
+func ParseTransactionIdleTimeout(value string) (time.Duration, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	d := time.Duration(n) * time.Second
+	if err != nil || d < MinTransactionIdleTimeout || d > maxTransactionIdleTimeout {
+		return 0, fmt.Errorf("must be %d to %d seconds",
+			int(MinTransactionIdleTimeout.Seconds()), int(maxTransactionIdleTimeout.Seconds()))
+	}
+	return d, nil
+}
+
+// transactionIdleTimeout reads the configured timeout, falling back to the
+// default when the preference is unset or invalid.
+func transactionIdleTimeout() time.Duration {
+	value, err := storage.GetPreference(TransactionIdleTimeoutPreference)
+	if err != nil || value == "" {
+		return defaultTransactionIdleTimeout
+	}
+	d, err := ParseTransactionIdleTimeout(value)
+	if err != nil {
+		return defaultTransactionIdleTimeout
+	}
+	return d
+}
+
+var (
+	transactions     *TransactionManager
+	transactionsOnce sync.Once
+)
+
+// TransactionManager holds interactive transactions between requests. Each
+// runs on a dedicated connection (see OpenDedicatedConn), so it neither
+// takes a pool slot nor keeps the pool from closing.
+type TransactionManager struct {
+	mu  sync.Mutex
+	txs map[string]*Transaction
+}
+
+// Transaction is one open interactive transaction.
+type Transaction struct {
+	ID        string
+	ConnID    string
+	StartedAt time.Time
+
+	// mu is held while a statement runs, so statements on one
+	// transaction run one at a time and can't race commit or rollback.
+	mu           sync.Mutex
+	conn         *pgx.Conn
+	tx           pgx.Tx
+	lastActivity time.Time
+	done         bool
+}
+
+// GetTransactionManager returns the manager, starting its idle reaper on
+// first use.
+func GetTransactionManager() *TransactionManager {
+	transactionsOnce.Do(func() {
+		transactions = &TransactionManager{txs: make(map[string]*Transaction)}
+		go transactions.reapIdle()
+	})
+	return transactions
+}
+
+// Begin opens a transaction on a new dedicated connection.
+func (m *TransactionManager) Begin(ctx context.Context, connId string) (*Transaction, error) {
+	if m.count(connId) >= MaxOpenTransactions {
+		return nil, ErrTooManyTransactions
+	}
+
+	conn, err := GetManager().OpenDedicatedConn(ctx, connId)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+
+	now := time.Now()
+	t := &Transaction{
+		ID:           uuid.New().String(),
+		ConnID:       connId,
+		StartedAt:    now,
+		conn:         conn,
+		tx:           tx,
+		lastActivity: now,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Re-check: another Begin may have won while this one connected.
+	if m.countLocked(connId) >= MaxOpenTransactions {
+		conn.Close(ctx)
+		return nil, ErrTooManyTransactions
+	}
+	m.txs[t.ID] = t
+	return t, nil
+}
+
+func (m *TransactionManager) count(connId string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.countLocked(connId)
+}
+
+func (m *TransactionManager) countLocked(connId string) int {
+	n := 0
+	for _, t := range m.txs {
+		if t.ConnID == connId {
+			n++
+		}
+	}
+	return n
+}
+
+// Get finds an open transaction. A txId from another connection is treated
+// as unknown.
+func (m *TransactionManager) Get(connId, txId string) (*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.txs[txId]
+	if !ok || t.ConnID != connId {
+		return nil, ErrTransactionNotFound
+	}
+	return t, nil
+}
+
+// List returns the connection's open transactions, oldest first.
+func (m *TransactionManager) List(connId string) []models.TransactionInfo {
+	m.mu.Lock()
+	var open []*Transaction
+	for _, t := range m.txs {
+		if t.ConnID == connId {
+			open = append(open, t)
+		}
+	}
+	m.mu.Unlock()
+
+	infos := []models.TransactionInfo{}
+	for _, t := range open {
+		infos = append(infos, t.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt.Before(infos[j].StartedAt) })
+	return infos
+}
+
+// Info describes the transaction. It doesn't wait for a running statement;
+// a busy transaction reports now as its last activity.
+func (t *Transaction) Info() models.TransactionInfo {
+	info := models.TransactionInfo{
+		ID:                 t.ID,
+		ConnectionID:       t.ConnID,
+		StartedAt:          t.StartedAt,
+		IdleTimeoutSeconds: int(transactionIdleTimeout().Seconds()),
+	}
+	if t.mu.TryLock() {
+		info.LastActivity = t.lastActivity
+		info.Failed = !t.done && t.conn.PgConn().TxStatus() == 'E'
+		t.mu.Unlock()
+	} else {
+		info.LastActivity = time.Now()
+	}
+	return info
+}
+
+// Run calls fn with the transaction, one caller at a time. If the
+// connection died during fn (a cancelled query closes it), the transaction
+// is dropped and later calls get ErrTransactionNotFound. If fn left the
+// server outside a transaction block (a COMMIT or ROLLBACK that got past
+// the handlers' checks), it is dropped too and Run returns
+// ErrTransactionEnded, rather than letting later statements autocommit.
+func (m *TransactionManager) Run(t *Transaction, fn func(tx pgx.Tx) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return ErrTransactionNotFound
+	}
+
+	err := fn(t.tx)
+	t.lastActivity = time.Now()
+	if t.conn.IsClosed() {
+		log.Printf("transactions: connection for transaction %s was lost; dropping it", t.ID)
+		m.finishLocked(t, false)
+	} else if t.conn.PgConn().TxStatus() == 'I' {
+		log.Printf("transactions: transaction %s was ended by a statement; dropping it", t.ID)
+		m.finishLocked(t, false)
+		return ErrTransactionEnded
+	}
+	return err
+}
+
+// Savepoint runs a savepoint operation (SavepointCreate, SavepointRelease
+// or SavepointRollback) in the transaction. Rolling back to a savepoint
+// also recovers a transaction failed since it was created; any other
+// error from Postgres, such as an unknown savepoint, fails the transaction.
+func (m *TransactionManager) Savepoint(ctx context.Context, t *Transaction, op, name string) error {
+	if err := ValidateSavepointName(name); err != nil {
+		return err
+	}
+	switch op {
+	case SavepointCreate, SavepointRelease, SavepointRollback:
+	default:
+		return fmt.Errorf("unknown savepoint operation %q", op)
+	}
+	return m.Run(t, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, op+" "+name)
+		return err
+	})
+}
+
+// Finish commits or rolls back the transaction and closes its connection.
+// A failed commit still ends it: Postgres has rolled it back.
+func (m *TransactionManager) Finish(t *Transaction, commit bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return ErrTransactionNotFound
+	}
+	return m.finishLocked(t, commit)
+}
+
+// finishLocked ends the transaction. Caller holds t.mu.
+func (m *TransactionManager) finishLocked(t *Transaction, commit bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	if commit {
+		err = t.tx.Commit(ctx)
+	} else if !t.conn.IsClosed() {
+		err = t.tx.Rollback(ctx)
+	}
+	t.conn.Close(ctx)
+	t.done = true
+
+	m.mu.Lock()
+	delete(m.txs, t.ID)
+	m.mu.Unlock()
+	return err
+}
+
+// expired reports whether the reaper should roll the transaction back:
+// idle past the timeout, or its connection was disconnected in PgVoyager.
+// One running a statement is never expired.
+func (t *Transaction) expired(now time.Time, timeout time.Duration) bool {
+	if !t.mu.TryLock() {
+		return false
+	}
+	defer t.mu.Unlock()
+	return !t.done && (now.Sub(t.lastActivity) > timeout || !GetManager().IsConnected(t.ConnID))
+}
+
+// reapIdle rolls back abandoned transactions, e.g. from a tab that was
+// closed mid-transaction, so their locks and connections are freed.
+func (m *TransactionManager) reapIdle() {
+	ticker := time.NewTicker(txReapInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		timeout := transactionIdleTimeout()
+
+		m.mu.Lock()
+		var expired []*Transaction
+		for _, t := range m.txs {
+			if t.expired(now, timeout) {
+				expired = append(expired, t)
+			}
+		}
+		m.mu.Unlock()
+
+		for _, t := range expired {
+			log.Printf("transactions: rolling back abandoned transaction %s on connection %s", t.ID, t.ConnID)
+			if err := m.Finish(t, false); err != nil && !errors.Is(err, ErrTransactionNotFound) {
+				log.Printf("transactions: rolling back %s: %v", t.ID, err)
+			}
+		}
+	}
+}
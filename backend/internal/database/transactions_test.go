@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTransactionIdleTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"300", 5 * time.Minute, false},
+		{" 30 ", 30 * time.Second, false},
+		{"3600", time.Hour, false},
+		{"0", 0, true},
+		{"29", 0, true},
+		{"3601", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTransactionIdleTimeout(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseTransactionIdleTimeout(%q) = (%v, %v), want (%v, err=%v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTransactionManagerGet(t *testing.T) {
+	m := &TransactionManager{txs: map[string]*Transaction{
+		"t1": {ID: "t1", ConnID: "conn"},
+		"t2": {ID: "t2", ConnID: "conn"},
+		"t3": {ID: "t3", ConnID: "other"},
+	}}
+
+	if tx, err := m.Get("conn", "t1"); err != nil || tx.ID != "t1" {
+		t.Errorf("Get(conn, t1) = (%v, %v), want t1", tx, err)
+	}
+	if _, err := m.Get("other", "t1"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("a txId from another connection should not be found, got %v", err)
+	}
+	if _, err := m.Get("conn", "missing"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Get(conn, missing) error = %v, want ErrTransactionNotFound", err)
+	}
+	if n := m.count("conn"); n != 2 {
+		t.Errorf("count(conn) = %d, want 2", n)
+	}
+}
+
+func TestValidateSavepointName(t *testing.T) {
+	for _, name := range []string{"sp1", "_before_update", "A", "a" + strings.Repeat("b", 62)} {
+		if err := ValidateSavepointName(name); err != nil {
+			t.Errorf("ValidateSavepointName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "1sp", "sp-1", "sp 1", `"sp"`, "sp;COMMIT", "a" + strings.Repeat("b", 63)} {
+		if err := ValidateSavepointName(name); !errors.Is(err, ErrInvalidSavepoint) {
+			t.Errorf("ValidateSavepointName(%q) = %v, want ErrInvalidSavepoint", name, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultSSHPort = 22
+
+// sshTimeout bounds dialling the bastion and, separately, the SSH
+// handshake and authentication, so a bastion that accepts the TCP
+// connection and then stalls can't hang a connect.
+var sshTimeout = 10 * time.Second
+
+// TunnelError marks a failure to establish the SSH hop, as opposed to an
+// error from Postgres itself, so callers can tell the user which leg broke.
+type TunnelError struct {
+	Err error
+}
+
+func (e *TunnelError) Error() string { return "ssh tunnel: " + e.Err.Error() }
+func (e *TunnelError) Unwrap() error { return e.Err }
+
+// IsTunnelError reports whether err came from the SSH tunnel.
+func IsTunnelError(err error) bool {
+	var te *TunnelError
+	return errors.As(err, &te)
+}
+
+// sshTunnel is an SSH client to a bastion host. Postgres connections are
+// dialed through it directly (see attach) rather than through a local
+// listening port: nothing else on the machine can reach the forward, and
+// the pgx TLS config still sees the real database host name.
+type sshTunnel struct {
+	client *ssh.Client
+}
+
+// openSSHTunnel connects and authenticates to the bastion described by t.
+// The bastion's host key must be present in ~/.ssh/known_hosts.
+func openSSHTunnel(t models.SSHTunnel) (*sshTunnel, error) {
+	if t.SSHUser == "" {
+		return nil, &TunnelError{Err: fmt.Errorf("ssh user is required")}
+	}
+
+	auth, err := sshAuthMethods(t)
+	if err != nil {
+		return nil, &TunnelError{Err: err}
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback()
+	if err != nil {
+		return nil, &TunnelError{Err: err}
+	}
+
+	port := t.SSHPort
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	addr := net.JoinHostPort(t.SSHHost, strconv.Itoa(port))
+
+	netConn, err := net.DialTimeout("tcp", addr, sshTimeout)
+	if err != nil {
+		return nil, &TunnelError{Err: err}
+	}
+	netConn.SetDeadline(time.Now().Add(sshTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, &ssh.ClientConfig{
+		User:            t.SSHUser,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		netConn.Close()
+		return nil, &TunnelError{Err: err}
+	}
+	netConn.SetDeadline(time.Time{})
+	return &sshTunnel{client: ssh.NewClient(sshConn, chans, reqs)}, nil
+}
+
+// sshAuthMethods offers key auth when a key path is set and password auth
+// when a password is set. For an encrypted key the password is tried as
+// its passphrase.
+func sshAuthMethods(t models.SSHTunnel) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if t.SSHKeyPath != "" {
+		keyPath := expandHome(t.SSHKeyPath)
+		pemBytes, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read ssh key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			if t.SSHPassword == "" {
+				return nil, fmt.Errorf("ssh key %s is encrypted; provide its passphrase as the ssh password", keyPath)
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(t.SSHPassword))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse ssh key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if t.SSHPassword != "" {
+		methods = append(methods, ssh.Password(t.SSHPassword))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("ssh key path or password is required")
+	}
+	return methods, nil
+}
+
+func sshHostKeyCallback() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("locate known_hosts: %w", err)
+	}
+	path := filepath.Join(home, ".ssh", "known_hosts")
+	cb, err := knownhosts.New(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found; connect to the bastion once with ssh (or use ssh-keyscan) to record its host key", path)
+		}
+		return nil, fmt.Errorf("load known_hosts: %w", err)
+	}
+	return cb, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// attach routes the pool's connections through the tunnel. Host names are
+// resolved by the bastion, since the database host is often only resolvable
+// from inside the private network.
+func (t *sshTunnel) attach(config *pgxpool.Config) {
+	config.ConnConfig.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		return []string{host}, nil
+	}
+	config.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := t.client.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, &TunnelError{Err: fmt.Errorf("forward to %s: %w", addr, err)}
+		}
+		return conn, nil
+	}
+}
+
+func (t *sshTunnel) Close() error {
+	return t.client.Close()
+}
+
+// tunnelSettings extracts the SSH settings from a stored connection.
+func tunnelSettings(conn *models.Connection) models.SSHTunnel {
+	return models.SSHTunnel{
+		SSHHost:     conn.SSHHost,
+		SSHPort:     conn.SSHPort,
+		SSHUser:     conn.SSHUser,
+		SSHKeyPath:  conn.SSHKeyPath,
+		SSHPassword: conn.SSHPassword,
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+// poolBackendPIDs returns the backend PIDs of conn and of the pool's idle
+// connections: every session PgVoyager itself holds open that isn't busy
+// serving another request.
+func poolBackendPIDs(ctx context.Context, pool *pgxpool.Pool, conn *pgxpool.Conn) map[int32]bool {
+	pids := map[int32]bool{int32(conn.Conn().PgConn().PID()): true}
+	for _, idle := range pool.AcquireAllIdle(ctx) {
+		pids[int32(idle.Conn().PgConn().PID())] = true
+		idle.Release()
+	}
+	return pids
+}
+
+// ListActivity lists the client sessions in pg_stat_activity, longest
+// running first.
+func ListActivity(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer conn.Release()
+
+	query := `
+		SELECT
+			pid,
+			COALESCE(usename, ''),
+			COALESCE(datname, ''),
+			COALESCE(application_name, ''),
+			COALESCE(host(client_addr), ''),
+			COALESCE(state, ''),
+			COALESCE(query, ''),
+			COALESCE(wait_event_type, ''),
+			COALESCE(wait_event, ''),
+			backend_start,
+			query_start,
+			COALESCE(EXTRACT(EPOCH FROM now() - query_start), 0)::float8 as duration_seconds
+		FROM pg_catalog.pg_stat_activity
+		WHERE backend_type = 'client backend'
+		ORDER BY query_start NULLS LAST, pid
+	`
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(
+			&s.PID, &s.User, &s.Database, &s.ApplicationName, &s.ClientAddr,
+			&s.State, &s.Query, &s.WaitEventType, &s.WaitEvent,
+			&s.BackendStart, &s.QueryStart, &s.DurationSeconds,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows.Close()
+
+	own := poolBackendPIDs(ctx, pool, conn)
+	for i := range sessions {
+		sessions[i].IsOwn = own[sessions[i].PID]
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// TerminateSession cancels the query running in another session with
+// pg_cancel_backend, or with force ends the session with
+// pg_terminate_backend. PgVoyager's own connections are refused. The body
+// is optional and defaults to force=false.
+func TerminateSession(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "terminating sessions") {
+		return
+	}
+
+	pid, err := strconv.ParseInt(c.Param("pid"), 10, 32)
+	if err != nil || pid <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pid"})
+		return
+	}
+	var req models.TerminateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if security.AbortIfBodyTooLarge(c, err) {
+			return
+		}
+		req = models.TerminateSessionRequest{}
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer conn.Release()
+
+	if poolBackendPIDs(ctx, pool, conn)[int32(pid)] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refusing to terminate PgVoyager's own connection"})
+		return
+	}
+
+	fn := "pg_cancel_backend"
+	if req.Force {
+		fn = "pg_terminate_backend"
+	}
+	// pg_*_backend return false (with a warning) for a PID that isn't a
+	// backend, e.g. one that has already exited.
+	var signalled bool
+	if err := conn.QueryRow(ctx, "SELECT "+fn+"($1)", int32(pid)).Scan(&signalled); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !signalled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No session with that pid"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pid": pid, "terminated": req.Force, "cancelled": !req.Force})
+}
+
+// ListLocks reports which sessions are waiting on locks held by which
+// others, longest wait first. Blockers come from pg_blocking_pids; the
+// lock waited for, and the conflicting modes the blocker holds on the same
+// object, from pg_locks.
+func ListLocks(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			blocked.pid,
+			COALESCE(blocked.usename, ''),
+			COALESCE(blocked.query, ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - blocked.query_start), 0)::float8 as wait_seconds,
+			b.pid,
+			COALESCE(blocking.usename, ''),
+			COALESCE(blocking.query, ''),
+			COALESCE(blocking.state, ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - blocking.xact_start), 0)::float8 as blocking_xact_seconds,
+			COALESCE(w.locktype, ''),
+			COALESCE(quote_ident(n.nspname) || '.' || quote_ident(cl.relname), ''),
+			COALESCE(w.mode, ''),
+			COALESCE((
+				SELECT string_agg(DISTINCT h.mode, ', ')
+				FROM pg_catalog.pg_locks h
+				WHERE h.pid = b.pid
+				  AND h.granted
+				  AND h.locktype = w.locktype
+				  AND h.database IS NOT DISTINCT FROM w.database
+				  AND h.relation IS NOT DISTINCT FROM w.relation
+				  AND h.page IS NOT DISTINCT FROM w.page
+				  AND h.tuple IS NOT DISTINCT FROM w.tuple
+				  AND h.transactionid IS NOT DISTINCT FROM w.transactionid
+				  AND h.virtualxid IS NOT DISTINCT FROM w.virtualxid
+				  AND h.classid IS NOT DISTINCT FROM w.classid
+				  AND h.objid IS NOT DISTINCT FROM w.objid
+				  AND h.objsubid IS NOT DISTINCT FROM w.objsubid
+			), '') as blocking_modes
+		FROM pg_catalog.pg_stat_activity blocked
+		CROSS JOIN LATERAL unnest(pg_catalog.pg_blocking_pids(blocked.pid)) AS b(pid)
+		LEFT JOIN pg_catalog.pg_stat_activity blocking ON blocking.pid = b.pid
+		LEFT JOIN LATERAL (
+			SELECT l.*
+			FROM pg_catalog.pg_locks l
+			WHERE l.pid = blocked.pid AND NOT l.granted
+			LIMIT 1
+		) w ON true
+		LEFT JOIN pg_catalog.pg_class cl ON cl.oid = w.relation
+		LEFT JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		ORDER BY wait_seconds DESC, blocked.pid, b.pid
+	`
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	waits := []models.LockWait{}
+	for rows.Next() {
+		var w models.LockWait
+		if err := rows.Scan(
+			&w.BlockedPID, &w.BlockedUser, &w.BlockedQuery, &w.WaitSeconds,
+			&w.BlockingPID, &w.BlockingUser, &w.BlockingQuery, &w.BlockingState, &w.BlockingXactSeconds,
+			&w.LockType, &w.Relation, &w.Mode, &w.BlockingModes,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		waits = append(waits, w)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, waits)
+}
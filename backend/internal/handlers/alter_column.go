@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// alterColumnLockTimeout bounds the wait for the ACCESS EXCLUSIVE lock an
+// ALTER TABLE takes. Without it the ALTER queues behind any long-running
+// transaction on the table, and every query on the table queues behind it.
+const alterColumnLockTimeout = "5s"
+
+// RenameColumn renames a column and returns the table's columns.
+func RenameColumn(c *gin.Context) {
+	var req models.RenameColumnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !isValidIdentifier(req.NewName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid new column name"})
+		return
+	}
+
+	alterColumn(c, "renaming columns", func(column string) string {
+		return fmt.Sprintf("RENAME COLUMN %s TO %s", quoteIdentifier(column), quoteIdentifier(req.NewName))
+	})
+}
+
+// ChangeColumnType changes a column's type, converting existing values
+// with the request's USING expression when one is given, and returns the
+// table's columns. A conversion that fails on any row rolls back.
+func ChangeColumnType(c *gin.Context) {
+	var req models.ChangeColumnTypeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	// Both are SQL fragments that can't be bound as parameters.
+	if !dbsafe.ValidColumnType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid column type: %s", req.Type)})
+		return
+	}
+	if err := dbsafe.AssertNoStatementBreakout(req.Using); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid USING expression: %v", err)})
+		return
+	}
+
+	alterColumn(c, "changing column types", func(column string) string {
+		action := fmt.Sprintf("ALTER COLUMN %s TYPE %s", quoteIdentifier(column), req.Type)
+		if req.Using != "" {
+			action += fmt.Sprintf(" USING (%s)", req.Using)
+		}
+		return action
+	})
+}
+
+// alterColumn runs ALTER TABLE <table> <action(column)> in a transaction
+// with a short lock timeout, then replies with the table's columns as
+// they are after the change.
+func alterColumn(c *gin.Context, operation string, action func(column string) string) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, operation) {
+		return
+	}
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	column := c.Param("column")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) || !isValidIdentifier(column) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema, table or column name"})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	// Changing a type rewrites the table, which can take a while.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = '%s'", alterColumnLockTimeout)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ddl := fmt.Sprintf("ALTER TABLE %s.%s %s", quoteIdentifier(schema), quoteIdentifier(table), action(column))
+	if _, err := tx.Exec(ctx, ddl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	byTable, err := loadTableColumns(ctx, tx, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, byTable[table])
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
 )
 
 // RunAnalysis performs database health and optimization analysis
@@ -29,48 +32,38 @@ func RunAnalysis(c *gin.Context) {
 		Categories: []models.AnalysisCategory{},
 	}
 
+	limit := analysisLimit(c)
+
 	// Gather all issues
-	indexIssues := analyzeIndexes(ctx, pool)
-	tableIssues := analyzeTables(ctx, pool)
-	constraintIssues := analyzeConstraints(ctx, pool)
-	sequenceIssues := analyzeSequences(ctx, pool)
-	performanceIssues := analyzePerformance(ctx, pool)
+	categories := []struct {
+		name, icon string
+		findings   analysisFindings
+	}{
+		{"Index Health", "zap", analyzeIndexes(ctx, pool, limit)},
+		{"Table Health", "table", analyzeTables(ctx, pool, limit, tableBloatCriticalPct(c))},
+		{"Constraints", "link", analyzeConstraints(ctx, pool, limit)},
+		{"Sequences", "hash", analyzeSequences(ctx, pool, limit)},
+		{"Performance", "activity", analyzePerformance(ctx, pool, limit)},
+		{"Access Control", "shield", analyzeAccess(ctx, pool, expectedOwner(c), limit)},
+		{"Custom Rules", "clipboard-check", analyzeCustomRules(ctx, pool, limit)},
+	}
 
 	// Build categories
-	if len(indexIssues) > 0 {
-		result.Categories = append(result.Categories, models.AnalysisCategory{
-			Name:   "Index Health",
-			Icon:   "zap",
-			Issues: indexIssues,
-		})
-	}
-	if len(tableIssues) > 0 {
-		result.Categories = append(result.Categories, models.AnalysisCategory{
-			Name:   "Table Health",
-			Icon:   "table",
-			Issues: tableIssues,
-		})
-	}
-	if len(constraintIssues) > 0 {
-		result.Categories = append(result.Categories, models.AnalysisCategory{
-			Name:   "Constraints",
-			Icon:   "link",
-			Issues: constraintIssues,
-		})
-	}
-	if len(sequenceIssues) > 0 {
-		result.Categories = append(result.Categories, models.AnalysisCategory{
-			Name:   "Sequences",
-			Icon:   "hash",
-			Issues: sequenceIssues,
-		})
-	}
-	if len(performanceIssues) > 0 {
-		result.Categories = append(result.Categories, models.AnalysisCategory{
-			Name:   "Performance",
-			Icon:   "activity",
-			Issues: performanceIssues,
-		})
+	for _, cat := range categories {
+		if len(cat.findings.Issues) == 0 {
+			continue
+		}
+		category := models.AnalysisCategory{
+			Name:   cat.name,
+			Icon:   cat.icon,
+			Issues: cat.findings.Issues,
+			Checks: cat.findings.Checks,
+		}
+		for _, check := range cat.findings.Checks {
+			category.TotalCount += check.TotalCount
+			category.Shown += check.Shown
+		}
+		result.Categories = append(result.Categories, category)
 	}
 
 	// Calculate summary
@@ -93,8 +86,8 @@ func RunAnalysis(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIssue {
-	issues := []models.AnalysisIssue{}
+func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
 
 	// Missing FK indexes
 	query := `
@@ -102,7 +95,8 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 			n.nspname || '.' || c.relname AS table_name,
 			a.attname AS column_name,
 			con.conname AS constraint_name,
-			nf.nspname || '.' || cf.relname AS ref_table
+			nf.nspname || '.' || cf.relname AS ref_table,
+			count(*) OVER () AS total_count
 		FROM pg_constraint con
 		JOIN pg_class c ON c.oid = con.conrelid
 		JOIN pg_namespace n ON n.oid = c.relnamespace
@@ -116,15 +110,17 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 			WHERE i.indrelid = c.oid
 			AND a.attnum = ANY(i.indkey)
 		)
-		LIMIT 20
+		LIMIT $1
 	`
-	rows, err := pool.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var tableName, columnName, constraintName, refTable string
-			if err := rows.Scan(&tableName, &columnName, &constraintName, &refTable); err == nil {
-				issues = append(issues, models.AnalysisIssue{
+			if err := rows.Scan(&tableName, &columnName, &constraintName, &refTable, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "warning",
 					Title:       "Missing index on foreign key",
 					Description: fmt.Sprintf("FK '%s' on column '%s' has no index", constraintName, columnName),
@@ -135,27 +131,31 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 				})
 			}
 		}
+		f.count("Missing index on foreign key", total, shown)
 	}
 
 	// Unused indexes (0 scans, not primary keys)
 	query = `
 		SELECT schemaname || '.' || relname AS table_name,
 		       indexrelname AS index_name,
-		       pg_size_pretty(pg_relation_size(indexrelid)) AS size
+		       pg_size_pretty(pg_relation_size(indexrelid)) AS size,
+		       count(*) OVER () AS total_count
 		FROM pg_stat_user_indexes
 		WHERE idx_scan = 0
 		AND indexrelname NOT LIKE '%_pkey'
 		AND pg_relation_size(indexrelid) > 8192
 		ORDER BY pg_relation_size(indexrelid) DESC
-		LIMIT 20
+		LIMIT $1
 	`
-	rows, err = pool.Query(ctx, query)
+	rows, err = pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var tableName, indexName, size string
-			if err := rows.Scan(&tableName, &indexName, &size); err == nil {
-				issues = append(issues, models.AnalysisIssue{
+			if err := rows.Scan(&tableName, &indexName, &size, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "info",
 					Title:       "Unused index",
 					Description: fmt.Sprintf("Index '%s' has never been used (size: %s)", indexName, size),
@@ -165,6 +165,7 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 				})
 			}
 		}
+		f.count("Unused index", total, shown)
 	}
 
 	// Duplicate indexes
@@ -172,7 +173,8 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 		SELECT
 			n.nspname || '.' || ct.relname AS table_name,
 			array_agg(ci.relname ORDER BY ci.relname) AS index_names,
-			pg_get_indexdef(i.indexrelid) AS definition
+			pg_get_indexdef(i.indexrelid) AS definition,
+			count(*) OVER () AS total_count
 		FROM pg_index i
 		JOIN pg_class ct ON ct.oid = i.indrelid
 		JOIN pg_class ci ON ci.oid = i.indexrelid
@@ -180,17 +182,19 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
 		GROUP BY n.nspname, ct.relname, i.indkey, pg_get_indexdef(i.indexrelid)
 		HAVING count(*) > 1
-		LIMIT 10
+		LIMIT $1
 	`
-	rows, err = pool.Query(ctx, query)
+	rows, err = pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var tableName string
 			var indexNames []string
 			var definition string
-			if err := rows.Scan(&tableName, &indexNames, &definition); err == nil {
-				issues = append(issues, models.AnalysisIssue{
+			if err := rows.Scan(&tableName, &indexNames, &definition, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "warning",
 					Title:       "Duplicate indexes",
 					Description: fmt.Sprintf("Indexes on same columns: %v", indexNames),
@@ -199,17 +203,25 @@ func analyzeIndexes(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIs
 				})
 			}
 		}
+		f.count("Duplicate indexes", total, shown)
 	}
 
-	return issues
+	// Bloated indexes
+	analyzeIndexBloat(ctx, pool, limit, &f)
+
+	// jsonb and array columns without GIN indexes
+	analyzeContainerIndexes(ctx, pool, limit, &f)
+
+	return f
 }
 
-func analyzeTables(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIssue {
-	issues := []models.AnalysisIssue{}
+func analyzeTables(ctx context.Context, pool *pgxpool.Pool, limit int, criticalBloatPct float64) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
 
 	// Tables without primary key
 	query := `
-		SELECT n.nspname || '.' || c.relname AS table_name
+		SELECT n.nspname || '.' || c.relname AS table_name,
+		       count(*) OVER () AS total_count
 		FROM pg_class c
 		JOIN pg_namespace n ON n.oid = c.relnamespace
 		WHERE c.relkind = 'r'
@@ -218,15 +230,17 @@ func analyzeTables(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIss
 			SELECT 1 FROM pg_constraint con
 			WHERE con.conrelid = c.oid AND con.contype = 'p'
 		)
-		LIMIT 20
+		LIMIT $1
 	`
-	rows, err := pool.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var tableName string
-			if err := rows.Scan(&tableName); err == nil {
-				issues = append(issues, models.AnalysisIssue{
+			if err := rows.Scan(&tableName, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "warning",
 					Title:       "Table without primary key",
 					Description: "No primary key defined",
@@ -235,63 +249,38 @@ func analyzeTables(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIss
 				})
 			}
 		}
+		f.count("Table without primary key", total, shown)
 	}
 
-	// Table bloat (high dead tuples)
-	query = `
-		SELECT schemaname || '.' || relname AS table_name,
-		       n_dead_tup,
-		       n_live_tup,
-		       ROUND(100.0 * n_dead_tup / NULLIF(n_live_tup + n_dead_tup, 0), 1) AS dead_pct
-		FROM pg_stat_user_tables
-		WHERE n_dead_tup > 10000
-		AND 100.0 * n_dead_tup / NULLIF(n_live_tup + n_dead_tup, 0) > 10
-		ORDER BY n_dead_tup DESC
-		LIMIT 10
-	`
-	rows, err = pool.Query(ctx, query)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var tableName string
-			var deadTup, liveTup int64
-			var deadPct float64
-			if err := rows.Scan(&tableName, &deadTup, &liveTup, &deadPct); err == nil {
-				issues = append(issues, models.AnalysisIssue{
-					Severity:    "warning",
-					Title:       "Table bloat",
-					Description: fmt.Sprintf("%.1f%% dead tuples (%d dead rows)", deadPct, deadTup),
-					Table:       tableName,
-					Suggestion:  fmt.Sprintf("VACUUM ANALYZE %s;", tableName),
-					Impact:      "Wasted disk space and slower queries",
-				})
-			}
-		}
-	}
+	// Table bloat (dead tuples and estimated wasted space)
+	analyzeTableBloat(ctx, pool, limit, criticalBloatPct, &f)
 
 	// Stale statistics (never analyzed or very old)
 	query = `
 		SELECT schemaname || '.' || relname AS table_name,
 		       last_analyze,
-		       last_autoanalyze
+		       last_autoanalyze,
+		       count(*) OVER () AS total_count
 		FROM pg_stat_user_tables
 		WHERE n_live_tup > 1000
 		AND (last_analyze IS NULL AND last_autoanalyze IS NULL)
 		   OR (COALESCE(last_analyze, last_autoanalyze) < NOW() - INTERVAL '7 days')
-		LIMIT 10
+		LIMIT $1
 	`
-	rows, err = pool.Query(ctx, query)
+	rows, err = pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var tableName string
 			var lastAnalyze, lastAutoanalyze *time.Time
-			if err := rows.Scan(&tableName, &lastAnalyze, &lastAutoanalyze); err == nil {
+			if err := rows.Scan(&tableName, &lastAnalyze, &lastAutoanalyze, &total); err == nil {
+				shown++
 				desc := "Never analyzed"
 				if lastAnalyze != nil || lastAutoanalyze != nil {
 					desc = "Statistics are stale (>7 days old)"
 				}
-				issues = append(issues, models.AnalysisIssue{
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "info",
 					Title:       "Stale table statistics",
 					Description: desc,
@@ -301,49 +290,53 @@ func analyzeTables(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIss
 				})
 			}
 		}
+		f.count("Stale table statistics", total, shown)
 	}
 
-	return issues
+	return f
 }
 
-func analyzeConstraints(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIssue {
-	issues := []models.AnalysisIssue{}
+func analyzeConstraints(ctx context.Context, pool *pgxpool.Pool, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
 	// Constraints analysis is typically covered by FK index check
 	// Could add check for invalid constraints if needed
-	return issues
+	return f
 }
 
-func analyzeSequences(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIssue {
-	issues := []models.AnalysisIssue{}
+func analyzeSequences(ctx context.Context, pool *pgxpool.Pool, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
 
 	// Sequences approaching exhaustion
 	query := `
 		SELECT schemaname || '.' || sequencename AS seq_name,
 		       last_value,
 		       max_value,
-		       ROUND(100.0 * last_value / max_value, 2) AS pct_used
+		       ROUND(100.0 * last_value / max_value, 2) AS pct_used,
+		       count(*) OVER () AS total_count
 		FROM pg_sequences
 		WHERE last_value IS NOT NULL
 		AND max_value > 0
 		AND 100.0 * last_value / max_value > 50
 		ORDER BY pct_used DESC
-		LIMIT 10
+		LIMIT $1
 	`
-	rows, err := pool.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var seqName string
 			var lastValue, maxValue int64
 			var pctUsed float64
-			if err := rows.Scan(&seqName, &lastValue, &maxValue, &pctUsed); err == nil {
+			if err := rows.Scan(&seqName, &lastValue, &maxValue, &pctUsed, &total); err == nil {
+				shown++
 				severity := "info"
 				if pctUsed > 90 {
 					severity = "critical"
 				} else if pctUsed > 75 {
 					severity = "warning"
 				}
-				issues = append(issues, models.AnalysisIssue{
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    severity,
 					Title:       "Sequence approaching limit",
 					Description: fmt.Sprintf("%.1f%% used (%d of %d)", pctUsed, lastValue, maxValue),
@@ -352,13 +345,14 @@ func analyzeSequences(ctx context.Context, pool *pgxpool.Pool) []models.Analysis
 				})
 			}
 		}
+		f.count("Sequence approaching limit", total, shown)
 	}
 
-	return issues
+	return f
 }
 
-func analyzePerformance(ctx context.Context, pool *pgxpool.Pool) []models.AnalysisIssue {
-	issues := []models.AnalysisIssue{}
+func analyzePerformance(ctx context.Context, pool *pgxpool.Pool, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
 
 	// Low cache hit ratio
 	query := `
@@ -369,7 +363,7 @@ func analyzePerformance(ctx context.Context, pool *pgxpool.Pool) []models.Analys
 	var cacheRatio *float64
 	err := pool.QueryRow(ctx, query).Scan(&cacheRatio)
 	if err == nil && cacheRatio != nil && *cacheRatio < 90 {
-		issues = append(issues, models.AnalysisIssue{
+		f.Issues = append(f.Issues, models.AnalysisIssue{
 			Severity:    "warning",
 			Title:       "Low cache hit ratio",
 			Description: fmt.Sprintf("Buffer cache hit ratio is %.1f%%", *cacheRatio),
@@ -383,23 +377,27 @@ func analyzePerformance(ctx context.Context, pool *pgxpool.Pool) []models.Analys
 		SELECT pid,
 		       usename,
 		       EXTRACT(EPOCH FROM (now() - query_start))::int AS duration_secs,
-		       LEFT(query, 100) AS query_preview
+		       LEFT(query, 100) AS query_preview,
+		       count(*) OVER () AS total_count
 		FROM pg_stat_activity
 		WHERE state = 'active'
 		AND query NOT LIKE '%pg_stat_activity%'
 		AND now() - query_start > INTERVAL '5 minutes'
-		LIMIT 5
+		ORDER BY query_start
+		LIMIT $1
 	`
-	rows, err := pool.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, limit)
 	if err == nil {
 		defer rows.Close()
+		var total, shown int
 		for rows.Next() {
 			var pid int
 			var usename string
 			var durationSecs int
 			var queryPreview string
-			if err := rows.Scan(&pid, &usename, &durationSecs, &queryPreview); err == nil {
-				issues = append(issues, models.AnalysisIssue{
+			if err := rows.Scan(&pid, &usename, &durationSecs, &queryPreview, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
 					Severity:    "warning",
 					Title:       "Long-running query",
 					Description: fmt.Sprintf("PID %d running for %d seconds: %s...", pid, durationSecs, queryPreview),
@@ -408,9 +406,192 @@ func analyzePerformance(ctx context.Context, pool *pgxpool.Pool) []models.Analys
 				})
 			}
 		}
+		f.count("Long-running query", total, shown)
+	}
+
+	return f
+}
+
+// analysisFindings collects one analyzer's issues along with a per-check
+// count of how many were found in total.
+type analysisFindings struct {
+	Issues []models.AnalysisIssue
+	Checks []models.AnalysisCheck
+}
+
+// count records a check's totals; checks that found nothing are omitted.
+func (f *analysisFindings) count(title string, total, shown int) {
+	if total == 0 {
+		return
+	}
+	f.Checks = append(f.Checks, models.AnalysisCheck{Title: title, TotalCount: total, Shown: shown})
+}
+
+// analysisLimitPreference caps how many issues each check lists. The
+// ?limit= query parameter overrides it for a single run.
+const (
+	analysisLimitPreference = "analysis.issue_limit"
+	defaultAnalysisLimit    = 20
+	maxAnalysisLimit        = 500
+)
+
+func analysisLimit(c *gin.Context) int {
+	value := c.Query("limit")
+	if value == "" {
+		value, _ = storage.GetPreference(analysisLimitPreference)
+	}
+	return parseAnalysisLimit(value)
+}
+
+// parseAnalysisLimit falls back to the default for missing or invalid
+// values and clamps the rest to maxAnalysisLimit.
+func parseAnalysisLimit(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return defaultAnalysisLimit
+	}
+	return min(n, maxAnalysisLimit)
+}
+
+// validateAnalysisLimit checks an analysisLimitPreference value, which
+// unlike ?limit= isn't clamped.
+func validateAnalysisLimit(value string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 || n > maxAnalysisLimit {
+		return fmt.Errorf("must be 1 to %d issues", maxAnalysisLimit)
+	}
+	return nil
+}
+
+// expectedOwnerPreference names the role that should own user tables. The
+// ?expectedOwner= query parameter overrides it for a single run; with
+// neither set the ownership check is skipped.
+const expectedOwnerPreference = "analysis.expected_owner"
+
+func expectedOwner(c *gin.Context) string {
+	if owner := c.Query("expectedOwner"); owner != "" {
+		return owner
+	}
+	owner, _ := storage.GetPreference(expectedOwnerPreference)
+	return owner
+}
+
+// analyzeAccess flags access-control problems: tables owned by someone
+// other than the expected owner, tables where PUBLIC can write, and schemas
+// where PUBLIC can create objects (the pre-15 default on `public`).
+func analyzeAccess(ctx context.Context, pool *pgxpool.Pool, owner string, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
+
+	if owner != "" {
+		query := `
+			SELECT format('%I.%I', n.nspname, c.relname) AS table_name,
+			       pg_get_userbyid(c.relowner) AS owner,
+			       format('ALTER TABLE %I.%I OWNER TO %I;', n.nspname, c.relname, $1::text) AS suggestion,
+			       count(*) OVER () AS total_count
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('r', 'p')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+			AND n.nspname NOT LIKE 'pg_toast%'
+			AND pg_get_userbyid(c.relowner) <> $1
+			ORDER BY 1
+			LIMIT $2
+		`
+		rows, err := pool.Query(ctx, query, owner, limit)
+		if err == nil {
+			defer rows.Close()
+			var total, shown int
+			for rows.Next() {
+				var tableName, actualOwner, suggestion string
+				if err := rows.Scan(&tableName, &actualOwner, &suggestion, &total); err == nil {
+					shown++
+					f.Issues = append(f.Issues, models.AnalysisIssue{
+						Severity:    "warning",
+						Title:       "Unexpected table owner",
+						Description: fmt.Sprintf("Owned by '%s', expected '%s'", actualOwner, owner),
+						Table:       tableName,
+						Suggestion:  suggestion,
+						Impact:      "The owner can alter or drop the table and bypasses its grants",
+					})
+				}
+			}
+			f.count("Unexpected table owner", total, shown)
+		}
+	}
+
+	// PUBLIC (grantee 0) write privileges on tables
+	query := `
+		SELECT format('%I.%I', n.nspname, c.relname) AS table_name,
+		       string_agg(a.privilege_type, ', ' ORDER BY a.privilege_type) AS privileges,
+		       count(*) OVER () AS total_count
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(c.relacl) a
+		WHERE c.relkind IN ('r', 'p', 'v', 'm')
+		AND c.relacl IS NOT NULL
+		AND a.grantee = 0
+		AND a.privilege_type IN ('INSERT', 'UPDATE', 'DELETE', 'TRUNCATE')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		GROUP BY n.nspname, c.relname
+		ORDER BY 1
+		LIMIT $1
+	`
+	rows, err := pool.Query(ctx, query, limit)
+	if err == nil {
+		defer rows.Close()
+		var total, shown int
+		for rows.Next() {
+			var tableName, privileges string
+			if err := rows.Scan(&tableName, &privileges, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
+					Severity:    "warning",
+					Title:       "PUBLIC can write to table",
+					Description: fmt.Sprintf("PUBLIC has %s", privileges),
+					Table:       tableName,
+					Suggestion:  fmt.Sprintf("REVOKE %s ON %s FROM PUBLIC;", privileges, tableName),
+					Impact:      "Every role that can connect can modify this table",
+				})
+			}
+		}
+		f.count("PUBLIC can write to table", total, shown)
+	}
+
+	// PUBLIC CREATE on schemas
+	query = `
+		SELECT quote_ident(n.nspname),
+		       count(*) OVER () AS total_count
+		FROM pg_namespace n
+		CROSS JOIN LATERAL aclexplode(n.nspacl) a
+		WHERE n.nspacl IS NOT NULL
+		AND a.grantee = 0
+		AND a.privilege_type = 'CREATE'
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_%'
+		ORDER BY 1
+		LIMIT $1
+	`
+	rows, err = pool.Query(ctx, query, limit)
+	if err == nil {
+		defer rows.Close()
+		var total, shown int
+		for rows.Next() {
+			var schemaName string
+			if err := rows.Scan(&schemaName, &total); err == nil {
+				shown++
+				f.Issues = append(f.Issues, models.AnalysisIssue{
+					Severity:    "warning",
+					Title:       "PUBLIC can create objects in schema",
+					Description: fmt.Sprintf("Any role can create objects in schema %s", schemaName),
+					Suggestion:  fmt.Sprintf("REVOKE CREATE ON SCHEMA %s FROM PUBLIC;", schemaName),
+					Impact:      "Lets any role shadow functions or tables used by others via search_path",
+				})
+			}
+		}
+		f.count("PUBLIC can create objects in schema", total, shown)
 	}
 
-	return issues
+	return f
 }
 
 func getDatabaseStats(ctx context.Context, pool *pgxpool.Pool) models.DatabaseStats {
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// indexBloatMinPct and indexBloatMinBytes are the thresholds above
+	// which an index is reported; both must be exceeded so small indexes
+	// with a high ratio don't drown out the ones worth rebuilding.
+	indexBloatMinPct   = 30
+	indexBloatMinBytes = 10 * 1024 * 1024
+	// indexBloatWarningPct raises the issue from info to warning.
+	indexBloatWarningPct = 50
+	// maxPgstatindexScans bounds how many indexes pgstatindex reads per
+	// run, largest first; each call scans the whole index.
+	maxPgstatindexScans = 50
+)
+
+const (
+	// tableBloatCriticalPreference is the estimated bloat percentage above
+	// which a table is reported as critical and VACUUM FULL or pg_repack
+	// is suggested. The ?tableBloatCriticalPct= query parameter overrides
+	// it for a single run.
+	tableBloatCriticalPreference = "analysis.table_bloat_critical_pct"
+	defaultTableBloatCriticalPct = 50
+	// tableBloatMinPct and tableBloatMinBytes: below either, estimated bloat
+	// alone doesn't make a table worth reporting.
+	tableBloatMinPct   = 20
+	tableBloatMinBytes = 10 * 1024 * 1024
+)
+
+// tableBloatQuery reports tables with many dead tuples or whose size is
+// well above what their live rows need. The expected size comes from
+// reltuples and the row width in pg_stats (tuple header plus line pointer,
+// 8-byte aligned) packed to the table's fillfactor; tables without
+// statistics only get the dead tuple check.
+const tableBloatQuery = `
+	WITH widths AS (
+		SELECT schemaname, tablename, sum((1 - null_frac) * avg_width) AS data_width
+		FROM pg_stats
+		GROUP BY schemaname, tablename
+	),
+	tables AS (
+		SELECT
+			quote_ident(st.schemaname) || '.' || quote_ident(st.relname) AS table_name,
+			st.n_dead_tup,
+			ROUND(100.0 * st.n_dead_tup / NULLIF(st.n_live_tup + st.n_dead_tup, 0), 1) AS dead_pct,
+			pg_relation_size(st.relid) AS size,
+			CASE WHEN c.reltuples > 0 AND w.data_width IS NOT NULL THEN
+				current_setting('block_size')::bigint * ceil(c.reltuples / GREATEST(floor(
+					(current_setting('block_size')::int - 24)
+					* COALESCE(substring(array_to_string(c.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::int, 100) / 100.0
+					/ (24 + 4 + 8 * ceil(w.data_width / 8))
+				), 1))
+			END AS expected_size
+		FROM pg_stat_user_tables st
+		JOIN pg_class c ON c.oid = st.relid
+		LEFT JOIN widths w ON w.schemaname = st.schemaname AND w.tablename = st.relname
+	),
+	bloat AS (
+		SELECT *,
+			CASE WHEN expected_size IS NOT NULL THEN GREATEST(size - expected_size, 0)::bigint END AS bloat_bytes,
+			CASE WHEN expected_size IS NOT NULL AND size > 0 THEN 100.0 * GREATEST(size - expected_size, 0) / size END AS bloat_pct
+		FROM tables
+	)
+	SELECT table_name, n_dead_tup, COALESCE(dead_pct, 0),
+	       pg_size_pretty(size), bloat_pct, pg_size_pretty(COALESCE(bloat_bytes, 0)),
+	       count(*) OVER () AS total_count
+	FROM bloat
+	WHERE (n_dead_tup > 10000 AND dead_pct > 10)
+	   OR (bloat_pct >= $2 AND bloat_bytes >= $3)
+	ORDER BY bloat_bytes DESC NULLS LAST, n_dead_tup DESC
+	LIMIT $1
+`
+
+func tableBloatCriticalPct(c *gin.Context) float64 {
+	value := c.Query("tableBloatCriticalPct")
+	if value == "" {
+		value, _ = storage.GetPreference(tableBloatCriticalPreference)
+	}
+	return parseTableBloatCriticalPct(value)
+}
+
+// parseTableBloatCriticalPct falls back to the default for missing values
+// and anything outside (0, 100].
+func parseTableBloatCriticalPct(value string) float64 {
+	pct, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || pct <= 0 || pct > 100 {
+		return defaultTableBloatCriticalPct
+	}
+	return pct
+}
+
+// validateTableBloatCriticalPct checks a tableBloatCriticalPreference
+// value.
+func validateTableBloatCriticalPct(value string) error {
+	pct, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || pct <= 0 || pct > 100 {
+		return fmt.Errorf("must be a percentage above 0 and at most 100")
+	}
+	return nil
+}
+
+// tableBloatAdvice picks severity and remedy. Plain VACUUM makes dead
+// space reusable but rarely returns it to the OS, so past criticalPct a
+// rewrite is suggested instead; pg_repack does it without holding an
+// exclusive lock for the duration.
+func tableBloatAdvice(table string, bloatPct *float64, criticalPct float64) (severity, suggestion string) {
+	if bloatPct != nil && *bloatPct >= criticalPct {
+		return "critical", fmt.Sprintf("VACUUM FULL %s; -- takes an exclusive lock; pg_repack rebuilds the table online", table)
+	}
+	return "warning", fmt.Sprintf("VACUUM ANALYZE %s;", table)
+}
+
+// analyzeTableBloat reports tables with many dead tuples or a high
+// estimated bloat, with the estimated reclaimable space.
+func analyzeTableBloat(ctx context.Context, pool *pgxpool.Pool, limit int, criticalPct float64, f *analysisFindings) {
+	rows, err := pool.Query(ctx, tableBloatQuery, limit, tableBloatMinPct, tableBloatMinBytes)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	var total, shown int
+	for rows.Next() {
+		var tableName, size, bloatSize string
+		var deadTup int64
+		var deadPct float64
+		var bloatPct *float64
+		if err := rows.Scan(&tableName, &deadTup, &deadPct, &size, &bloatPct, &bloatSize, &total); err != nil {
+			continue
+		}
+		shown++
+
+		desc := fmt.Sprintf("%.1f%% dead tuples (%d dead rows)", deadPct, deadTup)
+		if bloatPct != nil {
+			desc += fmt.Sprintf("; estimated %.0f%% bloat, %s of %s reclaimable", *bloatPct, bloatSize, size)
+		}
+		severity, suggestion := tableBloatAdvice(tableName, bloatPct, criticalPct)
+		f.Issues = append(f.Issues, models.AnalysisIssue{
+			Severity:    severity,
+			Title:       "Table bloat",
+			Description: desc,
+			Table:       tableName,
+			Suggestion:  suggestion,
+			Impact:      "Wasted disk space and slower queries",
+		})
+	}
+	f.count("Table bloat", total, shown)
+}
+
+// indexBloatEstimateQuery is the widely used statistical btree bloat
+// estimate (after ioguix/pgsql-bloat-estimation): it predicts an index's
+// page count from reltuples, column widths in pg_stats and the fillfactor,
+// and compares that to relpages. It assumes 8-byte alignment and skips
+// indexes whose columns have no statistics.
+const indexBloatEstimateQuery = `
+	WITH idx AS (
+		SELECT
+			n.nspname, ct.relname AS tblname, ci.relname AS idxname,
+			ci.oid AS idxoid, ci.reltuples, ci.relpages,
+			COALESCE(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::int, 90) AS fillfactor,
+			current_setting('block_size')::numeric AS bs,
+			CASE WHEN max(COALESCE(s.null_frac, 0)) = 0 THEN 8 ELSE 8 + ((32 + 8 - 1) / 8) END AS tuple_hdr,
+			sum((1 - COALESCE(s.null_frac, 0)) * COALESCE(s.avg_width, 1024)) AS data_width
+		FROM pg_index i
+		JOIN pg_class ci ON ci.oid = i.indexrelid
+		JOIN pg_class ct ON ct.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		JOIN pg_am am ON am.oid = ci.relam AND am.amname = 'btree'
+		CROSS JOIN LATERAL generate_series(1, i.indnatts) AS k(pos)
+		LEFT JOIN pg_attribute a ON a.attrelid = ct.oid AND a.attnum = i.indkey[k.pos - 1] AND i.indkey[k.pos - 1] <> 0
+		LEFT JOIN pg_attribute ae ON ae.attrelid = ci.oid AND ae.attnum = k.pos AND i.indkey[k.pos - 1] = 0
+		JOIN pg_stats s ON s.schemaname = n.nspname
+			AND s.tablename = CASE WHEN a.attnum IS NULL THEN ci.relname ELSE ct.relname END
+			AND s.attname = COALESCE(a.attname, ae.attname)
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND ci.relpages > 0
+		GROUP BY 1, 2, 3, 4, 5, 6, 7, 8
+	),
+	widths AS (
+		SELECT *,
+			(tuple_hdr + 8 - CASE WHEN tuple_hdr % 8 = 0 THEN 8 ELSE tuple_hdr % 8 END
+			 + data_width + 8 - CASE WHEN data_width = 0 THEN 0 WHEN data_width::int % 8 = 0 THEN 8 ELSE data_width::int % 8 END
+			)::numeric AS tuple_width
+		FROM idx
+	),
+	estimate AS (
+		SELECT *,
+			COALESCE(1 + ceil(reltuples / floor((bs - 24 - 16) * fillfactor / (100 * (4 + tuple_width)::float))), 0) AS est_pages_ff
+		FROM widths
+	),
+	bloat AS (
+		SELECT
+			quote_ident(nspname) || '.' || quote_ident(idxname) AS index_name,
+			nspname || '.' || tblname AS table_name,
+			(bs * relpages)::bigint AS size,
+			(bs * GREATEST(relpages - est_pages_ff, 0))::bigint AS bloat_bytes,
+			100 * (relpages - est_pages_ff)::float / relpages AS bloat_pct
+		FROM estimate
+	)
+	SELECT index_name, table_name,
+	       pg_size_pretty(size), pg_size_pretty(bloat_bytes), bloat_pct,
+	       count(*) OVER () AS total_count
+	FROM bloat
+	WHERE bloat_pct >= $2 AND bloat_bytes >= $3
+	ORDER BY bloat_bytes DESC
+	LIMIT $1
+`
+
+// pgstatindexBloatQuery measures leaf density with pgstattuple's
+// pgstatindex for the largest btree indexes. Bloat is the share of the
+// index beyond what its fillfactor calls for. %s is the extension's
+// quoted schema.
+const pgstatindexBloatQuery = `
+	WITH candidates AS (
+		SELECT ci.oid, n.nspname, ci.relname AS idxname, ct.relname AS tblname,
+		       pg_relation_size(ci.oid) AS size,
+		       COALESCE(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::int, 90) AS fillfactor
+		FROM pg_index i
+		JOIN pg_class ci ON ci.oid = i.indexrelid
+		JOIN pg_class ct ON ct.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = ci.relnamespace
+		JOIN pg_am am ON am.oid = ci.relam AND am.amname = 'btree'
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		  AND ci.relpersistence <> 't'
+		  AND i.indisvalid
+		  AND pg_relation_size(ci.oid) >= $3
+		ORDER BY pg_relation_size(ci.oid) DESC
+		LIMIT %d
+	),
+	bloat AS (
+		SELECT
+			quote_ident(c.nspname) || '.' || quote_ident(c.idxname) AS index_name,
+			c.nspname || '.' || c.tblname AS table_name,
+			c.size,
+			GREATEST(100 - s.avg_leaf_density * 100 / c.fillfactor, 0) AS bloat_pct
+		FROM candidates c
+		CROSS JOIN LATERAL %s.pgstatindex(c.oid::regclass) s
+		WHERE s.avg_leaf_density <> 'NaN'
+	)
+	SELECT index_name, table_name,
+	       pg_size_pretty(size), pg_size_pretty((size * bloat_pct / 100)::bigint), bloat_pct,
+	       count(*) OVER () AS total_count
+	FROM bloat
+	WHERE bloat_pct >= $2 AND size * bloat_pct / 100 >= $3
+	ORDER BY size * bloat_pct DESC
+	LIMIT $1
+`
+
+// extensionSchema returns the schema an extension is installed in, or ""
+// when it isn't installed.
+func extensionSchema(ctx context.Context, pool *pgxpool.Pool, name string) string {
+	var schema string
+	err := pool.QueryRow(ctx, `
+		SELECT n.nspname
+		FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = $1
+	`, name).Scan(&schema)
+	if err != nil {
+		return ""
+	}
+	return schema
+}
+
+// analyzeIndexBloat flags btree indexes worth a REINDEX. With pgstattuple
+// installed (and readable) it measures the largest indexes; otherwise, or
+// if that fails, it falls back to the statistical estimate.
+func analyzeIndexBloat(ctx context.Context, pool *pgxpool.Pool, limit int, f *analysisFindings) {
+	if schema := extensionSchema(ctx, pool, "pgstattuple"); schema != "" {
+		if quoted, err := dbsafe.QuoteIdent(schema); err == nil {
+			query := fmt.Sprintf(pgstatindexBloatQuery, maxPgstatindexScans, quoted)
+			if issues, total, err := indexBloatIssues(ctx, pool, query, "measured with pgstatindex", limit); err == nil {
+				f.Issues = append(f.Issues, issues...)
+				f.count("Bloated index", total, len(issues))
+				return
+			}
+		}
+	}
+
+	if issues, total, err := indexBloatIssues(ctx, pool, indexBloatEstimateQuery, "statistical estimate", limit); err == nil {
+		f.Issues = append(f.Issues, issues...)
+		f.count("Bloated index", total, len(issues))
+	}
+}
+
+// indexBloatIssues runs one of the bloat queries. Results are collected
+// before being returned so a query failing midway leaves no partial issues.
+func indexBloatIssues(ctx context.Context, pool *pgxpool.Pool, query, method string, limit int) ([]models.AnalysisIssue, int, error) {
+	rows, err := pool.Query(ctx, query, limit, indexBloatMinPct, indexBloatMinBytes)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var issues []models.AnalysisIssue
+	var total int
+	for rows.Next() {
+		var indexName, tableName, size, bloatSize string
+		var bloatPct float64
+		if err := rows.Scan(&indexName, &tableName, &size, &bloatSize, &bloatPct, &total); err != nil {
+			return nil, 0, err
+		}
+		severity := "info"
+		if bloatPct >= indexBloatWarningPct {
+			severity = "warning"
+		}
+		issues = append(issues, models.AnalysisIssue{
+			Severity:    severity,
+			Title:       "Bloated index",
+			Description: fmt.Sprintf("Index %s is about %.0f%% bloat: %s of %s reclaimable (%s)", indexName, bloatPct, bloatSize, size, method),
+			Table:       tableName,
+			Suggestion:  fmt.Sprintf("REINDEX INDEX CONCURRENTLY %s;", indexName),
+			Impact:      "Bloated indexes waste disk and cache and make scans slower",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return issues, total, nil
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	// ginUnindexedMinRows is the estimated row count below which a jsonb
+	// column nobody is seen filtering on isn't worth an index suggestion.
+	ginUnindexedMinRows = 10000
+	// maxStatementsScanned bounds how many pg_stat_statements entries, most
+	// called first, are searched for containment filters.
+	maxStatementsScanned = 1000
+)
+
+// ginCandidateQuery lists the jsonb and array columns of user tables that
+// no GIN or GiST index covers. An expression index on the column (on a
+// jsonb path, say) counts as covering it: someone chose how to index it.
+const ginCandidateQuery = `
+	SELECT
+		n.nspname || '.' || c.relname AS table_name,
+		quote_ident(n.nspname) || '.' || quote_ident(c.relname) AS quoted_table,
+		c.relname,
+		a.attname,
+		quote_ident(a.attname) AS quoted_column,
+		t.typname = 'jsonb' AS is_jsonb,
+		GREATEST(c.reltuples, 0)::bigint AS estimated_rows
+	FROM pg_attribute a
+	JOIN pg_class c ON c.oid = a.attrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	JOIN pg_type t ON t.oid = a.atttypid
+	WHERE c.relkind IN ('r', 'p')
+	  AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+	  AND n.nspname NOT LIKE 'pg_toast%'
+	  AND a.attnum > 0 AND NOT a.attisdropped
+	  AND (t.typname = 'jsonb' OR t.typcategory = 'A')
+	  AND NOT EXISTS (
+		SELECT 1
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_am am ON am.oid = ic.relam
+		WHERE i.indrelid = c.oid
+		  AND am.amname IN ('gin', 'gist')
+		  AND a.attnum = ANY(i.indkey)
+	  )
+	  AND NOT EXISTS (
+		SELECT 1
+		FROM pg_index i
+		JOIN pg_depend d ON d.classid = 'pg_class'::regclass
+			AND d.objid = i.indexrelid
+			AND d.refobjid = c.oid
+			AND d.refobjsubid = a.attnum
+		WHERE i.indrelid = c.oid AND i.indexprs IS NOT NULL
+	  )
+	ORDER BY c.reltuples DESC, n.nspname, c.relname, a.attnum
+`
+
+// ginCandidate is a jsonb or array column without a GIN index.
+type ginCandidate struct {
+	tableName, quotedTable, table string
+	column, quotedColumn          string
+	isJSONB                       bool
+	estimatedRows                 int64
+	filterCalls                   int64 // calls of statements filtering on it
+}
+
+// containmentOperators are the jsonb and array operators a GIN index
+// serves: containment, key existence, overlap and jsonpath matches.
+const containmentOperators = `(@>|<@|\?\||\?&|\?|&&|@@|@\?)`
+
+// filtersWithContainment reports whether a (normalized) statement applies a
+// GIN-indexable operator to the column and mentions its table. It's a text
+// match, so it can be fooled by an alias shared with another table's
+// column, but it's only used to raise a suggestion's severity.
+func filtersWithContainment(statement, table, column string) bool {
+	word := func(name string) string {
+		return `(^|[^\w$])"?` + regexp.QuoteMeta(name) + `"?`
+	}
+	tableRe, err := regexp.Compile(`(?i)` + word(table) + `($|[^\w$])`)
+	if err != nil || !tableRe.MatchString(statement) {
+		return false
+	}
+	columnRe, err := regexp.Compile(`(?i)` + word(column) + `\s*` + containmentOperators)
+	return err == nil && columnRe.MatchString(statement)
+}
+
+// analyzeContainerIndexes suggests GIN indexes on jsonb and array columns.
+// With pg_stat_statements installed (and readable), columns its statements
+// filter on with a containment or existence operator are warnings; other
+// jsonb columns on tables of some size are info, since whether an index
+// helps depends on how they're queried.
+func analyzeContainerIndexes(ctx context.Context, pool *pgxpool.Pool, limit int, f *analysisFindings) {
+	rows, err := pool.Query(ctx, ginCandidateQuery)
+	if err != nil {
+		return
+	}
+	var candidates []ginCandidate
+	for rows.Next() {
+		var cand ginCandidate
+		if err := rows.Scan(&cand.tableName, &cand.quotedTable, &cand.table, &cand.column,
+			&cand.quotedColumn, &cand.isJSONB, &cand.estimatedRows); err != nil {
+			rows.Close()
+			return
+		}
+		candidates = append(candidates, cand)
+	}
+	rows.Close()
+	if rows.Err() != nil || len(candidates) == 0 {
+		return
+	}
+
+	statsAvailable := false
+	if schema := extensionSchema(ctx, pool, "pg_stat_statements"); schema != "" {
+		if quoted, err := dbsafe.QuoteIdent(schema); err == nil {
+			statsAvailable = countContainmentFilters(ctx, pool, quoted, candidates) == nil
+		}
+	}
+
+	var filtered, unindexed []models.AnalysisIssue
+	var filteredTotal, unindexedTotal int
+	for _, cand := range candidates {
+		kind := "array"
+		if cand.isJSONB {
+			kind = "jsonb"
+		}
+		suggestion := fmt.Sprintf("CREATE INDEX ON %s USING GIN (%s);", cand.quotedTable, cand.quotedColumn)
+		switch {
+		case cand.filterCalls > 0:
+			filteredTotal++
+			if len(filtered) < limit {
+				filtered = append(filtered, models.AnalysisIssue{
+					Severity:    "warning",
+					Title:       "Filtered column without GIN index",
+					Description: fmt.Sprintf("Queries filter %s column '%s' with containment or existence operators (%d calls) but it has no GIN index", kind, cand.column, cand.filterCalls),
+					Table:       cand.tableName,
+					Column:      cand.column,
+					Suggestion:  suggestion,
+					Impact:      "Those filters scan the whole table",
+				})
+			}
+		case cand.isJSONB && cand.estimatedRows >= ginUnindexedMinRows:
+			unindexedTotal++
+			if len(unindexed) < limit {
+				description := fmt.Sprintf("jsonb column '%s' (~%d rows) has no GIN index", cand.column, cand.estimatedRows)
+				if !statsAvailable {
+					description += "; install pg_stat_statements to see whether queries filter on it"
+				}
+				unindexed = append(unindexed, models.AnalysisIssue{
+					Severity:    "info",
+					Title:       "jsonb column without GIN index",
+					Description: description,
+					Table:       cand.tableName,
+					Column:      cand.column,
+					Suggestion:  suggestion,
+					Impact:      "Containment (@>) and key existence (?) filters on it scan the whole table; jsonb_path_ops makes a smaller index if only @> is used",
+				})
+			}
+		}
+	}
+	f.Issues = append(f.Issues, filtered...)
+	f.count("Filtered column without GIN index", filteredTotal, len(filtered))
+	f.Issues = append(f.Issues, unindexed...)
+	f.count("jsonb column without GIN index", unindexedTotal, len(unindexed))
+}
+
+// countContainmentFilters sets each candidate's filterCalls from the most
+// called pg_stat_statements entries of the current database. It fails
+// when the view isn't readable, e.g. the library isn't preloaded.
+func countContainmentFilters(ctx context.Context, pool *pgxpool.Pool, quotedSchema string, candidates []ginCandidate) error {
+	query := fmt.Sprintf(`
+		SELECT query, calls
+		FROM %s.pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		  AND query ~ '%s'
+		ORDER BY calls DESC
+		LIMIT %d
+	`, quotedSchema, containmentOperators, maxStatementsScanned)
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var statement string
+		var calls int64
+		if err := rows.Scan(&statement, &calls); err != nil {
+			return err
+		}
+		lower := strings.ToLower(statement)
+		for i := range candidates {
+			if strings.Contains(lower, strings.ToLower(candidates[i].column)) &&
+				filtersWithContainment(statement, candidates[i].table, candidates[i].column) {
+				candidates[i].filterCalls += calls
+			}
+		}
+	}
+	return rows.Err()
+}
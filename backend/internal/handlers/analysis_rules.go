@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+// customRuleTimeout bounds each custom rule's query; a slow rule fails on
+// its own instead of eating the whole analysis run.
+const customRuleTimeout = 5 * time.Second
+
+// customRuleTotalColumn is appended to a rule's result to count its rows
+// past the limit. Chosen to be unlikely to clash with a rule's columns.
+const customRuleTotalColumn = "pgvoyager_total_count"
+
+var ruleTemplatePlaceholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+type AnalysisRuleRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Severity            string `json:"severity" binding:"required"`
+	SQL                 string `json:"sql" binding:"required"`
+	DescriptionTemplate string `json:"descriptionTemplate"`
+	// Enabled defaults to true when omitted
+	Enabled *bool `json:"enabled"`
+}
+
+// validateAnalysisRule checks the severity and that the SQL is a single
+// row-returning statement.
+func validateAnalysisRule(req *AnalysisRuleRequest) string {
+	switch req.Severity {
+	case "critical", "warning", "info":
+	default:
+		return "severity must be critical, warning or info"
+	}
+	statements := splitStatements(req.SQL)
+	if len(statements) != 1 {
+		return "sql must be a single statement"
+	}
+	if _, body := statementLabel(statements[0].SQL); !isSelectStatement(body) {
+		return "sql must be a SELECT query"
+	}
+	return ""
+}
+
+func ListAnalysisRules(c *gin.Context) {
+	rules, err := storage.ListAnalysisRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+func CreateAnalysisRule(c *gin.Context) {
+	var req AnalysisRuleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateAnalysisRule(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	now := time.Now()
+	rule := &storage.AnalysisRule{
+		ID:                  uuid.New().String(),
+		Name:                req.Name,
+		Severity:            req.Severity,
+		SQL:                 req.SQL,
+		DescriptionTemplate: req.DescriptionTemplate,
+		Enabled:             req.Enabled == nil || *req.Enabled,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := storage.SaveAnalysisRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func GetAnalysisRule(c *gin.Context) {
+	rule, err := storage.GetAnalysisRule(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+func UpdateAnalysisRule(c *gin.Context) {
+	var req AnalysisRuleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateAnalysisRule(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	rule, err := storage.GetAnalysisRule(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule.Name = req.Name
+	rule.Severity = req.Severity
+	rule.SQL = req.SQL
+	rule.DescriptionTemplate = req.DescriptionTemplate
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	rule.UpdatedAt = time.Now()
+	if err := storage.SaveAnalysisRule(rule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func DeleteAnalysisRule(c *gin.Context) {
+	if err := storage.DeleteAnalysisRule(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Analysis rule deleted"})
+}
+
+// analyzeCustomRules runs every enabled custom rule. A rule that fails
+// (bad SQL, timeout, attempted write) is reported as an info issue rather
+// than aborting the analysis.
+func analyzeCustomRules(ctx context.Context, pool *pgxpool.Pool, limit int) analysisFindings {
+	f := analysisFindings{Issues: []models.AnalysisIssue{}}
+
+	rules, err := storage.ListEnabledAnalysisRules()
+	if err != nil {
+		return f
+	}
+	for _, rule := range rules {
+		issues, total, err := runCustomRule(ctx, pool, rule, limit)
+		if err != nil {
+			f.Issues = append(f.Issues, models.AnalysisIssue{
+				Severity:    "info",
+				Title:       "Custom rule failed: " + rule.Name,
+				Description: err.Error(),
+			})
+			continue
+		}
+		f.Issues = append(f.Issues, issues...)
+		f.count(rule.Name, total, len(issues))
+	}
+	return f
+}
+
+// runCustomRule executes a rule's SQL in a read-only transaction with a
+// statement timeout, returning up to limit issues and the total row count.
+//
+// Each row becomes an issue with the rule's severity. The columns title,
+// table, column, suggestion, impact and description fill the matching
+// fields; title falls back to the rule name, and a description template,
+// when set, replaces the description column.
+func runCustomRule(ctx context.Context, pool *pgxpool.Pool, rule storage.AnalysisRule, limit int) ([]models.AnalysisIssue, int, error) {
+	statements := splitStatements(rule.SQL)
+	if len(statements) != 1 {
+		return nil, 0, fmt.Errorf("sql must be a single statement")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, customRuleTimeout)
+	defer cancel()
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", customRuleTimeout.Milliseconds())); err != nil {
+		return nil, 0, err
+	}
+
+	// Wrapping the rule as a subquery also keeps it to a single SELECT. The
+	// newline keeps a trailing line comment from swallowing the rest.
+	query := fmt.Sprintf("SELECT *, count(*) OVER () AS %s FROM (%s\n) AS rule LIMIT %d",
+		customRuleTotalColumn, statements[0].SQL, limit)
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	issues := []models.AnalysisIssue{}
+	total := 0
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, 0, err
+		}
+		last := len(values) - 1
+		if n, ok := values[last].(int64); ok {
+			total = int(n)
+		}
+		row := make(map[string]string, last)
+		for i, fd := range fieldDescs[:last] {
+			value := ""
+			if values[i] != nil {
+				value = fmt.Sprint(convertValue(values[i], fd.DataTypeOID))
+			}
+			row[string(fd.Name)] = value
+		}
+		issues = append(issues, customRuleIssue(rule, row))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return issues, total, nil
+}
+
+// customRuleIssue maps one result row onto an issue.
+func customRuleIssue(rule storage.AnalysisRule, row map[string]string) models.AnalysisIssue {
+	issue := models.AnalysisIssue{
+		Severity:    rule.Severity,
+		Title:       row["title"],
+		Description: row["description"],
+		Table:       row["table"],
+		Column:      row["column"],
+		Suggestion:  row["suggestion"],
+		Impact:      row["impact"],
+	}
+	if issue.Title == "" {
+		issue.Title = rule.Name
+	}
+	if rule.DescriptionTemplate != "" {
+		issue.Description = renderRuleTemplate(rule.DescriptionTemplate, row)
+	}
+	return issue
+}
+
+// renderRuleTemplate substitutes {{column}} placeholders with the row's
+// values. Placeholders naming a column the row doesn't have are left as-is.
+func renderRuleTemplate(tmpl string, row map[string]string) string {
+	return ruleTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := ruleTemplatePlaceholder.FindStringSubmatch(match)[1]
+		if value, ok := row[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+// GetSizeHistory returns the database and table sizes sampled in the
+// background while the connection was open, oldest first. ?days= narrows
+// it to recent samples; by default everything retained is returned. The
+// connection doesn't need to be connected.
+func GetSizeHistory(c *gin.Context) {
+	connId := c.Param("connId")
+	if _, err := database.GetManager().Get(connId); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	retention := storage.SizeHistoryRetention()
+	days := retention
+	if value := c.Query("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive number"})
+			return
+		}
+		days = min(n, retention)
+	}
+
+	samples, err := storage.GetSizeHistory(connId, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SizeHistory{
+		IntervalSeconds: int(database.SizeHistoryInterval().Seconds()),
+		RetentionDays:   retention,
+		Samples:         samples,
+	})
+}
+
+// SampleSize takes a size sample now, in addition to the background ones,
+// and returns it.
+func SampleSize(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sample, err := manager.SampleSize(ctx, connId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sample)
+}
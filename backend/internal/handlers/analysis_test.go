@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+func TestParseAnalysisLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", defaultAnalysisLimit},
+		{"abc", defaultAnalysisLimit},
+		{"0", defaultAnalysisLimit},
+		{"-5", defaultAnalysisLimit},
+		{"50", 50},
+		{" 7 ", 7},
+		{"100000", maxAnalysisLimit},
+	}
+	for _, tt := range tests {
+		if got := parseAnalysisLimit(tt.in); got != tt.want {
+			t.Errorf("parseAnalysisLimit(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAnalysisFindingsCount(t *testing.T) {
+	var f analysisFindings
+	f.count("Unused index", 147, 20)
+	f.count("Duplicate indexes", 0, 0)
+	if len(f.Checks) != 1 {
+		t.Fatalf("got %d checks, want 1", len(f.Checks))
+	}
+	if c := f.Checks[0]; c.Title != "Unused index" || c.TotalCount != 147 || c.Shown != 20 {
+		t.Errorf("unexpected check %+v", c)
+	}
+}
+
+func TestValidateAnalysisRule(t *testing.T) {
+	tests := []struct {
+		req  AnalysisRuleRequest
+		want bool
+	}{
+		{AnalysisRuleRequest{Severity: "warning", SQL: "SELECT relname AS table FROM pg_class"}, true},
+		{AnalysisRuleRequest{Severity: "info", SQL: "-- big tables\nWITH t AS (SELECT 1) SELECT * FROM t;"}, true},
+		{AnalysisRuleRequest{Severity: "urgent", SQL: "SELECT 1"}, false},
+		{AnalysisRuleRequest{Severity: "info", SQL: "SELECT 1; SELECT 2"}, false},
+		{AnalysisRuleRequest{Severity: "info", SQL: "DELETE FROM t"}, false},
+	}
+	for _, tt := range tests {
+		if got := validateAnalysisRule(&tt.req) == ""; got != tt.want {
+			t.Errorf("validateAnalysisRule(%+v) valid = %v, want %v", tt.req, got, tt.want)
+		}
+	}
+}
+
+func TestCustomRuleIssue(t *testing.T) {
+	rule := storage.AnalysisRule{
+		Name:                "Tables without comments",
+		Severity:            "info",
+		DescriptionTemplate: "{{table}} has {{ n }} columns{{missing}}",
+	}
+	issue := customRuleIssue(rule, map[string]string{"table": "public.orders", "n": "12", "suggestion": "COMMENT ON TABLE ..."})
+	if issue.Title != rule.Name || issue.Severity != "info" || issue.Table != "public.orders" {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+	if want := "public.orders has 12 columns{{missing}}"; issue.Description != want {
+		t.Errorf("Description = %q, want %q", issue.Description, want)
+	}
+
+	issue = customRuleIssue(storage.AnalysisRule{Name: "r"}, map[string]string{"title": "Custom", "description": "from row"})
+	if issue.Title != "Custom" || issue.Description != "from row" {
+		t.Errorf("row columns should be used without a template, got %+v", issue)
+	}
+}
+
+func TestParseTableBloatCriticalPct(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"", defaultTableBloatCriticalPct},
+		{"abc", defaultTableBloatCriticalPct},
+		{"0", defaultTableBloatCriticalPct},
+		{"150", defaultTableBloatCriticalPct},
+		{" 35.5 ", 35.5},
+		{"100", 100},
+	}
+	for _, tt := range tests {
+		if got := parseTableBloatCriticalPct(tt.in); got != tt.want {
+			t.Errorf("parseTableBloatCriticalPct(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTableBloatAdvice(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	tests := []struct {
+		bloat        *float64
+		wantSeverity string
+		wantPrefix   string
+	}{
+		{nil, "warning", "VACUUM ANALYZE"},
+		{pct(30), "warning", "VACUUM ANALYZE"},
+		{pct(50), "critical", "VACUUM FULL"},
+		{pct(80), "critical", "VACUUM FULL"},
+	}
+	for _, tt := range tests {
+		severity, suggestion := tableBloatAdvice("public.orders", tt.bloat, 50)
+		if severity != tt.wantSeverity || !strings.HasPrefix(suggestion, tt.wantPrefix+" public.orders") {
+			t.Errorf("tableBloatAdvice(%v) = (%q, %q), want %s / %s", tt.bloat, severity, suggestion, tt.wantSeverity, tt.wantPrefix)
+		}
+	}
+}
+
+func TestFiltersWithContainment(t *testing.T) {
+	tests := []struct {
+		statement string
+		want      bool
+	}{
+		{`SELECT * FROM events WHERE payload @> $1`, true},
+		{`SELECT * FROM public.events e WHERE e.payload ? $1`, true},
+		{`select id from "events" where "payload" ?| $1`, true},
+		{`SELECT * FROM events WHERE tags && $1 AND payload->>'a' = $2`, false},
+		{`SELECT * FROM events WHERE payload->>'a' = $1`, false},
+		{`SELECT * FROM other WHERE payload @> $1`, false},
+		{`SELECT * FROM events_archive WHERE payload @> $1`, false},
+		{`SELECT * FROM events WHERE old_payload @> $1`, false},
+	}
+	for _, tt := range tests {
+		if got := filtersWithContainment(tt.statement, "events", "payload"); got != tt.want {
+			t.Errorf("filtersWithContainment(%q) = %v, want %v", tt.statement, got, tt.want)
+		}
+	}
+}
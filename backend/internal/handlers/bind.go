@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+// bindJSON binds the request body into obj, answering 413 for a body over
+// the size limit and 400 for any other bind error. It reports whether the
+// handler should carry on.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		if !security.AbortIfBodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
+
+// bindOptionalJSON is bindJSON for an optional body: an empty one leaves
+// obj as it is.
+func bindOptionalJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		if !security.AbortIfBodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	return true
+}
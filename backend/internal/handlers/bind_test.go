@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+func TestBindJSONBodyTooLarge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(security.MaxBodyBytes(32))
+	r.POST("/bind", func(c *gin.Context) {
+		var req struct {
+			SQL string `json:"sql"`
+		}
+		if bindJSON(c, &req) {
+			c.String(http.StatusOK, req.SQL)
+		}
+	})
+	r.POST("/optional", func(c *gin.Context) {
+		var req struct {
+			Force bool `json:"force"`
+		}
+		if bindOptionalJSON(c, &req) {
+			c.Status(http.StatusNoContent)
+		}
+	})
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		// No declared length, as with a chunked body.
+		req := httptest.NewRequest(http.MethodPost, path, io.NopCloser(strings.NewReader(body)))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	big := `{"sql":"` + strings.Repeat("x", 64) + `"}`
+	if w := send("/bind", big); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversize body: got %d %s, want 413", w.Code, w.Body.String())
+	}
+	if w := send("/optional", big); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversize optional body: got %d %s, want 413", w.Code, w.Body.String())
+	}
+	if w := send("/bind", `{"sql":`); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: got %d, want 400", w.Code)
+	}
+	if w := send("/bind", `{"sql":"SELECT 1"}`); w.Code != http.StatusOK || w.Body.String() != "SELECT 1" {
+		t.Errorf("small body: got %d %q", w.Code, w.Body.String())
+	}
+	if w := send("/optional", ``); w.Code != http.StatusNoContent {
+		t.Errorf("empty optional body: got %d, want 204", w.Code)
+	}
+}
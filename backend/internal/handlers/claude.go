@@ -33,8 +33,7 @@ func authenticateSession(c *gin.Context, sessionID string) (*claude.Session, boo
 // must supply the token on every subsequent session-scoped request.
 func CreateClaudeSession(c *gin.Context) {
 	var req claude.CreateSessionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -54,6 +53,23 @@ func CreateClaudeSession(c *gin.Context) {
 	})
 }
 
+// ListClaudeSessions lists the live sessions (never their tokens), so
+// abandoned ones can be spotted and destroyed.
+func ListClaudeSessions(c *gin.Context) {
+	c.JSON(http.StatusOK, claude.GetManager().ListSessions())
+}
+
+// GetClaudeSessionTranscript returns the session's recent terminal output
+// for scrollback after reconnecting or to review what it did. Requires the
+// per-session bearer token.
+func GetClaudeSessionTranscript(c *gin.Context) {
+	session, ok := authenticateSession(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, session.Transcript())
+}
+
 // DestroyClaudeSession terminates a Claude Code terminal session. Requires
 // the per-session bearer token to prevent unauthenticated session
 // destruction.
@@ -101,8 +117,7 @@ func UpdateClaudeSessionConnection(c *gin.Context) {
 	var req struct {
 		ConnectionID string `json:"connectionId" binding:"required"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -113,3 +128,27 @@ func UpdateClaudeSessionConnection(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// UpdateClaudeSessionWrites lets the user allow or forbid writing
+// statements through the MCP execute_query tool for one session.
+// Requires the per-session bearer token.
+func UpdateClaudeSessionWrites(c *gin.Context) {
+	sessionID := c.Param("id")
+	if _, ok := authenticateSession(c, sessionID); !ok {
+		return
+	}
+
+	var req struct {
+		AllowWrites *bool `json:"allowWrites" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := claude.GetManager().SetAllowWrites(sessionID, *req.AllowWrites); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allowWrites": *req.AllowWrites})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	defaultStatsTopN = 10
+	maxStatsTopN     = 100
+	// columnStatsSampleRows bounds the fallback scan used when the table
+	// has no planner statistics yet.
+	columnStatsSampleRows = 10000
+)
+
+// parseStatsTopN reads the ?top= count of most common values, defaulting
+// missing or invalid values and capping at maxStatsTopN.
+func parseStatsTopN(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return defaultStatsTopN
+	}
+	return min(n, maxStatsTopN)
+}
+
+// distinctEstimate turns pg_stats.n_distinct into a count. Negative values
+// are minus the fraction of rows that are distinct, used by ANALYZE when
+// the count is expected to grow with the table.
+func distinctEstimate(nDistinct, rows float64) int64 {
+	if nDistinct < 0 {
+		return int64(math.Round(-nDistinct * rows))
+	}
+	return int64(math.Round(nDistinct))
+}
+
+// mostCommonValues pairs pg_stats.most_common_vals with most_common_freqs
+// (already most frequent first) and keeps the first n.
+func mostCommonValues(vals []string, freqs []float64, rows float64, n int) []models.ValueFrequency {
+	out := []models.ValueFrequency{}
+	for i := 0; i < len(vals) && i < len(freqs) && i < n; i++ {
+		out = append(out, models.ValueFrequency{
+			Value:     vals[i],
+			Count:     int64(math.Round(freqs[i] * rows)),
+			Frequency: freqs[i],
+		})
+	}
+	return out
+}
+
+// catalogColumnStats fills stats from pg_stats. It reports false when
+// ANALYZE hasn't covered the column (or the table looks empty), leaving
+// stats untouched. For partitioned and inheritance parents the
+// whole-hierarchy row is preferred.
+func catalogColumnStats(ctx context.Context, q queryRunner, stats *models.ColumnStats, reltuples float64, topN int) (bool, error) {
+	if reltuples <= 0 {
+		return false, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT
+			null_frac::float8,
+			n_distinct::float8,
+			COALESCE(most_common_vals::text::text[], '{}'),
+			COALESCE(most_common_freqs::float8[], '{}')
+		FROM pg_catalog.pg_stats
+		WHERE schemaname = $1 AND tablename = $2 AND attname = $3
+		ORDER BY inherited DESC
+		LIMIT 1
+	`, stats.Schema, stats.Table, stats.Column)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+
+	var nullFrac, nDistinct float64
+	var vals []string
+	var freqs []float64
+	if err := rows.Scan(&nullFrac, &nDistinct, &vals, &freqs); err != nil {
+		return false, err
+	}
+
+	stats.Source = models.ColumnStatsFromCatalog
+	stats.RowCount = int64(math.Round(reltuples))
+	stats.NullFraction = nullFrac
+	stats.NullCount = int64(math.Round(nullFrac * reltuples))
+	stats.DistinctCount = distinctEstimate(nDistinct, reltuples)
+	stats.MostCommon = mostCommonValues(vals, freqs, reltuples, topN)
+	return true, rows.Err()
+}
+
+// sampleColumnStats computes the figures over the first
+// columnStatsSampleRows rows. Values are compared as text so types
+// without an equality operator (json, point) still work.
+func sampleColumnStats(ctx context.Context, q queryRunner, stats *models.ColumnStats, relation, column string, topN int) error {
+	sample := fmt.Sprintf("WITH s AS (SELECT %s AS v FROM %s LIMIT %d) ", column, relation, columnStatsSampleRows)
+
+	rows, err := q.Query(ctx, sample+`
+		SELECT count(*), count(*) FILTER (WHERE v IS NULL), count(DISTINCT v::text)
+		FROM s
+	`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		if err := rows.Scan(&stats.SampleSize, &stats.NullCount, &stats.DistinctCount); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stats.Source = models.ColumnStatsFromSample
+	stats.RowCount = stats.SampleSize
+	if stats.SampleSize > 0 {
+		stats.NullFraction = float64(stats.NullCount) / float64(stats.SampleSize)
+	}
+
+	rows, err = q.Query(ctx, sample+`
+		SELECT v::text, count(*)
+		FROM s
+		WHERE v IS NOT NULL
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT $1
+	`, topN)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	stats.MostCommon = []models.ValueFrequency{}
+	for rows.Next() {
+		var vf models.ValueFrequency
+		if err := rows.Scan(&vf.Value, &vf.Count); err != nil {
+			return err
+		}
+		vf.Frequency = float64(vf.Count) / float64(stats.SampleSize)
+		stats.MostCommon = append(stats.MostCommon, vf)
+	}
+	return rows.Err()
+}
+
+// GetColumnStats profiles a column: null and distinct counts and the most
+// common values, from the planner statistics when ANALYZE has run and from
+// a sample of the table otherwise, plus the exact min and max of numeric
+// and date/time columns.
+func GetColumnStats(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	column := c.Param("column")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) || !isValidIdentifier(column) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema, table or column name"})
+		return
+	}
+	topN := parseStatsTopN(c.Query("top"))
+
+	stats := models.ColumnStats{Schema: schema, Table: table, Column: column}
+	var category string
+	var reltuples float64
+	err := pool.QueryRow(ctx, `
+		SELECT format_type(a.atttypid, a.atttypmod), t.typcategory::text, c.reltuples::float8
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attname = $3
+		  AND a.attnum > 0 AND NOT a.attisdropped
+	`, schema, table, column).Scan(&stats.DataType, &category, &reltuples)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Column not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	relation := quoteIdentifier(schema) + "." + quoteIdentifier(table)
+	quotedColumn := quoteIdentifier(column)
+
+	found, err := catalogColumnStats(ctx, pool, &stats, reltuples, topN)
+	if err == nil && !found {
+		err = sampleColumnStats(ctx, pool, &stats, relation, quotedColumn, topN)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// N: numeric types, D: date/time types
+	if category == "N" || category == "D" {
+		query := fmt.Sprintf("SELECT min(%s)::text, max(%s)::text FROM %s", quotedColumn, quotedColumn, relation)
+		if err := pool.QueryRow(ctx, query).Scan(&stats.Min, &stats.Max); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
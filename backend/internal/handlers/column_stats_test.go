@@ -0,0 +1,51 @@
+package handlers
+
+import "testing"
+
+func TestDistinctEstimate(t *testing.T) {
+	tests := []struct {
+		nDistinct, rows float64
+		want            int64
+	}{
+		{42, 1000, 42},
+		{-1, 1000, 1000},
+		{-0.25, 1000, 250},
+		{0, 1000, 0},
+	}
+	for _, tt := range tests {
+		if got := distinctEstimate(tt.nDistinct, tt.rows); got != tt.want {
+			t.Errorf("distinctEstimate(%v, %v) = %d, want %d", tt.nDistinct, tt.rows, got, tt.want)
+		}
+	}
+}
+
+func TestMostCommonValues(t *testing.T) {
+	got := mostCommonValues([]string{"a", "b", "c"}, []float64{0.5, 0.3, 0.1}, 200, 2)
+	if len(got) != 2 {
+		t.Fatalf("got %d values, want 2", len(got))
+	}
+	if got[0].Value != "a" || got[0].Count != 100 || got[1].Value != "b" || got[1].Count != 60 {
+		t.Errorf("unexpected values %+v", got)
+	}
+	if got := mostCommonValues(nil, nil, 200, 10); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty, non-nil slice, got %#v", got)
+	}
+}
+
+func TestParseStatsTopN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", defaultStatsTopN},
+		{"x", defaultStatsTopN},
+		{"0", defaultStatsTopN},
+		{"5", 5},
+		{"1000", maxStatsTopN},
+	}
+	for _, tt := range tests {
+		if got := parseStatsTopN(tt.in); got != tt.want {
+			t.Errorf("parseStatsTopN(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// relationCommentKinds maps pg_class.relkind to the COMMENT ON object type
+// for the relations the tables endpoints reach.
+var relationCommentKinds = map[string]string{
+	"r": "TABLE",
+	"p": "TABLE",
+	"v": "VIEW",
+	"m": "MATERIALIZED VIEW",
+	"f": "FOREIGN TABLE",
+}
+
+// commentLiteral renders a comment for COMMENT ON ... IS, which takes no
+// parameters: a quoted string literal, or NULL to drop an empty comment.
+func commentLiteral(comment string) (string, error) {
+	if strings.TrimSpace(comment) == "" {
+		return "NULL", nil
+	}
+	return dbsafe.QuoteString(comment)
+}
+
+// SetTableComment sets or, given an empty comment, removes the comment on
+// a table (or view, materialized view or foreign table).
+func SetTableComment(c *gin.Context) {
+	setComment(c, false)
+}
+
+// SetColumnComment sets or removes the comment on one of a table's columns.
+func SetColumnComment(c *gin.Context) {
+	setComment(c, true)
+}
+
+func setComment(c *gin.Context, onColumn bool) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "editing comments") {
+		return
+	}
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	column := c.Param("column")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) || (onColumn && !isValidIdentifier(column)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema, table or column name"})
+		return
+	}
+
+	var req models.CommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	literal, err := commentLiteral(*req.Comment)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var relkind string
+	err = pool.QueryRow(ctx, `
+		SELECT c.relkind::text
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, table).Scan(&relkind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("relation %s.%s not found", schema, table)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	kind, ok := relationCommentKinds[relkind]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s.%s can't be commented on here", schema, table)})
+		return
+	}
+
+	target := fmt.Sprintf("%s %s.%s", kind, quoteIdentifier(schema), quoteIdentifier(table))
+	if onColumn {
+		target = fmt.Sprintf("COLUMN %s.%s.%s", quoteIdentifier(schema), quoteIdentifier(table), quoteIdentifier(column))
+	}
+	if _, err := pool.Exec(ctx, fmt.Sprintf("COMMENT ON %s IS %s", target, literal)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Comment updated"
+	if literal == "NULL" {
+		message = "Comment removed"
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message})
+}
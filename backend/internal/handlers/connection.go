@@ -1,18 +1,41 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
 	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
 )
 
 // safeErr is a thin wrapper over dbsafe.SafeErrorMessage so handlers don't
 // have to import the package just for one call.
 func safeErr(err error) string { return dbsafe.SafeErrorMessage(err) }
 
+// connectErrorBody builds the error response for attempts to reach a
+// server. errorKind tells the UI whether the SSH hop or Postgres failed;
+// errorCode is a stable cause (see database.ClassifyConnectError) with a
+// friendly errorMessage, and transient says whether retrying may help.
+func connectErrorBody(err error) gin.H {
+	kind := "postgres"
+	if database.IsTunnelError(err) {
+		kind = "ssh_tunnel"
+	}
+	info := database.ClassifyConnectError(err)
+	return gin.H{
+		"error":        safeErr(err),
+		"errorKind":    kind,
+		"errorCode":    info.Code,
+		"errorMessage": info.Message,
+		"transient":    info.Transient,
+	}
+}
+
 func ListConnections(c *gin.Context) {
 	manager := database.GetManager()
 	connections := manager.List()
@@ -21,7 +44,26 @@ func ListConnections(c *gin.Context) {
 
 func CreateConnection(c *gin.Context) {
 	var req models.ConnectionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := database.ValidateRole(req.Role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateSearchPath(req.SearchPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidatePoolSize(req.MaxConns, req.MinConns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateConnectionTarget(req.ConnectionString, req.Host, req.Port, req.Username, req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateSSLFiles(req.SSLFiles, req.SSLMode, req.ConnectionString); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -35,39 +77,143 @@ func CreateConnection(c *gin.Context) {
 	c.JSON(http.StatusCreated, conn)
 }
 
+// DuplicateConnection copies a saved connection, credentials included,
+// into a new one named "<name> (copy)", for a near-identical setup such as
+// another database on the same server.
+func DuplicateConnection(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := database.GetManager().Get(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": safeErr(err)})
+		return
+	}
+
+	conn, err := database.GetManager().Duplicate(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err)})
+		return
+	}
+
+	c.JSON(http.StatusCreated, conn)
+}
+
+// ExportConnections downloads every saved connection as JSON. Passwords
+// are encrypted with the passphrase in the X-Export-Passphrase header, or
+// left out when there is none; they are never exported in plaintext.
+func ExportConnections(c *gin.Context) {
+	doc, err := database.GetManager().Export(c.GetHeader(models.ExportPassphraseHeader))
+	if errors.Is(err, database.ErrPassphraseTooShort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err)})
+		return
+	}
+
+	filename := fmt.Sprintf("pgvoyager-connections-%s.json", time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.JSON(http.StatusOK, doc)
+}
+
+// ImportConnections merges an ExportConnections file into the saved
+// connections. An encrypted file needs its passphrase in the
+// X-Export-Passphrase header; a wrong one imports nothing.
+func ImportConnections(c *gin.Context) {
+	var doc models.ConnectionExport
+	if !bindJSON(c, &doc) {
+		return
+	}
+
+	result, err := database.GetManager().Import(&doc, c.GetHeader(models.ExportPassphraseHeader))
+	if result == nil && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err), "imported": result.Imported})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 func TestConnection(c *gin.Context) {
 	var req models.TestConnectionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := database.ValidateRole(req.Role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateSearchPath(req.SearchPath); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if req.SSLMode == "" {
+	if err := database.ValidateConnectionTarget(req.ConnectionString, req.Host, req.Port, req.Username, req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "success": false})
+		return
+	}
+	if err := database.ValidateSSLFiles(req.SSLFiles, req.SSLMode, req.ConnectionString); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "success": false})
+		return
+	}
+
+	if req.SSLMode == "" && req.ConnectionString == "" {
 		req.SSLMode = "prefer"
 	}
 
-	if err := database.GetManager().TestConnection(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": safeErr(err), "success": false})
+	info, err := database.GetManager().TestConnection(&req)
+	if err != nil {
+		body := connectErrorBody(err)
+		body["success"] = false
+		c.JSON(http.StatusBadRequest, body)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Connection successful"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Connection successful", "info": info})
 }
 
 func GetConnection(c *gin.Context) {
 	id := c.Param("id")
-	conn, err := database.GetManager().Get(id)
+	manager := database.GetManager()
+	conn, err := manager.Get(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": safeErr(err)})
 		return
 	}
+	if conn.IsConnected {
+		// Best effort: a failed ping just leaves latency unset.
+		if rtt, err := manager.Latency(id); err == nil {
+			conn.LatencyMs = rtt.Seconds() * 1000
+		}
+	}
 	c.JSON(http.StatusOK, conn)
 }
 
 func UpdateConnection(c *gin.Context) {
 	id := c.Param("id")
 	var req models.ConnectionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := database.ValidateRole(req.Role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateSearchPath(req.SearchPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidatePoolSize(req.MaxConns, req.MinConns); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateConnectionTarget(req.ConnectionString, req.Host, req.Port, req.Username, req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.ValidateSSLFiles(req.SSLFiles, req.SSLMode, req.ConnectionString); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -81,6 +227,22 @@ func UpdateConnection(c *gin.Context) {
 	c.JSON(http.StatusOK, conn)
 }
 
+// SetConnectionFavorite pins or unpins a connection. An empty body flips
+// the current setting.
+func SetConnectionFavorite(c *gin.Context) {
+	var req models.FavoriteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	conn, err := database.GetManager().SetFavorite(c.Param("id"), req.Favorite)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": safeErr(err)})
+		return
+	}
+	c.JSON(http.StatusOK, conn)
+}
+
 func DeleteConnection(c *gin.Context) {
 	id := c.Param("id")
 	if err := database.GetManager().Delete(id); err != nil {
@@ -93,7 +255,7 @@ func DeleteConnection(c *gin.Context) {
 func Connect(c *gin.Context) {
 	id := c.Param("id")
 	if err := database.GetManager().Connect(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": safeErr(err)})
+		c.JSON(http.StatusBadRequest, connectErrorBody(err))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Connected successfully"})
@@ -108,17 +270,38 @@ func Disconnect(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Disconnected successfully"})
 }
 
+func GetPoolStats(c *gin.Context) {
+	id := c.Param("id")
+	stats, err := database.GetManager().PoolStats(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": safeErr(err)})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetConnectionHealth returns the result of the connection's background
+// health check: last ping time, latency and any failure.
+func GetConnectionHealth(c *gin.Context) {
+	id := c.Param("id")
+	health, err := database.GetManager().Health(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": safeErr(err)})
+		return
+	}
+	c.JSON(http.StatusOK, health)
+}
+
 func SwitchDatabase(c *gin.Context) {
 	id := c.Param("id")
 	var req models.SwitchDatabaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	conn, err := database.GetManager().SwitchDatabase(id, req.Database)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": safeErr(err)})
+		c.JSON(http.StatusBadRequest, connectErrorBody(err))
 		return
 	}
 
@@ -127,9 +310,11 @@ func SwitchDatabase(c *gin.Context) {
 
 func CreateDatabase(c *gin.Context) {
 	id := c.Param("id")
+	if rejectReadOnlyWrite(c, id, "creating databases") {
+		return
+	}
 	var req models.CreateDatabaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -143,9 +328,15 @@ func CreateDatabase(c *gin.Context) {
 
 func DropDatabase(c *gin.Context) {
 	id := c.Param("id")
+	if rejectReadOnlyWrite(c, id, "dropping databases") {
+		return
+	}
 	dbName := c.Param("name")
 	var req models.DropDatabaseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		if security.AbortIfBodyTooLarge(c, err) {
+			return
+		}
 		// body optional — default force=false
 		req = models.DropDatabaseRequest{}
 	}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,67 +16,60 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
 	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
 )
 
 var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
-// convertValue normalizes pgx-returned values so the JSON response is
-// the form the frontend already knows how to render:
-//
-//   - []byte (e.g. XML) → string (otherwise base64-encoded).
-//   - pgtype.Time → "HH:MM:SS[.ffffff]" string. By default pgx hands
-//     these back as {Microseconds, Valid} which serializes as an opaque
-//     JSON object that the DataGrid's temporal formatter can't parse.
-//   - pgtype.Interval → "HHHH:MM:SS" string for the same reason.
-//
-// Other temporal types (date / timestamp / timestamptz) already arrive
-// as time.Time and serialize to RFC3339 — the DataGrid formats those
-// itself.
-func convertValue(v any) any {
-	switch x := v.(type) {
-	case []byte:
-		return string(x)
-	case pgtype.Time:
-		if !x.Valid {
-			return nil
-		}
-		us := x.Microseconds
-		hours := us / 3_600_000_000
-		us -= hours * 3_600_000_000
-		mins := us / 60_000_000
-		us -= mins * 60_000_000
-		secs := us / 1_000_000
-		us -= secs * 1_000_000
-		if us == 0 {
-			return fmt.Sprintf("%02d:%02d:%02d", hours, mins, secs)
-		}
-		return fmt.Sprintf("%02d:%02d:%02d.%06d", hours, mins, secs, us)
-	case pgtype.Interval:
-		if !x.Valid {
-			return nil
-		}
-		// Render as ISO-ish "PnDThh:mm:ss" only when meaningful;
-		// otherwise just the time portion.
-		totalUs := x.Microseconds
-		hours := totalUs / 3_600_000_000
-		totalUs -= hours * 3_600_000_000
-		mins := totalUs / 60_000_000
-		totalUs -= mins * 60_000_000
-		secs := totalUs / 1_000_000
-		if x.Days == 0 && x.Months == 0 {
-			return fmt.Sprintf("%02d:%02d:%02d", hours, mins, secs)
-		}
-		return fmt.Sprintf("%d months %d days %02d:%02d:%02d", x.Months, x.Days, hours, mins, secs)
-	}
-	return v
-}
-
 func isValidIdentifier(s string) bool {
 	return identifierRegex.MatchString(s)
 }
 
+// builtinTypes knows the OIDs of Postgres's built-in types (json, jsonb,
+// uuid, timestamptz, their arrays, ...), so most result columns are named
+// without a pg_type lookup.
+var builtinTypes = pgtype.NewMap()
+
+// builtinTypeName names a built-in type OID, writing an array type as
+// elem[] rather than pg_type's _elem.
+func builtinTypeName(oid uint32) (string, bool) {
+	t, ok := builtinTypes.TypeForOID(oid)
+	if !ok {
+		return "", false
+	}
+	if elem, isArray := strings.CutPrefix(t.Name, "_"); isArray {
+		return elem + "[]", true
+	}
+	return t.Name, true
+}
+
+// resolveTypeNames names type OIDs, built-in ones directly and the rest
+// (enums, domains, extension types) from pg_type.
+func resolveTypeNames(ctx context.Context, pool interface{ Query(context.Context, string, ...any) (pgx.Rows, error) }, oids []uint32) (map[uint32]string, error) {
+	typeNames := make(map[uint32]string, len(oids))
+	var unknown []uint32
+	for _, oid := range oids {
+		if name, ok := builtinTypeName(oid); ok {
+			typeNames[oid] = name
+		} else {
+			unknown = append(unknown, oid)
+		}
+	}
+
+	looked, err := getTypeNames(ctx, pool, unknown)
+	if err != nil {
+		return typeNames, err
+	}
+	for oid, name := range looked {
+		typeNames[oid] = name
+	}
+	return typeNames, nil
+}
+
 // getTypeNames converts PostgreSQL OIDs to type names using pg_type
 func getTypeNames(ctx context.Context, pool interface{ Query(context.Context, string, ...any) (pgx.Rows, error) }, oids []uint32) (map[uint32]string, error) {
 	if len(oids) == 0 {
@@ -90,9 +85,10 @@ func getTypeNames(ctx context.Context, pool interface{ Query(context.Context, st
 	}
 
 	query := fmt.Sprintf(`
-		SELECT oid, typname
-		FROM pg_type
-		WHERE oid IN (%s)
+		SELECT t.oid, COALESCE(e.typname || '[]', t.typname)
+		FROM pg_type t
+		LEFT JOIN pg_type e ON e.oid = t.typelem AND t.typcategory = 'A'
+		WHERE t.oid IN (%s)
 	`, strings.Join(placeholders, ", "))
 
 	rows, err := pool.Query(ctx, query, args...)
@@ -226,6 +222,8 @@ func buildErrorResult(err error, duration float64, positionOffset int) models.Qu
 	}
 
 	// Try to extract PostgreSQL-specific error details
+	result.ErrorKind = queryErrorKind(err)
+
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
 		result.Error = pgErr.Message
@@ -247,6 +245,44 @@ func buildErrorResult(err error, duration float64, positionOffset int) models.Qu
 	return result
 }
 
+// queryErrorKind classifies timeouts for QueryResult.ErrorKind. Both
+// statement_timeout and a cancel sent on context expiry surface as SQLSTATE
+// 57014; only the former says "statement timeout".
+func queryErrorKind(err error) string {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "57014" && strings.Contains(pgErr.Message, "statement timeout"):
+		return models.QueryErrorStatementTimeout
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.QueryErrorDeadline
+	}
+	return ""
+}
+
+// markDeadline flags an error result as a deadline hit when ctx expired
+// during the run, however the driver reported it.
+func markDeadline(ctx context.Context, result *models.QueryResult) {
+	if result.Error != "" && result.ErrorKind == "" && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.ErrorKind = models.QueryErrorDeadline
+	}
+}
+
+// setStatementTimeout applies statement_timeout to conn for one run. The
+// returned func restores the default before the connection goes back to
+// the pool, closing it if that fails so the setting can't leak.
+func setStatementTimeout(ctx context.Context, conn *pgxpool.Conn, timeoutMs int) (func(), error) {
+	if _, err := conn.Exec(ctx, "SELECT set_config('statement_timeout', $1, false)", strconv.Itoa(timeoutMs)); err != nil {
+		return nil, err
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(ctx, "RESET statement_timeout"); err != nil {
+			conn.Conn().Close(ctx)
+		}
+	}, nil
+}
+
 func quoteIdentifier(s string) string {
 	// Defensive: at every call site we've already run isValidIdentifier,
 	// which rejects NUL. dbsafe.QuoteIdent re-checks so any new caller
@@ -260,6 +296,196 @@ func quoteIdentifier(s string) string {
 	return q
 }
 
+// filterOps is the whitelist of GetTableData filter operators. Keys are the
+// accepted (upper-cased) spellings, values the SQL emitted.
+var filterOps = map[string]string{
+	"=":           "=",
+	"!=":          "<>",
+	"<>":          "<>",
+	">":           ">",
+	"<":           "<",
+	">=":          ">=",
+	"<=":          "<=",
+	"LIKE":        "LIKE",
+	"ILIKE":       "ILIKE",
+	"IN":          "IN",
+	"IS NULL":     "IS NULL",
+	"IS NOT NULL": "IS NOT NULL",
+}
+
+// normalizeFilterOp maps a filterOp query value onto its SQL operator.
+func normalizeFilterOp(op string) (string, bool) {
+	sqlOp, ok := filterOps[strings.ToUpper(strings.Join(strings.Fields(op), " "))]
+	return sqlOp, ok
+}
+
+func filterOpIgnoresValue(op string) bool {
+	return op == "IS NULL" || op == "IS NOT NULL"
+}
+
+// buildFilterCondition renders the WHERE condition for a validated column
+// and operator, keeping every value a bind parameter numbered from
+// firstArg. LIKE/ILIKE compare the column's text form so patterns work on
+// numbers and dates too; IN takes a comma-separated list with one
+// parameter per item.
+func buildFilterCondition(column, op, value string, firstArg int) (string, []any) {
+	col := quoteIdentifier(column)
+	switch op {
+	case "IS NULL", "IS NOT NULL":
+		return fmt.Sprintf("%s %s", col, op), nil
+	case "LIKE", "ILIKE":
+		return fmt.Sprintf("%s::text %s $%d", col, op, firstArg), []any{value}
+	case "IN":
+		items := strings.Split(value, ",")
+		placeholders := make([]string, len(items))
+		args := make([]any, len(items))
+		for i, item := range items {
+			placeholders[i] = fmt.Sprintf("$%d", firstArg+i)
+			args[i] = strings.TrimSpace(item)
+		}
+		return fmt.Sprintf("%s IN (%s)", col, strings.Join(placeholders, ", ")), args
+	default:
+		return fmt.Sprintf("%s %s $%d", col, op, firstArg), []any{value}
+	}
+}
+
+// maxTableFilters caps the conditions GetTableData accepts in one request.
+const maxTableFilters = 20
+
+// buildWhereClause validates filters and joins them into a WHERE clause
+// (with leading space) plus its arguments. Each filter's Connector links
+// it to everything before it, read left to right: `a OR b AND c` becomes
+// `((a) OR (b)) AND (c)`, as the filter list reads, rather than SQL's
+// `a OR (b AND c)`. Filters with an empty value are
+// skipped unless the operator ignores the value, matching the single
+// filterColumn/filterValue behaviour.
+func buildWhereClause(filters []models.TableFilter) (string, []any, error) {
+	if len(filters) > maxTableFilters {
+		return "", nil, fmt.Errorf("at most %d filters are allowed", maxTableFilters)
+	}
+
+	var where, last string
+	var args []any
+	n := 0
+	for i, f := range filters {
+		op, ok := normalizeFilterOp(f.Op)
+		if !ok {
+			return "", nil, fmt.Errorf("filter %d: invalid operator: %s", i+1, f.Op)
+		}
+		if f.Column == "" || (f.Value == "" && !filterOpIgnoresValue(op)) {
+			continue
+		}
+		if !isValidIdentifier(f.Column) {
+			return "", nil, fmt.Errorf("filter %d: invalid column name", i+1)
+		}
+
+		connector := strings.ToUpper(strings.TrimSpace(f.Connector))
+		if connector == "" {
+			connector = "AND"
+		}
+		if connector != "AND" && connector != "OR" {
+			return "", nil, fmt.Errorf("filter %d: connector must be AND or OR", i+1)
+		}
+
+		cond, condArgs := buildFilterCondition(f.Column, op, f.Value, len(args)+1)
+		switch {
+		case n == 0:
+			where = "(" + cond + ")"
+		case n > 1 && connector != last:
+			where = "(" + where + ") " + connector + " (" + cond + ")"
+		default:
+			where += " " + connector + " (" + cond + ")"
+		}
+		last = connector
+		n++
+		args = append(args, condArgs...)
+	}
+	if n == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + where, args, nil
+}
+
+// tableFilterClause builds the WHERE clause for a table data request's
+// filters: the single filterColumn/filterOp/filterValue triple and/or
+// ?filters=<JSON array of {column, op, value, connector}>.
+func tableFilterClause(c *gin.Context) (string, []any, error) {
+	var filters []models.TableFilter
+	if column := c.Query("filterColumn"); column != "" {
+		filters = append(filters, models.TableFilter{
+			Column: column,
+			Op:     c.DefaultQuery("filterOp", "="),
+			Value:  c.Query("filterValue"),
+		})
+	}
+	if raw := c.Query("filters"); raw != "" {
+		var extra []models.TableFilter
+		if err := json.Unmarshal([]byte(raw), &extra); err != nil {
+			return "", nil, errors.New("filters must be a JSON array of {column, op, value, connector}")
+		}
+		filters = append(filters, extra...)
+	}
+	return buildWhereClause(filters)
+}
+
+// projectTableColumns picks the columns named in raw (comma-separated, as
+// in ?columns=) out of a table's columns, in the order given. A blank raw
+// keeps them all.
+func projectTableColumns(columns []models.ColumnInfo, raw string) ([]models.ColumnInfo, error) {
+	if strings.TrimSpace(raw) == "" {
+		return columns, nil
+	}
+
+	byName := make(map[string]models.ColumnInfo, len(columns))
+	for _, col := range columns {
+		byName[col.Name] = col
+	}
+	var projected []models.ColumnInfo
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !isValidIdentifier(name) {
+			return nil, fmt.Errorf("invalid column name: %s", name)
+		}
+		col, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("no such column: %s", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column listed twice: %s", name)
+		}
+		seen[name] = true
+		projected = append(projected, col)
+	}
+	if len(projected) == 0 {
+		return nil, errors.New("columns must name at least one column")
+	}
+	return projected, nil
+}
+
+// selectList is the SELECT list for columns: each one quoted, in order.
+func selectList(columns []models.ColumnInfo) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// GetTableData returns a page of a table's rows. Pages are LIMIT/OFFSET by
+// default, which reads and discards every row before the page, so deep
+// pages of big tables get slow. Passing ?cursor= (empty for the first page)
+// switches to keyset pagination instead: orderBy must be a NOT NULL column
+// with a unique index, each page seeks past the previous page's last value
+// through that index, and the response's nextCursor fetches the next one.
+// Keyset pages can only be walked in order, not jumped to by number.
+//
+// ?columns= (comma-separated) limits the rows and the returned column info
+// to those columns, in that order; ordering and filtering may still use
+// any column.
 func GetTableData(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
@@ -280,27 +506,31 @@ func GetTableData(c *gin.Context) {
 	}
 
 	// Parse query parameters
+	pageDefault, pageMax := pageSizeLimits()
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "100"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize"))
 	orderBy := c.Query("orderBy")
 	orderDir := c.DefaultQuery("orderDir", "ASC")
-	filterColumn := c.Query("filterColumn")
-	filterValue := c.Query("filterValue")
 
 	if page < 1 {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 1000 {
-		pageSize = 100
+	if pageSize < 1 || pageSize > pageMax {
+		pageSize = pageDefault
 	}
 	if orderDir != "ASC" && orderDir != "DESC" {
 		orderDir = "ASC"
 	}
 
-	// Validate filter column if provided
-	hasFilter := filterColumn != "" && filterValue != ""
-	if hasFilter && !isValidIdentifier(filterColumn) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter column name"})
+	whereClause, queryArgs, err := tableFilterClause(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cursor, keyset := c.GetQuery("cursor")
+	if keyset && (orderBy == "" || !isValidIdentifier(orderBy)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keyset pagination (cursor) needs orderBy"})
 		return
 	}
 
@@ -310,13 +540,21 @@ func GetTableData(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	selected := "*"
+	if raw := c.Query("columns"); strings.TrimSpace(raw) != "" {
+		columns, err = projectTableColumns(columns, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		selected = selectList(columns)
+	}
 
-	// Build WHERE clause for filter
-	var whereClause string
-	var queryArgs []any
-	if hasFilter {
-		whereClause = fmt.Sprintf(" WHERE %s = $1", quoteIdentifier(filterColumn))
-		queryArgs = append(queryArgs, filterValue)
+	if keyset {
+		if err := checkKeysetColumn(ctx, pool, schema, table, orderBy); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	// Get total row count (with filter if applicable)
@@ -328,15 +566,33 @@ func GetTableData(c *gin.Context) {
 	}
 
 	// Build data query
-	offset := (page - 1) * pageSize
-	dataQuery := fmt.Sprintf("SELECT * FROM %s.%s%s", quoteIdentifier(schema), quoteIdentifier(table), whereClause)
+	var dataQuery string
+	if keyset {
+		// One row past the page tells whether there's a next one.
+		if cursor != "" {
+			value, err := decodeCursor(cursor)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			queryArgs = append(queryArgs, value)
+			whereClause = keysetWhereClause(whereClause, orderBy, orderDir, len(queryArgs))
+		}
+		dataQuery = fmt.Sprintf("SELECT %s, %s::text AS %s FROM %s.%s%s ORDER BY %s %s LIMIT %d",
+			selected, quoteIdentifier(orderBy), quoteIdentifier(keysetCursorColumn),
+			quoteIdentifier(schema), quoteIdentifier(table), whereClause,
+			quoteIdentifier(orderBy), orderDir, pageSize+1)
+	} else {
+		offset := (page - 1) * pageSize
+		dataQuery = fmt.Sprintf("SELECT %s FROM %s.%s%s", selected, quoteIdentifier(schema), quoteIdentifier(table), whereClause)
+
+		if orderBy != "" && isValidIdentifier(orderBy) {
+			dataQuery += fmt.Sprintf(" ORDER BY %s %s", quoteIdentifier(orderBy), orderDir)
+		}
 
-	if orderBy != "" && isValidIdentifier(orderBy) {
-		dataQuery += fmt.Sprintf(" ORDER BY %s %s", quoteIdentifier(orderBy), orderDir)
+		dataQuery += fmt.Sprintf(" LIMIT %d OFFSET %d", pageSize, offset)
 	}
 
-	dataQuery += fmt.Sprintf(" LIMIT %d OFFSET %d", pageSize, offset)
-
 	rows, err := pool.Query(ctx, dataQuery, queryArgs...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -358,16 +614,44 @@ func GetTableData(c *gin.Context) {
 
 		row := make(map[string]any)
 		for i, fd := range fieldDescs {
-			row[string(fd.Name)] = convertValue(values[i])
+			row[string(fd.Name)] = convertValue(values[i], fd.DataTypeOID)
 		}
 		data = append(data, row)
 	}
 
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows.Close()
+
+	var nextCursor string
+	if keyset {
+		if len(data) > pageSize {
+			data = data[:pageSize]
+			if last, ok := data[pageSize-1][keysetCursorColumn].(string); ok {
+				nextCursor = encodeCursor(last)
+			}
+		}
+		for _, row := range data {
+			delete(row, keysetCursorColumn)
+		}
+	}
+
 	totalPages := int(totalRows) / pageSize
 	if int(totalRows)%pageSize > 0 {
 		totalPages++
 	}
 
+	var fkLabels map[string]map[string]string
+	if c.Query("expandFk") == "true" {
+		fkLabels = loadFKLabels(ctx, pool, columns, data)
+	}
+
+	// Paging through a table isn't opening it again.
+	if page == 1 && cursor == "" {
+		recordTableAccess(connId, schema, table)
+	}
 	c.JSON(http.StatusOK, models.TableDataResponse{
 		Columns:    columns,
 		Rows:       data,
@@ -375,6 +659,8 @@ func GetTableData(c *gin.Context) {
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
+		FKLabels:   fkLabels,
+		NextCursor: nextCursor,
 	})
 }
 
@@ -383,6 +669,16 @@ func getTableColumnInfo(ctx context.Context, pool interface{ Query(context.Conte
 		SELECT
 			a.attname as name,
 			pg_catalog.format_type(a.atttypid, a.atttypmod) as data_type,
+			COALESCE(bt.typname, t.typname) as base_type,
+			a.attnotnull OR t.typnotnull as not_null,
+			COALESCE(bt.typcategory, t.typcategory)::text as type_category,
+			(
+				SELECT array_agg(f.attname ORDER BY f.attnum)
+				FROM pg_catalog.pg_attribute f
+				WHERE f.attrelid = COALESCE(et.typrelid, bt.typrelid, t.typrelid)
+				  AND f.attnum > 0
+				  AND NOT f.attisdropped
+			) as composite_fields,
 			COALESCE(pk.is_pk, false) as is_primary_key,
 			COALESCE(fk.is_fk, false) as is_foreign_key,
 			fk.ref_schema,
@@ -391,6 +687,10 @@ func getTableColumnInfo(ctx context.Context, pool interface{ Query(context.Conte
 		FROM pg_catalog.pg_attribute a
 		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
 		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_catalog.pg_type bt ON bt.oid = t.typbasetype AND t.typtype = 'd'
+		LEFT JOIN pg_catalog.pg_type et ON et.oid = COALESCE(bt.typelem, t.typelem)
+			AND COALESCE(bt.typcategory, t.typcategory) = 'A'
 		LEFT JOIN LATERAL (
 			SELECT true as is_pk
 			FROM pg_constraint con
@@ -433,7 +733,8 @@ func getTableColumnInfo(ctx context.Context, pool interface{ Query(context.Conte
 		var refSchema, refTable, refColumn *string
 
 		if err := rows.Scan(
-			&col.Name, &col.DataType, &col.IsPrimaryKey, &col.IsForeignKey,
+			&col.Name, &col.DataType, &col.BaseType, &col.NotNull, &col.TypeCategory, &col.CompositeFields,
+			&col.IsPrimaryKey, &col.IsForeignKey,
 			&refSchema, &refTable, &refColumn,
 		); err != nil {
 			return nil, err
@@ -533,7 +834,7 @@ func GetForeignKeyPreview(c *gin.Context) {
 	fieldDescs := rows.FieldDescriptions()
 	row := make(map[string]any)
 	for i, fd := range fieldDescs {
-		row[string(fd.Name)] = convertValue(values[i])
+		row[string(fd.Name)] = convertValue(values[i], fd.DataTypeOID)
 	}
 
 	c.JSON(http.StatusOK, models.ForeignKeyPreview{
@@ -550,62 +851,50 @@ type StatementInfo struct {
 	Offset int // 0-based byte offset in original SQL where this statement starts
 }
 
-// splitStatements splits SQL into individual statements, handling string literals
+// splitStatements splits SQL into individual statements at semicolons
+// outside string literals, quoted identifiers, dollar-quoted bodies and
+// comments (block comments nest, as in Postgres).
 func splitStatements(sql string) []StatementInfo {
 	var statements []StatementInfo
-	var current strings.Builder
-	inString := false
-	stringChar := rune(0)
-	stmtStartByte := 0
-
-	byteOffset := 0
-	for i, ch := range sql {
-		current.WriteRune(ch)
-		charLen := len(string(ch))
-
-		if !inString {
-			if ch == '\'' || ch == '"' {
-				inString = true
-				stringChar = ch
-			} else if ch == ';' {
-				stmt := strings.TrimSpace(current.String())
-				// Remove trailing semicolon for cleaner statement
-				stmt = strings.TrimSuffix(stmt, ";")
-				stmt = strings.TrimSpace(stmt)
-				if len(stmt) > 0 {
-					// Find actual start by skipping whitespace from stmtStartByte
-					actualStart := stmtStartByte
-					for actualStart < len(sql) && (sql[actualStart] == ' ' || sql[actualStart] == '\t' || sql[actualStart] == '\n' || sql[actualStart] == '\r') {
-						actualStart++
-					}
-					statements = append(statements, StatementInfo{SQL: stmt, Offset: actualStart})
-				}
-				current.Reset()
-				stmtStartByte = byteOffset + charLen
-			}
-		} else {
-			if ch == stringChar {
-				// Check for escaped quote (two consecutive quotes)
-				if i+1 < len(sql) && rune(sql[i+1]) == stringChar {
-					byteOffset += charLen
-					continue
-				}
-				inString = false
-			}
+	start := 0
+	add := func(end int) {
+		raw := sql[start:end]
+		if stmt := strings.TrimSpace(raw); len(stmt) > 0 {
+			offset := start + len(raw) - len(strings.TrimLeft(raw, " \t\n\r"))
+			statements = append(statements, StatementInfo{SQL: stmt, Offset: offset})
 		}
-		byteOffset += charLen
 	}
 
-	// Handle last statement (may not end with semicolon)
-	stmt := strings.TrimSpace(current.String())
-	if len(stmt) > 0 {
-		// Find actual start by skipping whitespace from stmtStartByte
-		actualStart := stmtStartByte
-		for actualStart < len(sql) && (sql[actualStart] == ' ' || sql[actualStart] == '\t' || sql[actualStart] == '\n' || sql[actualStart] == '\r') {
-			actualStart++
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		end := i + 1
+
+		switch {
+		case ch == '\'':
+			end = skipQuoted(sql, i, '\'', isEscapeString(sql, i))
+		case ch == '"':
+			end = skipQuoted(sql, i, '"', false)
+		case ch == '-' && strings.HasPrefix(sql[i:], "--"):
+			end = len(sql)
+			if nl := strings.IndexByte(sql[i:], '\n'); nl >= 0 {
+				end = i + nl + 1
+			}
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end = skipBlockComment(sql, i)
+		case ch == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			if tag := dollarQuoteTag.FindString(sql[i:]); tag != "" {
+				end = len(sql)
+				if close := strings.Index(sql[i+len(tag):], tag); close >= 0 {
+					end = i + len(tag) + close + len(tag)
+				}
+			}
+		case ch == ';':
+			add(i)
+			start = end
 		}
-		statements = append(statements, StatementInfo{SQL: stmt, Offset: actualStart})
+		i = end
 	}
+	add(len(sql))
 
 	return statements
 }
@@ -619,33 +908,188 @@ func isSelectStatement(sql string) bool {
 		strings.HasPrefix(upper, "VALUES")
 }
 
+// isTransactionControl reports whether a statement would begin or end the
+// surrounding transaction. Savepoints are fine; ROLLBACK TO is allowed.
+// Comments anywhere before the first keyword are skipped.
+func isTransactionControl(sql string) bool {
+	words := sqlKeywords(sql)
+	if len(words) == 0 {
+		return false
+	}
+	switch words[0] {
+	case "BEGIN", "START", "COMMIT", "END", "ABORT":
+		return true
+	case "ROLLBACK":
+		return len(words) < 2 || words[1] != "TO"
+	case "PREPARE":
+		return len(words) > 1 && words[1] == "TRANSACTION"
+	}
+	return false
+}
+
+// maxQueryTimeout caps a connection's DefaultQueryTimeoutMs.
+const maxQueryTimeout = time.Hour
+
+// queryTimeout is the deadline for running SQL on connId: the connection's
+// DefaultQueryTimeoutMs, capped at maxQueryTimeout, or fallback when it
+// has none.
+func queryTimeout(connId string, fallback time.Duration) time.Duration {
+	return clampQueryTimeout(database.GetManager().DefaultQueryTimeout(connId), fallback)
+}
+
+func clampQueryTimeout(timeout, fallback time.Duration) time.Duration {
+	if timeout <= 0 {
+		return fallback
+	}
+	return min(timeout, maxQueryTimeout)
+}
+
 func ExecuteQuery(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
 		return
 	}
 
-	pool, _ := manager.GetPool(connId)
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
-
 	var req models.QueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindJSON(c, &req) {
+		return
+	}
+	if rejectReadOnlySQL(c, connId, req.SQL) {
+		return
+	}
+	runQuery(c, manager, connId, req)
+}
+
+// runQuery runs req on connId under the connection's query timeout,
+// records it in the query history and writes the result. ExecuteQuery and
+// RunSavedQuery share it; callers have already applied the read-only
+// checks.
+func runQuery(c *gin.Context, manager *database.ConnectionManager, connId string, req models.QueryRequest) {
+	params, err := coerceParams(req.Params, req.ParamTypes)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.Params = params
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout(connId, 120*time.Second))
+	defer cancel()
 
 	start := time.Now()
 
+	// Run every statement on one connection so session state (SET, temp
+	// tables) carries across a script, and so a cancel request can target
+	// its backend.
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		result := buildErrorResult(err, time.Since(start).Seconds()*1000, 0)
+		recordQueryHistory(connId, req.SQL, start, 0, result.Error)
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	defer conn.Release()
+
+	if req.TimeoutMs > 0 {
+		reset, err := setStatementTimeout(ctx, conn, req.TimeoutMs)
+		if err != nil {
+			result := buildErrorResult(err, time.Since(start).Seconds()*1000, 0)
+			recordQueryHistory(connId, req.SQL, start, 0, result.Error)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+		defer reset()
+	}
+
+	if req.QueryID != "" {
+		pgConn := conn.Conn().PgConn()
+		running := database.GetRunningQueryManager()
+		if !running.Register(connId, req.QueryID, pgConn.PID(), pgConn.CancelRequest) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a query with this queryId is already running"})
+			return
+		}
+		defer running.Unregister(connId, req.QueryID)
+	}
+
 	// Split into statements and handle multi-statement queries
 	statements := splitStatements(req.SQL)
+	if mayAlterSession(statements) && resetSessionEnabled() {
+		defer resetSession(conn, connId)
+	}
+
+	var q queryRunner = conn
+	var tx pgx.Tx
+	if req.Transactional || req.DryRun {
+		for _, stmt := range statements {
+			if isTransactionControl(stmt.SQL) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction control statements are not allowed in a transactional or dry run"})
+				return
+			}
+		}
+		tx, err = conn.Begin(ctx)
+		if err != nil {
+			result := buildErrorResult(err, time.Since(start).Seconds()*1000, 0)
+			recordQueryHistory(connId, req.SQL, start, 0, result.Error)
+			c.JSON(http.StatusOK, result)
+			return
+		}
+		defer tx.Rollback(ctx)
+		q = tx
+	}
+
+	if req.MultiResult {
+		results := executeMultiResult(ctx, q, statements, req.Params, start)
+		if tx != nil {
+			last := &results[len(results)-1]
+			outcome, err := endTransaction(ctx, tx, req.DryRun || last.Error != "")
+			if err != nil {
+				results = append(results, buildErrorResult(err, time.Since(start).Seconds()*1000, 0))
+				last = &results[len(results)-1]
+			}
+			last.Transaction = outcome
+		}
+		markDeadline(ctx, &results[len(results)-1])
+		rowCount, errMsg := summarizeResults(results)
+		recordQueryHistory(connId, req.SQL, start, rowCount, errMsg)
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	result := executeSingleResult(ctx, q, req.SQL, statements, req.Params, start)
+	if tx != nil {
+		outcome, err := endTransaction(ctx, tx, req.DryRun || result.Error != "")
+		if err != nil {
+			result = buildErrorResult(err, time.Since(start).Seconds()*1000, 0)
+		}
+		result.Transaction = outcome
+	}
+	markDeadline(ctx, &result)
+	recordQueryHistory(connId, req.SQL, start, result.RowCount, result.Error)
+	c.JSON(http.StatusOK, result)
+}
+
+// summarizeResults reduces a multi-result run to one history entry: the
+// total row count and the first error, if any.
+func summarizeResults(results []models.QueryResult) (int, string) {
+	rowCount := 0
+	for _, r := range results {
+		if r.Error != "" {
+			return rowCount, r.Error
+		}
+		rowCount += r.RowCount
+	}
+	return rowCount, ""
+}
 
+// executeSingleResult runs a script and returns the result of its last
+// row-returning statement. Non-SELECT statements run first, in order.
+func executeSingleResult(ctx context.Context, q queryRunner, sql string, statements []StatementInfo, params []any, start time.Time) models.QueryResult {
 	// Track the offset of the statement being executed (for error position)
 	currentOffset := 0
 
 	// If multiple statements, execute non-SELECT statements first with Exec
 	// then execute the final SELECT with Query
-	if len(statements) > 1 && len(req.Params) == 0 {
+	if len(statements) > 1 && len(params) == 0 {
 		var selectStmtInfo *StatementInfo
 		for i := range statements {
 			stmtInfo := &statements[i]
@@ -653,46 +1097,117 @@ func ExecuteQuery(c *gin.Context) {
 				selectStmtInfo = stmtInfo
 			} else {
 				// Execute non-SELECT statements (SET, CREATE, etc.)
-				_, err := pool.Exec(ctx, stmtInfo.SQL)
+				_, err := q.Exec(ctx, stmtInfo.SQL)
 				if err != nil {
 					duration := time.Since(start).Seconds() * 1000
-					c.JSON(http.StatusOK, buildErrorResult(err, duration, stmtInfo.Offset))
-					return
+					return buildErrorResult(err, duration, stmtInfo.Offset)
 				}
 			}
 		}
 
 		// If there was a SELECT statement, execute it
-		if selectStmtInfo != nil {
-			req.SQL = selectStmtInfo.SQL
-			currentOffset = selectStmtInfo.Offset
-		} else {
+		if selectStmtInfo == nil {
 			// All statements were non-SELECT, return success
-			duration := time.Since(start).Seconds() * 1000
-			c.JSON(http.StatusOK, models.QueryResult{
+			return models.QueryResult{
 				Columns:  []models.ColumnInfo{},
 				Rows:     []map[string]any{},
 				RowCount: 0,
-				Duration: duration,
-			})
-			return
+				Duration: time.Since(start).Seconds() * 1000,
+			}
 		}
+		sql = selectStmtInfo.SQL
+		currentOffset = selectStmtInfo.Offset
 	} else if len(statements) == 1 {
 		// Single statement - use its offset (usually 0, but could have leading whitespace)
 		currentOffset = statements[0].Offset
 	}
 
-	rows, err := pool.Query(ctx, req.SQL, req.Params...)
-	duration := time.Since(start).Seconds() * 1000
+	return runResultQuery(ctx, q, sql, params, start, currentOffset)
+}
 
-	if err != nil {
-		c.JSON(http.StatusOK, buildErrorResult(err, duration, currentOffset))
-		return
+// endTransaction finishes a transactional or dry run, committing unless
+// rollback is set, and returns the outcome for QueryResult.Transaction.
+func endTransaction(ctx context.Context, tx pgx.Tx, rollback bool) (string, error) {
+	if rollback {
+		// A failed ROLLBACK closes the connection, which discards the
+		// transaction just the same.
+		tx.Rollback(ctx)
+		return models.TransactionRolledBack, nil
 	}
-	defer rows.Close()
+	if err := tx.Commit(ctx); err != nil {
+		return models.TransactionRolledBack, err
+	}
+	return models.TransactionCommitted, nil
+}
 
-	fieldDescs := rows.FieldDescriptions()
+// executeMultiResult runs every statement in order and returns one result
+// per row-returning statement, each labelled from its leading `-- comment`
+// so scripts can present titled result tabs. Statements that don't return
+// rows run but don't produce a tab. Execution stops at the first error,
+// which is reported as the final result.
+func executeMultiResult(ctx context.Context, q queryRunner, statements []StatementInfo, params []any, start time.Time) []models.QueryResult {
+	results := []models.QueryResult{}
+	// Params only make sense when there's a single statement to bind to.
+	if len(statements) > 1 {
+		params = nil
+	}
+
+	for _, stmt := range statements {
+		label, body := statementLabel(stmt.SQL)
+		stmtStart := time.Now()
+
+		if isSelectStatement(body) {
+			result := runResultQuery(ctx, q, stmt.SQL, params, stmtStart, stmt.Offset)
+			result.Label = label
+			results = append(results, result)
+			if result.Error != "" {
+				return results
+			}
+			continue
+		}
+
+		if _, err := q.Exec(ctx, stmt.SQL, params...); err != nil {
+			result := buildErrorResult(err, time.Since(stmtStart).Seconds()*1000, stmt.Offset)
+			result.Label = label
+			return append(results, result)
+		}
+	}
+
+	if len(results) == 0 {
+		results = append(results, models.QueryResult{
+			Columns:  []models.ColumnInfo{},
+			Rows:     []map[string]any{},
+			Duration: time.Since(start).Seconds() * 1000,
+		})
+	}
+	return results
+}
+
+// statementLabel splits leading `--` comment lines off a statement. The
+// first non-empty comment becomes the label; the remainder is the SQL with
+// those comments removed (used to classify the statement).
+func statementLabel(sql string) (label, body string) {
+	rest := strings.TrimSpace(sql)
+	for strings.HasPrefix(rest, "--") {
+		line, after, _ := strings.Cut(rest, "\n")
+		if label == "" {
+			label = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		}
+		rest = strings.TrimSpace(after)
+	}
+	return label, rest
+}
+
+// queryRunner is satisfied by both *pgxpool.Pool and *pgxpool.Conn.
+type queryRunner interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
 
+// describeColumns resolves result-set field descriptions to column info:
+// type names, plus PK/FK flags for columns that come straight from a table.
+// Lookup failures degrade to "oid:N" types and no key info.
+func describeColumns(ctx context.Context, q interface{ Query(context.Context, string, ...any) (pgx.Rows, error) }, fieldDescs []pgconn.FieldDescription) []models.ColumnInfo {
 	// Collect unique type OIDs and table OIDs
 	typeOIDSet := make(map[uint32]bool)
 	tableOIDSet := make(map[uint32]bool)
@@ -713,15 +1228,11 @@ func ExecuteQuery(c *gin.Context) {
 		tableOIDs = append(tableOIDs, oid)
 	}
 
-	// Look up type names
-	typeNames, err := getTypeNames(ctx, pool, typeOIDs)
-	if err != nil {
-		// Fall back to OID if type lookup fails
-		typeNames = make(map[uint32]string)
-	}
+	// Look up type names; any the lookup misses fall back to their OID
+	typeNames, _ := resolveTypeNames(ctx, q, typeOIDs)
 
 	// Look up FK info for columns that come from real tables
-	fkInfo, err := getColumnFKInfo(ctx, pool, tableOIDs)
+	fkInfo, err := getColumnFKInfo(ctx, q, tableOIDs)
 	if err != nil {
 		// Continue without FK info if lookup fails
 		fkInfo = make(map[uint32]map[uint16]ColumnFKInfo)
@@ -752,27 +1263,85 @@ func ExecuteQuery(c *gin.Context) {
 		columns[i] = col
 	}
 
+	return columns
+}
+
+// runResultQuery runs a row-returning statement on q and collects the full
+// result with column types and PK/FK annotations. The rows are read and
+// closed before the catalog lookups, which then run on q too: borrowing a
+// second connection from the small pool while the rows are open can
+// deadlock when every connection is doing the same, and in a transaction
+// only q can see the tables and types created in it. Errors
+// are folded into the result (with the statement's offset so positions map
+// back to the editor) rather than returned, matching what ExecuteQuery
+// sends to the client.
+func runResultQuery(ctx context.Context, q queryRunner, sql string, params []any, start time.Time, offset int) models.QueryResult {
+	rows, err := q.Query(ctx, sql, params...)
+	duration := time.Since(start).Seconds() * 1000
+
+	if err != nil {
+		return buildErrorResult(err, duration, offset)
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+
 	var data []map[string]any
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
-			c.JSON(http.StatusOK, buildErrorResult(err, duration, currentOffset))
-			return
+			return buildErrorResult(err, duration, offset)
 		}
 
 		row := make(map[string]any)
 		for i, fd := range fieldDescs {
-			row[string(fd.Name)] = convertValue(values[i])
+			row[string(fd.Name)] = convertValue(values[i], fd.DataTypeOID)
 		}
 		data = append(data, row)
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return buildErrorResult(err, duration, offset)
+	}
 
-	c.JSON(http.StatusOK, models.QueryResult{
+	columns := describeColumns(ctx, q, fieldDescs)
+
+	return models.QueryResult{
 		Columns:  columns,
 		Rows:     data,
 		RowCount: len(data),
 		Duration: duration,
-	})
+	}
+}
+
+// CancelQuery asks Postgres to cancel a running ExecuteQuery call, found by
+// the queryId the client sent with it. The cancel request goes over its own
+// connection, so it works even while the pool is busy.
+func CancelQuery(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelQueryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	found, err := database.GetRunningQueryManager().Cancel(ctx, connId, req.QueryID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No running query with that queryId"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cancelled": true})
 }
 
 func ExplainQuery(c *gin.Context) {
@@ -782,12 +1351,25 @@ func ExplainQuery(c *gin.Context) {
 	}
 
 	pool, _ := manager.GetPool(connId)
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout(connId, 60*time.Second))
 	defer cancel()
 
-	var req models.QueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req models.ExplainRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Format == "" {
+		req.Format = "text"
+	}
+	if req.Format != "text" && req.Format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be text or json"})
+		return
+	}
+	analyze, warning := explainAnalyze(req)
+
+	// Without ANALYZE the statement is only planned, never executed, so a
+	// read-only connection can still show the plan for a write.
+	if analyze && rejectReadOnlySQL(c, connId, req.SQL) {
 		return
 	}
 
@@ -801,7 +1383,7 @@ func ExplainQuery(c *gin.Context) {
 		return
 	}
 
-	explainQuery := "EXPLAIN (ANALYZE, BUFFERS, FORMAT TEXT) " + req.SQL
+	explainQuery := explainPrefix(analyze, req.Format) + req.SQL
 
 	start := time.Now()
 	rows, err := pool.Query(ctx, explainQuery, req.Params...)
@@ -822,17 +1404,57 @@ func ExplainQuery(c *gin.Context) {
 		}
 		planLines = append(planLines, line)
 	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, models.ExplainResult{
-		Plan:     strings.Join(planLines, "\n"),
-		Duration: duration,
-	})
+	result := models.ExplainResult{Duration: duration, Warning: warning}
+	if req.Format == "json" {
+		// FORMAT JSON yields a single row holding the whole plan document.
+		result.PlanJSON = json.RawMessage(strings.Join(planLines, ""))
+		// Diagnostics are a convenience; a plan shape we can't parse still
+		// returns the raw plan.
+		if nodes, err := diagnosePlan(result.PlanJSON); err == nil {
+			result.Nodes = nodes
+		}
+	} else {
+		result.Plan = strings.Join(planLines, "\n")
+	}
+	c.JSON(http.StatusOK, result)
 }
 
-func InsertRow(c *gin.Context) {
-	manager, connId, ok := getPool(c)
-	if !ok {
-		return
+// explainAnalyze decides whether to EXPLAIN ANALYZE. ANALYZE executes the
+// statement, so one that may write (by the read-only classifier, which errs
+// towards calling things writes) is only planned unless the caller opted in
+// with AnalyzeWrites; the returned warning says so.
+func explainAnalyze(req models.ExplainRequest) (bool, string) {
+	if req.Analyze != nil && !*req.Analyze {
+		return false, ""
+	}
+	if !req.AnalyzeWrites && !isReadOnlyStatement(req.SQL) {
+		return false, "ANALYZE was skipped because the statement may modify data; set analyzeWrites to run it"
+	}
+	return true, ""
+}
+
+// explainPrefix builds the EXPLAIN option list. BUFFERS is left out of
+// plain EXPLAIN: before Postgres 13 it requires ANALYZE.
+func explainPrefix(analyze bool, format string) string {
+	if analyze {
+		return "EXPLAIN (ANALYZE, BUFFERS, FORMAT " + strings.ToUpper(format) + ") "
+	}
+	return "EXPLAIN (FORMAT " + strings.ToUpper(format) + ") "
+}
+
+func InsertRow(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "inserting rows") {
+		return
 	}
 
 	pool, _ := manager.GetPool(connId)
@@ -848,8 +1470,7 @@ func InsertRow(c *gin.Context) {
 	}
 
 	var req models.InsertRowRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -858,30 +1479,21 @@ func InsertRow(c *gin.Context) {
 		return
 	}
 
-	// Build INSERT query
-	columns := make([]string, 0, len(req.Data))
-	placeholders := make([]string, 0, len(req.Data))
-	values := make([]any, 0, len(req.Data))
-	i := 1
-
-	for col, val := range req.Data {
-		if !isValidIdentifier(col) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid column name: %s", col)})
-			return
-		}
-		columns = append(columns, quoteIdentifier(col))
-		placeholders = append(placeholders, fmt.Sprintf("$%d", i))
-		values = append(values, val)
-		i++
+	columns, err := getTableColumnInfo(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Data, err = convertRowValues(columns, req.Data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	query := fmt.Sprintf(
-		"INSERT INTO %s.%s (%s) VALUES (%s) RETURNING *",
-		quoteIdentifier(schema),
-		quoteIdentifier(table),
-		strings.Join(columns, ", "),
-		strings.Join(placeholders, ", "),
-	)
+	query, values, err := buildInsertQuery(schema, table, req.Data, req.OnConflict)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	rows, err := pool.Query(ctx, query, values...)
 	if err != nil {
@@ -891,6 +1503,19 @@ func InsertRow(c *gin.Context) {
 	defer rows.Close()
 
 	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.OnConflict != nil {
+			// DO NOTHING returns no row for a conflicting insert
+			c.JSON(http.StatusOK, models.CrudResponse{
+				Success: true,
+				Message: "Row already exists; nothing inserted",
+				Outcome: models.InsertOutcomeSkipped,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Insert succeeded but no row returned"})
 		return
 	}
@@ -903,24 +1528,325 @@ func InsertRow(c *gin.Context) {
 
 	fieldDescs := rows.FieldDescriptions()
 	insertedRow := make(map[string]any)
+	outcome := models.InsertOutcomeInserted
 	for i, fd := range fieldDescs {
+		if req.OnConflict != nil && i == len(fieldDescs)-1 {
+			if inserted, _ := rowValues[i].(bool); !inserted {
+				outcome = models.InsertOutcomeUpdated
+			}
+			continue
+		}
 		insertedRow[string(fd.Name)] = rowValues[i]
 	}
 
-	c.JSON(http.StatusCreated, models.CrudResponse{
+	status, message := http.StatusCreated, "Row inserted successfully"
+	if outcome == models.InsertOutcomeUpdated {
+		status, message = http.StatusOK, "Existing row updated"
+	}
+	c.JSON(status, models.CrudResponse{
 		Success:      true,
 		RowsAffected: 1,
-		Message:      "Row inserted successfully",
+		Message:      message,
 		InsertedRow:  insertedRow,
+		Outcome:      outcome,
+	})
+}
+
+// buildInsertQuery builds the INSERT for InsertRow, with columns in sorted
+// order. With onConflict it becomes an upsert whose RETURNING list ends
+// with an extra boolean column, true when the row was newly inserted: a
+// freshly inserted tuple has no xmax, an updated one carries the updating
+// transaction's.
+func buildInsertQuery(schema, table string, data map[string]any, onConflict *models.OnConflict) (string, []any, error) {
+	names := make([]string, 0, len(data))
+	for col := range data {
+		if !isValidIdentifier(col) {
+			return "", nil, fmt.Errorf("Invalid column name: %s", col)
+		}
+		names = append(names, col)
+	}
+	sort.Strings(names)
+
+	columns := make([]string, len(names))
+	placeholders := make([]string, len(names))
+	values := make([]any, len(names))
+	for i, col := range names {
+		columns[i] = quoteIdentifier(col)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		values[i] = data[col]
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s.%s (%s) VALUES (%s)",
+		quoteIdentifier(schema),
+		quoteIdentifier(table),
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+	if onConflict == nil {
+		return query + " RETURNING *", values, nil
+	}
+
+	target := make([]string, len(onConflict.Columns))
+	isTarget := make(map[string]bool, len(onConflict.Columns))
+	for i, col := range onConflict.Columns {
+		if !isValidIdentifier(col) {
+			return "", nil, fmt.Errorf("Invalid conflict column name: %s", col)
+		}
+		target[i] = quoteIdentifier(col)
+		isTarget[col] = true
+	}
+	query += fmt.Sprintf(" ON CONFLICT (%s)", strings.Join(target, ", "))
+
+	switch onConflict.Action {
+	case "nothing":
+		query += " DO NOTHING"
+	case "update":
+		var set []string
+		for _, col := range names {
+			if !isTarget[col] {
+				set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", quoteIdentifier(col), quoteIdentifier(col)))
+			}
+		}
+		if len(set) == 0 {
+			return "", nil, errors.New("onConflict update needs data for at least one column outside the conflict columns")
+		}
+		query += " DO UPDATE SET " + strings.Join(set, ", ")
+	default:
+		return "", nil, fmt.Errorf("Invalid onConflict action: %s", onConflict.Action)
+	}
+	return query + " RETURNING *, (xmax = 0) AS pgvoyager_inserted", values, nil
+}
+
+// maxBulkInsertRows caps the rows accepted by one bulk insert request.
+const maxBulkInsertRows = 10000
+
+// bulkInsertColumns validates that every row has the same columns, all of
+// them valid identifiers, and returns them sorted. On failure it also
+// returns the index of the offending row.
+func bulkInsertColumns(rows []map[string]any) ([]string, int, error) {
+	if len(rows) == 0 {
+		return nil, 0, errors.New("No rows provided")
+	}
+	if len(rows[0]) == 0 {
+		return nil, 0, errors.New("Row has no columns")
+	}
+
+	columns := make([]string, 0, len(rows[0]))
+	for col := range rows[0] {
+		if !isValidIdentifier(col) {
+			return nil, 0, fmt.Errorf("Invalid column name: %s", col)
+		}
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	for i, row := range rows[1:] {
+		if len(row) != len(columns) {
+			return nil, i + 1, fmt.Errorf("Row has %d columns, expected %d like the first row", len(row), len(columns))
+		}
+		for _, col := range columns {
+			if _, ok := row[col]; !ok {
+				return nil, i + 1, fmt.Errorf("Row is missing column %s", col)
+			}
+		}
+	}
+	return columns, 0, nil
+}
+
+// BulkInsertRows inserts all rows in a single transaction, sent as one
+// pgx batch of per-row INSERTs so a failure can be pinned to its row. Any
+// error rolls back every row; the response's rowIndex names the culprit.
+func BulkInsertRows(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "inserting rows") {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+
+	var req models.BulkInsertRowsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if len(req.Rows) > maxBulkInsertRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d rows can be inserted at once", maxBulkInsertRows)})
+		return
+	}
+
+	columns, badRow, err := bulkInsertColumns(req.Rows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "rowIndex": badRow})
+		return
+	}
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdentifier(col)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf(
+		"INSERT INTO %s.%s (%s) VALUES (%s)",
+		quoteIdentifier(schema),
+		quoteIdentifier(table),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	tableColumns, err := getTableColumnInfo(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i, row := range req.Rows {
+		if req.Rows[i], err = convertRowValues(tableColumns, row); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    fmt.Sprintf("Row %d: %v", i, err),
+				"rowIndex": i,
+			})
+			return
+		}
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, row := range req.Rows {
+		values := make([]any, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		batch.Queue(query, values...)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	var inserted int64
+	for i := range req.Rows {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    fmt.Sprintf("Row %d: %v", i, err),
+				"rowIndex": i,
+			})
+			return
+		}
+		inserted += tag.RowsAffected()
+	}
+	if err := results.Close(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CrudResponse{
+		Success:      true,
+		RowsAffected: inserted,
+		Message:      fmt.Sprintf("%d rows inserted successfully", inserted),
 	})
 }
 
+// errMultipleRows means a statement keyed on a primary key matched more
+// than one row and was rolled back.
+var errMultipleRows = errors.New("the primary key matched more than one row")
+
+// validatePrimaryKey checks that pk names exactly the table's primary key
+// columns, so a typo can't turn into a match on some other column.
+func validatePrimaryKey(columns []models.ColumnInfo, pk map[string]any) error {
+	var pkColumns []string
+	isPK := make(map[string]bool)
+	for _, col := range columns {
+		if col.IsPrimaryKey {
+			pkColumns = append(pkColumns, col.Name)
+			isPK[col.Name] = true
+		}
+	}
+	if len(pkColumns) == 0 {
+		return errors.New("table has no primary key; rows can't be edited by key")
+	}
+
+	var unknown, missing []string
+	for col := range pk {
+		if !isPK[col] {
+			unknown = append(unknown, col)
+		}
+	}
+	for _, col := range pkColumns {
+		if _, ok := pk[col]; !ok {
+			missing = append(missing, col)
+		}
+	}
+	if len(unknown) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	msg := fmt.Sprintf("primary key must be exactly (%s)", strings.Join(pkColumns, ", "))
+	if len(unknown) > 0 {
+		msg += fmt.Sprintf("; not primary key columns: %s", strings.Join(unknown, ", "))
+	}
+	if len(missing) > 0 {
+		msg += fmt.Sprintf("; missing: %s", strings.Join(missing, ", "))
+	}
+	return errors.New(msg)
+}
+
+// execSingleRow runs a keyed UPDATE or DELETE in a transaction, rolling it
+// back with errMultipleRows if it touched more than one row.
+func execSingleRow(ctx context.Context, pool *pgxpool.Pool, query string, values []any) (int64, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, values...)
+	if err != nil {
+		return 0, err
+	}
+	if result.RowsAffected() > 1 {
+		return 0, errMultipleRows
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 func UpdateRow(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "updating rows") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -934,8 +1860,7 @@ func UpdateRow(c *gin.Context) {
 	}
 
 	var req models.UpdateRowRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -944,10 +1869,35 @@ func UpdateRow(c *gin.Context) {
 		return
 	}
 
+	columns, err := getTableColumnInfo(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validatePrimaryKey(columns, req.PrimaryKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if len(req.Data) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No data to update"})
 		return
 	}
+	if fieldErrs := validateRowData(columns, req.Data); len(fieldErrs) > 0 {
+		c.JSON(http.StatusBadRequest, models.FieldValidationError{
+			Error:  fmt.Sprintf("%d invalid value(s); nothing was changed", len(fieldErrs)),
+			Fields: fieldErrs,
+		})
+		return
+	}
+	if req.Data, err = convertRowValues(columns, req.Data); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PrimaryKey, err = convertRowValues(columns, req.PrimaryKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Build SET clause
 	setClauses := make([]string, 0, len(req.Data))
@@ -984,13 +1934,16 @@ func UpdateRow(c *gin.Context) {
 		strings.Join(whereClauses, " AND "),
 	)
 
-	result, err := pool.Exec(ctx, query, values...)
+	rowsAffected, err := execSingleRow(ctx, pool, query, values)
+	if errors.Is(err, errMultipleRows) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s; nothing was changed", err.Error())})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No row found with the specified primary key"})
 		return
@@ -1009,6 +1962,10 @@ func DeleteRow(c *gin.Context) {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "deleting rows") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1022,8 +1979,7 @@ func DeleteRow(c *gin.Context) {
 	}
 
 	var req models.DeleteRowRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -1032,6 +1988,20 @@ func DeleteRow(c *gin.Context) {
 		return
 	}
 
+	columns, err := getTableColumnInfo(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validatePrimaryKey(columns, req.PrimaryKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PrimaryKey, err = convertRowValues(columns, req.PrimaryKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Build WHERE clause from primary key
 	whereClauses := make([]string, 0, len(req.PrimaryKey))
 	values := make([]any, 0)
@@ -1054,13 +2024,16 @@ func DeleteRow(c *gin.Context) {
 		strings.Join(whereClauses, " AND "),
 	)
 
-	result, err := pool.Exec(ctx, query, values...)
+	rowsAffected, err := execSingleRow(ctx, pool, query, values)
+	if errors.Is(err, errMultipleRows) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s; nothing was changed", err.Error())})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No row found with the specified primary key"})
 		return
@@ -1073,12 +2046,96 @@ func DeleteRow(c *gin.Context) {
 	})
 }
 
+// DeleteRowsByFilter deletes every row matching the request's filters in
+// one transaction. At least one filter must survive buildWhereClause (which
+// drops value-less ones) so a request can never wipe the whole table; a
+// dry run counts the matching rows instead.
+func DeleteRowsByFilter(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+
+	var req models.DeleteByFilterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !req.DryRun && rejectReadOnlyWrite(c, connId, "deleting rows") {
+		return
+	}
+
+	whereClause, args, err := buildWhereClause(req.Filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if whereClause == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one filter condition is required"})
+		return
+	}
+	relation := fmt.Sprintf("%s.%s", quoteIdentifier(schema), quoteIdentifier(table))
+
+	if req.DryRun {
+		var count int64
+		if err := pool.QueryRow(ctx, "SELECT count(*) FROM "+relation+whereClause, args...).Scan(&count); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, models.CrudResponse{
+			Success:      true,
+			RowsAffected: count,
+			Message:      fmt.Sprintf("%d rows would be deleted", count),
+			DryRun:       true,
+		})
+		return
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM "+relation+whereClause, args...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CrudResponse{
+		Success:      true,
+		RowsAffected: tag.RowsAffected(),
+		Message:      fmt.Sprintf("%d rows deleted", tag.RowsAffected()),
+	})
+}
+
 func DropTable(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "dropping tables") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1095,7 +2152,9 @@ func DropTable(c *gin.Context) {
 	var req struct {
 		Cascade bool `json:"cascade"`
 	}
-	c.ShouldBindJSON(&req)
+	if err := c.ShouldBindJSON(&req); security.AbortIfBodyTooLarge(c, err) {
+		return
+	}
 
 	query := fmt.Sprintf("DROP TABLE %s.%s", quoteIdentifier(schema), quoteIdentifier(table))
 	if req.Cascade {
@@ -1120,6 +2179,10 @@ func CreateSchema(c *gin.Context) {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "creating schemas") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1128,7 +2191,9 @@ func CreateSchema(c *gin.Context) {
 		Name string `json:"name"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		if !security.AbortIfBodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		}
 		return
 	}
 
@@ -1156,6 +2221,10 @@ func DropSchema(c *gin.Context) {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "dropping schemas") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1169,7 +2238,9 @@ func DropSchema(c *gin.Context) {
 	var req struct {
 		Cascade bool `json:"cascade"`
 	}
-	c.ShouldBindJSON(&req)
+	if err := c.ShouldBindJSON(&req); security.AbortIfBodyTooLarge(c, err) {
+		return
+	}
 
 	query := fmt.Sprintf("DROP SCHEMA %s", quoteIdentifier(schema))
 	if req.Cascade {
@@ -1194,6 +2265,10 @@ func CreateTable(c *gin.Context) {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "creating tables") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1217,7 +2292,9 @@ func CreateTable(c *gin.Context) {
 		Columns []ColumnDef `json:"columns"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		if !security.AbortIfBodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		}
 		return
 	}
 
@@ -1294,6 +2371,10 @@ func AddConstraint(c *gin.Context) {
 		return
 	}
 
+	if rejectReadOnlyWrite(c, connId, "adding constraints") {
+		return
+	}
+
 	pool, _ := manager.GetPool(connId)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -1318,7 +2399,9 @@ func AddConstraint(c *gin.Context) {
 		Expression string   `json:"expression"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		if !security.AbortIfBodyTooLarge(c, err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		}
 		return
 	}
 
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/thelinuxer/pgvoyager/internal/models"
 )
 
 func TestConvertValueBytes(t *testing.T) {
-	got := convertValue([]byte("hello"))
+	got := convertValue([]byte("hello"), pgtype.XMLOID)
 	if s, ok := got.(string); !ok || s != "hello" {
 		t.Errorf("convertValue([]byte) = %v (%T), want string \"hello\"", got, got)
 	}
@@ -16,7 +22,7 @@ func TestConvertValueBytes(t *testing.T) {
 func TestConvertValueTimeBasic(t *testing.T) {
 	// 13:45:30 in microseconds = (13*3600 + 45*60 + 30) * 1_000_000.
 	const us = (13*3600 + 45*60 + 30) * int64(1_000_000)
-	got := convertValue(pgtype.Time{Microseconds: us, Valid: true})
+	got := convertValue(pgtype.Time{Microseconds: us, Valid: true}, 0)
 	if got != "13:45:30" {
 		t.Errorf("convertValue(pgtype.Time HH:MM:SS) = %v, want \"13:45:30\"", got)
 	}
@@ -24,14 +30,14 @@ func TestConvertValueTimeBasic(t *testing.T) {
 
 func TestConvertValueTimeWithFraction(t *testing.T) {
 	const us = (1*3600+2*60+3)*int64(1_000_000) + 456789
-	got := convertValue(pgtype.Time{Microseconds: us, Valid: true})
+	got := convertValue(pgtype.Time{Microseconds: us, Valid: true}, 0)
 	if got != "01:02:03.456789" {
 		t.Errorf("convertValue(pgtype.Time w/ fraction) = %v, want \"01:02:03.456789\"", got)
 	}
 }
 
 func TestConvertValueTimeInvalidIsNil(t *testing.T) {
-	got := convertValue(pgtype.Time{Valid: false})
+	got := convertValue(pgtype.Time{Valid: false}, 0)
 	if got != nil {
 		t.Errorf("convertValue(invalid pgtype.Time) = %v, want nil", got)
 	}
@@ -39,14 +45,14 @@ func TestConvertValueTimeInvalidIsNil(t *testing.T) {
 
 func TestConvertValueIntervalPureTime(t *testing.T) {
 	const us = (2*3600 + 30*60 + 15) * int64(1_000_000)
-	got := convertValue(pgtype.Interval{Microseconds: us, Valid: true})
+	got := convertValue(pgtype.Interval{Microseconds: us, Valid: true}, 0)
 	if got != "02:30:15" {
 		t.Errorf("convertValue(pgtype.Interval pure time) = %v, want \"02:30:15\"", got)
 	}
 }
 
 func TestConvertValueIntervalWithMonthsDays(t *testing.T) {
-	got := convertValue(pgtype.Interval{Months: 2, Days: 5, Microseconds: 0, Valid: true})
+	got := convertValue(pgtype.Interval{Months: 2, Days: 5, Microseconds: 0, Valid: true}, 0)
 	if got != "2 months 5 days 00:00:00" {
 		t.Errorf("convertValue(pgtype.Interval w/ months+days) = %v", got)
 	}
@@ -55,9 +61,9 @@ func TestConvertValueIntervalWithMonthsDays(t *testing.T) {
 func TestConvertValuePassthrough(t *testing.T) {
 	// Anything not specifically handled returns as-is so pgx + JSON
 	// marshalling produces the same output as before.
-	got := convertValue(42)
+	got := convertValue(42, pgtype.Int8OID)
 	if got != 42 {
-		t.Errorf("convertValue(42) = %v, want 42", got)
+		t.Errorf("convertValue(42, pgtype.Int8OID) = %v, want 42", got)
 	}
 }
 
@@ -77,6 +83,11 @@ func TestExplainMultiStatementGuard(t *testing.T) {
 		{"multi-statement with comment suffix", "SELECT 1; DROP TABLE t;--", true},
 		{"double semi in string literal", "SELECT ';' AS x", false},
 		{"two real statements", "SELECT 1; SELECT 2", true},
+		{"semicolon in comments", "SELECT 1 -- a; b\n/* c; /* d; */ e; */", false},
+		{"semicolon in dollar quotes", "DO $body$ BEGIN PERFORM 1; END $body$", false},
+		{"quote in comment", "SELECT 1 /* don't */; COMMIT", true},
+		{"quote in line comment", "-- it's\nDELETE FROM t; COMMIT", true},
+		{"quote in dollar quotes", "SELECT $$'$$; COMMIT", true},
 	}
 
 	for _, tc := range cases {
@@ -90,3 +101,451 @@ func TestExplainMultiStatementGuard(t *testing.T) {
 		})
 	}
 }
+
+func TestExportTextValue(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{"null", nil, ""},
+		{"string", "a,b\nc", "a,b\nc"},
+		{"int", int64(42), "42"},
+		{"bool", true, "true"},
+		{"timestamp", ts, "2024-03-01T12:30:00Z"},
+		{"uuid", [16]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}, "12345678-9abc-def0-1234-56789abcdef0"},
+		{"array", []any{int32(1), nil, int32(3)}, "[1,null,3]"},
+		{"typed array", []string{"a", "b"}, `["a","b"]`},
+		{"json", map[string]any{"k": "v"}, `{"k":"v"}`},
+	}
+	for _, tc := range cases {
+		if got := exportTextValue(tc.in, 0); got != tc.want {
+			t.Errorf("%s: exportTextValue(%v) = %q, want %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestStatementLabel(t *testing.T) {
+	cases := []struct {
+		in, label, body string
+	}{
+		{"SELECT 1", "", "SELECT 1"},
+		{"-- Active users\nSELECT * FROM users", "Active users", "SELECT * FROM users"},
+		{"  --\n-- Totals  \n-- by month\nSELECT 2", "Totals", "SELECT 2"},
+		{"-- only a comment", "only a comment", ""},
+	}
+	for _, tc := range cases {
+		label, body := statementLabel(tc.in)
+		if label != tc.label || body != tc.body {
+			t.Errorf("statementLabel(%q) = (%q, %q), want (%q, %q)", tc.in, label, body, tc.label, tc.body)
+		}
+	}
+}
+
+func TestExplainPrefix(t *testing.T) {
+	cases := []struct {
+		analyze bool
+		format  string
+		want    string
+	}{
+		{true, "text", "EXPLAIN (ANALYZE, BUFFERS, FORMAT TEXT) "},
+		{true, "json", "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "},
+		{false, "json", "EXPLAIN (FORMAT JSON) "},
+	}
+	for _, tc := range cases {
+		if got := explainPrefix(tc.analyze, tc.format); got != tc.want {
+			t.Errorf("explainPrefix(%v, %q) = %q, want %q", tc.analyze, tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestProjectTableColumns(t *testing.T) {
+	columns := []models.ColumnInfo{{Name: "id"}, {Name: "name"}, {Name: "email"}}
+
+	got, err := projectTableColumns(columns, "email, id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "email" || got[1].Name != "id" {
+		t.Errorf("projected = %+v, want email, id", got)
+	}
+	if sel := selectList(got); sel != `"email", "id"` {
+		t.Errorf("selectList = %s", sel)
+	}
+
+	if got, _ := projectTableColumns(columns, " "); len(got) != 3 {
+		t.Errorf("blank columns kept %d, want all 3", len(got))
+	}
+
+	for _, bad := range []string{"id,missing", "id,id", "id;drop", ",,"} {
+		if _, err := projectTableColumns(columns, bad); err == nil {
+			t.Errorf("projectTableColumns(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestClampQueryTimeout(t *testing.T) {
+	cases := []struct {
+		timeout, want time.Duration
+	}{
+		{0, 2 * time.Minute},
+		{5 * time.Second, 5 * time.Second},
+		{10 * time.Minute, 10 * time.Minute},
+		{3 * time.Hour, maxQueryTimeout},
+	}
+	for _, tc := range cases {
+		if got := clampQueryTimeout(tc.timeout, 2*time.Minute); got != tc.want {
+			t.Errorf("clampQueryTimeout(%v) = %v, want %v", tc.timeout, got, tc.want)
+		}
+	}
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	yes, no := true, false
+	cases := []struct {
+		req         models.ExplainRequest
+		want        bool
+		wantWarning bool
+	}{
+		{models.ExplainRequest{SQL: "SELECT * FROM t"}, true, false},
+		{models.ExplainRequest{SQL: "SELECT * FROM t", Analyze: &no}, false, false},
+		{models.ExplainRequest{SQL: "DELETE FROM t"}, false, true},
+		{models.ExplainRequest{SQL: "UPDATE t SET a = 1", Analyze: &yes}, false, true},
+		{models.ExplainRequest{SQL: "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d"}, false, true},
+		{models.ExplainRequest{SQL: "DELETE FROM t", AnalyzeWrites: true}, true, false},
+		{models.ExplainRequest{SQL: "DELETE FROM t", Analyze: &no, AnalyzeWrites: true}, false, false},
+	}
+	for _, tc := range cases {
+		got, warning := explainAnalyze(tc.req)
+		if got != tc.want || (warning != "") != tc.wantWarning {
+			t.Errorf("explainAnalyze(%+v) = (%v, %q), want analyze %v, warning %v", tc.req, got, warning, tc.want, tc.wantWarning)
+		}
+	}
+}
+
+func TestNormalizeFilterOp(t *testing.T) {
+	cases := map[string]string{
+		"=":            "=",
+		"!=":           "<>",
+		"ilike":        "ILIKE",
+		"is  not null": "IS NOT NULL",
+		"In":           "IN",
+	}
+	for in, want := range cases {
+		got, ok := normalizeFilterOp(in)
+		if !ok || got != want {
+			t.Errorf("normalizeFilterOp(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "; DROP", "= 1 OR 1=1", "BETWEEN"} {
+		if _, ok := normalizeFilterOp(bad); ok {
+			t.Errorf("normalizeFilterOp(%q) should be rejected", bad)
+		}
+	}
+}
+
+func TestBuildFilterCondition(t *testing.T) {
+	cases := []struct {
+		op, value string
+		wantSQL   string
+		wantArgs  int
+	}{
+		{"=", "5", `"age" = $1`, 1},
+		{">=", "5", `"age" >= $1`, 1},
+		{"ILIKE", "%bo%", `"age"::text ILIKE $1`, 1},
+		{"IN", "1, 2,3", `"age" IN ($1, $2, $3)`, 3},
+		{"IS NULL", "ignored", `"age" IS NULL`, 0},
+	}
+	for _, tc := range cases {
+		sql, args := buildFilterCondition("age", tc.op, tc.value, 1)
+		if sql != tc.wantSQL || len(args) != tc.wantArgs {
+			t.Errorf("%s: got %q with %d args, want %q with %d", tc.op, sql, len(args), tc.wantSQL, tc.wantArgs)
+		}
+	}
+	if _, args := buildFilterCondition("age", "IN", "1, 2", 1); args[1] != "2" {
+		t.Errorf("IN items should be trimmed, got %q", args[1])
+	}
+}
+
+func TestBuildWhereClause(t *testing.T) {
+	where, args, err := buildWhereClause([]models.TableFilter{
+		{Column: "status", Op: "=", Value: "active"},
+		{Column: "created_at", Op: ">", Value: "2024-01-01", Connector: "and"},
+		{Column: "id", Op: "IN", Value: "1,2", Connector: "OR"},
+		{Column: "note", Op: "=", Value: ""}, // no value: skipped
+		{Column: "deleted_at", Op: "IS NULL"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ` WHERE ((("status" = $1) AND ("created_at" > $2)) OR ("id" IN ($3, $4))) AND ("deleted_at" IS NULL)`
+	if where != want {
+		t.Errorf("where =\n%s\nwant\n%s", where, want)
+	}
+	if len(args) != 4 {
+		t.Errorf("got %d args, want 4", len(args))
+	}
+
+	where, _, err = buildWhereClause([]models.TableFilter{
+		{Column: "a", Op: "=", Value: "1"},
+		{Column: "b", Op: "=", Value: "2", Connector: "OR"},
+		{Column: "c", Op: "=", Value: "3", Connector: "OR"},
+		{Column: "d", Op: "=", Value: "4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ` WHERE (("a" = $1) OR ("b" = $2) OR ("c" = $3)) AND ("d" = $4)`; where != want {
+		t.Errorf("mixed connectors: where =\n%s\nwant\n%s", where, want)
+	}
+
+	if where, args, err := buildWhereClause(nil); where != "" || args != nil || err != nil {
+		t.Errorf("no filters: got %q, %v, %v", where, args, err)
+	}
+
+	bad := [][]models.TableFilter{
+		{{Column: "a; DROP TABLE x", Op: "=", Value: "1"}},
+		{{Column: "a", Op: "=", Value: "1"}, {Column: "b", Op: "=", Value: "2", Connector: "XOR"}},
+		{{Column: "a", Op: "~", Value: "1"}},
+		make([]models.TableFilter, maxTableFilters+1),
+	}
+	for i, filters := range bad {
+		if _, _, err := buildWhereClause(filters); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
+
+func TestInlineFilterArgs(t *testing.T) {
+	where, args, err := buildWhereClause([]models.TableFilter{
+		{Column: "a", Op: "=", Value: "$2"},
+		{Column: "b", Op: "LIKE", Value: `it's\%`},
+		{Column: "c", Op: "IN", Value: "1,2,3,4,5,6,7,8,9,10"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := inlineFilterArgs(where, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ` WHERE ("a" = E'$2') AND ("b"::text LIKE E'it''s\\%') AND ("c" IN (E'1', E'2', E'3', E'4', E'5', E'6', E'7', E'8', E'9', E'10'))`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := inlineFilterArgs(` WHERE ("a" = $2)`, []any{"x"}); err == nil {
+		t.Error("missing parameter should fail")
+	}
+	if _, err := inlineFilterArgs(` WHERE ("a" = $1)`, []any{"x\x00"}); err == nil {
+		t.Error("NUL byte should fail")
+	}
+}
+
+func TestBulkInsertColumns(t *testing.T) {
+	cols, _, err := bulkInsertColumns([]map[string]any{
+		{"name": "a", "id": 1},
+		{"id": 2, "name": nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 2 || cols[0] != "id" || cols[1] != "name" {
+		t.Errorf("columns = %v, want [id name]", cols)
+	}
+
+	cases := []struct {
+		rows    []map[string]any
+		wantIdx int
+	}{
+		{[]map[string]any{}, 0},
+		{[]map[string]any{{"bad col": 1}}, 0},
+		{[]map[string]any{{"id": 1}, {"id": 2}, {"id": 3, "extra": 1}}, 2},
+		{[]map[string]any{{"id": 1, "name": "a"}, {"id": 2, "nmae": "b"}}, 1},
+	}
+	for i, tc := range cases {
+		_, idx, err := bulkInsertColumns(tc.rows)
+		if err == nil {
+			t.Errorf("case %d: expected an error", i)
+			continue
+		}
+		if idx != tc.wantIdx {
+			t.Errorf("case %d: rowIndex = %d, want %d", i, idx, tc.wantIdx)
+		}
+	}
+}
+
+func TestIsTransactionControl(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"BEGIN", true},
+		{"start transaction isolation level serializable", true},
+		{"commit", true},
+		{"END", true},
+		{"ROLLBACK", true},
+		{"rollback and chain", true},
+		{"ROLLBACK TO SAVEPOINT a", false},
+		{"SAVEPOINT a", false},
+		{"PREPARE TRANSACTION 'x'", true},
+		{"PREPARE q AS SELECT 1", false},
+		{"-- tidy up\nCOMMIT", true},
+		{"/* x */ COMMIT", true},
+		{"/**/ COMMIT", true},
+		{"/*a*/ /*b*/ end", true},
+		{"/* outer /* inner */ still outer */ BEGIN", true},
+		{"/* rollback */ ROLLBACK /* to */ TO a", false},
+		{"UPDATE t SET begin = 1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isTransactionControl(tt.sql); got != tt.want {
+			t.Errorf("isTransactionControl(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeResults(t *testing.T) {
+	rows, errMsg := summarizeResults([]models.QueryResult{{RowCount: 3}, {RowCount: 2}})
+	if rows != 5 || errMsg != "" {
+		t.Errorf("got (%d, %q), want (5, \"\")", rows, errMsg)
+	}
+	rows, errMsg = summarizeResults([]models.QueryResult{{RowCount: 3}, {Error: "boom"}, {RowCount: 9}})
+	if rows != 3 || errMsg != "boom" {
+		t.Errorf("got (%d, %q), want (3, \"boom\")", rows, errMsg)
+	}
+}
+
+func TestValidatePrimaryKey(t *testing.T) {
+	columns := []models.ColumnInfo{
+		{Name: "order_id", IsPrimaryKey: true},
+		{Name: "line_no", IsPrimaryKey: true},
+		{Name: "sku"},
+	}
+	tests := []struct {
+		pk      map[string]any
+		wantErr bool
+	}{
+		{map[string]any{"order_id": 1, "line_no": 2}, false},
+		{map[string]any{"order_id": 1}, true},
+		{map[string]any{"order_id": 1, "line_no": 2, "sku": "x"}, true},
+		{map[string]any{"orderid": 1, "line_no": 2}, true},
+	}
+	for _, tt := range tests {
+		if err := validatePrimaryKey(columns, tt.pk); (err != nil) != tt.wantErr {
+			t.Errorf("validatePrimaryKey(%v) err = %v, wantErr %v", tt.pk, err, tt.wantErr)
+		}
+	}
+
+	if err := validatePrimaryKey([]models.ColumnInfo{{Name: "a"}}, map[string]any{"a": 1}); err == nil {
+		t.Error("a table without a primary key should be rejected")
+	}
+}
+
+func TestQueryErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}, models.QueryErrorStatementTimeout},
+		{&pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"}, ""},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), models.QueryErrorDeadline},
+		{&pgconn.PgError{Code: "42P01", Message: "relation does not exist"}, ""},
+	}
+	for _, tt := range tests {
+		if got := queryErrorKind(tt.err); got != tt.want {
+			t.Errorf("queryErrorKind(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBuildInsertQuery(t *testing.T) {
+	data := map[string]any{"name": "a", "id": 1, "email": "a@example.com"}
+
+	query, values, err := buildInsertQuery("public", "users", data, nil)
+	if err != nil {
+		t.Fatalf("plain insert: %v", err)
+	}
+	if want := `INSERT INTO "public"."users" ("email", "id", "name") VALUES ($1, $2, $3) RETURNING *`; query != want {
+		t.Errorf("plain insert = %q, want %q", query, want)
+	}
+	if len(values) != 3 || values[1] != 1 {
+		t.Errorf("values = %v, want sorted by column", values)
+	}
+
+	query, _, err = buildInsertQuery("public", "users", data, &models.OnConflict{Columns: []string{"id"}, Action: "update"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	want := `INSERT INTO "public"."users" ("email", "id", "name") VALUES ($1, $2, $3) ON CONFLICT ("id") DO UPDATE SET "email" = EXCLUDED."email", "name" = EXCLUDED."name" RETURNING *, (xmax = 0) AS pgvoyager_inserted`
+	if query != want {
+		t.Errorf("upsert = %q, want %q", query, want)
+	}
+
+	query, _, err = buildInsertQuery("public", "users", data, &models.OnConflict{Columns: []string{"email"}, Action: "nothing"})
+	if err != nil || !strings.Contains(query, `ON CONFLICT ("email") DO NOTHING RETURNING *`) {
+		t.Errorf("do nothing = %q, %v", query, err)
+	}
+
+	if _, _, err := buildInsertQuery("public", "users", map[string]any{"id": 1}, &models.OnConflict{Columns: []string{"id"}, Action: "update"}); err == nil {
+		t.Error("expected an error when nothing is left to update")
+	}
+	if _, _, err := buildInsertQuery("public", "users", data, &models.OnConflict{Columns: []string{"id; drop"}, Action: "nothing"}); err == nil {
+		t.Error("expected an error for an invalid conflict column")
+	}
+}
+
+func TestBuiltinTypeName(t *testing.T) {
+	cases := []struct {
+		oid  uint32
+		want string
+	}{
+		{pgtype.JSONBOID, "jsonb"},
+		{pgtype.JSONOID, "json"},
+		{pgtype.UUIDOID, "uuid"},
+		{pgtype.TimestamptzOID, "timestamptz"},
+		{pgtype.Int4ArrayOID, "int4[]"},
+		{pgtype.JSONBArrayOID, "jsonb[]"},
+	}
+	for _, tc := range cases {
+		if got, ok := builtinTypeName(tc.oid); !ok || got != tc.want {
+			t.Errorf("builtinTypeName(%d) = %q, %v; want %q", tc.oid, got, ok, tc.want)
+		}
+	}
+
+	// Enums, domains and extension types get OIDs of their own.
+	if got, ok := builtinTypeName(900000); ok {
+		t.Errorf("builtinTypeName(900000) = %q, want unknown", got)
+	}
+}
+
+func TestKeysetWhereClause(t *testing.T) {
+	tests := []struct {
+		where, dir string
+		argN       int
+		want       string
+	}{
+		{"", "ASC", 1, ` WHERE "id" > $1`},
+		{"", "DESC", 1, ` WHERE "id" < $1`},
+		{` WHERE ("a" = $1) OR ("b" = $2)`, "ASC", 3, ` WHERE (("a" = $1) OR ("b" = $2)) AND "id" > $3`},
+	}
+	for _, tt := range tests {
+		if got := keysetWhereClause(tt.where, "id", tt.dir, tt.argN); got != tt.want {
+			t.Errorf("keysetWhereClause(%q, %s) = %q, want %q", tt.where, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, v := range []string{"42", "2024-01-02 03:04:05+00", "a/b+c=d é", ""} {
+		got, err := decodeCursor(encodeCursor(v))
+		if err != nil || got != v {
+			t.Errorf("decodeCursor(encodeCursor(%q)) = %q, %v", v, got, err)
+		}
+	}
+	if _, err := decodeCursor("not base64!"); err == nil {
+		t.Error("decodeCursor accepted an invalid cursor")
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// dependencyTargetsCTE resolves ($1 schema, $2 name) to every catalog object
+// that could be meant: relations (tables, views, matviews, sequences),
+// functions, and standalone types. The `sources` CTE widens each relation to
+// its rewrite rules and user triggers, because pg_depend records a view's
+// references against its _RETURN rule and a trigger's function against the
+// trigger — never against the relation itself.
+const dependencyTargetsCTE = `
+	WITH target AS (
+		SELECT 'pg_class'::regclass AS classid, c.oid AS objid
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+		UNION ALL
+		SELECT 'pg_proc'::regclass, p.oid
+		FROM pg_catalog.pg_proc p
+		JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.proname = $2
+		UNION ALL
+		SELECT 'pg_type'::regclass, t.oid
+		FROM pg_catalog.pg_type t
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1 AND t.typname = $2
+		  AND t.typtype IN ('e', 'd', 'r')
+	),
+	sources AS (
+		SELECT classid, objid FROM target
+		UNION ALL
+		SELECT 'pg_rewrite'::regclass, r.oid
+		FROM pg_catalog.pg_rewrite r
+		JOIN target t ON t.classid = 'pg_class'::regclass AND r.ev_class = t.objid
+		UNION ALL
+		SELECT 'pg_trigger'::regclass, tg.oid
+		FROM pg_catalog.pg_trigger tg
+		JOIN target t ON t.classid = 'pg_class'::regclass AND tg.tgrelid = t.objid
+		WHERE NOT tg.tgisinternal
+	)
+`
+
+// queryObjectDependencies walks pg_depend in both directions for the named
+// object. Only normal and auto dependencies are reported; internal ones
+// (a view's own rule, a table's row type) are implementation details that
+// don't break anything when the object is dropped. Rewrite rules on the
+// dependent side are folded back into the view that owns them.
+func queryObjectDependencies(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}, schema, name string) (*models.ObjectDependencies, error) {
+	var found int
+	if err := pool.QueryRow(ctx, dependencyTargetsCTE+`SELECT count(*) FROM target`, schema, name).Scan(&found); err != nil {
+		return nil, err
+	}
+	if found == 0 {
+		return nil, nil
+	}
+
+	dependsOnQuery := dependencyTargetsCTE + `
+		SELECT DISTINCT
+			o.type,
+			COALESCE(o.schema, ''),
+			COALESCE(o.name, o.identity),
+			o.identity,
+			CASE d.deptype WHEN 'a' THEN 'auto' ELSE 'normal' END
+		FROM pg_catalog.pg_depend d
+		JOIN sources s ON s.classid = d.classid AND s.objid = d.objid
+		CROSS JOIN LATERAL pg_catalog.pg_identify_object(d.refclassid, d.refobjid, 0) o
+		WHERE d.deptype IN ('n', 'a')
+		  AND NOT EXISTS (
+			SELECT 1 FROM target t
+			WHERE t.classid = d.refclassid AND t.objid = d.refobjid
+		  )
+		  AND COALESCE(o.schema, '') NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY 1, 4
+	`
+
+	dependedOnByQuery := dependencyTargetsCTE + `,
+	dependents AS (
+		SELECT
+			CASE WHEN d.classid = 'pg_rewrite'::regclass
+				THEN 'pg_class'::regclass ELSE d.classid END AS classid,
+			CASE WHEN d.classid = 'pg_rewrite'::regclass
+				THEN r.ev_class ELSE d.objid END AS objid,
+			d.deptype
+		FROM pg_catalog.pg_depend d
+		JOIN target t ON t.classid = d.refclassid AND t.objid = d.refobjid
+		LEFT JOIN pg_catalog.pg_rewrite r
+			ON d.classid = 'pg_rewrite'::regclass AND r.oid = d.objid
+		WHERE d.deptype IN ('n', 'a')
+	)
+	SELECT DISTINCT
+		o.type,
+		COALESCE(o.schema, ''),
+		COALESCE(o.name, o.identity),
+		o.identity,
+		CASE dep.deptype WHEN 'a' THEN 'auto' ELSE 'normal' END
+	FROM dependents dep
+	CROSS JOIN LATERAL pg_catalog.pg_identify_object(dep.classid, dep.objid, 0) o
+	WHERE NOT EXISTS (
+		SELECT 1 FROM target t
+		WHERE t.classid = dep.classid AND t.objid = dep.objid
+	)
+	ORDER BY 1, 4
+	`
+
+	result := &models.ObjectDependencies{
+		Schema:       schema,
+		Name:         name,
+		DependsOn:    []models.ObjectDependency{},
+		DependedOnBy: []models.ObjectDependency{},
+	}
+
+	var err error
+	if result.DependsOn, err = scanObjectDependencies(ctx, pool, dependsOnQuery, schema, name); err != nil {
+		return nil, err
+	}
+	if result.DependedOnBy, err = scanObjectDependencies(ctx, pool, dependedOnByQuery, schema, name); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func scanObjectDependencies(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, query, schema, name string) ([]models.ObjectDependency, error) {
+	rows, err := pool.Query(ctx, query, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []models.ObjectDependency{}
+	for rows.Next() {
+		var dep models.ObjectDependency
+		if err := rows.Scan(&dep.Kind, &dep.Schema, &dep.Name, &dep.Identity, &dep.DependencyType); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, rows.Err()
+}
+
+// GetObjectDependencies returns what a database object depends on and what
+// depends on it (views on tables, functions used by triggers, ...). Unlike
+// the FK relationship endpoints this covers every pg_depend edge, so it's
+// what to check before dropping an object.
+func GetObjectDependencies(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	name := c.Param("name")
+
+	deps, err := queryObjectDependencies(ctx, pool, schema, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if deps == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Object not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deps)
+}
+
+// viewReadsFromQuery lists the relations a view's query reads, taken from
+// the pg_depend entries of its _RETURN rule. Those are recorded per
+// column, hence DISTINCT.
+const viewReadsFromQuery = `
+	SELECT DISTINCT o.type, o.schema, o.name
+	FROM pg_catalog.pg_rewrite r
+	JOIN pg_catalog.pg_depend d
+		ON d.classid = 'pg_rewrite'::regclass AND d.objid = r.oid
+		AND d.refclassid = 'pg_class'::regclass AND d.refobjid <> r.ev_class
+	CROSS JOIN LATERAL pg_catalog.pg_identify_object('pg_class'::regclass, d.refobjid, 0) o
+	WHERE r.ev_class = $1
+	ORDER BY 2, 3
+`
+
+// viewReadByQuery lists the objects that depend on a view. Views reading
+// it are found through their rewrite rules and reported as the view.
+const viewReadByQuery = `
+	WITH dependents AS (
+		SELECT
+			CASE WHEN d.classid = 'pg_rewrite'::regclass
+				THEN 'pg_class'::regclass ELSE d.classid END AS classid,
+			CASE WHEN d.classid = 'pg_rewrite'::regclass
+				THEN r.ev_class ELSE d.objid END AS objid
+		FROM pg_catalog.pg_depend d
+		LEFT JOIN pg_catalog.pg_rewrite r
+			ON d.classid = 'pg_rewrite'::regclass AND r.oid = d.objid
+		WHERE d.refclassid = 'pg_class'::regclass AND d.refobjid = $1
+		  AND d.deptype IN ('n', 'a')
+	)
+	SELECT DISTINCT o.type, COALESCE(o.schema, ''), COALESCE(o.name, o.identity)
+	FROM dependents dep
+	CROSS JOIN LATERAL pg_catalog.pg_identify_object(dep.classid, dep.objid, 0) o
+	WHERE NOT (dep.classid = 'pg_class'::regclass AND dep.objid = $1)
+	ORDER BY 2, 3
+`
+
+func scanViewDependencies(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, query string, viewOID uint32) ([]models.ViewDependency, error) {
+	rows, err := pool.Query(ctx, query, viewOID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []models.ViewDependency{}
+	for rows.Next() {
+		var dep models.ViewDependency
+		if err := rows.Scan(&dep.Kind, &dep.Schema, &dep.Name); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, rows.Err()
+}
+
+// GetViewDependencies returns the tables and views a view (or materialized
+// view) reads from, and the objects that depend on it — what breaks if
+// it's dropped or its columns change.
+func GetViewDependencies(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	view := c.Param("view")
+
+	var viewOID uint32
+	err := pool.QueryRow(ctx, `
+		SELECT c.oid
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('v', 'm')
+	`, schema, view).Scan(&viewOID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.ViewDependencies{Schema: schema, Name: view}
+	if result.ReadsFrom, err = scanViewDependencies(ctx, pool, viewReadsFromQuery, viewOID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result.ReadBy, err = scanViewDependencies(ctx, pool, viewReadByQuery, viewOID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
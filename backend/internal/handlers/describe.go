@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// DescribeTable bundles everything the table view shows — summary,
+// columns, constraints, indexes, foreign keys and triggers — into one
+// response, running the catalog queries concurrently on separate pool
+// connections. Every failing part is reported, not just the first.
+func DescribeTable(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+
+	var (
+		desc        models.TableDescription
+		info        *models.Table
+		columns     map[string][]models.Column
+		constraints map[string][]models.Constraint
+		indexes     map[string][]models.Index
+		errs        [6]error
+	)
+	var g errgroup.Group
+	g.Go(func() error {
+		var err error
+		info, err = loadTableInfo(ctx, pool, schema, table)
+		errs[0] = err
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		columns, err = loadTableColumns(ctx, pool, schema, table)
+		errs[1] = describeErr("columns", err)
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		constraints, err = loadTableConstraints(ctx, pool, schema, table)
+		errs[2] = describeErr("constraints", err)
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		indexes, err = loadTableIndexes(ctx, pool, schema, table)
+		errs[3] = describeErr("indexes", err)
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		desc.ForeignKeys, err = loadForeignKeys(ctx, pool, schema, table)
+		errs[4] = describeErr("foreign keys", err)
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		query := triggersQuery + " AND n.nspname = $1 AND c.relname = $2 ORDER BY t.tgname"
+		desc.Triggers, err = scanTriggers(ctx, pool, query, schema, table)
+		errs[5] = describeErr("triggers", err)
+		return nil
+	})
+	g.Wait()
+
+	if errors.Is(errs[0], pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
+	errs[0] = describeErr("table", errs[0])
+	if err := errors.Join(errs[:]...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	desc.Table = *info
+	desc.Columns = columns[table]
+	desc.Constraints = constraints[table]
+	desc.Indexes = indexes[table]
+	recordTableAccess(connId, schema, table)
+	c.JSON(http.StatusOK, desc)
+}
+
+// describeErr labels an error with the part of the description it broke.
+func describeErr(part string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", part, err)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// diffRowKey identifies a row by the JSON of its converted values, so two
+// rows match when they would display the same. Numbers compare by their
+// text: numeric 1.0 and 1.00 differ.
+func diffRowKey(row []any) string {
+	b, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Sprint(row)
+	}
+	return string(b)
+}
+
+// diffRows compares two results as multisets and returns the rows only in
+// a and only in b (as many times as they are extra), each sorted by value
+// so the output doesn't depend on either query's order.
+func diffRows(a, b [][]any) (onlyA, onlyB [][]any) {
+	counts := make(map[string]int)
+	rows := make(map[string][]any)
+	for _, r := range a {
+		k := diffRowKey(r)
+		counts[k]++
+		rows[k] = r
+	}
+	for _, r := range b {
+		k := diffRowKey(r)
+		counts[k]--
+		rows[k] = r
+	}
+
+	keys := make([]string, 0, len(counts))
+	for k, n := range counts {
+		if n != 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	onlyA, onlyB = [][]any{}, [][]any{}
+	for _, k := range keys {
+		for n := counts[k]; n > 0; n-- {
+			onlyA = append(onlyA, rows[k])
+		}
+		for n := counts[k]; n < 0; n++ {
+			onlyB = append(onlyB, rows[k])
+		}
+	}
+	return onlyA, onlyB
+}
+
+// fetchDiffRows reads up to limit rows of a query, converted as for any
+// result. truncated reports that there were more.
+func fetchDiffRows(ctx context.Context, tx pgx.Tx, sql string, params []interface{}, limit int) (columns []string, out [][]any, truncated bool, err error) {
+	rows, err := tx.Query(ctx, sql, params...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	columns = make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		columns[i] = fd.Name
+	}
+
+	out = [][]any{}
+	for rows.Next() {
+		if len(out) == limit {
+			truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		row := make([]any, len(values))
+		for i, v := range values {
+			row[i] = convertValue(v, fieldDescs[i].DataTypeOID)
+		}
+		out = append(out, row)
+	}
+	rows.Close()
+	return columns, out, truncated, rows.Err()
+}
+
+// DiffQueries runs two queries and reports the rows each returns that the
+// other doesn't, ignoring order, e.g. to check that a rewritten query is
+// equivalent. Both run in one read-only REPEATABLE READ transaction, so
+// they see the same data and neither can write. Each reads at most the
+// query.diff_max_rows preference (or the request's lower limit) rows.
+func DiffQueries(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.QueryDiffRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	for name, sql := range map[string]string{"sqlA": req.SQLA, "sqlB": req.SQLB} {
+		if len(splitStatements(sql)) != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a single statement"})
+			return
+		}
+	}
+
+	limit := rowLimit(QueryDiffMaxRowsPreference, defaultDiffMaxRows)
+	if req.Limit > 0 {
+		limit = min(limit, req.Limit)
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	columnsA, rowsA, truncA, err := fetchDiffRows(ctx, tx, req.SQLA, req.Params, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query A: " + err.Error()})
+		return
+	}
+	columnsB, rowsB, truncB, err := fetchDiffRows(ctx, tx, req.SQLB, req.Params, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query B: " + err.Error()})
+		return
+	}
+	if len(columnsA) != len(columnsB) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("queries return different numbers of columns (%d and %d)", len(columnsA), len(columnsB)),
+		})
+		return
+	}
+
+	onlyA, onlyB := diffRows(rowsA, rowsB)
+	result := models.QueryDiffResult{
+		Columns:   columnsA,
+		RowCountA: len(rowsA),
+		RowCountB: len(rowsB),
+		OnlyInA:   onlyA,
+		OnlyInB:   onlyB,
+		Truncated: truncA || truncB,
+		Limit:     limit,
+		Duration:  time.Since(start).Seconds() * 1000,
+	}
+	result.Match = !result.Truncated && len(onlyA) == 0 && len(onlyB) == 0
+	c.JSON(http.StatusOK, result)
+}
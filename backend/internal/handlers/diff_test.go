@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffRows(t *testing.T) {
+	a := [][]any{{1.0, "x"}, {2.0, "y"}, {2.0, "y"}, {3.0, nil}}
+	b := [][]any{{3.0, nil}, {2.0, "y"}, {1.0, "x"}, {4.0, "z"}}
+
+	onlyA, onlyB := diffRows(a, b)
+	if want := [][]any{{2.0, "y"}}; !reflect.DeepEqual(onlyA, want) {
+		t.Errorf("onlyA = %v, want %v (the extra duplicate)", onlyA, want)
+	}
+	if want := [][]any{{4.0, "z"}}; !reflect.DeepEqual(onlyB, want) {
+		t.Errorf("onlyB = %v, want %v", onlyB, want)
+	}
+
+	// Same rows in another order match.
+	onlyA, onlyB = diffRows(a[:2], [][]any{{2.0, "y"}, {1.0, "x"}})
+	if len(onlyA) != 0 || len(onlyB) != 0 {
+		t.Errorf("reordered rows differ: %v / %v", onlyA, onlyB)
+	}
+
+	// NULL is not the string "null".
+	onlyA, onlyB = diffRows([][]any{{nil}}, [][]any{{"null"}})
+	if len(onlyA) != 1 || len(onlyB) != 1 {
+		t.Errorf("NULL matched 'null': %v / %v", onlyA, onlyB)
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// integerRanges bounds the integer types by their pg_type name.
+var integerRanges = map[string][2]float64{
+	"int2": {math.MinInt16, math.MaxInt16},
+	"int4": {math.MinInt32, math.MaxInt32},
+	"int8": {math.MinInt64, math.MaxInt64},
+}
+
+// boolLiterals are the strings Postgres accepts as a boolean, with their
+// value.
+var boolLiterals = map[string]bool{
+	"t": true, "true": true, "y": true, "yes": true, "on": true, "1": true,
+	"f": false, "false": false, "n": false, "no": false, "off": false, "0": false,
+}
+
+// temporalTypes take their value as a string in one of Postgres's many
+// input formats, which are left to Postgres to parse.
+var temporalTypes = map[string]bool{
+	"date": true, "time": true, "timetz": true, "timestamp": true,
+	"timestamptz": true, "interval": true,
+}
+
+// jsonKind names the kind of a decoded JSON value for error messages.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("a %T", v)
+}
+
+// checkColumnValue checks a decoded JSON value against a column's type
+// family, returning why it can't be stored or "". Only the families with
+// an unambiguous JSON form are checked (integers, other numbers, booleans,
+// uuid, bytea, date/time types, arrays); anything else is left to Postgres.
+func checkColumnValue(col models.ColumnInfo, v any) string {
+	if v == nil {
+		if col.NotNull {
+			return "can't be null"
+		}
+		return ""
+	}
+
+	base := col.BaseType
+	if bounds, ok := integerRanges[base]; ok {
+		switch x := v.(type) {
+		case float64:
+			if x != math.Trunc(x) {
+				return fmt.Sprintf("expected an integer, got %v", x)
+			}
+			if x < bounds[0] || x > bounds[1] {
+				return fmt.Sprintf("%v is out of range for %s", x, col.DataType)
+			}
+		case string:
+			if _, err := strconv.ParseInt(strings.TrimSpace(x), 10, 8*integerBytes(base)); err != nil {
+				return fmt.Sprintf("expected an integer, got %q", x)
+			}
+		default:
+			return "expected an integer, got " + jsonKind(v)
+		}
+		return ""
+	}
+
+	switch {
+	case base == "numeric" || base == "float4" || base == "float8":
+		switch x := v.(type) {
+		case float64:
+		case string:
+			s := strings.TrimSpace(x)
+			if _, err := strconv.ParseFloat(s, 64); err != nil && !isSpecialNumber(s) {
+				return fmt.Sprintf("expected a number, got %q", x)
+			}
+		default:
+			return "expected a number, got " + jsonKind(v)
+		}
+	case base == "bool":
+		switch x := v.(type) {
+		case bool:
+		case string:
+			if _, ok := boolLiterals[strings.ToLower(strings.TrimSpace(x))]; !ok {
+				return fmt.Sprintf("expected a boolean, got %q", x)
+			}
+		default:
+			return "expected a boolean, got " + jsonKind(v)
+		}
+	case base == "uuid":
+		s, ok := v.(string)
+		if !ok {
+			return "expected a UUID string, got " + jsonKind(v)
+		}
+		if _, err := uuid.Parse(strings.TrimSpace(s)); err != nil {
+			return fmt.Sprintf("%q is not a valid UUID", s)
+		}
+	case base == "bytea":
+		s, ok := v.(string)
+		if !ok {
+			return "expected a base64 string, got " + jsonKind(v)
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return "expected base64-encoded bytes"
+		}
+	case temporalTypes[base]:
+		if _, ok := v.(string); !ok {
+			return fmt.Sprintf("expected a %s string, got %s", col.DataType, jsonKind(v))
+		}
+	case strings.HasPrefix(base, "_"):
+		// An array, or its '{...}' literal.
+		switch v.(type) {
+		case []any, string:
+		default:
+			return "expected an array, got " + jsonKind(v)
+		}
+	}
+	return ""
+}
+
+// integerBytes is the width of an integer type.
+func integerBytes(base string) int {
+	switch base {
+	case "int2":
+		return 2
+	case "int4":
+		return 4
+	}
+	return 8
+}
+
+// isSpecialNumber reports the non-finite values numeric and float accept.
+func isSpecialNumber(s string) bool {
+	switch strings.ToLower(s) {
+	case "nan", "infinity", "+infinity", "-infinity", "inf", "+inf", "-inf":
+		return true
+	}
+	return false
+}
+
+// validateRowData checks the values of a row edit against the table's
+// columns, returning a FieldError per unknown column or unacceptable
+// value, ordered by column name.
+func validateRowData(columns []models.ColumnInfo, data map[string]any) []models.FieldError {
+	byName := make(map[string]models.ColumnInfo, len(columns))
+	for _, col := range columns {
+		byName[col.Name] = col
+	}
+
+	var errs []models.FieldError
+	for name, v := range data {
+		col, ok := byName[name]
+		if !ok {
+			errs = append(errs, models.FieldError{Column: name, Message: "no such column"})
+			continue
+		}
+		if msg := checkColumnValue(col, v); msg != "" {
+			errs = append(errs, models.FieldError{Column: name, Message: msg})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Column < errs[j].Column })
+	return errs
+}
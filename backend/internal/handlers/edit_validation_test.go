@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestCheckColumnValue(t *testing.T) {
+	col := func(base string, notNull bool) models.ColumnInfo {
+		return models.ColumnInfo{Name: "c", DataType: base, BaseType: base, NotNull: notNull}
+	}
+	tests := []struct {
+		col models.ColumnInfo
+		v   any
+		ok  bool
+	}{
+		{col("int4", false), nil, true},
+		{col("int4", true), nil, false},
+		{col("int4", false), 42.0, true},
+		{col("int4", false), " 42 ", true},
+		{col("int4", false), 4.5, false},
+		{col("int4", false), "forty", false},
+		{col("int4", false), true, false},
+		{col("int2", false), 40000.0, false},
+		{col("int2", false), "40000", false},
+		{col("int8", false), "9223372036854775807", true},
+		{col("numeric", false), 1.5, true},
+		{col("numeric", false), "1e10", true},
+		{col("float8", false), "NaN", true},
+		{col("numeric", false), "1,5", false},
+		{col("numeric", false), []any{1.0}, false},
+		{col("bool", false), false, true},
+		{col("bool", false), "Yes", true},
+		{col("bool", false), 1.0, false},
+		{col("bool", false), "maybe", false},
+		{col("uuid", false), "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", true},
+		{col("uuid", false), "not-a-uuid", false},
+		{col("bytea", false), "3q2+7w==", true},
+		{col("bytea", false), "not base64!", false},
+		{col("bytea", false), 1.0, false},
+		{col("timestamptz", false), "2024-01-02 03:04:05+00", true},
+		{col("date", false), 20240102.0, false},
+		{col("_text", false), []any{"a", "b"}, true},
+		{col("_text", false), "{a,b}", true},
+		{col("_text", false), map[string]any{}, false},
+		{col("jsonb", false), map[string]any{"a": 1.0}, true},
+		{col("text", false), "anything", true},
+	}
+	for _, tt := range tests {
+		msg := checkColumnValue(tt.col, tt.v)
+		if (msg == "") != tt.ok {
+			t.Errorf("checkColumnValue(%s, %#v) = %q, want ok=%v", tt.col.BaseType, tt.v, msg, tt.ok)
+		}
+	}
+}
+
+func TestValidateRowData(t *testing.T) {
+	columns := []models.ColumnInfo{
+		{Name: "id", BaseType: "int4", NotNull: true},
+		{Name: "name", BaseType: "text", NotNull: true},
+		{Name: "active", BaseType: "bool"},
+	}
+	got := validateRowData(columns, map[string]any{
+		"name":   nil,
+		"active": "sometimes",
+		"id":     7.0,
+		"ghost":  1.0,
+	})
+	want := []models.FieldError{
+		{Column: "active", Message: `expected a boolean, got "sometimes"`},
+		{Column: "ghost", Message: "no such column"},
+		{Column: "name", Message: "can't be null"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateRowData = %+v, want %+v", got, want)
+	}
+	if errs := validateRowData(columns, map[string]any{"active": true}); len(errs) != 0 {
+		t.Errorf("valid data rejected: %+v", errs)
+	}
+}
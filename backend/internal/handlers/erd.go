@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// tableKey identifies a table across schemas.
+type tableKey struct{ schema, table string }
+
+// GetERDiagram returns a schema's ER diagram in one call: a node per table
+// (the schema's own, plus any table elsewhere that a foreign key links
+// them to) with its columns, and an edge per foreign key annotated with
+// its cardinality.
+func GetERDiagram(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := c.Param("schema")
+
+	relationships, err := fetchSchemaRelationships(ctx, pool, schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	nodes, err := fetchERDNodes(ctx, pool, schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	uniqueKeys, err := fetchUniqueKeys(ctx, pool, schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ERDiagram{
+		Schema: schema,
+		Nodes:  nodes,
+		Edges:  buildERDEdges(relationships, nodes, uniqueKeys),
+	})
+}
+
+// fetchERDNodes lists the schema's tables, and the tables in other
+// schemas linked to them by a foreign key, with their columns in order.
+// Partitions are left out; their parent stands for them.
+func fetchERDNodes(ctx context.Context, q queryRunner, schema string) ([]models.ERDNode, error) {
+	query := `
+		WITH tables AS (
+			SELECT c.oid
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+			UNION
+			SELECT unnest(ARRAY[con.conrelid, con.confrelid])
+			FROM pg_constraint con
+			JOIN pg_class c ON c.oid = con.conrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			JOIN pg_class cf ON cf.oid = con.confrelid
+			JOIN pg_namespace nf ON nf.oid = cf.relnamespace
+			WHERE con.contype = 'f' AND (n.nspname = $1 OR nf.nspname = $1)
+		)
+		SELECT
+			n.nspname,
+			c.relname,
+			a.attname,
+			format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull,
+			EXISTS (
+				SELECT 1 FROM pg_constraint p
+				WHERE p.conrelid = c.oid AND p.contype = 'p' AND a.attnum = ANY(p.conkey)
+			),
+			EXISTS (
+				SELECT 1 FROM pg_constraint f
+				WHERE f.conrelid = c.oid AND f.contype = 'f' AND a.attnum = ANY(f.conkey)
+			)
+		FROM tables t
+		JOIN pg_class c ON c.oid = t.oid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY n.nspname, c.relname, a.attnum
+	`
+
+	rows, err := q.Query(ctx, query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := []models.ERDNode{}
+	for rows.Next() {
+		var key tableKey
+		var col models.ERDColumn
+		if err := rows.Scan(&key.schema, &key.table, &col.Name, &col.DataType,
+			&col.IsNullable, &col.IsPrimaryKey, &col.IsForeignKey); err != nil {
+			return nil, err
+		}
+		if n := len(nodes); n == 0 || nodes[n-1].Schema != key.schema || nodes[n-1].Table != key.table {
+			nodes = append(nodes, models.ERDNode{Schema: key.schema, Table: key.table})
+		}
+		node := &nodes[len(nodes)-1]
+		node.Columns = append(node.Columns, col)
+	}
+	return nodes, rows.Err()
+}
+
+// fetchUniqueKeys returns the column sets of the primary keys and unique
+// indexes on the tables whose foreign keys fetchSchemaRelationships lists.
+// Partial and expression indexes are skipped since they don't make their
+// columns unique across the table; INCLUDE columns aren't part of the key.
+func fetchUniqueKeys(ctx context.Context, q queryRunner, schema string) (map[tableKey][][]string, error) {
+	query := `
+		SELECT n.nspname, c.relname, array_agg(a.attname)
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = i.indrelid
+			AND a.attnum = ANY((i.indkey::int2[])[0:i.indnkeyatts - 1])
+		WHERE i.indisunique AND i.indpred IS NULL AND i.indexprs IS NULL
+		  AND i.indrelid IN (
+			SELECT con.conrelid
+			FROM pg_constraint con
+			JOIN pg_class c ON c.oid = con.conrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			JOIN pg_class cf ON cf.oid = con.confrelid
+			JOIN pg_namespace nf ON nf.oid = cf.relnamespace
+			WHERE con.contype = 'f' AND (n.nspname = $1 OR nf.nspname = $1)
+		  )
+		GROUP BY i.indexrelid, n.nspname, c.relname
+	`
+
+	rows, err := q.Query(ctx, query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[tableKey][][]string)
+	for rows.Next() {
+		var key tableKey
+		var columns []string
+		if err := rows.Scan(&key.schema, &key.table, &columns); err != nil {
+			return nil, err
+		}
+		keys[key] = append(keys[key], columns)
+	}
+	return keys, rows.Err()
+}
+
+// buildERDEdges annotates each relationship with its cardinality, from the
+// referencing table's unique keys, and whether it's optional, from the
+// nullability of the referencing columns.
+func buildERDEdges(relationships []models.SchemaRelationship, nodes []models.ERDNode, uniqueKeys map[tableKey][][]string) []models.ERDEdge {
+	nullable := make(map[tableKey]map[string]bool, len(nodes))
+	for _, node := range nodes {
+		cols := make(map[string]bool, len(node.Columns))
+		for _, col := range node.Columns {
+			cols[col.Name] = col.IsNullable
+		}
+		nullable[tableKey{node.Schema, node.Table}] = cols
+	}
+
+	edges := make([]models.ERDEdge, 0, len(relationships))
+	for _, rel := range relationships {
+		source := tableKey{rel.SourceSchema, rel.SourceTable}
+		edge := models.ERDEdge{SchemaRelationship: rel, Cardinality: models.CardinalityOneToMany}
+		if columnsUnique(rel.SourceColumns, uniqueKeys[source]) {
+			edge.Cardinality = models.CardinalityOneToOne
+		}
+		for _, col := range rel.SourceColumns {
+			if nullable[source][col] {
+				edge.Optional = true
+			}
+		}
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
+// columnsUnique reports whether values of columns are unique in their
+// table: some unique key is made up only of those columns.
+func columnsUnique(columns []string, keys [][]string) bool {
+	set := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		set[col] = true
+	}
+	for _, key := range keys {
+		covered := len(key) > 0
+		for _, col := range key {
+			if !set[col] {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
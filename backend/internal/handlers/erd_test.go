@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestColumnsUnique(t *testing.T) {
+	keys := [][]string{{"id"}, {"tenant_id", "email"}}
+	tests := []struct {
+		columns []string
+		want    bool
+	}{
+		{[]string{"id"}, true},
+		{[]string{"user_id", "id"}, true}, // a superset of a key is unique too
+		{[]string{"email", "tenant_id"}, true},
+		{[]string{"email"}, false},
+		{[]string{"user_id"}, false},
+	}
+	for _, tt := range tests {
+		if got := columnsUnique(tt.columns, keys); got != tt.want {
+			t.Errorf("columnsUnique(%v) = %v, want %v", tt.columns, got, tt.want)
+		}
+	}
+	if columnsUnique([]string{"id"}, nil) {
+		t.Error("no keys should mean not unique")
+	}
+}
+
+func TestBuildERDEdges(t *testing.T) {
+	nodes := []models.ERDNode{
+		{Schema: "public", Table: "profiles", Columns: []models.ERDColumn{{Name: "user_id"}}},
+		{Schema: "public", Table: "orders", Columns: []models.ERDColumn{{Name: "user_id", IsNullable: true}}},
+	}
+	rels := []models.SchemaRelationship{
+		{SourceSchema: "public", SourceTable: "profiles", SourceColumns: []string{"user_id"}, TargetSchema: "public", TargetTable: "users"},
+		{SourceSchema: "public", SourceTable: "orders", SourceColumns: []string{"user_id"}, TargetSchema: "public", TargetTable: "users"},
+	}
+	keys := map[tableKey][][]string{
+		{"public", "profiles"}: {{"user_id"}},
+		{"public", "orders"}:   {{"id"}},
+	}
+
+	edges := buildERDEdges(rels, nodes, keys)
+	if len(edges) != 2 {
+		t.Fatalf("got %d edges, want 2", len(edges))
+	}
+	if edges[0].Cardinality != models.CardinalityOneToOne || edges[0].Optional {
+		t.Errorf("profiles edge = %+v, want required one-to-one", edges[0])
+	}
+	if edges[1].Cardinality != models.CardinalityOneToMany || !edges[1].Optional {
+		t.Errorf("orders edge = %+v, want optional one-to-many", edges[1])
+	}
+}
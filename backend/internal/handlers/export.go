@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	defaultExportTimeout = 120 * time.Second
+	maxExportTimeout     = time.Hour
+	// exportFlushEvery bounds how many rows sit in the write buffer before
+	// being pushed to the client, so large exports show download progress.
+	exportFlushEvery = 1000
+)
+
+var exportContentTypes = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"json":  "application/json; charset=utf-8",
+	"jsonl": "application/x-ndjson; charset=utf-8",
+}
+
+// exportTextValue renders a value of type oid for a CSV cell. NULL becomes
+// an empty cell; arrays, composite and JSON values are written as JSON so
+// they survive a round-trip; anything with a String method uses it.
+func exportTextValue(v any, oid uint32) string {
+	switch x := convertValue(v, oid).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case fmt.Stringer:
+		return x.String()
+	case json.Marshaler, map[string]any, []any:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprint(x)
+		}
+		return string(b)
+	default:
+		if b, err := json.Marshal(x); err == nil && len(b) > 0 && (b[0] == '[' || b[0] == '{') {
+			return string(b)
+		}
+		return fmt.Sprint(x)
+	}
+}
+
+// writeJSONRow writes a row as a JSON object with keys in column order
+// (encoding a map would sort them).
+func writeJSONRow(w *bufio.Writer, names []string, oids []uint32, values []any) error {
+	w.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		w.Write(key)
+		w.WriteByte(':')
+		val, err := json.Marshal(convertValue(values[i], oids[i]))
+		if err != nil {
+			return err
+		}
+		w.Write(val)
+	}
+	return w.WriteByte('}')
+}
+
+// exportTimeout reads ?timeout=<seconds>, defaulting to 120s and capped at
+// an hour, writing a 400 and returning false when it's invalid.
+func exportTimeout(c *gin.Context) (time.Duration, bool) {
+	t := c.Query("timeout")
+	if t == "" {
+		return defaultExportTimeout, true
+	}
+	secs, err := strconv.Atoi(t)
+	if err != nil || secs <= 0 || time.Duration(secs)*time.Second > maxExportTimeout {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timeout must be between 1 and 3600 seconds"})
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// ExportQuery runs a query and streams the complete result set as CSV, JSON
+// or JSON Lines. Rows are written as pgx yields them, so the export is not
+// capped and never held in memory. The default 120s timeout can be changed
+// with ?timeout=<seconds> (up to an hour). With ?operationId= the rows
+// written so far are reported to WatchProgress subscribers.
+//
+// Errors before the first byte is written come back as JSON; once rows are
+// streaming a failure can only truncate the download.
+func ExportQuery(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.ExportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	contentType, ok := exportContentTypes[req.Format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, json, jsonl"})
+		return
+	}
+
+	timeout, ok := exportTimeout(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlySQL(c, connId, req.SQL) {
+		return
+	}
+
+	// Same reasoning as ExplainQuery: with no params pgx uses the simple
+	// protocol, which would run every statement in the string.
+	if stmts := splitStatements(req.SQL); len(stmts) > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "export accepts a single statement"})
+		return
+	}
+
+	progress, ok := startProgress(c, "export", "rows")
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	rows, err := pool.Query(ctx, req.SQL, req.Params...)
+	if err != nil {
+		progress.finish(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	// Query errors (syntax, permissions) surface on the first Next for the
+	// extended protocol, so peek before committing to a 200.
+	hasRow := rows.Next()
+	if !hasRow && rows.Err() != nil {
+		progress.finish(rows.Err())
+		c.JSON(http.StatusBadRequest, gin.H{"error": rows.Err().Error()})
+		return
+	}
+
+	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	oids := make([]uint32, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		names[i] = fd.Name
+		oids[i] = fd.DataTypeOID
+	}
+
+	filename := fmt.Sprintf("query-%s.%s", time.Now().Format("20060102-150405"), req.Format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	err = streamExport(w, c.Writer, rows, hasRow, names, oids, req.Format, progress)
+	progress.finish(err)
+	if err != nil {
+		// Headers are already sent; all we can do is stop writing.
+		_ = c.Error(err)
+	}
+	w.Flush()
+	c.Writer.Flush()
+}
+
+func streamExport(w *bufio.Writer, flusher http.Flusher, rows pgx.Rows, hasRow bool, names []string, oids []uint32, format string, progress *progressTracker) error {
+	var csvw *csv.Writer
+	switch format {
+	case "csv":
+		csvw = csv.NewWriter(w)
+		if err := csvw.Write(names); err != nil {
+			return err
+		}
+	case "json":
+		w.WriteByte('[')
+	}
+
+	record := make([]string, len(names))
+	n := 0
+	for ; hasRow; hasRow = rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+
+		switch format {
+		case "csv":
+			for i, v := range values {
+				record[i] = exportTextValue(v, oids[i])
+			}
+			if err := csvw.Write(record); err != nil {
+				return err
+			}
+		case "json":
+			if n > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeJSONRow(w, names, oids, values); err != nil {
+				return err
+			}
+		case "jsonl":
+			if err := writeJSONRow(w, names, oids, values); err != nil {
+				return err
+			}
+			w.WriteByte('\n')
+		}
+
+		n++
+		progress.update("writing", int64(n), 0)
+		if n%exportFlushEvery == 0 {
+			if csvw != nil {
+				csvw.Flush()
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if csvw != nil {
+		csvw.Flush()
+		return csvw.Error()
+	}
+	if format == "json" {
+		w.WriteByte(']')
+	}
+	return nil
+}
+
+// placeholderRegex matches the $n bind parameters buildWhereClause emits.
+var placeholderRegex = regexp.MustCompile(`\$([0-9]+)`)
+
+// inlineFilterArgs substitutes a WHERE clause's bind parameters with
+// quoted literals, for COPY, which can't take parameters. The clause holds
+// only validated identifiers besides its placeholders, and the
+// substitution is a single pass, so a value containing "$1" stays text.
+// The literals are untyped, so Postgres infers each from its column just
+// as it does for the parameters.
+func inlineFilterArgs(where string, args []any) (string, error) {
+	var firstErr error
+	out := placeholderRegex.ReplaceAllStringFunc(where, func(m string) string {
+		n, _ := strconv.Atoi(m[1:])
+		if n < 1 || n > len(args) {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("no value for parameter %s", m)
+			}
+			return m
+		}
+		lit, err := dbsafe.QuoteString(fmt.Sprint(args[n-1]))
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("filter value: %w", err)
+		}
+		return lit
+	})
+	return out, firstErr
+}
+
+// copyResponseWriter sends the export headers with COPY's first chunk of
+// output, so a failure before then can still be reported as JSON. It
+// reports the bytes written to progress.
+type copyResponseWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+	progress    *progressTracker
+	written     int64
+}
+
+func (w *copyResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	n, err := w.c.Writer.Write(p)
+	w.written += int64(n)
+	w.progress.update("writing", w.written, 0)
+	return n, err
+}
+
+// ExportTableData streams a whole table as CSV, JSON or JSON Lines, with
+// the same filter and ordering params as GetTableData but no paging. CSV
+// is produced by Postgres itself with COPY (SELECT ...) TO STDOUT, so
+// values use its text forms (arrays as {a,b}, not JSON); the JSON formats
+// iterate the rows like ExportQuery. ?timeout= works as for ExportQuery,
+// and so does ?operationId=, except that CSV progress counts bytes.
+func ExportTableData(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be one of csv, json, jsonl"})
+		return
+	}
+	timeout, ok := exportTimeout(c)
+	if !ok {
+		return
+	}
+
+	whereClause, args, err := tableFilterClause(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := fmt.Sprintf("SELECT * FROM %s.%s%s", quoteIdentifier(schema), quoteIdentifier(table), whereClause)
+	if orderBy := c.Query("orderBy"); orderBy != "" && isValidIdentifier(orderBy) {
+		orderDir := c.DefaultQuery("orderDir", "ASC")
+		if orderDir != "ASC" && orderDir != "DESC" {
+			orderDir = "ASC"
+		}
+		query += fmt.Sprintf(" ORDER BY %s %s", quoteIdentifier(orderBy), orderDir)
+	}
+
+	filename := fmt.Sprintf("%s.%s-%s.%s", schema, table, time.Now().Format("20060102-150405"), format)
+
+	if format == "csv" {
+		query, err = inlineFilterArgs(query, args)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	unit := "rows"
+	if format == "csv" {
+		unit = "bytes"
+	}
+	progress, ok := startProgress(c, "export", unit)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	if format == "csv" {
+		conn, err := pool.Acquire(ctx)
+		if err != nil {
+			progress.finish(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer conn.Release()
+
+		w := &copyResponseWriter{c: c, contentType: contentType, filename: filename, progress: progress}
+		_, err = conn.Conn().PgConn().CopyTo(ctx, w, "COPY ("+query+") TO STDOUT WITH (FORMAT csv, HEADER)")
+		progress.finish(err)
+		if err != nil {
+			if !w.started {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			// Headers are already sent; all we can do is stop writing.
+			_ = c.Error(err)
+		}
+		c.Writer.Flush()
+		return
+	}
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		progress.finish(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	hasRow := rows.Next()
+	if !hasRow && rows.Err() != nil {
+		progress.finish(rows.Err())
+		c.JSON(http.StatusBadRequest, gin.H{"error": rows.Err().Error()})
+		return
+	}
+
+	fieldDescs := rows.FieldDescriptions()
+	names := make([]string, len(fieldDescs))
+	oids := make([]uint32, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		names[i] = fd.Name
+		oids[i] = fd.DataTypeOID
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	err = streamExport(w, c.Writer, rows, hasRow, names, oids, format, progress)
+	progress.finish(err)
+	if err != nil {
+		_ = c.Error(err)
+	}
+	w.Flush()
+	c.Writer.Flush()
+}
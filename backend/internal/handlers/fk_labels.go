@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// fkLabelTarget is one referenced table and, per referenced column, the
+// foreign-key columns pointing at it and the values seen on the page.
+type fkLabelTarget struct {
+	schema, table string
+	refColumns    []string
+	fkColumns     map[string][]string        // ref column -> FK columns
+	values        map[string]map[string]bool // ref column -> value keys
+}
+
+// fkLabelKey renders an FK value as the key fkLabels uses, reporting false
+// for types that don't make sensible keys.
+func fkLabelKey(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case int16:
+		return strconv.FormatInt(int64(x), 10), true
+	case int32:
+		return strconv.FormatInt(int64(x), 10), true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case [16]byte:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", x[0:4], x[4:6], x[6:8], x[8:10], x[10:16]), true
+	}
+	return "", false
+}
+
+// collectFKValues groups the page's distinct foreign-key values by
+// referenced table, in a stable order.
+func collectFKValues(columns []models.ColumnInfo, rows []map[string]any) []*fkLabelTarget {
+	byTable := make(map[string]*fkLabelTarget)
+	var targets []*fkLabelTarget
+	for _, col := range columns {
+		ref := col.FKReference
+		if ref == nil {
+			continue
+		}
+		key := ref.Schema + "." + ref.Table
+		t, ok := byTable[key]
+		if !ok {
+			t = &fkLabelTarget{
+				schema:    ref.Schema,
+				table:     ref.Table,
+				fkColumns: make(map[string][]string),
+				values:    make(map[string]map[string]bool),
+			}
+			byTable[key] = t
+			targets = append(targets, t)
+		}
+		if _, seen := t.values[ref.Column]; !seen {
+			t.refColumns = append(t.refColumns, ref.Column)
+			t.values[ref.Column] = make(map[string]bool)
+		}
+		t.fkColumns[ref.Column] = append(t.fkColumns[ref.Column], col.Name)
+		for _, row := range rows {
+			if v, ok := fkLabelKey(row[col.Name]); ok {
+				t.values[ref.Column][v] = true
+			}
+		}
+	}
+	return targets
+}
+
+// buildFKLabelQuery builds one query returning (ref column index, key,
+// label) for every referenced column of a table. Branch i joins the keys
+// in $i+1 (a text[]) to the table, cast to the column's type so an index
+// on it can be used.
+func buildFKLabelQuery(schema, table, labelColumn string, refColumns, refTypes []string) string {
+	branches := make([]string, len(refColumns))
+	for i, col := range refColumns {
+		branches[i] = fmt.Sprintf(
+			"(SELECT DISTINCT ON (k.key) %d, k.key, t.%s::text FROM unnest($%d::text[]) AS k(key) JOIN %s.%s t ON t.%s = k.key::%s WHERE t.%s IS NOT NULL)",
+			i, quoteIdentifier(labelColumn), i+1, quoteIdentifier(schema), quoteIdentifier(table),
+			quoteIdentifier(col), refTypes[i], quoteIdentifier(labelColumn),
+		)
+	}
+	return strings.Join(branches, "\nUNION ALL\n")
+}
+
+// fkLabelColumns looks up a table's first text-type column, used as the
+// human-readable label, and the types of the given columns.
+func fkLabelColumns(ctx context.Context, q queryRunner, schema, table string, columns []string) (string, []string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod), t.typcategory = 'S'
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1
+		  AND c.relname = $2
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`, schema, table)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	label := ""
+	typeOf := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		var isText bool
+		if err := rows.Scan(&name, &typ, &isText); err != nil {
+			return "", nil, err
+		}
+		if isText && label == "" {
+			label = name
+		}
+		typeOf[name] = typ
+	}
+	if err := rows.Err(); err != nil {
+		return "", nil, err
+	}
+
+	types := make([]string, len(columns))
+	for i, col := range columns {
+		if types[i] = typeOf[col]; types[i] == "" {
+			return "", nil, fmt.Errorf("column %s not found in %s.%s", col, schema, table)
+		}
+	}
+	return label, types, nil
+}
+
+// loadFKLabels fetches a label for each foreign-key value on the page, one
+// query per referenced table, as FK column -> value -> label. Referenced
+// tables without a text column, or that can't be read, are left out.
+func loadFKLabels(ctx context.Context, q queryRunner, columns []models.ColumnInfo, rows []map[string]any) map[string]map[string]string {
+	labels := make(map[string]map[string]string)
+	for _, t := range collectFKValues(columns, rows) {
+		args := make([]any, len(t.refColumns))
+		empty := true
+		for i, col := range t.refColumns {
+			keys := make([]string, 0, len(t.values[col]))
+			for k := range t.values[col] {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			args[i] = keys
+			empty = empty && len(keys) == 0
+		}
+		if empty {
+			continue
+		}
+
+		labelColumn, types, err := fkLabelColumns(ctx, q, t.schema, t.table, t.refColumns)
+		if err != nil {
+			log.Printf("fk labels: %s.%s: %v", t.schema, t.table, err)
+			continue
+		}
+		if labelColumn == "" {
+			continue
+		}
+
+		result, err := q.Query(ctx, buildFKLabelQuery(t.schema, t.table, labelColumn, t.refColumns, types), args...)
+		if err != nil {
+			log.Printf("fk labels: %s.%s: %v", t.schema, t.table, err)
+			continue
+		}
+		for result.Next() {
+			var index int
+			var key, label string
+			if err := result.Scan(&index, &key, &label); err != nil {
+				log.Printf("fk labels: %s.%s: %v", t.schema, t.table, err)
+				break
+			}
+			for _, fkCol := range t.fkColumns[t.refColumns[index]] {
+				if labels[fkCol] == nil {
+					labels[fkCol] = make(map[string]string)
+				}
+				labels[fkCol][key] = label
+			}
+		}
+		if err := result.Err(); err != nil {
+			log.Printf("fk labels: %s.%s: %v", t.schema, t.table, err)
+		}
+		result.Close()
+	}
+	return labels
+}
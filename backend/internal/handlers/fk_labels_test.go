@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestFKLabelKey(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+		ok   bool
+	}{
+		{"abc", "abc", true},
+		{int32(42), "42", true},
+		{int64(-7), "-7", true},
+		{[16]byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}, "12345678-9abc-def0-1234-56789abcdef0", true},
+		{nil, "", false},
+		{3.5, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := fkLabelKey(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("fkLabelKey(%v) = (%q, %v), want (%q, %v)", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCollectFKValues(t *testing.T) {
+	columns := []models.ColumnInfo{
+		{Name: "id"},
+		{Name: "created_by", FKReference: &models.FKRef{Schema: "public", Table: "users", Column: "id"}},
+		{Name: "updated_by", FKReference: &models.FKRef{Schema: "public", Table: "users", Column: "id"}},
+		{Name: "team", FKReference: &models.FKRef{Schema: "public", Table: "teams", Column: "code"}},
+	}
+	rows := []map[string]any{
+		{"id": int32(1), "created_by": int32(10), "updated_by": int32(11), "team": "ops"},
+		{"id": int32(2), "created_by": int32(10), "updated_by": nil, "team": "ops"},
+	}
+
+	targets := collectFKValues(columns, rows)
+	if len(targets) != 2 || targets[0].table != "users" || targets[1].table != "teams" {
+		t.Fatalf("unexpected targets %+v", targets)
+	}
+	users := targets[0]
+	if len(users.refColumns) != 1 || len(users.fkColumns["id"]) != 2 {
+		t.Errorf("users: refColumns %v, fkColumns %v", users.refColumns, users.fkColumns)
+	}
+	if v := users.values["id"]; len(v) != 2 || !v["10"] || !v["11"] {
+		t.Errorf("users values = %v, want 10 and 11", v)
+	}
+	if v := targets[1].values["code"]; len(v) != 1 || !v["ops"] {
+		t.Errorf("teams values = %v, want ops", v)
+	}
+}
+
+func TestBuildFKLabelQuery(t *testing.T) {
+	got := buildFKLabelQuery("public", "users", "name", []string{"id", "email"}, []string{"integer", "text"})
+	want := `(SELECT DISTINCT ON (k.key) 0, k.key, t."name"::text FROM unnest($1::text[]) AS k(key) JOIN "public"."users" t ON t."id" = k.key::integer WHERE t."name" IS NOT NULL)` +
+		"\nUNION ALL\n" +
+		`(SELECT DISTINCT ON (k.key) 1, k.key, t."name"::text FROM unnest($2::text[]) AS k(key) JOIN "public"."users" t ON t."email" = k.key::text WHERE t."name" IS NOT NULL)`
+	if got != want {
+		t.Errorf("buildFKLabelQuery =\n%s\nwant\n%s", got, want)
+	}
+}
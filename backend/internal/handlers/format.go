@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+type formatTokenKind int
+
+const (
+	fmtWord    formatTokenKind = iota // keyword or bare identifier
+	fmtLiteral                        // string, quoted identifier, dollar-quoted body
+	fmtNumber
+	fmtParam // $1
+	fmtLineComment
+	fmtBlockComment
+	fmtOperator
+	fmtPunct // ( ) , . ; [ ]
+)
+
+// formatToken is one token of SQL being formatted, with its text exactly
+// as written.
+type formatToken struct {
+	Kind formatTokenKind
+	Text string
+}
+
+// operatorChars are the characters Postgres builds operators from.
+const operatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// tokenizeForFormat splits sql into tokens without losing anything but
+// whitespace: literals, quoted identifiers, dollar-quoted bodies and
+// comments come through verbatim.
+func tokenizeForFormat(sql string) []formatToken {
+	var tokens []formatToken
+	rs := []rune(sql)
+	n := len(rs)
+	emit := func(kind formatTokenKind, from, to int) {
+		tokens = append(tokens, formatToken{Kind: kind, Text: string(rs[from:to])})
+	}
+	// quoted scans a ' or " section starting at i, returning its end; with
+	// backslash set (E'' strings) a backslash escapes the next rune.
+	quoted := func(i int, backslash bool) int {
+		q := rs[i]
+		i++
+		for i < n {
+			switch {
+			case backslash && rs[i] == '\\':
+				i += 2
+				continue
+			case rs[i] == q && i+1 < n && rs[i+1] == q:
+				i += 2
+				continue
+			case rs[i] == q:
+				return i + 1
+			}
+			i++
+		}
+		return n
+	}
+
+	for i := 0; i < n; {
+		ch := rs[i]
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '-' && i+1 < n && rs[i+1] == '-':
+			j := i
+			for j < n && rs[j] != '\n' {
+				j++
+			}
+			emit(fmtLineComment, i, j)
+			i = j
+		case ch == '/' && i+1 < n && rs[i+1] == '*':
+			// Block comments nest in Postgres.
+			j, depth := i+2, 1
+			for j < n && depth > 0 {
+				switch {
+				case rs[j] == '/' && j+1 < n && rs[j+1] == '*':
+					depth++
+					j += 2
+				case rs[j] == '*' && j+1 < n && rs[j+1] == '/':
+					depth--
+					j += 2
+				default:
+					j++
+				}
+			}
+			emit(fmtBlockComment, i, min(j, n))
+			i = min(j, n)
+		case ch == '\'' || ch == '"':
+			j := quoted(i, false)
+			emit(fmtLiteral, i, j)
+			i = j
+		case ch == '$':
+			j := i + 1
+			for j < n && (rs[j] == '_' || unicode.IsLetter(rs[j]) || (j > i+1 && unicode.IsDigit(rs[j]))) {
+				j++
+			}
+			if j < n && rs[j] == '$' {
+				tag := string(rs[i : j+1])
+				end := n
+				if k := strings.Index(string(rs[j+1:]), tag); k >= 0 {
+					end = j + 1 + len([]rune(string(rs[j+1:])[:k])) + len([]rune(tag))
+				}
+				emit(fmtLiteral, i, end)
+				i = end
+				continue
+			}
+			for j < n && unicode.IsDigit(rs[j]) {
+				j++
+			}
+			emit(fmtParam, i, j)
+			i = j
+		case ch == '_' || unicode.IsLetter(ch):
+			j := i
+			for j < n && (rs[j] == '_' || rs[j] == '$' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+			// E'...', B'...' and X'...' are one literal.
+			if j == i+1 && j < n && rs[j] == '\'' && strings.ContainsRune("EeBbXx", ch) {
+				end := quoted(j, ch == 'E' || ch == 'e')
+				emit(fmtLiteral, i, end)
+				i = end
+				continue
+			}
+			// So are U&'...' and U&"...".
+			if j == i+1 && (ch == 'U' || ch == 'u') && j+1 < n && rs[j] == '&' && (rs[j+1] == '\'' || rs[j+1] == '"') {
+				end := quoted(j+1, false)
+				emit(fmtLiteral, i, end)
+				i = end
+				continue
+			}
+			emit(fmtWord, i, j)
+			i = j
+		case unicode.IsDigit(ch) || (ch == '.' && i+1 < n && unicode.IsDigit(rs[i+1])):
+			j := i
+			for j < n && (unicode.IsDigit(rs[j]) || rs[j] == '.' || rs[j] == '_' || unicode.IsLetter(rs[j]) ||
+				((rs[j] == '+' || rs[j] == '-') && (rs[j-1] == 'e' || rs[j-1] == 'E'))) {
+				j++
+			}
+			emit(fmtNumber, i, j)
+			i = j
+		case strings.ContainsRune("(),.;[]", ch):
+			emit(fmtPunct, i, i+1)
+			i++
+		case strings.ContainsRune(operatorChars, ch):
+			j := i
+			for j < n && strings.ContainsRune(operatorChars, rs[j]) &&
+				!(rs[j] == '-' && j+1 < n && rs[j+1] == '-') &&
+				!(rs[j] == '/' && j+1 < n && rs[j+1] == '*') {
+				j++
+			}
+			emit(fmtOperator, i, j)
+			i = j
+		default:
+			emit(fmtOperator, i, i+1)
+			i++
+		}
+	}
+	return tokens
+}
+
+// formatKeywords are the words whose case the formatter normalizes; any
+// other word is taken to be a name and left as written.
+var formatKeywords = toSet(
+	"ADD", "ALL", "ALTER", "ANALYZE", "AND", "ANY", "ARRAY", "AS", "ASC", "BEGIN",
+	"BETWEEN", "BY", "CASCADE", "CASE", "CAST", "CHECK", "COLUMN", "COMMIT", "CONFLICT",
+	"CONSTRAINT", "CREATE", "CROSS", "CURRENT_DATE", "CURRENT_TIMESTAMP", "DEFAULT",
+	"DELETE", "DESC", "DISTINCT", "DO", "DROP", "ELSE", "END", "EXCEPT", "EXISTS",
+	"EXPLAIN", "FALSE", "FETCH", "FILTER", "FIRST", "FOR", "FOREIGN", "FROM", "FULL",
+	"FUNCTION", "GRANT", "GROUP", "HAVING", "ILIKE", "IF", "IN", "INDEX", "INNER",
+	"INSERT", "INTERSECT", "INTO", "IS", "JOIN", "KEY", "LANGUAGE", "LAST", "LATERAL", "LEFT",
+	"LIKE", "LIMIT", "MATERIALIZED", "NATURAL", "NOT", "NOTHING", "NULL", "NULLS",
+	"OFFSET", "ON", "ONLY", "OR", "ORDER", "OUTER", "OVER", "PARTITION", "PRIMARY",
+	"RECURSIVE", "REFERENCES", "RETURNING", "RETURNS", "REVOKE", "RIGHT", "ROLLBACK", "ROW", "ROWS",
+	"SCHEMA", "SELECT", "SET", "SIMILAR", "TABLE", "THEN", "TIME", "TO", "TRUE", "TRUNCATE",
+	"UNION", "UNIQUE", "UPDATE", "USING", "VALUES", "VIEW", "WHEN", "WHERE", "WINDOW",
+	"WITH", "WITHIN", "ZONE",
+)
+
+// callKeywords are keywords written like functions, without a space
+// before their parenthesis.
+var callKeywords = toSet("ANY", "CAST", "LEFT", "RIGHT")
+
+// clauseKeywords start a new line at their query's indent.
+var clauseKeywords = toSet(
+	"SELECT", "FROM", "WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET",
+	"FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "VALUES", "SET", "RETURNING", "WITH",
+	"INSERT", "UPDATE", "DELETE", "JOIN", "LEFT", "RIGHT", "FULL", "INNER", "CROSS",
+	"NATURAL",
+)
+
+// listClauses put each top-level comma-separated item on its own line.
+var listClauses = toSet("SELECT", "GROUP", "ORDER", "SET", "RETURNING", "VALUES", "WITH")
+
+// conditionClauses put each top-level AND/OR on its own line.
+var conditionClauses = toSet("WHERE", "HAVING", "ON")
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// formatFrame is a parenthesized level. Query frames (the top level and
+// subqueries) get clause layout; others, such as argument lists, are
+// written inline.
+type formatFrame struct {
+	query  bool
+	indent int
+	clause string
+	// between is set after BETWEEN, so its AND stays inline.
+	between bool
+}
+
+// queryStarts are the first words of statements laid out as queries. Other
+// statements (DDL and the like) are written inline, apart from a query
+// after AS (CREATE VIEW ... AS SELECT) and parenthesized subqueries.
+var queryStarts = toSet("SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "VALUES", "EXPLAIN", "TABLE")
+
+// sqlFormatter lays out one statement's tokens.
+type sqlFormatter struct {
+	out         bytes.Buffer
+	indentUnit  string
+	keywordCase string
+	frames      []*formatFrame
+	// lineStart is set after a newline; lineAt is where that line begins.
+	lineStart bool
+	lineAt    int
+	prev      *formatToken
+	prev2     *formatToken // the token before prev
+	// unaryPrev is set when the last token was a sign rather than an
+	// operator, so no space follows it.
+	unaryPrev bool
+}
+
+func (f *sqlFormatter) frame() *formatFrame { return f.frames[len(f.frames)-1] }
+
+// newline starts a line at indent, or re-indents the current line if
+// nothing has been written on it yet.
+func (f *sqlFormatter) newline(indent int) {
+	if f.out.Len() == 0 {
+		return
+	}
+	if f.lineStart {
+		f.out.Truncate(f.lineAt)
+	} else {
+		f.out.WriteByte('\n')
+		f.lineAt = f.out.Len()
+	}
+	f.out.WriteString(strings.Repeat(f.indentUnit, indent))
+	f.lineStart = true
+}
+
+func (f *sqlFormatter) write(s string, spaceBefore bool) {
+	if spaceBefore && !f.lineStart && f.out.Len() > 0 {
+		f.out.WriteByte(' ')
+	}
+	f.out.WriteString(s)
+	f.lineStart = false
+}
+
+func (f *sqlFormatter) keyword(word string) string {
+	switch f.keywordCase {
+	case "lower":
+		return strings.ToLower(word)
+	case "preserve":
+		return word
+	}
+	return strings.ToUpper(word)
+}
+
+// startsQuery reports whether the token after i opens a query.
+func startsQuery(tokens []formatToken, i int) bool {
+	for j := i + 1; j < len(tokens); j++ {
+		switch tokens[j].Kind {
+		case fmtLineComment, fmtBlockComment:
+			continue
+		case fmtWord:
+			w := strings.ToUpper(tokens[j].Text)
+			return w == "SELECT" || w == "WITH" || w == "VALUES"
+		}
+		return false
+	}
+	return false
+}
+
+// needsSpace decides the spacing between the previous token and t.
+func (f *sqlFormatter) needsSpace(t formatToken) bool {
+	p := f.prev
+	if p == nil {
+		return false
+	}
+	switch {
+	case t.Kind == fmtPunct && strings.Contains(",.;)]", t.Text):
+		return false
+	case p.Kind == fmtPunct && strings.Contains("(.[", p.Text):
+		return false
+	case t.Kind == fmtPunct && t.Text == "[":
+		return false
+	case t.Kind == fmtOperator && t.Text == "::", p.Kind == fmtOperator && p.Text == "::":
+		return false
+	case t.Kind == fmtOperator && t.Text == ":", p.Kind == fmtOperator && p.Text == ":":
+		return false
+	case t.Kind == fmtPunct && t.Text == "(":
+		// A function call or type modifier hugs its name; a keyword
+		// (IN, VALUES, AS, ...) or the table of INSERT INTO t (...) is
+		// followed by a space.
+		if p.Kind != fmtWord {
+			return true
+		}
+		if upper := strings.ToUpper(p.Text); formatKeywords[upper] {
+			return !callKeywords[upper]
+		}
+		return f.prev2 != nil && strings.EqualFold(f.prev2.Text, "INTO")
+	case p.Kind == fmtOperator && (p.Text == "-" || p.Text == "+") && f.unaryPrev:
+		return false
+	}
+	return true
+}
+
+// formatStatement formats one statement's tokens.
+func formatStatement(tokens []formatToken, indentUnit, keywordCase string) string {
+	f := &sqlFormatter{
+		indentUnit:  indentUnit,
+		keywordCase: keywordCase,
+		frames:      []*formatFrame{{query: queryStarts[nextWord(tokens, -1)]}},
+	}
+
+	for i := range tokens {
+		t := tokens[i]
+		fr := f.frame()
+
+		// After a line comment the next token must start a new line.
+		if f.prev != nil && f.prev.Kind == fmtLineComment && !f.lineStart {
+			f.newline(fr.indent + boolInt(fr.clause != ""))
+		}
+
+		switch t.Kind {
+		case fmtWord:
+			upper := strings.ToUpper(t.Text)
+			text := t.Text
+			if formatKeywords[upper] {
+				text = f.keyword(t.Text)
+			}
+			if !fr.query && len(f.frames) == 1 && (upper == "SELECT" || upper == "WITH") &&
+				f.prev != nil && strings.EqualFold(f.prev.Text, "AS") {
+				fr.query = true
+			}
+			isFunc := i+1 < len(tokens) && tokens[i+1].Text == "(" && (upper == "LEFT" || upper == "RIGHT")
+			if fr.query && clauseKeywords[upper] && !isFunc && !f.continuesClause(upper) {
+				f.newline(fr.indent)
+				fr.clause = upper
+				fr.between = false
+				if upper == "JOIN" || upper == "LEFT" || upper == "RIGHT" || upper == "FULL" ||
+					upper == "INNER" || upper == "CROSS" || upper == "NATURAL" {
+					fr.clause = "JOIN"
+				}
+			} else if fr.query && upper == "ON" && fr.clause == "JOIN" {
+				fr.clause = "ON"
+			} else if fr.query && conditionClauses[fr.clause] && (upper == "AND" || upper == "OR") {
+				if upper == "AND" && fr.between {
+					fr.between = false
+				} else {
+					f.newline(fr.indent + 1)
+				}
+			} else if upper == "BETWEEN" {
+				fr.between = true
+			}
+			f.write(text, f.needsSpace(t))
+			if fr.query && (upper == "UNION" || upper == "INTERSECT" || upper == "EXCEPT") {
+				if next := nextWord(tokens, i); next != "ALL" && next != "DISTINCT" {
+					fr.clause = ""
+				}
+			}
+
+		case fmtPunct:
+			switch t.Text {
+			case "[":
+				f.write("[", f.needsSpace(t))
+				f.frames = append(f.frames, &formatFrame{indent: fr.indent})
+			case "]":
+				if len(f.frames) > 1 {
+					f.frames = f.frames[:len(f.frames)-1]
+				}
+				f.write("]", false)
+			case "(":
+				f.write("(", f.needsSpace(t))
+				if startsQuery(tokens, i) {
+					f.frames = append(f.frames, &formatFrame{query: true, indent: fr.indent + 1})
+					f.newline(fr.indent + 1)
+				} else {
+					f.frames = append(f.frames, &formatFrame{indent: fr.indent})
+				}
+			case ")":
+				if len(f.frames) > 1 {
+					closing := f.frame()
+					f.frames = f.frames[:len(f.frames)-1]
+					if closing.query {
+						f.newline(f.frame().indent)
+					}
+				}
+				f.write(")", f.needsSpace(t))
+			case ",":
+				f.write(",", false)
+				if fr.query && listClauses[fr.clause] {
+					f.newline(fr.indent + boolInt(fr.clause != "WITH"))
+				}
+			default:
+				f.write(t.Text, f.needsSpace(t))
+			}
+
+		case fmtOperator:
+			space := f.needsSpace(t)
+			f.write(t.Text, space)
+			f.unaryPrev = (t.Text == "-" || t.Text == "+") && f.isOperandStart()
+
+		default:
+			f.write(t.Text, f.needsSpace(t))
+		}
+
+		if t.Kind != fmtOperator {
+			f.unaryPrev = false
+		}
+		f.prev2 = f.prev
+		f.prev = &tokens[i]
+	}
+	return f.out.String()
+}
+
+// continuesClause reports whether a clause keyword carries on the current
+// line instead: the JOIN of LEFT JOIN, the FROM of DELETE FROM and IS
+// DISTINCT FROM, the UPDATE of DO UPDATE and FOR UPDATE, the GROUP of
+// WITHIN GROUP, and a WITH that isn't a CTE (WITH TIME ZONE).
+func (f *sqlFormatter) continuesClause(word string) bool {
+	if f.prev == nil || f.prev.Kind != fmtWord {
+		return false
+	}
+	prev := strings.ToUpper(f.prev.Text)
+	switch word {
+	case "JOIN":
+		return prev == "LEFT" || prev == "RIGHT" || prev == "FULL" || prev == "INNER" ||
+			prev == "CROSS" || prev == "NATURAL" || prev == "OUTER"
+	case "LEFT", "RIGHT", "FULL", "INNER", "CROSS":
+		return prev == "NATURAL"
+	case "FROM":
+		return prev == "DELETE" || prev == "DISTINCT"
+	case "UPDATE":
+		return prev == "DO" || prev == "FOR" || prev == "KEY"
+	case "GROUP":
+		return prev == "WITHIN"
+	case "WITH":
+		return prev != "AS"
+	}
+	return false
+}
+
+// isOperandStart reports whether the token before the operator just
+// written could not end an operand, making a following +/- a sign.
+func (f *sqlFormatter) isOperandStart() bool {
+	p := f.prev
+	if p == nil {
+		return true
+	}
+	switch p.Kind {
+	case fmtOperator:
+		return true
+	case fmtPunct:
+		return p.Text == "(" || p.Text == "," || p.Text == "["
+	case fmtWord:
+		return formatKeywords[strings.ToUpper(p.Text)] && !isValueKeyword(p.Text)
+	}
+	return false
+}
+
+// isValueKeyword reports keywords that are values themselves, after which
+// +/- is binary.
+func isValueKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "NULL", "TRUE", "FALSE", "CURRENT_DATE", "CURRENT_TIMESTAMP", "END":
+		return true
+	}
+	return false
+}
+
+func nextWord(tokens []formatToken, i int) string {
+	for j := i + 1; j < len(tokens); j++ {
+		switch tokens[j].Kind {
+		case fmtLineComment, fmtBlockComment:
+			continue
+		case fmtWord:
+			return strings.ToUpper(tokens[j].Text)
+		}
+		return ""
+	}
+	return ""
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// formatSQL pretty-prints a script: keywords in keywordCase ("upper",
+// "lower" or "preserve"), one clause per line, list items and AND/OR
+// conditions on their own indented lines, subqueries indented. Statements
+// are split on the semicolons the tokenizer finds rather than by
+// splitStatements, so one inside a comment or a dollar-quoted function
+// body doesn't cut a statement short. A script that doesn't end with a
+// semicolon doesn't get one, so formatting a selection adds nothing.
+func formatSQL(sql string, indent int, keywordCase string) string {
+	unit := strings.Repeat(" ", indent)
+
+	var statements []string
+	var current []formatToken
+	endsWithSemicolon := false
+	flush := func() {
+		if len(current) > 0 {
+			end := ";"
+			// After a line comment, a semicolon on the same line would be
+			// commented out.
+			if current[len(current)-1].Kind == fmtLineComment {
+				end = "\n;"
+			}
+			statements = append(statements, formatStatement(current, unit, keywordCase)+end)
+		}
+		current = nil
+	}
+	for _, t := range tokenizeForFormat(sql) {
+		switch {
+		case t.Kind == fmtPunct && t.Text == ";":
+			flush()
+			endsWithSemicolon = true
+			continue
+		case t.Kind != fmtLineComment && t.Kind != fmtBlockComment:
+			endsWithSemicolon = false
+		}
+		current = append(current, t)
+	}
+	flush()
+
+	if len(statements) > 0 && !endsWithSemicolon {
+		last := len(statements) - 1
+		statements[last] = strings.TrimSuffix(strings.TrimSuffix(statements[last], ";"), "\n")
+	}
+	return strings.Join(statements, "\n\n")
+}
+
+// FormatSQL pretty-prints SQL for the editor. It needs no connection.
+func FormatSQL(c *gin.Context) {
+	var req models.FormatRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Indent == 0 {
+		req.Indent = 2
+	}
+	if req.KeywordCase == "" {
+		req.KeywordCase = "upper"
+	}
+
+	c.JSON(http.StatusOK, models.FormatResponse{SQL: formatSQL(req.SQL, req.Indent, req.KeywordCase)})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import "testing"
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		name, sql, want string
+	}{
+		{
+			name: "clauses, lists and conditions",
+			sql:  "select a, b.c as x, count(*) from users u left join orders o on o.user_id = u.id and o.total > -5 where u.id between 1 and 10 and (u.name like 'a%' or u.x is null) order by 1 desc limit 10",
+			want: `SELECT a,
+  b.c AS x,
+  count(*)
+FROM users u
+LEFT JOIN orders o ON o.user_id = u.id
+  AND o.total > -5
+WHERE u.id BETWEEN 1 AND 10
+  AND (u.name LIKE 'a%' OR u.x IS NULL)
+ORDER BY 1 DESC
+LIMIT 10`,
+		},
+		{
+			name: "subquery and literals kept verbatim",
+			sql:  "select * from t where id in (select id from s where x = 'where;from') and y = any($1::int[]); insert into t (a, b) values (1, 'it''s'), (2, E'\\n;') returning id;",
+			want: `SELECT *
+FROM t
+WHERE id IN (
+  SELECT id
+  FROM s
+  WHERE x = 'where;from'
+)
+  AND y = ANY($1::int[]);
+
+INSERT INTO t (a, b)
+VALUES (1, 'it''s'),
+  (2, E'\n;')
+RETURNING id;`,
+		},
+		{
+			name: "dollar quotes and comments",
+			sql:  "-- make f\ncreate function f() returns int as $$ begin return 1; end $$ language sql; select /* one */ 1",
+			want: `-- make f
+CREATE FUNCTION f() RETURNS int AS $$ begin return 1; end $$ LANGUAGE sql;
+
+SELECT /* one */ 1`,
+		},
+		{
+			name: "semicolon after a trailing line comment",
+			sql:  "select 1 -- one\n; select 2 -- two",
+			want: "SELECT 1 -- one\n;\n\nSELECT 2 -- two",
+		},
+		{
+			name: "unicode escape literals",
+			sql:  "select U&'d\\0061t;a' from U&\"t\\0061\"",
+			want: "SELECT U&'d\\0061t;a'\nFROM U&\"t\\0061\"",
+		},
+		{
+			name: "ctes and set operations",
+			sql:  "with a as (select 1), b as (select 2) select * from a union all select * from b",
+			want: `WITH a AS (
+  SELECT 1
+),
+b AS (
+  SELECT 2
+)
+SELECT *
+FROM a
+UNION ALL
+SELECT *
+FROM b`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSQL(tt.sql, 2, "upper"); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSQLKeywordCase(t *testing.T) {
+	sql := "Select Name From Users Where ID = 1"
+	if got, want := formatSQL(sql, 4, "lower"), "select Name\nfrom Users\nwhere ID = 1"; got != want {
+		t.Errorf("lower: got %q, want %q", got, want)
+	}
+	if got, want := formatSQL(sql, 4, "preserve"), "Select Name\nFrom Users\nWhere ID = 1"; got != want {
+		t.Errorf("preserve: got %q, want %q", got, want)
+	}
+}
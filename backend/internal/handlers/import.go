@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+const (
+	// importBatchSize is how many records go into each INSERT.
+	importBatchSize = 1000
+	// maxImportErrors bounds the per-line errors echoed back.
+	maxImportErrors = 100
+)
+
+// ndjsonBatch accumulates validated records for one INSERT.
+type ndjsonBatch struct {
+	payload   bytes.Buffer // JSON array of the raw records
+	keys      map[string]bool
+	count     int
+	firstLine int
+	lastLine  int
+}
+
+func (b *ndjsonBatch) add(line int, raw []byte, record map[string]json.RawMessage) {
+	if b.count == 0 {
+		b.payload.Reset()
+		b.payload.WriteByte('[')
+		b.keys = make(map[string]bool)
+		b.firstLine = line
+	} else {
+		b.payload.WriteByte(',')
+	}
+	b.payload.Write(raw)
+	for k := range record {
+		b.keys[k] = true
+	}
+	b.count++
+	b.lastLine = line
+}
+
+// parseNDJSONRecord decodes one line and checks every key names a column
+// of the target table.
+func parseNDJSONRecord(raw []byte, columns map[string]bool) (map[string]json.RawMessage, error) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &record); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, errors.New("record is not a JSON object")
+		}
+		return nil, fmt.Errorf("malformed JSON: %v", err)
+	}
+	if record == nil {
+		return nil, errors.New("record is not a JSON object")
+	}
+	if len(record) == 0 {
+		return nil, errors.New("record has no fields")
+	}
+	for key := range record {
+		if !columns[key] {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
+	}
+	return record, nil
+}
+
+// tableColumnNames returns the table's insertable columns in order.
+func tableColumnNames(ctx context.Context, q queryRunner, schema, table string) ([]string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relname = $2
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// insertNDJSONBatch inserts a batch with json_populate_recordset, so
+// Postgres does the JSON-to-column type conversion exactly as it would for
+// a literal. Only keys present in the batch are listed; other columns get
+// their defaults, and a record missing a listed key gets NULL.
+func insertNDJSONBatch(ctx context.Context, tx pgx.Tx, schema, table string, columns []string, batch *ndjsonBatch) (int64, error) {
+	var cols []string
+	for _, col := range columns {
+		if batch.keys[col] {
+			cols = append(cols, quoteIdentifier(col))
+		}
+	}
+	target := quoteIdentifier(schema) + "." + quoteIdentifier(table)
+	colList := strings.Join(cols, ", ")
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM json_populate_recordset(NULL::%s, $1::json)",
+		target, colList, colList, target,
+	)
+
+	batch.payload.WriteByte(']')
+	tag, err := tx.Exec(ctx, query, batch.payload.String())
+	batch.count = 0
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ImportNDJSON inserts newline-delimited JSON objects from the request body
+// into a table, one object per row with keys matching column names.
+//
+// A malformed line (bad JSON, not an object, unknown column) aborts the
+// whole import unless ?continueOnError=true, in which case it is skipped
+// and listed in the response. Errors Postgres raises on insert (type
+// conversion, constraints) always abort, reporting the batch's line range.
+// Nothing is committed unless the import succeeds.
+func ImportNDJSON(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "importing rows") {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+	continueOnError := c.Query("continueOnError") == "true"
+
+	columns, err := tableColumnNames(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(columns) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
+	columnSet := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		columnSet[col] = true
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	start := time.Now()
+	result := models.ImportResult{}
+	fail := func(status int) {
+		result.Success = false
+		result.Inserted = 0
+		result.Duration = time.Since(start).Seconds() * 1000
+		c.JSON(status, result)
+	}
+	addError := func(line int, err error) {
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, models.ImportLineError{Line: line, Error: err.Error()})
+		} else {
+			result.ErrorsTruncated = true
+		}
+	}
+
+	var batch ndjsonBatch
+	flush := func() bool {
+		if batch.count == 0 {
+			return true
+		}
+		first, last := batch.firstLine, batch.lastLine
+		n, err := insertNDJSONBatch(ctx, tx, schema, table, columns, &batch)
+		if err != nil {
+			addError(first, fmt.Errorf("insert of lines %d-%d failed: %v", first, last, err))
+			fail(http.StatusBadRequest)
+			return false
+		}
+		result.Inserted += n
+		return true
+	}
+
+	reader := bufio.NewReader(c.Request.Body)
+	for line := 1; ; line++ {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			if security.AbortIfBodyTooLarge(c, readErr) {
+				return
+			}
+			addError(line, fmt.Errorf("reading request body: %v", readErr))
+			fail(http.StatusBadRequest)
+			return
+		}
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 {
+			record, err := parseNDJSONRecord(raw, columnSet)
+			if err != nil {
+				addError(line, err)
+				if !continueOnError {
+					fail(http.StatusBadRequest)
+					return
+				}
+				result.Skipped++
+			} else {
+				batch.add(line, raw, record)
+				if batch.count >= importBatchSize && !flush() {
+					return
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if !flush() {
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result.Success = true
+	result.Duration = time.Since(start).Seconds() * 1000
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+// copyLineContext finds the row number in a COPY error's context, e.g.
+// "COPY orders, line 3, column total: ...".
+var copyLineContext = regexp.MustCompile(`COPY [^,]+, line (\d+)`)
+
+// parseCSVDelimiter validates a delimiter option; empty means ",".
+func parseCSVDelimiter(s string) (rune, error) {
+	if s == "" {
+		return ',', nil
+	}
+	delim, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || delim == '"' || delim == '\n' || delim == '\r' {
+		return 0, errors.New("delimiter must be a single character")
+	}
+	return delim, nil
+}
+
+// csvTargetColumns picks the columns CSV fields load into: the requested
+// columns, else the header's names, else every column in table order.
+// Named columns must exist in the table and appear once.
+func csvTargetColumns(tableColumns, header, requested []string) ([]string, error) {
+	names := requested
+	source := "column"
+	if len(names) == 0 {
+		names = header
+		source = "header column"
+	}
+	if len(names) == 0 {
+		return tableColumns, nil
+	}
+
+	exists := make(map[string]bool, len(tableColumns))
+	for _, col := range tableColumns {
+		exists[col] = true
+	}
+	seen := make(map[string]bool, len(names))
+	var unknown []string
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("%s %q is listed twice", source, name)
+		}
+		seen[name] = true
+		if !exists[name] {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%s not in table: %s", source, strings.Join(unknown, ", "))
+	}
+	return names, nil
+}
+
+// csvLineError is a CSV record that couldn't be read or converted.
+type csvLineError struct {
+	line int
+	err  error
+}
+
+func (e *csvLineError) Error() string { return e.err.Error() }
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// csvCopySource feeds CSV records to CopyFrom, decoding each field from
+// Postgres text format into the Go value for its column type so pgx can
+// send it in binary. Fields equal to nullString become NULL.
+type csvCopySource struct {
+	reader     *csv.Reader
+	oids       []uint32
+	typeMap    *pgtype.Map
+	nullString string
+
+	// progress reports how much of the file (read, of size bytes) has
+	// been sent.
+	progress *progressTracker
+	read     *countingReader
+	size     int64
+
+	lines  []int // CSV line of each row sent, for mapping COPY errors back
+	values []any
+	err    error
+}
+
+func (s *csvCopySource) Next() bool {
+	record, err := s.reader.Read()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		line := len(s.lines) + 1
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line = parseErr.StartLine
+			err = parseErr.Err
+		}
+		s.err = &csvLineError{line: line, err: err}
+		return false
+	}
+
+	line, _ := s.reader.FieldPos(0)
+	if len(record) != len(s.oids) {
+		s.err = &csvLineError{line: line, err: fmt.Errorf("expected %d fields, got %d", len(s.oids), len(record))}
+		return false
+	}
+
+	values := make([]any, len(record))
+	for i, field := range record {
+		if field == s.nullString {
+			continue
+		}
+		if err := s.typeMap.Scan(s.oids[i], pgtype.TextFormatCode, []byte(field), &values[i]); err != nil {
+			s.err = &csvLineError{line: line, err: fmt.Errorf("field %d: %v", i+1, err)}
+			return false
+		}
+	}
+	s.values = values
+	s.lines = append(s.lines, line)
+	if s.read != nil {
+		s.progress.update("copying", s.read.n, s.size)
+	}
+	return true
+}
+
+func (s *csvCopySource) Values() ([]any, error) { return s.values, nil }
+
+func (s *csvCopySource) Err() error { return s.err }
+
+// copyErrorLine maps a COPY error back to its CSV line, or 0 if the error
+// doesn't name a row.
+func copyErrorLine(err error, lines []int) int {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return 0
+	}
+	m := copyLineContext.FindStringSubmatch(pgErr.Where)
+	if m == nil {
+		return 0
+	}
+	row, _ := strconv.Atoi(m[1])
+	if row < 1 || row > len(lines) {
+		return 0
+	}
+	return lines[row-1]
+}
+
+// columnTypeOIDs returns the type OID of each named column, using a
+// domain's base type since that's what COPY reports for it.
+func columnTypeOIDs(ctx context.Context, q queryRunner, schema, table string, columns []string) ([]uint32, error) {
+	rows, err := q.Query(ctx, `
+		SELECT a.attname, CASE WHEN t.typtype = 'd' THEN t.typbasetype ELSE a.atttypid END
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1
+		  AND c.relname = $2
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+	`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byName := make(map[string]uint32)
+	for rows.Next() {
+		var name string
+		var oid uint32
+		if err := rows.Scan(&name, &oid); err != nil {
+			return nil, err
+		}
+		byName[name] = oid
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	oids := make([]uint32, len(columns))
+	for i, col := range columns {
+		oids[i] = byName[col]
+	}
+	return oids, nil
+}
+
+// ImportCSV loads an uploaded CSV file (multipart field "file") into an
+// existing table with COPY. Form options:
+//
+//   - delimiter: single character, default ","
+//   - header: "false" if the first line is data, default "true"
+//   - nullString: field value loaded as NULL, default "" (so empty fields
+//     are NULL)
+//   - columns: comma-separated target columns in field order; defaults to
+//     the header's names, or every column in table order without a header
+//
+// Header and column names are checked against the table first. The load
+// runs in one transaction: a bad record or a rejected row rolls it all
+// back and is reported with its line number.
+//
+// With ?operationId= the load reports the bytes of the file sent so far
+// to WatchProgress subscribers.
+func ImportCSV(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "importing rows") {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+
+	delim, err := parseCSVDelimiter(c.PostForm("delimiter"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hasHeader := c.DefaultPostForm("header", "true") != "false"
+	nullString := c.PostForm("nullString")
+	var requested []string
+	if raw := strings.TrimSpace(c.PostForm("columns")); raw != "" {
+		for _, col := range strings.Split(raw, ",") {
+			requested = append(requested, strings.TrimSpace(col))
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if security.AbortIfBodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file upload named \"file\" is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	tableColumns, err := tableColumnNames(ctx, pool, schema, table)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(tableColumns) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
+
+	start := time.Now()
+	result := models.ImportResult{}
+	fail := func(status int, line int, err error) {
+		result.Success = false
+		result.Errors = []models.ImportLineError{{Line: line, Error: err.Error()}}
+		result.Duration = time.Since(start).Seconds() * 1000
+		c.JSON(status, result)
+	}
+
+	counter := &countingReader{r: file}
+	reader := csv.NewReader(counter)
+	reader.Comma = delim
+	reader.FieldsPerRecord = -1 // checked against the target columns instead
+	reader.ReuseRecord = true
+
+	var header []string
+	if hasHeader {
+		record, err := reader.Read()
+		if err == io.EOF {
+			fail(http.StatusBadRequest, 1, errors.New("file is empty"))
+			return
+		}
+		if err != nil {
+			fail(http.StatusBadRequest, 1, err)
+			return
+		}
+		header = make([]string, len(record))
+		for i, name := range record {
+			header[i] = strings.TrimSpace(name)
+		}
+		// Excel writes a byte order mark before the first header.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns, err := csvTargetColumns(tableColumns, header, requested)
+	if err != nil {
+		fail(http.StatusBadRequest, 1, err)
+		return
+	}
+	if header != nil && len(header) != len(columns) {
+		fail(http.StatusBadRequest, 1, fmt.Errorf("header has %d fields but %d columns are targeted", len(header), len(columns)))
+		return
+	}
+
+	oids, err := columnTypeOIDs(ctx, pool, schema, table, columns)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	progress, ok := startProgress(c, "import", "bytes")
+	if !ok {
+		return
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		progress.finish(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	source := &csvCopySource{
+		reader:     reader,
+		oids:       oids,
+		typeMap:    tx.Conn().TypeMap(),
+		nullString: nullString,
+		progress:   progress,
+		read:       counter,
+		size:       fileHeader.Size,
+	}
+	n, err := tx.CopyFrom(ctx, pgx.Identifier{schema, table}, columns, source)
+	if err != nil {
+		progress.finish(err)
+		var lineErr *csvLineError
+		if errors.As(err, &lineErr) {
+			fail(http.StatusBadRequest, lineErr.line, lineErr.err)
+			return
+		}
+		fail(http.StatusBadRequest, copyErrorLine(err, source.lines), err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		progress.finish(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	progress.finish(nil)
+
+	result.Success = true
+	result.Inserted = n
+	result.Duration = time.Since(start).Seconds() * 1000
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseNDJSONRecord(t *testing.T) {
+	columns := map[string]bool{"id": true, "name": true}
+	cases := []struct {
+		line    string
+		wantErr string
+	}{
+		{`{"id": 1, "name": "a"}`, ""},
+		{`{"id": 1, "extra": true}`, `unknown column "extra"`},
+		{`[1, 2]`, "not a JSON object"},
+		{`null`, "not a JSON object"},
+		{`{}`, "no fields"},
+		{`{"id": 1,`, "malformed JSON"},
+	}
+	for _, tc := range cases {
+		_, err := parseNDJSONRecord([]byte(tc.line), columns)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tc.line, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: error = %v, want containing %q", tc.line, err, tc.wantErr)
+		}
+	}
+}
+
+func TestNDJSONBatchPayload(t *testing.T) {
+	columns := map[string]bool{"id": true, "name": true}
+	var b ndjsonBatch
+	for i, line := range []string{`{"id":1}`, `{"name":"x"}`} {
+		rec, err := parseNDJSONRecord([]byte(line), columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.add(i+3, []byte(line), rec)
+	}
+	b.payload.WriteByte(']')
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(b.payload.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Fatalf("payload %q is not a 2-element JSON array: %v", b.payload.String(), err)
+	}
+	if !b.keys["id"] || !b.keys["name"] {
+		t.Errorf("keys = %v, want id and name", b.keys)
+	}
+	if b.firstLine != 3 || b.lastLine != 4 {
+		t.Errorf("line range = %d-%d, want 3-4", b.firstLine, b.lastLine)
+	}
+}
+
+func TestCSVTargetColumns(t *testing.T) {
+	table := []string{"id", "name", "email"}
+	cases := []struct {
+		header, requested []string
+		want              string
+		wantErr           string
+	}{
+		{nil, nil, "id,name,email", ""},
+		{[]string{"email", "id"}, nil, "email,id", ""},
+		{[]string{"id", "mail"}, nil, "", `header column not in table: "mail"`},
+		{[]string{"id", "id"}, nil, "", "listed twice"},
+		{[]string{"a", "b"}, []string{"name", "id"}, "name,id", ""},
+		{nil, []string{"nope"}, "", `column not in table: "nope"`},
+	}
+	for _, tc := range cases {
+		got, err := csvTargetColumns(table, tc.header, tc.requested)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("csvTargetColumns(%v, %v) err = %v, want %q", tc.header, tc.requested, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || strings.Join(got, ",") != tc.want {
+			t.Errorf("csvTargetColumns(%v, %v) = %v, %v; want %s", tc.header, tc.requested, got, err, tc.want)
+		}
+	}
+}
+
+func TestCSVCopySource(t *testing.T) {
+	r := csv.NewReader(strings.NewReader("1,alice,\n2,\"multi\nline\",x\nthree,bob,y\n"))
+	r.FieldsPerRecord = -1
+	src := &csvCopySource{
+		reader:  r,
+		oids:    []uint32{pgtype.Int4OID, pgtype.TextOID, pgtype.TextOID},
+		typeMap: pgtype.NewMap(),
+	}
+
+	if !src.Next() {
+		t.Fatalf("first record: %v", src.Err())
+	}
+	values, _ := src.Values()
+	if values[0] != int32(1) || values[1] != "alice" || values[2] != nil {
+		t.Errorf("first record values = %#v", values)
+	}
+	if !src.Next() {
+		t.Fatalf("second record: %v", src.Err())
+	}
+	if src.Next() {
+		t.Fatal("third record should fail to convert")
+	}
+	var lineErr *csvLineError
+	if !errors.As(src.Err(), &lineErr) || lineErr.line != 4 {
+		t.Errorf("err = %v, want a line 4 error", src.Err())
+	}
+	if want := []int{1, 2}; len(src.lines) != 2 || src.lines[0] != want[0] || src.lines[1] != want[1] {
+		t.Errorf("lines = %v, want %v", src.lines, want)
+	}
+
+	pgErr := &pgconn.PgError{Message: "null value", Where: "COPY users, line 2, column name: null input"}
+	if got := copyErrorLine(pgErr, src.lines); got != 2 {
+		t.Errorf("copyErrorLine = %d, want 2", got)
+	}
+	if got := copyErrorLine(errors.New("boom"), src.lines); got != 0 {
+		t.Errorf("copyErrorLine(non-pg error) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// maxIdentifierLength is Postgres's NAMEDATALEN - 1; longer names are
+// silently truncated.
+const maxIdentifierLength = 63
+
+// defaultIndexName names an index like Postgres does when CREATE INDEX
+// has no name, <table>_<col>_<col>_idx, cut to the identifier limit.
+// The name is fixed up front so a failed concurrent build can be found.
+func defaultIndexName(table string, columns []string) string {
+	name := table + "_" + strings.Join(columns, "_") + "_idx"
+	if len(name) > maxIdentifierLength {
+		name = name[:maxIdentifierLength-len("_idx")] + "_idx"
+	}
+	return name
+}
+
+// buildCreateIndex assembles the CREATE INDEX statement for a request,
+// filling in its defaults. Every name must be a plain identifier.
+func buildCreateIndex(req *models.CreateIndexRequest) (string, error) {
+	if !isValidIdentifier(req.Schema) || !isValidIdentifier(req.Table) {
+		return "", errors.New("invalid schema or table name")
+	}
+	quotedCols := make([]string, len(req.Columns))
+	for i, col := range req.Columns {
+		if !isValidIdentifier(col) {
+			return "", fmt.Errorf("invalid column name: %s", col)
+		}
+		quotedCols[i] = quoteIdentifier(col)
+	}
+	if req.Name == "" {
+		req.Name = defaultIndexName(req.Table, req.Columns)
+	}
+	if !isValidIdentifier(req.Name) || len(req.Name) > maxIdentifierLength {
+		return "", errors.New("invalid index name")
+	}
+	if req.Method == "" {
+		req.Method = "btree"
+	}
+	if req.Unique && req.Method != "btree" {
+		return "", fmt.Errorf("%s indexes can't be unique", req.Method)
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if req.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	b.WriteString("INDEX ")
+	if req.Concurrently {
+		b.WriteString("CONCURRENTLY ")
+	}
+	fmt.Fprintf(&b, "%s ON %s.%s USING %s (%s)", quoteIdentifier(req.Name),
+		quoteIdentifier(req.Schema), quoteIdentifier(req.Table), req.Method, strings.Join(quotedCols, ", "))
+	return b.String(), nil
+}
+
+// CreateIndex builds an index on a table, over a dedicated connection so
+// a CONCURRENTLY build runs outside any transaction. A concurrent build
+// that fails leaves an invalid index behind, which is dropped again.
+func CreateIndex(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "creating indexes") {
+		return
+	}
+
+	var req models.CreateIndexRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	sql, err := buildCreateIndex(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+	defer cancel()
+
+	conn, err := manager.OpenDedicatedConn(ctx, connId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err)})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	// Refuse a taken name up front, so the cleanup below can't drop
+	// someone else's index.
+	if _, err := indexValid(ctx, conn, req.Schema, req.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("relation %s.%s already exists", req.Schema, req.Name)})
+		return
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	start := time.Now()
+	if _, err := conn.Exec(ctx, sql, pgx.QueryExecModeSimpleProtocol); err != nil {
+		msg := err.Error()
+		if req.Concurrently {
+			if cleanupErr := dropInvalidIndex(manager, connId, req.Schema, req.Name); cleanupErr != nil {
+				log.Printf("create index: dropping invalid index %s.%s: %v", req.Schema, req.Name, cleanupErr)
+				msg += fmt.Sprintf(" (the invalid index %s.%s could not be dropped and may need DROP INDEX: %s)", req.Schema, req.Name, safeErr(cleanupErr))
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("Index %s created on %s.%s", req.Name, req.Schema, req.Table),
+		"command":  sql,
+		"duration": time.Since(start).Seconds() * 1000,
+	})
+}
+
+// indexValid reports whether schema.name is a valid index, or
+// pgx.ErrNoRows when there's no index by that name.
+func indexValid(ctx context.Context, conn *pgx.Conn, schema, name string) (bool, error) {
+	var valid bool
+	err := conn.QueryRow(ctx, `
+		SELECT COALESCE(i.indisvalid, false)
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_catalog.pg_index i ON i.indexrelid = c.oid
+		WHERE n.nspname = $1 AND c.relname = $2
+	`, schema, name).Scan(&valid)
+	return valid, err
+}
+
+// dropInvalidIndex removes the invalid index a failed CREATE INDEX
+// CONCURRENTLY leaves behind. It opens its own connection on its own
+// context: the build may have failed by running out of time, and pgx
+// closes a connection whose query was canceled.
+func dropInvalidIndex(manager *database.ConnectionManager, connId, schema, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	conn, err := manager.OpenDedicatedConn(ctx, connId)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	valid, err := indexValid(ctx, conn, schema, name)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = conn.Exec(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s.%s", quoteIdentifier(schema), quoteIdentifier(name)),
+		pgx.QueryExecModeSimpleProtocol)
+	return err
+}
+
+// DropIndex drops an index; ?concurrently=true drops it without blocking
+// queries on its table. Indexes backing a constraint can't be dropped
+// this way, and Postgres's error says so.
+func DropIndex(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "dropping indexes") {
+		return
+	}
+
+	schema := c.Param("schema")
+	name := c.Param("name")
+	if !isValidIdentifier(schema) || !isValidIdentifier(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or index name"})
+		return
+	}
+
+	sql := "DROP INDEX "
+	if c.Query("concurrently") == "true" {
+		sql += "CONCURRENTLY "
+	}
+	sql += fmt.Sprintf("%s.%s", quoteIdentifier(schema), quoteIdentifier(name))
+
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+	defer cancel()
+
+	conn, err := manager.OpenDedicatedConn(ctx, connId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err)})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	start := time.Now()
+	if _, err := conn.Exec(ctx, sql, pgx.QueryExecModeSimpleProtocol); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("Index %s.%s dropped", schema, name),
+		"command":  sql,
+		"duration": time.Since(start).Seconds() * 1000,
+	})
+}
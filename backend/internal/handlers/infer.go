@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// maxInferSampleRows caps how many data rows are inspected; the request is
+// meant to carry a sample, not the whole file.
+const maxInferSampleRows = 1000
+
+// inferredKind orders the types inference can pick. Kinds in the same
+// family widen (integer → bigint → numeric, date → timestamp →
+// timestamptz); anything else that disagrees falls back to text.
+type inferredKind int
+
+const (
+	kindUnknown inferredKind = iota // only empty values seen so far
+	kindBoolean
+	kindInteger
+	kindBigint
+	kindNumeric
+	kindDate
+	kindTimestamp
+	kindTimestamptz
+	kindText
+)
+
+var inferredTypeNames = map[inferredKind]string{
+	kindUnknown:     "text",
+	kindBoolean:     "boolean",
+	kindInteger:     "integer",
+	kindBigint:      "bigint",
+	kindNumeric:     "numeric",
+	kindDate:        "date",
+	kindTimestamp:   "timestamp",
+	kindTimestamptz: "timestamptz",
+	kindText:        "text",
+}
+
+var (
+	integerRegex = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	numericRegex = regexp.MustCompile(`^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+	timestampLayouts   = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+	timestamptzLayouts = []string{time.RFC3339, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05Z07"}
+)
+
+// inferValueKind returns the narrowest kind that can hold s.
+func inferValueKind(s string) inferredKind {
+	switch strings.ToLower(s) {
+	case "true", "false", "t", "f", "yes", "no":
+		return kindBoolean
+	}
+
+	if integerRegex.MatchString(s) {
+		// Leading zeros (ZIP codes, account numbers) would be lost.
+		digits := strings.TrimLeft(s, "+-")
+		if len(digits) > 1 && digits[0] == '0' {
+			return kindText
+		}
+		if _, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return kindInteger
+		}
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return kindBigint
+		}
+		return kindNumeric
+	}
+	if numericRegex.MatchString(s) {
+		return kindNumeric
+	}
+
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return kindDate
+	}
+	for _, layout := range timestampLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return kindTimestamp
+		}
+	}
+	for _, layout := range timestamptzLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return kindTimestamptz
+		}
+	}
+	return kindText
+}
+
+// widenKind merges the kind seen so far with a new value's kind.
+func widenKind(a, b inferredKind) inferredKind {
+	switch {
+	case a == kindUnknown:
+		return b
+	case a == b:
+		return a
+	case isNumericKind(a) && isNumericKind(b), isTemporalKind(a) && isTemporalKind(b):
+		return max(a, b)
+	default:
+		return kindText
+	}
+}
+
+func isNumericKind(k inferredKind) bool  { return k >= kindInteger && k <= kindNumeric }
+func isTemporalKind(k inferredKind) bool { return k >= kindDate && k <= kindTimestamptz }
+
+var nonIdentChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// sanitizeColumnName turns a CSV header into a lower-case identifier that
+// passes isValidIdentifier, deduplicating against names already used.
+func sanitizeColumnName(header string, index int, used map[string]bool) string {
+	name := nonIdentChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(header)), "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		name = fmt.Sprintf("column_%d", index+1)
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	base := name
+	for n := 2; used[name]; n++ {
+		suffix := fmt.Sprintf("_%d", n)
+		name = base
+		if len(name)+len(suffix) > 63 {
+			name = name[:63-len(suffix)]
+		}
+		name += suffix
+	}
+	used[name] = true
+	return name
+}
+
+// inferColumns reads the CSV sample and returns the suggested columns and
+// the number of data rows inspected.
+func inferColumns(r *csv.Reader, hasHeader bool) ([]models.InferredColumn, int, error) {
+	var headers []string
+	if hasHeader {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil, 0, errors.New("CSV sample is empty")
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		headers = rec
+	}
+
+	var kinds []inferredKind
+	var nullable []bool
+	rows := 0
+	for rows < maxInferSampleRows {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		rows++
+
+		width := max(len(rec), len(headers), len(kinds))
+		for len(kinds) < width {
+			kinds = append(kinds, kindUnknown)
+			// A column that only appears in later rows was missing before.
+			nullable = append(nullable, rows > 1)
+		}
+		for i := range width {
+			value := ""
+			if i < len(rec) {
+				value = strings.TrimSpace(rec[i])
+			}
+			if value == "" {
+				nullable[i] = true
+				continue
+			}
+			kinds[i] = widenKind(kinds[i], inferValueKind(value))
+		}
+	}
+
+	for len(kinds) < len(headers) {
+		kinds = append(kinds, kindUnknown)
+		nullable = append(nullable, true)
+	}
+	if len(kinds) == 0 {
+		return nil, 0, errors.New("CSV sample has no columns")
+	}
+
+	used := make(map[string]bool)
+	columns := make([]models.InferredColumn, len(kinds))
+	for i, kind := range kinds {
+		source := ""
+		if i < len(headers) {
+			source = headers[i]
+		}
+		columns[i] = models.InferredColumn{
+			Name:       sanitizeColumnName(source, i, used),
+			SourceName: source,
+			Type:       inferredTypeNames[kind],
+			Nullable:   nullable[i] || kind == kindUnknown,
+		}
+	}
+	return columns, rows, nil
+}
+
+// buildCreateTable renders the suggested DDL. Every name has been through
+// isValidIdentifier or sanitizeColumnName, so quoteIdentifier can't panic.
+func buildCreateTable(schema, table string, columns []models.InferredColumn) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s.%s (\n", quoteIdentifier(schema), quoteIdentifier(table))
+	for i, col := range columns {
+		fmt.Fprintf(&b, "    %s %s", quoteIdentifier(col.Name), col.Type)
+		if !col.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		if i < len(columns)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(");")
+	return b.String()
+}
+
+// InferColumnTypes suggests Postgres column types for a CSV sample and a
+// CREATE TABLE statement to hold it. Nothing is run against the database;
+// the user reviews the DDL before executing it.
+func InferColumnTypes(c *gin.Context) {
+	var req models.InferTypesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if req.Schema == "" {
+		req.Schema = "public"
+	}
+	if req.Table == "" {
+		req.Table = "imported_data"
+	}
+	if !isValidIdentifier(req.Schema) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema name"})
+		return
+	}
+	if !isValidIdentifier(req.Table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid table name"})
+		return
+	}
+
+	r := csv.NewReader(strings.NewReader(req.CSV))
+	r.FieldsPerRecord = -1 // tolerate ragged rows; short rows count as empty
+	delim, err := parseCSVDelimiter(req.Delimiter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	r.Comma = delim
+
+	hasHeader := req.HasHeader == nil || *req.HasHeader
+	columns, rows, err := inferColumns(r, hasHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid CSV: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.InferTypesResponse{
+		Columns:     columns,
+		SampledRows: rows,
+		CreateTable: buildCreateTable(req.Schema, req.Table, columns),
+	})
+}
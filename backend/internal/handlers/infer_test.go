@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestInferValueKind(t *testing.T) {
+	cases := map[string]inferredKind{
+		"42":                        kindInteger,
+		"-7":                        kindInteger,
+		"9999999999":                kindBigint,
+		"99999999999999999999":      kindNumeric,
+		"3.14":                      kindNumeric,
+		"1e10":                      kindNumeric,
+		"00501":                     kindText,
+		"TRUE":                      kindBoolean,
+		"no":                        kindBoolean,
+		"2024-03-01":                kindDate,
+		"2024-03-01 12:30:00":       kindTimestamp,
+		"2024-03-01T12:30:00.5":     kindTimestamp,
+		"2024-03-01T12:30:00Z":      kindTimestamptz,
+		"2024-03-01 12:30:00+02:00": kindTimestamptz,
+		"inf":                       kindText,
+		"hello":                     kindText,
+	}
+	for in, want := range cases {
+		if got := inferValueKind(in); got != want {
+			t.Errorf("inferValueKind(%q) = %s, want %s", in, inferredTypeNames[got], inferredTypeNames[want])
+		}
+	}
+}
+
+func TestWidenKind(t *testing.T) {
+	cases := []struct {
+		a, b, want inferredKind
+	}{
+		{kindUnknown, kindDate, kindDate},
+		{kindInteger, kindNumeric, kindNumeric},
+		{kindDate, kindTimestamptz, kindTimestamptz},
+		{kindInteger, kindDate, kindText},
+		{kindBoolean, kindInteger, kindText},
+	}
+	for _, tc := range cases {
+		if got := widenKind(tc.a, tc.b); got != tc.want {
+			t.Errorf("widenKind(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSanitizeColumnName(t *testing.T) {
+	used := map[string]bool{}
+	got := []string{
+		sanitizeColumnName("First Name", 0, used),
+		sanitizeColumnName("first-name", 1, used),
+		sanitizeColumnName("2024 Sales ($)", 2, used),
+		sanitizeColumnName("  ", 3, used),
+	}
+	want := []string{"first_name", "first_name_2", "_2024_sales", "column_4"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("column %d = %q, want %q", i, got[i], want[i])
+		}
+		if !isValidIdentifier(got[i]) {
+			t.Errorf("column %d = %q is not a valid identifier", i, got[i])
+		}
+	}
+}
+
+func TestInferColumnsAndDDL(t *testing.T) {
+	sample := "id,name,price,active,created\n" +
+		"1,Widget,9.99,true,2024-01-02\n" +
+		"2,,12,false,2024-01-03 08:00:00\n"
+	columns, rows, err := inferColumns(csv.NewReader(strings.NewReader(sample)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("rows = %d, want 2", rows)
+	}
+	wantTypes := []string{"integer", "text", "numeric", "boolean", "timestamp"}
+	for i, col := range columns {
+		if col.Type != wantTypes[i] {
+			t.Errorf("%s type = %s, want %s", col.Name, col.Type, wantTypes[i])
+		}
+	}
+	if columns[0].Nullable || !columns[1].Nullable {
+		t.Errorf("nullability: id=%v name=%v, want false/true", columns[0].Nullable, columns[1].Nullable)
+	}
+
+	ddl := buildCreateTable("public", "items", columns)
+	for _, want := range []string{`CREATE TABLE "public"."items" (`, `"id" integer NOT NULL,`, `"name" text,`, `"created" timestamp NOT NULL`} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("DDL missing %q:\n%s", want, ddl)
+		}
+	}
+}
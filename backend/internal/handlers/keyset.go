@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keysetCursorColumn is the alias under which a keyset page's query also
+// selects the order column as text, to build the next cursor from. It's
+// dropped from the rows sent back.
+const keysetCursorColumn = "__pgvoyager_cursor"
+
+// checkKeysetColumn makes sure a column can order keyset pages: it must be
+// NOT NULL and alone make up a unique index (the primary key included), so
+// that "after this value" skips and repeats no rows and the seek is an
+// index scan. Partial and expression indexes don't count.
+func checkKeysetColumn(ctx context.Context, q queryRunner, schema, table, column string) error {
+	query := `
+		SELECT a.attnotnull, EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_index i
+			WHERE i.indrelid = a.attrelid
+			  AND i.indisunique
+			  AND i.indnkeyatts = 1
+			  AND i.indkey[0] = a.attnum
+			  AND i.indpred IS NULL
+			  AND i.indexprs IS NULL
+		)
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attname = $3
+		  AND a.attnum > 0 AND NOT a.attisdropped
+	`
+
+	rows, err := q.Query(ctx, query, schema, table, column)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("column %s not found", column)
+	}
+	var notNull, unique bool
+	if err := rows.Scan(&notNull, &unique); err != nil {
+		return err
+	}
+	if !notNull || !unique {
+		return fmt.Errorf("keyset pagination needs orderBy to be a NOT NULL column with a unique index of its own; %s isn't", column)
+	}
+	return nil
+}
+
+// keysetWhereClause adds the seek condition, the order column past the
+// cursor's value, to a filter WHERE clause from buildWhereClause. The
+// filters are parenthesised since they may mix AND and OR.
+func keysetWhereClause(where, column, orderDir string, argN int) string {
+	op := ">"
+	if orderDir == "DESC" {
+		op = "<"
+	}
+	cond := fmt.Sprintf("%s %s $%d", quoteIdentifier(column), op, argN)
+	if where == "" {
+		return " WHERE " + cond
+	}
+	return fmt.Sprintf(" WHERE (%s) AND %s", strings.TrimPrefix(where, " WHERE "), cond)
+}
+
+// encodeCursor and decodeCursor wrap the order column's text value in an
+// opaque, URL-safe cursor. The value goes back to Postgres as a parameter,
+// which parses it as the column's type.
+func encodeCursor(value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errors.New("invalid cursor")
+	}
+	return string(b), nil
+}
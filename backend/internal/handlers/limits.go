@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+// Row limit preferences. Each holds a row count from 1 to rowLimitCeiling
+// and is read on every request, so changes apply immediately.
+const (
+	// DefaultPageSizePreference is the GetTableData page size used when the
+	// request doesn't ask for one (or asks for more than the maximum).
+	DefaultPageSizePreference = "data.default_page_size"
+	// MaxPageSizePreference is the largest page GetTableData returns.
+	MaxPageSizePreference = "data.max_page_size"
+	// MCPMaxQueryRowsPreference caps the rows the MCP query tool returns.
+	MCPMaxQueryRowsPreference = "mcp.max_query_rows"
+	// QueryDiffMaxRowsPreference caps the rows DiffQueries reads from each
+	// query.
+	QueryDiffMaxRowsPreference = "query.diff_max_rows"
+
+	rowLimitCeiling        = 100000
+	defaultPageSize        = 100
+	defaultMaxPageSize     = 1000
+	defaultMCPQueryRows    = 100
+	defaultMCPMaxQueryRows = 1000
+	defaultDiffMaxRows     = 10000
+)
+
+// ParseRowLimit validates a row limit preference value.
+func ParseRowLimit(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 || n > rowLimitCeiling {
+		return 0, fmt.Errorf("must be 1 to %d rows", rowLimitCeiling)
+	}
+	return n, nil
+}
+
+// rowLimit reads a row limit preference, falling back to def when it is
+// unset or invalid.
+func rowLimit(key string, def int) int {
+	value, err := storage.GetPreference(key)
+	if err != nil || value == "" {
+		return def
+	}
+	n, err := ParseRowLimit(value)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// pageSizeLimits returns GetTableData's default and maximum page sizes.
+// The default never exceeds the maximum.
+func pageSizeLimits() (def, largest int) {
+	largest = rowLimit(MaxPageSizePreference, defaultMaxPageSize)
+	return min(rowLimit(DefaultPageSizePreference, defaultPageSize), largest), largest
+}
+
+// clampMCPQueryRows applies the MCP query row cap to a requested limit,
+// substituting the default for a missing one.
+func clampMCPQueryRows(limit int) int {
+	if limit <= 0 {
+		limit = defaultMCPQueryRows
+	}
+	return min(limit, rowLimit(MCPMaxQueryRowsPreference, defaultMCPMaxQueryRows))
+}
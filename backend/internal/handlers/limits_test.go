@@ -0,0 +1,27 @@
+package handlers
+
+import "testing"
+
+func TestParseRowLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"100", 100, false},
+		{" 5000 ", 5000, false},
+		{"1", 1, false},
+		{"100000", 100000, false},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"100001", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRowLimit(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseRowLimit(%q) = (%d, %v), want (%d, err=%v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
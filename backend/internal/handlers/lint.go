@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// largeSelectStarRows is the planner's row estimate from which SELECT * is
+// worth a warning: every column of that many rows is a lot to fetch.
+const largeSelectStarRows = 100_000
+
+// dropDataObjects are the DROP targets that take data with them; dropping
+// anything else (an index, a view, a function) can be undone by recreating
+// it.
+var dropDataObjects = map[string]bool{
+	"TABLE":    true,
+	"SCHEMA":   true,
+	"DATABASE": true,
+	"OWNED":    true,
+}
+
+// mainVerb returns the index of the statement's leading keyword, past any
+// WITH clause.
+func mainVerb(tokens []sqlToken) int {
+	if len(tokens) == 0 || tokens[0].Text != "WITH" {
+		return 0
+	}
+	// The first top-level word after a closing parenthesis, other than the
+	// AS of a CTE with a column list: WITH x (a, b) AS (...) DELETE ...
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i].Depth == 0 && tokens[i].Word && tokens[i].Text != "AS" && tokens[i-1].Text == ")" {
+			return i
+		}
+	}
+	return 0
+}
+
+// hasWhere reports whether the statement or subquery whose verb is at
+// tokens[verb] has its own WHERE clause; one inside a nested subquery
+// doesn't count.
+func hasWhere(tokens []sqlToken, verb int) bool {
+	depth := tokens[verb].Depth
+	for _, t := range tokens[verb+1:] {
+		if t.Depth < depth {
+			break
+		}
+		if t.Depth == depth && t.Text == "WHERE" {
+			return true
+		}
+	}
+	return false
+}
+
+// selectsStar reports whether the statement's top-level query fetches
+// every column: TABLE t, SELECT * or SELECT t.*.
+func selectsStar(tokens []sqlToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	verb := mainVerb(tokens)
+	switch tokens[verb].Text {
+	case "TABLE":
+		return true
+	case "SELECT":
+		for i := verb + 1; i < len(tokens); i++ {
+			t := tokens[i]
+			if t.Depth != 0 {
+				continue
+			}
+			if t.Text == "FROM" {
+				return false
+			}
+			if t.Text == "*" {
+				switch tokens[i-1].Text {
+				case "SELECT", "DISTINCT", "ALL", ",", ".":
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// lintStatement checks one statement for the risks that need no database:
+// UPDATE or DELETE without WHERE (including in a WITH clause), DROP,
+// TRUNCATE and dropping a column. It is light on SQL: an UPDATE or DELETE
+// counts when it starts the statement, its main query, or a parenthesised
+// query.
+func lintStatement(stmt StatementInfo) []models.LintWarning {
+	tokens := sqlTokens(stmt.SQL)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var warnings []models.LintWarning
+	warn := func(severity, rule, message string) {
+		warnings = append(warnings, models.LintWarning{
+			Severity:  severity,
+			Rule:      rule,
+			Message:   message,
+			Statement: truncateStatement(stmt.SQL),
+			Offset:    stmt.Offset,
+		})
+	}
+
+	switch tokens[0].Text {
+	case "DROP":
+		object := "object"
+		if len(tokens) > 1 && tokens[1].Word {
+			object = tokens[1].Text
+		}
+		severity := "warning"
+		if dropDataObjects[object] {
+			severity = "critical"
+		}
+		warn(severity, "drop", fmt.Sprintf("DROP %s permanently removes it", object))
+	case "TRUNCATE":
+		warn("critical", "truncate", "TRUNCATE removes every row of the table")
+	case "ALTER":
+		for i := 1; i+1 < len(tokens); i++ {
+			if tokens[i].Depth == 0 && tokens[i].Text == "DROP" && tokens[i+1].Text == "COLUMN" {
+				warn("critical", "drop_column", "DROP COLUMN permanently removes the column's data")
+				break
+			}
+		}
+	}
+
+	main := mainVerb(tokens)
+	for i, t := range tokens {
+		if t.Text != "UPDATE" && t.Text != "DELETE" {
+			continue
+		}
+		if i != main && tokens[i-1].Text != "(" {
+			continue // FOR UPDATE, ON DELETE CASCADE, GRANT UPDATE, ...
+		}
+		if !hasWhere(tokens, i) {
+			warn("critical", strings.ToLower(t.Text)+"_without_where",
+				t.Text+" without WHERE affects every row of the table")
+		}
+	}
+	return warnings
+}
+
+// estimatedRows plans a statement without running it and returns the
+// planner's estimate of the rows it returns.
+func estimatedRows(ctx context.Context, pool *pgxpool.Pool, sql string, params []interface{}) (float64, error) {
+	var plan string
+	if err := pool.QueryRow(ctx, explainPrefix(false, "json")+sql, params...).Scan(&plan); err != nil {
+		return 0, err
+	}
+	nodes, err := diagnosePlan([]byte(plan))
+	if err != nil {
+		return 0, err
+	}
+	return nodes[0].EstimatedRows, nil
+}
+
+// LintQuery flags risky statements in SQL before it runs, so the UI can ask
+// for confirmation. Nothing is executed: SELECT * statements are only
+// planned, to see whether they would return a very large result. A
+// statement that can't be planned (a syntax error, a missing table) is
+// left for execution to report.
+func LintQuery(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.LintRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	statements := splitStatements(req.SQL)
+	result := models.LintResult{Warnings: []models.LintWarning{}}
+	for _, stmt := range statements {
+		result.Warnings = append(result.Warnings, lintStatement(stmt)...)
+
+		if !selectsStar(sqlTokens(stmt.SQL)) {
+			continue
+		}
+		// Params belong to the whole SQL, which only plans as one statement.
+		var params []interface{}
+		if len(statements) == 1 {
+			params = req.Params
+		}
+		rows, err := estimatedRows(ctx, pool, stmt.SQL, params)
+		if err != nil || rows < largeSelectStarRows {
+			continue
+		}
+		result.Warnings = append(result.Warnings, models.LintWarning{
+			Severity:  "warning",
+			Rule:      "select_star_large",
+			Message:   fmt.Sprintf("SELECT * returns every column of an estimated %.0f rows; name the columns or add a LIMIT", rows),
+			Statement: truncateStatement(stmt.SQL),
+			Offset:    stmt.Offset,
+		})
+	}
+
+	for _, w := range result.Warnings {
+		if w.Severity == "critical" {
+			result.RequiresConfirmation = true
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
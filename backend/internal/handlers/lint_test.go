@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+)
+
+func lintRules(sql string) []string {
+	var rules []string
+	for _, w := range lintStatement(StatementInfo{SQL: sql}) {
+		rules = append(rules, w.Severity+" "+w.Rule)
+	}
+	return rules
+}
+
+func TestLintStatement(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want []string
+	}{
+		{"SELECT * FROM t", nil},
+		{"UPDATE t SET a = 1 WHERE id = 2", nil},
+		{"update t set a = 1", []string{"critical update_without_where"}},
+		{"DELETE FROM t", []string{"critical delete_without_where"}},
+		{"DELETE FROM t WHERE CURRENT OF c", nil},
+		// A WHERE in a subquery doesn't filter the outer statement.
+		{"UPDATE t SET a = (SELECT max(b) FROM u WHERE u.id = 1)", []string{"critical update_without_where"}},
+		{"DELETE FROM t WHERE id IN (SELECT id FROM u)", nil},
+		{"DELETE FROM t -- WHERE id = 1", []string{"critical delete_without_where"}},
+		{"DELETE FROM t WHERE note = 'no where here'", nil},
+		{"WITH gone AS (DELETE FROM t RETURNING *) SELECT * FROM gone", []string{"critical delete_without_where"}},
+		{"WITH ids (id) AS (SELECT 1) DELETE FROM t", []string{"critical delete_without_where"}},
+		{"WITH ids AS (SELECT 1) DELETE FROM t WHERE id IN (SELECT id FROM ids)", nil},
+		{"SELECT * FROM t FOR UPDATE", nil},
+		{"INSERT INTO t VALUES (1) ON CONFLICT (id) DO UPDATE SET a = 1", nil},
+		{"ALTER TABLE t ADD FOREIGN KEY (a) REFERENCES u ON DELETE CASCADE", nil},
+		{"GRANT UPDATE, DELETE ON t TO bob", nil},
+		{"DROP TABLE t", []string{"critical drop"}},
+		{"drop index t_a_idx", []string{"warning drop"}},
+		{"TRUNCATE t", []string{"critical truncate"}},
+		{"ALTER TABLE t DROP COLUMN a", []string{"critical drop_column"}},
+		{"ALTER TABLE t DROP CONSTRAINT t_a_key", nil},
+	}
+	for _, tc := range cases {
+		if got := lintRules(tc.sql); !slices.Equal(got, tc.want) {
+			t.Errorf("lintStatement(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestSelectsStar(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM t", true},
+		{"select distinct * from t", true},
+		{"SELECT id, t.* FROM t", true},
+		{`SELECT "t".* FROM t`, true},
+		{"TABLE t", true},
+		{"WITH x AS (SELECT id FROM t) SELECT * FROM x", true},
+		{"SELECT id FROM t", false},
+		{"SELECT count(*) FROM t", false},
+		{"SELECT price * 2 FROM t", false},
+		{"SELECT 1.5 * price FROM t", false},
+		{"SELECT id FROM (SELECT * FROM t) s", false},
+		{"WITH x AS (SELECT * FROM t) SELECT id FROM x", false},
+		{"DELETE FROM t RETURNING *", false},
+	}
+	for _, tc := range cases {
+		if got := selectsStar(sqlTokens(tc.sql)); got != tc.want {
+			t.Errorf("selectsStar(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+)
+
+const (
+	// maxListenChannels bounds the channels one socket can subscribe to.
+	maxListenChannels = 32
+	// maxChannelNameLen is Postgres's identifier limit (NAMEDATALEN - 1).
+	maxChannelNameLen = 63
+
+	listenReconnectMin = time.Second
+	listenReconnectMax = 30 * time.Second
+	// listenCheckInterval is how often an idle socket checks that its
+	// connection is still connected in PgVoyager.
+	listenCheckInterval = 30 * time.Second
+)
+
+// listenMessage is one frame sent to a LISTEN client. Type is
+// "listening" (after each change to the channel set, with all channels),
+// "notification", "reconnecting" (the dedicated connection was lost),
+// "reconnected", or "error" (a rejected command, or fatal when the
+// socket closes right after).
+type listenMessage struct {
+	Type     string   `json:"type"`
+	Channel  string   `json:"channel,omitempty"`
+	Payload  string   `json:"payload,omitempty"`
+	PID      uint32   `json:"pid,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// listenCommand is a client frame changing the channel set: Action is
+// "listen" or "unlisten".
+type listenCommand struct {
+	Action  string `json:"action"`
+	Channel string `json:"channel"`
+}
+
+// validateListenChannel checks a channel name. Any name is fine once
+// quoted, but Postgres would silently truncate a long one.
+func validateListenChannel(channel string) error {
+	if channel == "" {
+		return errors.New("channel name required")
+	}
+	if len(channel) > maxChannelNameLen {
+		return fmt.Errorf("channel name longer than %d bytes: %s", maxChannelNameLen, channel)
+	}
+	return nil
+}
+
+// listenSession owns the dedicated connection behind one socket. Only the
+// ListenNotifications goroutine touches it.
+type listenSession struct {
+	connId   string
+	conn     *pgx.Conn
+	channels []string
+}
+
+// acquire opens a dedicated connection, outside the small pool so a
+// long-lived socket neither starves it nor keeps it from closing, and
+// subscribes to every channel.
+func (s *listenSession) acquire(ctx context.Context) error {
+	conn, err := database.GetManager().OpenDedicatedConn(ctx, s.connId)
+	if err != nil {
+		return err
+	}
+	for _, ch := range s.channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+quoteIdentifier(ch)); err != nil {
+			conn.Close(ctx)
+			return err
+		}
+	}
+	s.conn = conn
+	return nil
+}
+
+// release unsubscribes and closes the connection.
+func (s *listenSession) release() {
+	if s.conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.conn.Exec(ctx, "UNLISTEN *")
+	s.conn.Close(ctx)
+	s.conn = nil
+}
+
+// apply runs a client command on the connection.
+func (s *listenSession) apply(ctx context.Context, cmd listenCommand) error {
+	if cmd.Action != "listen" && cmd.Action != "unlisten" {
+		return errors.New(`expected {"action": "listen" or "unlisten", "channel": name}`)
+	}
+	if err := validateListenChannel(cmd.Channel); err != nil {
+		return err
+	}
+	switch cmd.Action {
+	case "listen":
+		if slices.Contains(s.channels, cmd.Channel) {
+			return nil
+		}
+		if len(s.channels) >= maxListenChannels {
+			return fmt.Errorf("at most %d channels per socket", maxListenChannels)
+		}
+		if _, err := s.conn.Exec(ctx, "LISTEN "+quoteIdentifier(cmd.Channel)); err != nil {
+			return err
+		}
+		s.channels = append(s.channels, cmd.Channel)
+	case "unlisten":
+		if _, err := s.conn.Exec(ctx, "UNLISTEN "+quoteIdentifier(cmd.Channel)); err != nil {
+			return err
+		}
+		s.channels = slices.DeleteFunc(s.channels, func(ch string) bool { return ch == cmd.Channel })
+	}
+	return nil
+}
+
+// reconnect replaces a lost connection, backing off between attempts until
+// one succeeds or ctx ends. It gives up at once if the connection has been
+// disconnected in PgVoyager.
+func (s *listenSession) reconnect(ctx context.Context) error {
+	s.conn.Close(ctx)
+	s.conn = nil
+
+	wait := listenReconnectMin
+	for {
+		if !database.GetManager().IsConnected(s.connId) {
+			return errors.New("connection was closed")
+		}
+		err := s.acquire(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("listen: reconnect failed for connection %s: %v", s.connId, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, listenReconnectMax)
+	}
+}
+
+// ListenNotifications streams NOTIFY payloads over a WebSocket. The
+// ?channel= parameters (repeatable) are subscribed on a dedicated
+// connection, opened outside the connection's pool and held for the
+// socket's lifetime; the client can send listenCommand frames to
+// change the set. If the connection drops it is replaced and the channels
+// re-subscribed. Closing the socket UNLISTENs and closes the connection, as
+// does disconnecting in PgVoyager.
+func ListenNotifications(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	channels := []string{}
+	for _, ch := range c.QueryArray("channel") {
+		if err := validateListenChannel(ch); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !slices.Contains(channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+	if len(channels) > maxListenChannels {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d channels per socket", maxListenChannels)})
+		return
+	}
+
+	ws, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("listen upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session := &listenSession{connId: connId, channels: channels}
+	acquireCtx, acquireCancel := context.WithTimeout(ctx, 30*time.Second)
+	err = session.acquire(acquireCtx)
+	acquireCancel()
+	if err != nil {
+		ws.WriteJSON(listenMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	defer session.release()
+
+	if err := ws.WriteJSON(listenMessage{Type: "listening", Channels: session.channels}); err != nil {
+		return
+	}
+
+	// The reader hands commands to serve, which owns the
+	// connection; a failed read means the client went away.
+	cmds := make(chan listenCommand)
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var cmd listenCommand
+			if json.Unmarshal(data, &cmd) != nil {
+				cmd = listenCommand{} // rejected by apply
+			}
+			select {
+			case cmds <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	session.serve(ctx, cmds, func(msg listenMessage) error { return ws.WriteJSON(msg) })
+}
+
+// serve relays notifications and applies cmds until ctx ends, the socket
+// fails (send returns an error) or the connection can't be replaced.
+func (s *listenSession) serve(ctx context.Context, cmds <-chan listenCommand, send func(listenMessage) error) {
+	for {
+		// Wait for a notification, breaking off when a command arrives or
+		// it is time to check the connection.
+		waitCtx, stopWait := context.WithTimeout(ctx, listenCheckInterval)
+		var cmd *listenCommand
+		waited := make(chan struct{})
+		go func() {
+			defer close(waited)
+			select {
+			case received := <-cmds:
+				cmd = &received
+				stopWait()
+			case <-waitCtx.Done():
+			}
+		}()
+		n, err := s.conn.WaitForNotification(waitCtx)
+		stopWait()
+		<-waited
+
+		if n != nil {
+			if send(listenMessage{Type: "notification", Channel: n.Channel, Payload: n.Payload, PID: n.PID}) != nil {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A timeout or cancellation is the wait being broken off, for a
+		// command or to check the connection (ctx itself is still live);
+		// anything else lost the connection.
+		brokenOff := pgconn.Timeout(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+		if brokenOff && cmd == nil && !database.GetManager().IsConnected(s.connId) {
+			send(listenMessage{Type: "error", Error: "connection was closed"})
+			return
+		}
+		if err != nil && !brokenOff {
+			if send(listenMessage{Type: "reconnecting", Error: err.Error()}) != nil {
+				return
+			}
+			if err := s.reconnect(ctx); err != nil {
+				send(listenMessage{Type: "error", Error: err.Error()})
+				return
+			}
+			if send(listenMessage{Type: "reconnected", Channels: s.channels}) != nil {
+				return
+			}
+		}
+
+		if cmd != nil {
+			msg := listenMessage{Type: "listening"}
+			if err := s.apply(ctx, *cmd); err != nil {
+				msg = listenMessage{Type: "error", Error: err.Error()}
+			}
+			msg.Channels = s.channels
+			if send(msg) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+func TestValidateListenChannel(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"orders", false},
+		{"Order Events", false},
+		{strings.Repeat("a", 63), false},
+		{strings.Repeat("a", 64), true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := validateListenChannel(tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("validateListenChannel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+// fakeListenServer accepts one connection and speaks just enough of the
+// protocol for pgx to connect and run simple queries, recording each.
+func fakeListenServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	queries := make(chan string, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		backend := pgproto3.NewBackend(conn, conn)
+		if _, err := backend.ReceiveStartupMessage(); err != nil {
+			return
+		}
+		backend.Send(&pgproto3.AuthenticationOk{})
+		backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+		backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+		if backend.Flush() != nil {
+			return
+		}
+		for {
+			msg, err := backend.Receive()
+			if err != nil {
+				return
+			}
+			switch msg := msg.(type) {
+			case *pgproto3.Query:
+				queries <- msg.String
+				tag, _, _ := strings.Cut(msg.String, " ")
+				backend.Send(&pgproto3.CommandComplete{CommandTag: []byte(tag)})
+				backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+				if backend.Flush() != nil {
+					return
+				}
+			case *pgproto3.Terminate:
+				return
+			}
+		}
+	}()
+	return fmt.Sprintf("postgres://u@%s/db?sslmode=disable", ln.Addr()), queries
+}
+
+func TestListenSessionCommandIsNotReconnect(t *testing.T) {
+	dsn, queries := fakeListenServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(context.Background())
+
+	session := &listenSession{connId: "c1", conn: conn}
+	cmds := make(chan listenCommand)
+	frames := make(chan listenMessage, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		session.serve(ctx, cmds, func(msg listenMessage) error {
+			frames <- msg
+			return nil
+		})
+	}()
+
+	cmds <- listenCommand{Action: "listen", Channel: "orders"}
+	select {
+	case msg := <-frames:
+		if msg.Type != "listening" || !slices.Equal(msg.Channels, []string{"orders"}) {
+			t.Errorf("first frame = %+v, want listening on [orders]", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("no frame after the command")
+	}
+	select {
+	case q := <-queries:
+		if q != `LISTEN "orders"` {
+			t.Errorf("server got %q, want LISTEN \"orders\"", q)
+		}
+	case <-ctx.Done():
+		t.Error("server never got the LISTEN")
+	}
+
+	cancel()
+	<-done
+	close(frames)
+	for msg := range frames {
+		t.Errorf("unexpected frame %+v", msg)
+	}
+}
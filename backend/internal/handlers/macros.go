@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+type MacroRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Description string              `json:"description"`
+	Steps       []storage.MacroStep `json:"steps" binding:"required"`
+}
+
+type RunMacroRequest struct {
+	ContinueOnError bool `json:"continueOnError"`
+}
+
+// MacroStepResult reports the outcome of one macro step
+type MacroStepResult struct {
+	Index        int     `json:"index"`
+	Label        string  `json:"label,omitempty"`
+	SQL          string  `json:"sql"`
+	Success      bool    `json:"success"`
+	Skipped      bool    `json:"skipped,omitempty"`
+	Error        string  `json:"error,omitempty"`
+	RowsAffected int64   `json:"rowsAffected"`
+	Duration     float64 `json:"duration"`
+}
+
+// MacroRunReport is the combined result of replaying a macro
+type MacroRunReport struct {
+	MacroID  string            `json:"macroId"`
+	Name     string            `json:"name"`
+	Success  bool              `json:"success"`
+	Steps    []MacroStepResult `json:"steps"`
+	Duration float64           `json:"duration"`
+}
+
+// validateMacroSteps checks that every step has exactly one source
+func validateMacroSteps(steps []storage.MacroStep) string {
+	if len(steps) == 0 {
+		return "macro must have at least one step"
+	}
+	for i, step := range steps {
+		hasSQL := strings.TrimSpace(step.SQL) != ""
+		hasRef := step.SavedQueryID != ""
+		if hasSQL == hasRef {
+			return fmt.Sprintf("step %d: exactly one of sql or savedQueryId is required", i+1)
+		}
+	}
+	return ""
+}
+
+func ListMacros(c *gin.Context) {
+	macros, err := storage.ListMacros()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, macros)
+}
+
+func CreateMacro(c *gin.Context) {
+	var req MacroRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateMacroSteps(req.Steps); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	now := time.Now()
+	macro := &storage.Macro{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Steps:       req.Steps,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := storage.SaveMacro(macro); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, macro)
+}
+
+func GetMacro(c *gin.Context) {
+	macro, err := storage.GetMacro(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, macro)
+}
+
+func UpdateMacro(c *gin.Context) {
+	var req MacroRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateMacroSteps(req.Steps); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	macro, err := storage.GetMacro(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	macro.Name = req.Name
+	macro.Description = req.Description
+	macro.Steps = req.Steps
+	macro.UpdatedAt = time.Now()
+	if err := storage.SaveMacro(macro); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, macro)
+}
+
+func DeleteMacro(c *gin.Context) {
+	if err := storage.DeleteMacro(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Macro deleted"})
+}
+
+// RunMacro replays a macro's steps in order against a connection. Steps that
+// reference saved queries are resolved at run time. Execution stops at the
+// first failing step unless continueOnError is set; remaining steps are
+// reported as skipped so the report always lines up with the macro.
+func RunMacro(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	macro, err := storage.GetMacro(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req RunMacroRequest
+	// Body is optional
+	if err := c.ShouldBindJSON(&req); security.AbortIfBodyTooLarge(c, err) {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	report := MacroRunReport{
+		MacroID: macro.ID,
+		Name:    macro.Name,
+		Success: true,
+		Steps:   make([]MacroStepResult, 0, len(macro.Steps)),
+	}
+	start := time.Now()
+	halted := false
+	readOnly := manager.IsReadOnly(connId)
+
+	for i, step := range macro.Steps {
+		result := MacroStepResult{Index: i, Label: step.Label, SQL: step.SQL}
+
+		if halted {
+			result.Skipped = true
+			report.Steps = append(report.Steps, result)
+			continue
+		}
+
+		if step.SavedQueryID != "" {
+			saved, err := database.GetQueryManager().Get(step.SavedQueryID)
+			if err != nil {
+				result.Error = err.Error()
+				report.Success = false
+				halted = !req.ContinueOnError
+				report.Steps = append(report.Steps, result)
+				continue
+			}
+			result.SQL = saved.SQL
+			if result.Label == "" {
+				result.Label = saved.Name
+			}
+		}
+
+		if readOnly {
+			if stmt, bad := readOnlyViolation(result.SQL); bad {
+				result.Error = "connection is read-only; statement not allowed: " + truncateStatement(stmt)
+				report.Success = false
+				halted = !req.ContinueOnError
+				report.Steps = append(report.Steps, result)
+				continue
+			}
+		}
+
+		stepStart := time.Now()
+		result.Success = true
+		for _, stmt := range splitStatements(result.SQL) {
+			tag, err := pool.Exec(ctx, stmt.SQL)
+			if err != nil {
+				result.Success = false
+				result.Error = err.Error()
+				break
+			}
+			result.RowsAffected += tag.RowsAffected()
+		}
+		result.Duration = time.Since(stepStart).Seconds() * 1000
+
+		if !result.Success {
+			report.Success = false
+			halted = !req.ContinueOnError
+		}
+		report.Steps = append(report.Steps, result)
+	}
+
+	report.Duration = time.Since(start).Seconds() * 1000
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// maintenanceTimeout bounds a VACUUM or ANALYZE. VACUUM FULL rewrites the
+// whole table, so this is far longer than other requests.
+const maintenanceTimeout = time.Hour
+
+// VacuumTable runs VACUUM on a table, with ANALYZE and FULL as requested.
+// VACUUM can't run inside a transaction, and a long one shouldn't hold one
+// of the pool's few connections, so it gets a dedicated connection.
+func VacuumTable(c *gin.Context) {
+	var req models.VacuumRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	var options []string
+	if req.Full {
+		options = append(options, "FULL")
+	}
+	if req.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	command := "VACUUM"
+	if len(options) > 0 {
+		command += " (" + strings.Join(options, ", ") + ")"
+	}
+	runMaintenance(c, command, req.QueryID)
+}
+
+// AnalyzeTable runs ANALYZE on a table to refresh its planner statistics.
+func AnalyzeTable(c *gin.Context) {
+	var req models.AnalyzeRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	runMaintenance(c, "ANALYZE", req.QueryID)
+}
+
+// runMaintenance runs command on the :schema.:table table over a dedicated
+// connection, registered under queryId (if any) for progress and
+// cancellation, and reports how long it took. With ?operationId= the
+// blocks processed are pushed to WatchProgress subscribers as well.
+func runMaintenance(c *gin.Context, command, queryId string) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "running "+strings.Fields(command)[0]) {
+		return
+	}
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+	sql := fmt.Sprintf("%s %s.%s", command, quoteIdentifier(schema), quoteIdentifier(table))
+
+	ctx, cancel := context.WithTimeout(context.Background(), maintenanceTimeout)
+	defer cancel()
+
+	conn, err := manager.OpenDedicatedConn(ctx, connId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": safeErr(err)})
+		return
+	}
+	defer conn.Close(context.Background())
+
+	if queryId != "" {
+		running := database.GetRunningQueryManager()
+		if !running.Register(connId, queryId, conn.PgConn().PID(), conn.PgConn().CancelRequest) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a query with this queryId is already running"})
+			return
+		}
+		defer running.Unregister(connId, queryId)
+	}
+
+	operation := strings.ToLower(strings.Fields(command)[0])
+	progress, ok := startProgress(c, operation, "blocks")
+	if !ok {
+		return
+	}
+	stopPolling := pollMaintenanceProgress(ctx, manager, connId, conn.PgConn().PID(), progress)
+
+	start := time.Now()
+	_, err = conn.Exec(ctx, sql, pgx.QueryExecModeSimpleProtocol)
+	stopPolling()
+	progress.finish(err)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("%s of %s.%s finished", strings.Fields(command)[0], schema, table),
+		"command":  sql,
+		"duration": time.Since(start).Seconds() * 1000,
+	})
+}
+
+// pollMaintenanceProgress reports the progress of the maintenance running
+// in backend pid every progressInterval, from the connection's pool, until
+// the returned function is called. It does nothing without a tracker.
+func pollMaintenanceProgress(ctx context.Context, manager *database.ConnectionManager, connId string, pid uint32, progress *progressTracker) func() {
+	if progress == nil {
+		return func() {}
+	}
+	pool, err := manager.GetPool(connId)
+	if err != nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// A failed poll just skips a report.
+			if p, err := maintenanceProgress(ctx, pool, pid); err == nil {
+				progress.update(p.Phase, p.BlocksDone, p.BlocksTotal)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// maintenanceProgressQuery unions the pg_stat_progress_* views the server
+// has: CLUSTER arrived in 12 and ANALYZE in 13.
+func maintenanceProgressQuery(version int) string {
+	parts := []string{`
+		SELECT 'vacuum', phase, heap_blks_total, heap_blks_vacuumed
+		FROM pg_stat_progress_vacuum WHERE pid = $1`}
+	if version >= pg12 {
+		// VACUUM FULL reports through the CLUSTER view.
+		parts = append(parts, `
+		SELECT 'vacuum full', phase, heap_blks_total, heap_blks_scanned
+		FROM pg_stat_progress_cluster WHERE pid = $1`)
+	}
+	if version >= pg13 {
+		parts = append(parts, `
+		SELECT 'analyze', phase, sample_blks_total, sample_blks_scanned
+		FROM pg_stat_progress_analyze WHERE pid = $1`)
+	}
+	return strings.Join(parts, "\n\t\tUNION ALL")
+}
+
+// maintenanceProgress reads the pg_stat_progress_* row for backend pid,
+// leaving Command and Phase empty when there is none or the server has no
+// view for the running command.
+func maintenanceProgress(ctx context.Context, pool *pgxpool.Pool, pid uint32) (models.MaintenanceProgress, error) {
+	version, err := serverVersionNum(ctx, pool)
+	if err != nil {
+		return models.MaintenanceProgress{}, err
+	}
+	query := maintenanceProgressQuery(version)
+	var progress models.MaintenanceProgress
+	err = pool.QueryRow(ctx, query, int32(pid)).Scan(&progress.Command, &progress.Phase, &progress.BlocksTotal, &progress.BlocksDone)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return progress, err
+	}
+	if progress.BlocksTotal > 0 {
+		progress.Percent = float64(progress.BlocksDone) * 100 / float64(progress.BlocksTotal)
+	}
+	return progress, nil
+}
+
+// GetMaintenanceProgress reports the progress of a VACUUM or ANALYZE
+// started with a queryId, or 404 once it has finished.
+func GetMaintenanceProgress(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	queryId := c.Param("queryId")
+	pid, ok := database.GetRunningQueryManager().Lookup(connId, queryId)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No running maintenance with that queryId"})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	progress, err := maintenanceProgress(ctx, pool, pid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	progress.QueryID = queryId
+
+	c.JSON(http.StatusOK, progress)
+}
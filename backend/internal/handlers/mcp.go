@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,6 +49,12 @@ func getMCPPool(c *gin.Context) (*database.ConnectionManager, string, bool) {
 	if !ok {
 		return nil, "", false
 	}
+	return sessionPool(c, session)
+}
+
+// sessionPool resolves the database manager + connection ID for an
+// already authenticated session.
+func sessionPool(c *gin.Context, session *claude.Session) (*database.ConnectionManager, string, bool) {
 	dbManager := database.GetManager()
 	if !dbManager.IsConnected(session.ConnectionID) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "database not connected"})
@@ -69,13 +77,18 @@ func MCPGetConnectionInfo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	info := gin.H{
 		"database":     conn.Database,
 		"host":         conn.Host,
 		"port":         conn.Port,
 		"user":         conn.Username,
+		"role":         conn.Role,
 		"is_connected": dbManager.IsConnected(session.ConnectionID),
-	})
+	}
+	if rtt, err := dbManager.Latency(session.ConnectionID); err == nil {
+		info["latency_ms"] = rtt.Seconds() * 1000
+	}
+	c.JSON(http.StatusOK, info)
 }
 
 // MCPListSchemas lists all schemas
@@ -364,38 +377,61 @@ func MCPGetColumns(c *gin.Context) {
 // MCPExecuteQuery executes a SQL query against the active connection. By
 // default the query runs inside a `READ ONLY DEFERRABLE` transaction so
 // the LLM cannot accidentally (or maliciously) DROP/DELETE/UPDATE through
-// the MCP tool. Set `"allowWrites": true` in the request body to opt in
-// to a writable transaction.
+// the MCP tool, and statements that write are rejected up front with a
+// message asking for permission. Once the user allows writes for the
+// session (UpdateClaudeSessionWrites) the query runs in a normal
+// transaction that is committed.
 func MCPExecuteQuery(c *gin.Context) {
-	manager, connId, ok := getMCPPool(c)
+	session, ok := authenticateMCP(c)
+	if !ok {
+		return
+	}
+	manager, connId, ok := sessionPool(c, session)
 	if !ok {
 		return
 	}
 
 	var req struct {
-		SQL         string `json:"sql" binding:"required"`
-		Limit       int    `json:"limit"`
-		AllowWrites bool   `json:"allowWrites"`
+		SQL       string `json:"sql" binding:"required"`
+		Limit     int    `json:"limit"`
+		TimeoutMs int    `json:"timeoutMs"` // the MCP server's own deadline
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	if req.Limit <= 0 {
-		req.Limit = 100
+	runMCPQuery(c, session, manager, connId, req.SQL, nil, req.Limit, mcpQueryTimeout(req.TimeoutMs))
+}
+
+// runMCPQuery runs SQL for a Claude session under the read/write guard
+// described on MCPExecuteQuery and writes up to limit rows.
+func runMCPQuery(c *gin.Context, session *claude.Session, manager *database.ConnectionManager, connId, sql string, args []any, limit int, timeout time.Duration) {
+	// The MCP server never sends the confirmation header, so writes to a
+	// production connection stay with the user even when writes are on.
+	if rejectReadOnlySQL(c, connId, sql) || rejectUnconfirmedProductionWrite(c, connId, sql) {
+		return
 	}
-	if req.Limit > 1000 {
-		req.Limit = 1000
+	allowWrites := session.AllowsWrites()
+	if stmt, bad := readOnlyViolation(sql); bad && !allowWrites {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("writes are disabled for this session; statement not allowed: %s. "+
+				"Ask the user to enable writes for the Claude assistant in PgVoyager, then try again.", truncateStatement(stmt)),
+			"statement": stmt,
+		})
+		return
 	}
 
+	limit = clampMCPQueryRows(limit)
+
+	// Tied to the request so a canceled or timed-out tool call stops the
+	// query instead of leaving it running.
 	pool, _ := manager.GetPool(connId)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 
 	txOpts := pgx.TxOptions{AccessMode: pgx.ReadOnly, DeferrableMode: pgx.Deferrable}
-	if req.AllowWrites {
+	if allowWrites {
 		txOpts = pgx.TxOptions{}
 	}
 	tx, err := pool.BeginTx(ctx, txOpts)
@@ -403,15 +439,10 @@ func MCPExecuteQuery(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": dbsafe.SafeErrorMessage(err)})
 		return
 	}
-	defer func() {
-		// Always roll back. For read-only this is the only sensible
-		// outcome; for explicit writes we still want callers to be
-		// deliberate about persistence (they can use the main query
-		// endpoint for committed writes).
-		_ = tx.Rollback(context.Background())
-	}()
+	// A no-op after a successful commit; otherwise nothing persists.
+	defer tx.Rollback(context.Background())
 
-	rows, err := tx.Query(ctx, req.SQL)
+	rows, err := tx.Query(ctx, sql, args...)
 	if err != nil {
 		status := http.StatusBadRequest
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -432,7 +463,7 @@ func MCPExecuteQuery(c *gin.Context) {
 	// Fetch rows
 	var results []map[string]interface{}
 	count := 0
-	for rows.Next() && count < req.Limit {
+	for rows.Next() && count < limit {
 		values, err := rows.Values()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -441,12 +472,30 @@ func MCPExecuteQuery(c *gin.Context) {
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			row[col] = values[i]
+			row[col] = convertValue(values[i], fieldDescs[i].DataTypeOID)
 		}
 		results = append(results, row)
 		count++
 	}
 
+	// An error partway through (a failed cast, the timeout) only shows up
+	// here; don't pass the rows before it off as the whole result.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		c.JSON(status, gin.H{"error": dbsafe.SafeErrorMessage(err)})
+		return
+	}
+	if allowWrites {
+		if err := tx.Commit(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": dbsafe.SafeErrorMessage(err)})
+			return
+		}
+	}
+
 	output := map[string]interface{}{
 		"columns":   columns,
 		"rows":      results,
@@ -457,6 +506,105 @@ func MCPExecuteQuery(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", result)
 }
 
+const (
+	defaultMCPQueryTimeout = 30 * time.Second
+	maxMCPQueryTimeout     = 10 * time.Minute
+)
+
+// mcpQueryTimeout is how long execute_query may run: the MCP server's
+// timeout (PGVOYAGER_MCP_TIMEOUT) when it sends one, capped at
+// maxMCPQueryTimeout.
+func mcpQueryTimeout(ms int) time.Duration {
+	if ms <= 0 {
+		return defaultMCPQueryTimeout
+	}
+	return min(time.Duration(ms)*time.Millisecond, maxMCPQueryTimeout)
+}
+
+const (
+	defaultSampleRows = 10
+	maxSampleRows     = 100
+)
+
+// parseSampleRows reads the sample_table row count, defaulting missing or
+// invalid values and capping at maxSampleRows.
+func parseSampleRows(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return defaultSampleRows
+	}
+	return min(n, maxSampleRows)
+}
+
+// MCPSampleTable returns the first few rows of a table so Claude can see
+// what the data looks like. Runs read-only like MCPExecuteQuery.
+func MCPSampleTable(c *gin.Context) {
+	manager, connId, ok := getMCPPool(c)
+	if !ok {
+		return
+	}
+
+	schema := c.Param("schema")
+	table := c.Param("table")
+	if !isValidIdentifier(schema) || !isValidIdentifier(table) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or table name"})
+		return
+	}
+	limit := parseSampleRows(c.Query("limit"))
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": dbsafe.SafeErrorMessage(err)})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	query := "SELECT * FROM " + quoteIdentifier(schema) + "." + quoteIdentifier(table) + " LIMIT $1"
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": dbsafe.SafeErrorMessage(err)})
+		return
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	columns := make([]string, len(fieldDescs))
+	for i, fd := range fieldDescs {
+		columns[i] = string(fd.Name)
+	}
+
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = convertValue(values[i], fieldDescs[i].DataTypeOID)
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": dbsafe.SafeErrorMessage(err)})
+		return
+	}
+
+	output := map[string]interface{}{
+		"columns":   columns,
+		"rows":      results,
+		"row_count": len(results),
+	}
+
+	result, _ := json.MarshalIndent(output, "", "  ")
+	c.Data(http.StatusOK, "application/json", result)
+}
+
 // MCPListViews lists views
 func MCPListViews(c *gin.Context) {
 	manager, connId, ok := getMCPPool(c)
@@ -705,8 +853,7 @@ func MCPInsertToEditor(c *gin.Context) {
 		} `json:"position"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -731,7 +878,8 @@ func MCPInsertToEditor(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
-// MCPReplaceEditorContent replaces the entire editor content
+// MCPReplaceEditorContent replaces the entire editor content, or with
+// preview proposes the replacement for the user to accept
 func MCPReplaceEditorContent(c *gin.Context) {
 	session, ok := authenticateMCP(c)
 	if !ok {
@@ -740,10 +888,26 @@ func MCPReplaceEditorContent(c *gin.Context) {
 
 	var req struct {
 		Content string `json:"content" binding:"required"`
+		// Preview shows the change as a diff for the user to accept
+		// instead of applying it.
+		Preview bool `json:"preview"`
+	}
+
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if req.Preview {
+		previewID, err := claude.GetManager().PreviewReplace(session.ID, req.Content)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success":   true,
+			"previewId": previewID,
+			"message":   "Proposed change shown to the user; the editor is unchanged until they accept it",
+		})
 		return
 	}
 
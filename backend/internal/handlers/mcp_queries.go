@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// mcpSavedQuery is what list_saved_queries shows Claude of a saved query.
+type mcpSavedQuery struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Folder      string              `json:"folder,omitempty"`
+	SQL         string              `json:"sql"`
+	Parameters  []models.QueryParam `json:"parameters,omitempty"`
+}
+
+// savedQueryUsable reports whether a saved query may run on a session's
+// connection: it's either bound to that connection or to none.
+func savedQueryUsable(query *models.SavedQuery, connId string) bool {
+	return query.ConnectionID == "" || query.ConnectionID == connId
+}
+
+// MCPListSavedQueries lists the user's saved queries that apply to the
+// session's connection, optionally narrowed by ?tag= and ?folder=.
+func MCPListSavedQueries(c *gin.Context) {
+	session, ok := authenticateMCP(c)
+	if !ok {
+		return
+	}
+
+	var filter models.SavedQueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	queries, err := database.GetQueryManager().List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := []mcpSavedQuery{}
+	for _, q := range queries {
+		if !savedQueryUsable(q, session.ConnectionID) {
+			continue
+		}
+		result = append(result, mcpSavedQuery{
+			ID:          q.ID,
+			Name:        q.Name,
+			Description: q.Description,
+			Tags:        q.Tags,
+			Folder:      q.Folder,
+			SQL:         q.SQL,
+			Parameters:  q.Parameters,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// MCPRunSavedQuery runs a saved query by ID on the session's connection,
+// binding its parameters like POST /queries/:id/run and under the same
+// read/write guard as MCPExecuteQuery.
+func MCPRunSavedQuery(c *gin.Context) {
+	session, ok := authenticateMCP(c)
+	if !ok {
+		return
+	}
+
+	query, err := database.GetQueryManager().Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !savedQueryUsable(query, session.ConnectionID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "this query is saved for a different connection"})
+		return
+	}
+
+	var req struct {
+		Params    map[string]any `json:"params"`
+		Limit     int            `json:"limit"`
+		TimeoutMs int            `json:"timeoutMs"`
+	}
+	// Body is optional when every parameter has a default
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	manager, connId, ok := sessionPool(c, session)
+	if !ok {
+		return
+	}
+	sql, args, err := bindSavedQueryParams(query, req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	runMCPQuery(c, session, manager, connId, sql, args, req.Limit, mcpQueryTimeout(req.TimeoutMs))
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSampleRows(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", defaultSampleRows},
+		{"abc", defaultSampleRows},
+		{"0", defaultSampleRows},
+		{"-3", defaultSampleRows},
+		{"25", 25},
+		{"500", maxSampleRows},
+	}
+	for _, tt := range tests {
+		if got := parseSampleRows(tt.in); got != tt.want {
+			t.Errorf("parseSampleRows(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMCPQueryTimeout(t *testing.T) {
+	tests := []struct {
+		ms   int
+		want time.Duration
+	}{
+		{0, defaultMCPQueryTimeout},
+		{-1, defaultMCPQueryTimeout},
+		{90000, 90 * time.Second},
+		{int(time.Hour / time.Millisecond), maxMCPQueryTimeout},
+	}
+	for _, tt := range tests {
+		if got := mcpQueryTimeout(tt.ms); got != tt.want {
+			t.Errorf("mcpQueryTimeout(%d) = %s, want %s", tt.ms, got, tt.want)
+		}
+	}
+}
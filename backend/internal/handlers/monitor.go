@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// maxGIDLength is the longest transaction identifier PREPARE TRANSACTION
+// accepts.
+const maxGIDLength = 200
+
+// ListPreparedTransactions lists two-phase transactions awaiting COMMIT or
+// ROLLBACK PREPARED, oldest first. Forgotten ones hold their locks and
+// pin the xmin horizon, so vacuum can't clean up behind them.
+func ListPreparedTransactions(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			gid,
+			transaction::text,
+			prepared,
+			owner,
+			database,
+			EXTRACT(EPOCH FROM now() - prepared)::float8 as age_seconds,
+			database = current_database() as in_current_database
+		FROM pg_catalog.pg_prepared_xacts
+		ORDER BY prepared
+	`
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	xacts := []models.PreparedTransaction{}
+	for rows.Next() {
+		var x models.PreparedTransaction
+		if err := rows.Scan(
+			&x.GID, &x.Transaction, &x.Prepared, &x.Owner, &x.Database,
+			&x.AgeSeconds, &x.InCurrentDatabase,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		xacts = append(xacts, x)
+	}
+
+	c.JSON(http.StatusOK, xacts)
+}
+
+// RollbackPrepared runs ROLLBACK PREPARED for one transaction. The gid is
+// the rest of the path, so identifiers containing '/' still work.
+//
+// ROLLBACK PREPARED takes a string literal, not a parameter, so the gid is
+// checked against pg_prepared_xacts first and then quoted with
+// dbsafe.QuoteString.
+func RollbackPrepared(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "rolling back prepared transactions") {
+		return
+	}
+
+	gid := strings.TrimPrefix(c.Param("gid"), "/")
+	if gid == "" || len(gid) > maxGIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction identifier"})
+		return
+	}
+	literal, err := dbsafe.QuoteString(gid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction identifier"})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var database string
+	var inCurrent bool
+	err = pool.QueryRow(ctx,
+		`SELECT database, database = current_database() FROM pg_catalog.pg_prepared_xacts WHERE gid = $1`,
+		gid,
+	).Scan(&database, &inCurrent)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prepared transaction not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !inCurrent {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    fmt.Sprintf("Prepared transaction belongs to database %s; switch to it to roll it back", database),
+			"database": database,
+		})
+		return
+	}
+
+	if _, err := pool.Exec(ctx, "ROLLBACK PREPARED "+literal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Prepared transaction %s rolled back", gid),
+		"warning": "The transaction's changes were discarded. If an external transaction manager " +
+			"still tracks it, that coordinator may now report it as failed or in doubt.",
+	})
+}
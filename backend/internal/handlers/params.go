@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+var dollarQuoteTag = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+func isIdentStart(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isIdentChar(b byte) bool {
+	return isIdentStart(b) || (b >= '0' && b <= '9') || b == '$'
+}
+
+// rewriteNamedParams replaces :name placeholders with positional $n
+// parameters, numbering names in order of first use so a repeated name
+// reuses its number. When types has an entry for a name the placeholder is
+// cast to it ($n::type). Returns the rewritten SQL and the names by
+// position.
+//
+// String literals (including E'' escapes), quoted identifiers, dollar-
+// quoted bodies and comments are copied untouched, as are :: casts. A
+// colon directly after an identifier or number (array slices such as
+// arr[lo:hi]) is not a placeholder.
+func rewriteNamedParams(sql string, types map[string]string) (string, []string) {
+	var out strings.Builder
+	var names []string
+	index := make(map[string]int)
+
+	i := 0
+	for i < len(sql) {
+		ch := sql[i]
+		end := i + 1
+
+		switch {
+		case ch == '\'':
+			end = skipQuoted(sql, i, '\'', isEscapeString(sql, i))
+		case ch == '"':
+			end = skipQuoted(sql, i, '"', false)
+		case ch == '-' && strings.HasPrefix(sql[i:], "--"):
+			end = len(sql)
+			if nl := strings.IndexByte(sql[i:], '\n'); nl >= 0 {
+				end = i + nl + 1
+			}
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end = skipBlockComment(sql, i)
+		case ch == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			if tag := dollarQuoteTag.FindString(sql[i:]); tag != "" {
+				end = len(sql)
+				if close := strings.Index(sql[i+len(tag):], tag); close >= 0 {
+					end = i + len(tag) + close + len(tag)
+				}
+			}
+		case ch == ':' && strings.HasPrefix(sql[i:], "::"):
+			end = i + 2
+		case ch == ':' && i+1 < len(sql) && isIdentStart(sql[i+1]) && (i == 0 || !isIdentChar(sql[i-1])):
+			j := i + 1
+			for j < len(sql) && isIdentChar(sql[j]) && sql[j] != '$' {
+				j++
+			}
+			name := sql[i+1 : j]
+			n, ok := index[name]
+			if !ok {
+				names = append(names, name)
+				n = len(names)
+				index[name] = n
+			}
+			out.WriteString("$" + strconv.Itoa(n))
+			if t := types[name]; t != "" {
+				out.WriteString("::" + t)
+			}
+			i = j
+			continue
+		}
+
+		out.WriteString(sql[i:end])
+		i = end
+	}
+	return out.String(), names
+}
+
+// skipQuoted returns the index just past the quoted section starting at
+// start. A doubled quote is an escaped quote; with backslash set (E''
+// strings) a backslash escapes the next byte too.
+func skipQuoted(sql string, start int, quote byte, backslash bool) int {
+	i := start + 1
+	for i < len(sql) {
+		switch {
+		case backslash && sql[i] == '\\':
+			i += 2
+			continue
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return len(sql)
+}
+
+// isEscapeString reports whether the quote at pos opens an E'' string.
+func isEscapeString(sql string, pos int) bool {
+	if pos == 0 || (sql[pos-1] != 'E' && sql[pos-1] != 'e') {
+		return false
+	}
+	return pos < 2 || !isIdentChar(sql[pos-2])
+}
+
+// skipBlockComment returns the index just past the comment starting at
+// start; Postgres block comments nest.
+func skipBlockComment(sql string, start int) int {
+	depth := 0
+	i := start
+	for i < len(sql)-1 {
+		switch sql[i : i+2] {
+		case "/*":
+			depth++
+			i += 2
+			continue
+		case "*/":
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+			continue
+		}
+		i++
+	}
+	return len(sql)
+}
+
+// validateQueryParams checks declared parameters: valid, unique names and
+// plausible type hints.
+func validateQueryParams(params []models.QueryParam) string {
+	seen := make(map[string]bool, len(params))
+	for _, p := range params {
+		if !isValidIdentifier(p.Name) {
+			return fmt.Sprintf("invalid parameter name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Sprintf("parameter %q is declared twice", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Type != "" && !dbsafe.ValidColumnType(p.Type) {
+			return fmt.Sprintf("invalid type %q for parameter %q", p.Type, p.Name)
+		}
+	}
+	return ""
+}
+
+// bindSavedQueryParams rewrites a saved query's placeholders and resolves
+// their values: the supplied value, else the declared default. Placeholders
+// with neither are missing and reject the run, as do supplied values for
+// names the query doesn't use.
+func bindSavedQueryParams(query *models.SavedQuery, values map[string]any) (string, []any, error) {
+	declared := make(map[string]models.QueryParam, len(query.Parameters))
+	types := make(map[string]string, len(query.Parameters))
+	for _, p := range query.Parameters {
+		declared[p.Name] = p
+		types[p.Name] = p.Type
+	}
+
+	sql, names := rewriteNamedParams(query.SQL, types)
+
+	used := make(map[string]bool, len(names))
+	args := make([]any, len(names))
+	var missing []string
+	for i, name := range names {
+		used[name] = true
+		if v, ok := values[name]; ok {
+			args[i] = v
+		} else if p, ok := declared[name]; ok && p.Default != nil {
+			args[i] = p.Default
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+
+	var unknown []string
+	for name := range values {
+		if !used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", nil, fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", "))
+	}
+	return sql, args, nil
+}
+
+// paramCoercers convert a decoded JSON param to the Go value pgx binds as
+// the named type, by the type names (and common aliases) a query's
+// paramTypes may use.
+var paramCoercers = map[string]func(any) (any, error){
+	"int2": coerceInteger(16), "smallint": coerceInteger(16),
+	"int4": coerceInteger(32), "integer": coerceInteger(32), "int": coerceInteger(32),
+	"int8": coerceInteger(64), "bigint": coerceInteger(64),
+	"float4": coerceFloat, "real": coerceFloat,
+	"float8": coerceFloat, "double precision": coerceFloat,
+	"numeric": coerceNumeric, "decimal": coerceNumeric,
+	"bool": coerceBool, "boolean": coerceBool,
+	"uuid": coerceUUID, "date": coerceTime([]string{time.DateOnly}),
+	"timestamp": coerceTime(paramTimeLayouts), "timestamptz": coerceTime(paramTimeLayouts),
+	"text": coerceText, "varchar": coerceText,
+	"json": coerceJSON, "jsonb": coerceJSON,
+}
+
+// paramTimeLayouts are the forms accepted for timestamp params. Without a
+// zone the time is taken as UTC.
+var paramTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+// maxExactFloat is the largest integer a JSON number holds exactly.
+const maxExactFloat = 1 << 53
+
+// coerceParams converts params to the types named in types, position by
+// position. Params beyond types, empty type names and nulls are left as
+// they are. Unknown type names and values that don't fit their type are
+// an error naming the param.
+func coerceParams(params []any, types []string) ([]any, error) {
+	if len(types) == 0 {
+		return params, nil
+	}
+	if len(types) > len(params) {
+		return nil, fmt.Errorf("paramTypes has %d entries but only %d params were given", len(types), len(params))
+	}
+
+	out := make([]any, len(params))
+	copy(out, params)
+	for i, name := range types {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		coerce, ok := paramCoercers[name]
+		if !ok {
+			return nil, fmt.Errorf("param $%d: unsupported type %q", i+1, types[i])
+		}
+		if out[i] == nil {
+			continue
+		}
+		v, err := coerce(out[i])
+		if err != nil {
+			return nil, fmt.Errorf("param $%d (%s): %w", i+1, name, err)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func coerceInteger(bits int) func(any) (any, error) {
+	return func(v any) (any, error) {
+		switch x := v.(type) {
+		case float64:
+			if x != math.Trunc(x) {
+				return nil, fmt.Errorf("expected an integer, got %v", x)
+			}
+			if math.Abs(x) > maxExactFloat {
+				return nil, fmt.Errorf("%v is too large to send as a JSON number; send it as a string", x)
+			}
+			limit := math.Ldexp(1, bits-1)
+			if x < -limit || x >= limit {
+				return nil, fmt.Errorf("%v is out of range for a %d-bit integer", x, bits)
+			}
+			return int64(x), nil
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(x), 10, bits)
+			if err != nil {
+				return nil, fmt.Errorf("expected a %d-bit integer, got %q", bits, x)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected an integer, got %s", jsonKind(v))
+	}
+}
+
+func coerceFloat(v any) (any, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", x)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("expected a number, got %s", jsonKind(v))
+}
+
+// coerceNumeric keeps a string's exact digits; a JSON number has already
+// been through float64.
+func coerceNumeric(v any) (any, error) {
+	var s string
+	switch x := v.(type) {
+	case float64:
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	case string:
+		s = strings.TrimSpace(x)
+	default:
+		return nil, fmt.Errorf("expected a number, got %s", jsonKind(v))
+	}
+	var n pgtype.Numeric
+	if err := n.Scan(s); err != nil {
+		return nil, fmt.Errorf("expected a number, got %q", s)
+	}
+	return n, nil
+}
+
+func coerceBool(v any) (any, error) {
+	switch x := v.(type) {
+	case bool:
+		return x, nil
+	case string:
+		if b, ok := boolLiterals[strings.ToLower(strings.TrimSpace(x))]; ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %q", x)
+	}
+	return nil, fmt.Errorf("expected a boolean, got %s", jsonKind(v))
+}
+
+func coerceUUID(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a UUID string, got %s", jsonKind(v))
+	}
+	id, err := uuid.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid UUID", s)
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, nil
+}
+
+func coerceTime(layouts []string) func(any) (any, error) {
+	return func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a date/time string, got %s", jsonKind(v))
+		}
+		s = strings.TrimSpace(s)
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a recognized date/time (use ISO 8601, e.g. 2024-01-31T12:00:00Z)", s)
+	}
+}
+
+func coerceText(v any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	}
+	return nil, fmt.Errorf("expected a string, got %s", jsonKind(v))
+}
+
+// coerceJSON encodes the param itself as the JSON document, so a string
+// param becomes a JSON string rather than being taken as raw JSON text.
+func coerceJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
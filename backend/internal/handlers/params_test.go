@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestRewriteNamedParams(t *testing.T) {
+	tests := []struct {
+		name, sql, want string
+		names           []string
+	}{
+		{
+			name:  "basic and repeated",
+			sql:   "SELECT * FROM t WHERE a >= :start AND a < :end OR b = :start",
+			want:  "SELECT * FROM t WHERE a >= $1 AND a < $2 OR b = $1",
+			names: []string{"start", "end"},
+		},
+		{
+			name:  "casts are not placeholders",
+			sql:   "SELECT :x::int, now()::date",
+			want:  "SELECT $1::int, now()::date",
+			names: []string{"x"},
+		},
+		{
+			name:  "quoted text untouched",
+			sql:   `SELECT ':a', 'it''s :b', E'\':c', ":d", $$ :e $$, $fn$ :f $fn$ WHERE x = :g`,
+			want:  `SELECT ':a', 'it''s :b', E'\':c', ":d", $$ :e $$, $fn$ :f $fn$ WHERE x = $1`,
+			names: []string{"g"},
+		},
+		{
+			name:  "comments untouched",
+			sql:   "SELECT 1 -- :a\n/* :b /* :c */ :d */ WHERE y = :h",
+			want:  "SELECT 1 -- :a\n/* :b /* :c */ :d */ WHERE y = $1",
+			names: []string{"h"},
+		},
+		{
+			name: "array slices",
+			sql:  "SELECT arr[lo:hi], arr[1:n] FROM t",
+			want: "SELECT arr[lo:hi], arr[1:n] FROM t",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, names := rewriteNamedParams(tt.sql, nil)
+			if got != tt.want {
+				t.Errorf("sql = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %v, want %v", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestBindSavedQueryParams(t *testing.T) {
+	query := &models.SavedQuery{
+		SQL: "SELECT * FROM orders WHERE created_at >= :since AND status = :status",
+		Parameters: []models.QueryParam{
+			{Name: "since", Type: "date"},
+			{Name: "status", Default: "open"},
+		},
+	}
+
+	sql, args, err := bindSavedQueryParams(query, map[string]any{"since": "2024-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT * FROM orders WHERE created_at >= $1::date AND status = $2"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if want := []any{"2024-01-01", "open"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	if _, _, err := bindSavedQueryParams(query, nil); err == nil || !strings.Contains(err.Error(), "since") {
+		t.Errorf("missing required parameter should fail, got %v", err)
+	}
+	if _, _, err := bindSavedQueryParams(query, map[string]any{"since": "x", "typo": 1}); err == nil || !strings.Contains(err.Error(), "typo") {
+		t.Errorf("unknown parameter should fail, got %v", err)
+	}
+}
+
+func TestValidateQueryParams(t *testing.T) {
+	if msg := validateQueryParams([]models.QueryParam{{Name: "a", Type: "timestamp with time zone"}, {Name: "b"}}); msg != "" {
+		t.Errorf("valid params rejected: %s", msg)
+	}
+	for _, params := range [][]models.QueryParam{
+		{{Name: "1a"}},
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "a", Type: "int; DROP TABLE t"}},
+	} {
+		if validateQueryParams(params) == "" {
+			t.Errorf("validateQueryParams(%v) should fail", params)
+		}
+	}
+}
+
+func TestCoerceParams(t *testing.T) {
+	id := "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	got, err := coerceParams(
+		[]any{"42", float64(7), id, "2024-01-31T12:00:00Z", "2024-01-31", "yes", "12.50", "keep", nil, "untyped"},
+		[]string{"int8", "INT4", "uuid", "timestamptz", "date", "boolean", "numeric", "text", "int8"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != int64(42) || got[1] != int64(7) || got[5] != true || got[7] != "keep" || got[8] != nil || got[9] != "untyped" {
+		t.Errorf("unexpected coercion: %#v", got)
+	}
+	if u, ok := got[2].(pgtype.UUID); !ok || !u.Valid || uuid.UUID(u.Bytes).String() != id {
+		t.Errorf("uuid = %#v", got[2])
+	}
+	if ts, ok := got[3].(time.Time); !ok || !ts.Equal(time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("timestamptz = %#v", got[3])
+	}
+	if d, ok := got[4].(time.Time); !ok || d.Day() != 31 {
+		t.Errorf("date = %#v", got[4])
+	}
+	if n, ok := got[6].(pgtype.Numeric); !ok || !n.Valid || n.Int.Int64() != 1250 || n.Exp != -2 {
+		t.Errorf("numeric = %#v", got[6])
+	}
+
+	if got, err := coerceParams([]any{map[string]any{"a": 1.0}, "x"}, []string{"jsonb", "json"}); err != nil || got[0] != `{"a":1}` || got[1] != `"x"` {
+		t.Errorf("json = %#v, %v", got, err)
+	}
+
+	for _, tc := range []struct {
+		params []any
+		types  []string
+		want   string
+	}{
+		{[]any{"1"}, []string{"int4", "int4"}, "2 entries"},
+		{[]any{"1"}, []string{"money"}, `unsupported type "money"`},
+		{[]any{1.5}, []string{"int8"}, "param $1 (int8): expected an integer"},
+		{[]any{float64(40000)}, []string{"int2"}, "out of range"},
+		{[]any{float64(1 << 60)}, []string{"bigint"}, "send it as a string"},
+		{[]any{"x", "nope"}, []string{"", "uuid"}, "param $2 (uuid)"},
+		{[]any{"yesterday"}, []string{"timestamp"}, "not a recognized date/time"},
+		{[]any{true}, []string{"numeric"}, "got a boolean"},
+		{[]any{[]any{"a"}}, []string{"text"}, "got an array"},
+	} {
+		if _, err := coerceParams(tc.params, tc.types); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("coerceParams(%v, %v) error = %v, want %q", tc.params, tc.types, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// Server versions, as server_version_num, that introduced catalog objects
+// PgVoyager reads. Queries using them check the version first and fall
+// back, or report the feature as unsupported, on older servers.
+const (
+	pg10 = 100000 // declarative partitioning: pg_get_partkeydef; pg_attribute.attidentity
+	pg12 = 120000 // pg_partition_tree; pg_attribute.attgenerated; pg_stat_progress_cluster
+	pg13 = 130000 // pg_stat_progress_analyze
+)
+
+// serverVersionNum reads the server's version as server_version_num, e.g.
+// 160002 for 16.2.
+func serverVersionNum(ctx context.Context, q queryRunner) (int, error) {
+	rows, err := q.Query(ctx, "SELECT current_setting('server_version_num')::int")
+	if err != nil {
+		return 0, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowTo[int])
+}
+
+// requireServerVersion answers 501 and returns false when the server is
+// older than minVersion, which feature needs.
+func requireServerVersion(ctx context.Context, c *gin.Context, q queryRunner, minVersion int, feature string) bool {
+	version, err := serverVersionNum(ctx, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if version < minVersion {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error": fmt.Sprintf("%s needs PostgreSQL %d or later; this server is %s", feature, minVersion/10000, formatServerVersion(version)),
+		})
+		return false
+	}
+	return true
+}
+
+// formatServerVersion renders a server_version_num as "16.2", or "9.6.24"
+// before Postgres 10.
+func formatServerVersion(version int) string {
+	if version < pg10 {
+		return fmt.Sprintf("%d.%d.%d", version/10000, version/100%100, version%100)
+	}
+	return fmt.Sprintf("%d.%d", version/10000, version%10000)
+}
+
+// columnQueryForVersion adapts a query reading pg_attribute's attgenerated
+// and attidentity (as a.attgenerated and a.attidentity) to servers that
+// predate them, where no column is generated or an identity.
+func columnQueryForVersion(query string, version int) string {
+	generated, identity := "a.attgenerated", "a.attidentity"
+	if version < pg12 {
+		generated = `''::"char"`
+	}
+	if version < pg10 {
+		identity = `''::"char"`
+	}
+	return strings.NewReplacer("a.attgenerated", generated, "a.attidentity", identity).Replace(query)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatServerVersion(t *testing.T) {
+	for version, want := range map[int]string{160002: "16.2", 100023: "10.23", 90624: "9.6.24"} {
+		if got := formatServerVersion(version); got != want {
+			t.Errorf("formatServerVersion(%d) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestTableQueryByVersion(t *testing.T) {
+	if q := tableQuery(110000); strings.Contains(q, "pg_partition_tree") || !strings.Contains(q, "pg_get_partkeydef") {
+		t.Errorf("Postgres 11 query should skip pg_partition_tree but keep the partition key:\n%s", q)
+	}
+	if q := tableQuery(90600); strings.Contains(q, "pg_get_partkeydef") {
+		t.Errorf("Postgres 9.6 query should not use pg_get_partkeydef:\n%s", q)
+	}
+	if q := tableQuery(160000); !strings.Contains(q, "pg_partition_tree") {
+		t.Errorf("Postgres 16 query should sum partitions:\n%s", q)
+	}
+}
+
+func TestColumnQueryForVersion(t *testing.T) {
+	query := "SELECT a.attgenerated <> '', a.attidentity <> '' FROM pg_attribute a"
+	if q := columnQueryForVersion(query, 110000); strings.Contains(q, "attgenerated") || !strings.Contains(q, "a.attidentity") {
+		t.Errorf("Postgres 11 query should skip attgenerated but keep attidentity:\n%s", q)
+	}
+	if q := columnQueryForVersion(query, 90600); strings.Contains(q, "attgenerated") || strings.Contains(q, "attidentity") {
+		t.Errorf("Postgres 9.6 query should skip both columns:\n%s", q)
+	}
+	if q := columnQueryForVersion(query, 160000); q != query {
+		t.Errorf("Postgres 16 query should be unchanged:\n%s", q)
+	}
+}
+
+func TestMaintenanceProgressQueryByVersion(t *testing.T) {
+	if q := maintenanceProgressQuery(110000); strings.Contains(q, "pg_stat_progress_cluster") || strings.Contains(q, "pg_stat_progress_analyze") {
+		t.Errorf("Postgres 11 query should read only pg_stat_progress_vacuum:\n%s", q)
+	}
+	if q := maintenanceProgressQuery(120000); !strings.Contains(q, "pg_stat_progress_cluster") || strings.Contains(q, "pg_stat_progress_analyze") {
+		t.Errorf("Postgres 12 query should add pg_stat_progress_cluster only:\n%s", q)
+	}
+	if q := maintenanceProgressQuery(160000); strings.Count(q, "UNION ALL") != 2 {
+		t.Errorf("Postgres 16 query should union all three views:\n%s", q)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	// misestimateFactor flags nodes whose actual rows are off from the
+	// planner's estimate by at least this ratio in either direction.
+	misestimateFactor = 10
+	// hotspotCount and hotspotMinPercent pick the nodes that dominate
+	// execution time: the top few by self time, if they matter at all.
+	hotspotCount      = 3
+	hotspotMinPercent = 10
+	// largeSeqScanRows is how many rows a sequential scan has to read
+	// before it's worth pointing out.
+	largeSeqScanRows = 100_000
+)
+
+// planNode is the subset of an EXPLAIN (FORMAT JSON) node we inspect.
+type planNode struct {
+	NodeType          string     `json:"Node Type"`
+	RelationName      string     `json:"Relation Name"`
+	PlanRows          float64    `json:"Plan Rows"`
+	ActualRows        *float64   `json:"Actual Rows"`
+	ActualLoops       float64    `json:"Actual Loops"`
+	ActualTotalTime   float64    `json:"Actual Total Time"`
+	RowsRemovedFilter float64    `json:"Rows Removed by Filter"`
+	Plans             []planNode `json:"Plans"`
+}
+
+type explainDocument struct {
+	Plan          planNode `json:"Plan"`
+	ExecutionTime *float64 `json:"Execution Time"`
+}
+
+// analyzed reports whether EXPLAIN ANALYZE data is present and the node
+// actually ran.
+func (n *planNode) analyzed() bool {
+	return n.ActualRows != nil && n.ActualLoops > 0
+}
+
+// totalTime is the node's inclusive time over all loops, in ms.
+func (n *planNode) totalTime() float64 {
+	return n.ActualTotalTime * n.ActualLoops
+}
+
+// diagnosePlan walks an EXPLAIN (FORMAT JSON) document and returns one
+// diagnostic per node in pre-order.
+func diagnosePlan(planJSON []byte) ([]models.PlanNodeDiagnostic, error) {
+	var docs []explainDocument
+	if err := json.Unmarshal(planJSON, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("empty plan")
+	}
+	doc := docs[0]
+
+	var nodes []models.PlanNodeDiagnostic
+	var walk func(n *planNode, depth int)
+	walk = func(n *planNode, depth int) {
+		d := models.PlanNodeDiagnostic{
+			Index:         len(nodes),
+			Depth:         depth,
+			NodeType:      n.NodeType,
+			Relation:      n.RelationName,
+			EstimatedRows: n.PlanRows,
+		}
+
+		scanned := n.PlanRows
+		if n.analyzed() {
+			d.ActualRows = *n.ActualRows
+			d.Loops = n.ActualLoops
+			d.EstimateFactor = estimateFactor(d.EstimatedRows, d.ActualRows)
+			d.Misestimated = d.EstimateFactor >= misestimateFactor
+
+			self := n.totalTime()
+			for i := range n.Plans {
+				if n.Plans[i].analyzed() {
+					self -= n.Plans[i].totalTime()
+				}
+			}
+			d.SelfTimeMs = max(self, 0)
+
+			scanned = (d.ActualRows + n.RowsRemovedFilter) * n.ActualLoops
+		}
+		d.LargeSeqScan = n.NodeType == "Seq Scan" && scanned >= largeSeqScanRows
+
+		nodes = append(nodes, d)
+		for i := range n.Plans {
+			walk(&n.Plans[i], depth+1)
+		}
+	}
+	walk(&doc.Plan, 0)
+
+	markHotspots(nodes, doc)
+	return nodes, nil
+}
+
+func estimateFactor(estimated, actual float64) float64 {
+	e, a := max(estimated, 1), max(actual, 1)
+	return max(e, a) / min(e, a)
+}
+
+// markHotspots sets SelfTimePercent and flags the nodes with the most self
+// time. Percentages are of the execution time when reported, else of the
+// root node's time.
+func markHotspots(nodes []models.PlanNodeDiagnostic, doc explainDocument) {
+	total := doc.Plan.totalTime()
+	if doc.ExecutionTime != nil && *doc.ExecutionTime > 0 {
+		total = *doc.ExecutionTime
+	}
+	if total <= 0 {
+		return
+	}
+
+	order := make([]int, len(nodes))
+	for i := range nodes {
+		nodes[i].SelfTimePercent = nodes[i].SelfTimeMs / total * 100
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return nodes[order[a]].SelfTimeMs > nodes[order[b]].SelfTimeMs
+	})
+	for _, i := range order[:min(hotspotCount, len(order))] {
+		if nodes[i].SelfTimePercent >= hotspotMinPercent {
+			nodes[i].Hotspot = true
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import "testing"
+
+const samplePlanJSON = `[{
+  "Plan": {
+    "Node Type": "Hash Join", "Plan Rows": 10, "Actual Rows": 5000, "Actual Loops": 1, "Actual Total Time": 100,
+    "Plans": [
+      {"Node Type": "Seq Scan", "Relation Name": "orders", "Plan Rows": 200000, "Actual Rows": 150000,
+       "Actual Loops": 1, "Actual Total Time": 70, "Rows Removed by Filter": 50000},
+      {"Node Type": "Hash", "Plan Rows": 100, "Actual Rows": 100, "Actual Loops": 1, "Actual Total Time": 5,
+       "Plans": [
+         {"Node Type": "Index Scan", "Relation Name": "customers", "Plan Rows": 100, "Actual Rows": 100,
+          "Actual Loops": 1, "Actual Total Time": 4}
+       ]}
+    ]
+  },
+  "Execution Time": 100
+}]`
+
+func TestDiagnosePlan(t *testing.T) {
+	nodes, err := diagnosePlan([]byte(samplePlanJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("got %d nodes, want 4", len(nodes))
+	}
+
+	join, seq, hash, idx := nodes[0], nodes[1], nodes[2], nodes[3]
+	if join.NodeType != "Hash Join" || seq.Relation != "orders" || idx.Depth != 2 {
+		t.Errorf("unexpected pre-order: %+v", nodes)
+	}
+	if !join.Misestimated || join.EstimateFactor != 500 {
+		t.Errorf("join: misestimated=%v factor=%v, want true/500", join.Misestimated, join.EstimateFactor)
+	}
+	if seq.Misestimated || hash.Misestimated {
+		t.Error("accurate estimates should not be flagged")
+	}
+	if join.SelfTimeMs != 25 || hash.SelfTimeMs != 1 {
+		t.Errorf("self time: join=%v hash=%v, want 25/1", join.SelfTimeMs, hash.SelfTimeMs)
+	}
+	if !seq.Hotspot || !join.Hotspot || hash.Hotspot {
+		t.Errorf("hotspots: seq=%v join=%v hash=%v, want true/true/false", seq.Hotspot, join.Hotspot, hash.Hotspot)
+	}
+	if !seq.LargeSeqScan {
+		t.Error("seq scan over 200k rows should be flagged")
+	}
+}
+
+func TestDiagnosePlanWithoutAnalyze(t *testing.T) {
+	nodes, err := diagnosePlan([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Plan Rows": 500000}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := nodes[0]
+	if n.Misestimated || n.Hotspot || n.SelfTimeMs != 0 {
+		t.Errorf("plain EXPLAIN has no actuals to judge: %+v", n)
+	}
+	if !n.LargeSeqScan {
+		t.Error("large estimated seq scan should still be flagged")
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/claude"
+	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/storage"
 )
 
@@ -43,8 +46,12 @@ func GetPreference(c *gin.Context) {
 // SetPreference sets a preference value
 func SetPreference(c *gin.Context) {
 	var req SetPreferenceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := validatePreference(req.Key, req.Value); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid value for %s: %v", req.Key, err)})
 		return
 	}
 
@@ -56,6 +63,51 @@ func SetPreference(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"key": req.Key, "value": req.Value})
 }
 
+// validatePreference checks values for keys the backend itself reads;
+// other keys are opaque frontend settings and accepted as-is.
+func validatePreference(key, value string) error {
+	switch key {
+	case storage.HistoryMaxEntriesPreference:
+		_, err := storage.ParseHistoryRetention(value)
+		return err
+	case database.HealthCheckIntervalPreference:
+		_, err := database.ParseHealthCheckInterval(value)
+		return err
+	case database.TransactionIdleTimeoutPreference:
+		_, err := database.ParseTransactionIdleTimeout(value)
+		return err
+	case database.SizeHistoryIntervalPreference:
+		_, err := database.ParseSizeHistoryInterval(value)
+		return err
+	case storage.SizeHistoryRetentionPreference:
+		_, err := storage.ParseSizeHistoryRetention(value)
+		return err
+	case claude.IdleTimeoutPreference:
+		_, err := claude.ParseIdleTimeout(value)
+		return err
+	case claude.TranscriptSizePreference:
+		_, err := claude.ParseTranscriptSize(value)
+		return err
+	case DefaultPageSizePreference, MaxPageSizePreference, MCPMaxQueryRowsPreference, QueryDiffMaxRowsPreference:
+		_, err := ParseRowLimit(value)
+		return err
+	case analysisLimitPreference:
+		return validateAnalysisLimit(value)
+	case tableBloatCriticalPreference:
+		return validateTableBloatCriticalPct(value)
+	case expectedOwnerPreference:
+		return database.ValidateRole(value)
+	case searchMaxRowsPreference:
+		_, err := parseSearchMaxTableRows(value)
+		return err
+	case storage.HistoryAutoLogPreference, QueryResetSessionPreference:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be \"true\" or \"false\"")
+		}
+	}
+	return nil
+}
+
 // DeletePreference removes a preference
 func DeletePreference(c *gin.Context) {
 	key := c.Param("key")
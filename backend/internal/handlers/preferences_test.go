@@ -0,0 +1,29 @@
+package handlers
+
+import "testing"
+
+func TestValidatePreference(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{analysisLimitPreference, "50", false},
+		{analysisLimitPreference, "0", true},
+		{analysisLimitPreference, "501", true},
+		{tableBloatCriticalPreference, "37.5", false},
+		{tableBloatCriticalPreference, "150", true},
+		{tableBloatCriticalPreference, "lots", true},
+		{expectedOwnerPreference, "app_owner", false},
+		{expectedOwnerPreference, "bad\nrole", true},
+		{searchMaxRowsPreference, "250000", false},
+		{searchMaxRowsPreference, "-5", true},
+		{QueryResetSessionPreference, "false", false},
+		{QueryResetSessionPreference, "no", true},
+		{"editor.theme", "anything", false},
+	}
+	for _, tt := range tests {
+		if err := validatePreference(tt.key, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("validatePreference(%q, %q) = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+// readOnlyDataRoutes are the non-GET data routes that never write. An
+// interactive transaction's writes are confirmed statement by statement,
+// so opening and ending it, or managing its savepoints, needs no
+// confirmation; linting only inspects its SQL, and a diff runs in a
+// read-only transaction.
+var readOnlyDataRoutes = map[string]bool{
+	"/api/data/:connId/infer-types":     true,
+	"/api/data/:connId/search":          true,
+	"/api/query/:connId/lint":           true,
+	"/api/query/:connId/diff":           true,
+	"/api/tx/:connId/begin":             true,
+	"/api/tx/:connId/:txId/commit":      true,
+	"/api/tx/:connId/:txId/rollback":    true,
+	"/api/tx/:connId/:txId/savepoint":   true,
+	"/api/tx/:connId/:txId/release":     true,
+	"/api/tx/:connId/:txId/rollback-to": true,
+}
+
+// isProductionWrite reports whether a guarded request would change the
+// database. Data, maintenance and other guarded routes write unless they
+// are GETs or listed in readOnlyDataRoutes; query
+// and tx routes write when their "sql" body holds a statement a read-only
+// connection would refuse.
+func isProductionWrite(c *gin.Context) (bool, error) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return false, nil
+	}
+	if readOnlyDataRoutes[c.FullPath()] {
+		return false, nil
+	}
+	if !strings.HasPrefix(c.FullPath(), "/api/query/") && !strings.HasPrefix(c.FullPath(), "/api/tx/") {
+		return true, nil
+	}
+
+	// Buffer the body so the handler can still bind it.
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return false, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		SQL string `json:"sql"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.SQL == "" {
+		// Let the handler report the malformed request.
+		return false, nil
+	}
+	_, bad := readOnlyViolation(req.SQL)
+	return bad, nil
+}
+
+// productionConnID is the connection a guarded route writes through: its
+// :connId, or the :id of a connections-group route such as CREATE and
+// DROP DATABASE.
+func productionConnID(c *gin.Context) string {
+	if connId := c.Param("connId"); connId != "" {
+		return connId
+	}
+	return c.Param("id")
+}
+
+// RequireProductionConfirmation guards the data, query, tx and maintenance
+// route groups and the other routes that write through a connection in
+// their path, such as the schema group's DDL routes, macro runs, session
+// termination and creating or dropping databases: writes to a connection
+// tagged production fail with 428 unless the request carries
+// models.ConfirmProductionHeader: true. The query stream WebSocket can't
+// send headers and confirms in its request message instead (see
+// StreamQuery); routes whose connection isn't in the path use
+// rejectUnconfirmedProductionWrite.
+func RequireProductionConfirmation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		connId := productionConnID(c)
+		if !database.GetManager().IsProduction(connId) || c.GetHeader(models.ConfirmProductionHeader) == "true" {
+			c.Next()
+			return
+		}
+
+		write, err := isProductionWrite(c)
+		if err != nil {
+			if security.AbortIfBodyTooLarge(c, err) {
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if write {
+			abortUnconfirmedProduction(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectUnconfirmedProductionWrite is RequireProductionConfirmation for
+// handlers that resolve their connection from the body or a session: it
+// answers 428 and returns true when sql would write to a production
+// connection without models.ConfirmProductionHeader: true.
+func rejectUnconfirmedProductionWrite(c *gin.Context, connId, sql string) bool {
+	if !database.GetManager().IsProduction(connId) || c.GetHeader(models.ConfirmProductionHeader) == "true" {
+		return false
+	}
+	if _, bad := readOnlyViolation(sql); !bad {
+		return false
+	}
+	abortUnconfirmedProduction(c)
+	return true
+}
+
+func abortUnconfirmedProduction(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+		"error": fmt.Sprintf("connection is tagged production; resend with %s: true to confirm", models.ConfirmProductionHeader),
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsProductionWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got bool
+	var body string
+	handler := func(c *gin.Context) {
+		got, _ = isProductionWrite(c)
+		b, _ := io.ReadAll(c.Request.Body)
+		body = string(b)
+	}
+	r := gin.New()
+	r.GET("/api/data/:connId/tables/:schema/:table", handler)
+	r.POST("/api/data/:connId/tables/:schema/:table/rows", handler)
+	r.POST("/api/data/:connId/search", handler)
+	r.POST("/api/query/:connId/execute", handler)
+	r.POST("/api/query/:connId/lint", handler)
+	r.POST("/api/tx/:connId/begin", handler)
+	r.POST("/api/tx/:connId/:txId/execute", handler)
+	r.POST("/api/maintenance/:connId/tables/:schema/:table/vacuum", handler)
+	r.GET("/api/maintenance/:connId/progress/:queryId", handler)
+	r.POST("/api/schema/:connId/tables/:schema/:table/columns/:column/rename", handler)
+	r.PUT("/api/schema/:connId/tables/:schema/:table/comment", handler)
+	r.POST("/api/macros/:id/run/:connId", handler)
+	r.POST("/api/analysis/:connId/activity/:pid/terminate", handler)
+	r.POST("/api/connections/:id/databases", handler)
+	r.DELETE("/api/connections/:id/databases/:name", handler)
+
+	cases := []struct {
+		method, path, body string
+		want               bool
+	}{
+		{"GET", "/api/data/c1/tables/public/t", "", false},
+		{"POST", "/api/data/c1/tables/public/t/rows", `{"data":{}}`, true},
+		{"POST", "/api/data/c1/search", `{"term":"x"}`, false},
+		{"POST", "/api/query/c1/execute", `{"sql":"SELECT 1"}`, false},
+		{"POST", "/api/query/c1/execute", `{"sql":"SELECT 1; DELETE FROM t"}`, true},
+		{"POST", "/api/query/c1/lint", `{"sql":"DELETE FROM t"}`, false},
+		{"POST", "/api/tx/c1/begin", ``, false},
+		{"POST", "/api/tx/c1/t1/execute", `{"sql":"SELECT 1"}`, false},
+		{"POST", "/api/tx/c1/t1/execute", `{"sql":"UPDATE t SET a = 1"}`, true},
+		{"POST", "/api/maintenance/c1/tables/public/t/vacuum", `{"full":true}`, true},
+		{"GET", "/api/maintenance/c1/progress/q1", "", false},
+		{"POST", "/api/schema/c1/tables/public/t/columns/a/rename", `{"newName":"b"}`, true},
+		{"PUT", "/api/schema/c1/tables/public/t/comment", `{"comment":"x"}`, true},
+		{"POST", "/api/macros/m1/run/c1", `{}`, true},
+		{"POST", "/api/analysis/c1/activity/42/terminate", ``, true},
+		{"POST", "/api/connections/c1/databases", `{"name":"db"}`, true},
+		{"DELETE", "/api/connections/c1/databases/db", `{"force":true}`, true},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tc.want {
+			t.Errorf("%s %s %s: write = %v, want %v", tc.method, tc.path, tc.body, got, tc.want)
+		}
+		if tc.method == http.MethodPost && body != tc.body {
+			t.Errorf("%s %s: handler saw body %q, want %q", tc.method, tc.path, body, tc.body)
+		}
+	}
+}
+
+func TestProductionConnID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got string
+	handler := func(c *gin.Context) { got = productionConnID(c) }
+	r := gin.New()
+	r.POST("/api/data/:connId/tables/:schema/:table/rows", handler)
+	r.POST("/api/connections/:id/databases", handler)
+	r.DELETE("/api/connections/:id/databases/:name", handler)
+
+	for _, tc := range []struct{ method, path string }{
+		{"POST", "/api/data/c1/tables/public/t/rows"},
+		{"POST", "/api/connections/c1/databases"},
+		{"DELETE", "/api/connections/c1/databases/db"},
+	} {
+		got = ""
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+		if got != "c1" {
+			t.Errorf("%s %s: productionConnID = %q, want c1", tc.method, tc.path, got)
+		}
+	}
+}
+
+func TestStreamRequestConfirmProduction(t *testing.T) {
+	var req streamRequest
+	if err := json.Unmarshal([]byte(`{"sql":"DELETE FROM t","confirmProduction":true}`), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.SQL != "DELETE FROM t" || !req.ConfirmProduction {
+		t.Errorf("decoded %+v, want the sql and confirmProduction set", req)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// progressInterval is the least time between progress events for one
+// operation.
+const progressInterval = 500 * time.Millisecond
+
+// progressTracker reports one operation's progress to the ProgressManager.
+// A nil tracker (no ?operationId=) ignores every call, so handlers report
+// unconditionally.
+type progressTracker struct {
+	id        string
+	operation string
+	unit      string
+	sent      time.Time
+
+	// The latest values, sent by finish even if update held them back.
+	phase       string
+	done, total int64
+}
+
+// startProgress begins tracking the operation named by ?operationId=, if
+// given. It writes a 409 and returns false when that ID is already
+// running.
+func startProgress(c *gin.Context, operation, unit string) (*progressTracker, bool) {
+	id := c.Query("operationId")
+	if id == "" {
+		return nil, true
+	}
+	if !database.GetProgressManager().Begin(id, operation) {
+		c.JSON(http.StatusConflict, gin.H{"error": "an operation with this operationId is already running"})
+		return nil, false
+	}
+	return &progressTracker{id: id, operation: operation, unit: unit}, true
+}
+
+// update reports done out of total (0 if unknown), at most every
+// progressInterval.
+func (t *progressTracker) update(phase string, done, total int64) {
+	if t == nil {
+		return
+	}
+	t.phase, t.done, t.total = phase, done, total
+	if time.Since(t.sent) >= progressInterval {
+		t.send()
+	}
+}
+
+func (t *progressTracker) send() {
+	t.sent = time.Now()
+	event := models.ProgressEvent{Operation: t.operation, Phase: t.phase, Done: t.done, Total: t.total, Unit: t.unit}
+	if t.total > 0 {
+		event.Percent = float64(t.done) * 100 / float64(t.total)
+	}
+	database.GetProgressManager().Report(t.id, event)
+}
+
+// finish sends the latest progress and ends the operation, failed if err
+// is set.
+func (t *progressTracker) finish(err error) {
+	if t == nil {
+		return
+	}
+	t.send()
+	database.GetProgressManager().Finish(t.id, err)
+}
+
+// WatchProgress streams models.ProgressEvent frames for an operation over
+// a WebSocket until it sends "done" or "error". The socket can be opened
+// before the operation is started with the same operationId, so no early
+// progress is missed.
+func WatchProgress(c *gin.Context) {
+	id := c.Param("operationId")
+
+	ws, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("progress upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	events, unsubscribe := database.GetProgressManager().Subscribe(id)
+	defer unsubscribe()
+
+	// Nothing is read from the client; a failed read means it went away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case event := <-events:
+			if ws.WriteJSON(event) != nil {
+				return
+			}
+			if event.Type != "progress" {
+				return
+			}
+		}
+	}
+}
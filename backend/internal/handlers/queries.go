@@ -8,16 +8,40 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ListSavedQueries lists saved queries, optionally filtered by ?tag= and
+// ?folder=.
 func ListSavedQueries(c *gin.Context) {
-	manager := database.GetQueryManager()
-	queries := manager.List()
+	var filter models.SavedQueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	queries, err := database.GetQueryManager().List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, queries)
 }
 
+// ListSavedQueryTags returns the distinct tags with their query counts.
+func ListSavedQueryTags(c *gin.Context) {
+	tags, err := database.GetQueryManager().Tags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
 func CreateSavedQuery(c *gin.Context) {
 	var req models.SavedQueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateQueryParams(req.Parameters); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -43,8 +67,11 @@ func GetSavedQuery(c *gin.Context) {
 func UpdateSavedQuery(c *gin.Context) {
 	id := c.Param("id")
 	var req models.SavedQueryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := validateQueryParams(req.Parameters); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -57,6 +84,22 @@ func UpdateSavedQuery(c *gin.Context) {
 	c.JSON(http.StatusOK, query)
 }
 
+// SetSavedQueryFavorite pins or unpins a saved query. An empty body flips
+// the current setting.
+func SetSavedQueryFavorite(c *gin.Context) {
+	var req models.FavoriteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	query, err := database.GetQueryManager().SetFavorite(c.Param("id"), req.Favorite)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, query)
+}
+
 func DeleteSavedQuery(c *gin.Context) {
 	id := c.Param("id")
 	if err := database.GetQueryManager().Delete(id); err != nil {
@@ -65,3 +108,42 @@ func DeleteSavedQuery(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Query deleted"})
 }
+
+// RunSavedQuery runs a saved query with values for its :name parameters,
+// which are bound as positional arguments rather than spliced into the
+// SQL. The connection comes from the body, else the query's own. It runs
+// like the editor's queries (see runQuery), so it gets the connection's
+// query timeout and a history entry.
+func RunSavedQuery(c *gin.Context) {
+	query, err := database.GetQueryManager().Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.RunSavedQueryRequest
+	// Body is optional when every parameter has a default
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	connId := req.ConnectionID
+	if connId == "" {
+		connId = query.ConnectionID
+	}
+	manager := database.GetManager()
+	if connId == "" || !manager.IsConnected(connId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not connected"})
+		return
+	}
+
+	sql, args, err := bindSavedQueryParams(query, req.Params)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if rejectReadOnlySQL(c, connId, sql) || rejectUnconfirmedProductionWrite(c, connId, sql) {
+		return
+	}
+	runQuery(c, manager, connId, models.QueryRequest{SQL: sql, Params: args})
+}
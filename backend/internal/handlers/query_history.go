@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/storage"
 )
 
@@ -20,9 +24,10 @@ type AddQueryHistoryRequest struct {
 	Error          string  `json:"error"`
 }
 
-// GetQueryHistory retrieves query history
+// GetQueryHistory retrieves query history. Optional filters: connectionId,
+// search (substring of the SQL), from/to (RFC 3339 timestamps or
+// YYYY-MM-DD dates, inclusive) and successOnly=true.
 func GetQueryHistory(c *gin.Context) {
-	connectionID := c.Query("connectionId")
 	limitStr := c.DefaultQuery("limit", "100")
 
 	limit, err := strconv.Atoi(limitStr)
@@ -30,7 +35,22 @@ func GetQueryHistory(c *gin.Context) {
 		limit = 100
 	}
 
-	entries, err := storage.GetQueryHistory(connectionID, limit)
+	filter := storage.QueryHistoryFilter{
+		ConnectionID: c.Query("connectionId"),
+		Search:       c.Query("search"),
+		SuccessOnly:  c.Query("successOnly") == "true",
+		Limit:        limit,
+	}
+	if filter.From, err = parseHistoryTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+		return
+	}
+	if filter.To, err = parseHistoryTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+		return
+	}
+
+	entries, err := storage.GetQueryHistory(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -39,11 +59,30 @@ func GetQueryHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, entries)
 }
 
+// parseHistoryTime parses an RFC 3339 timestamp or a YYYY-MM-DD date in
+// local time. With endOfDay, a bare date means the last instant of that
+// day so a to= date includes the whole day. Empty input yields nil.
+func parseHistoryTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
 // AddQueryHistory adds a new query to history
 func AddQueryHistory(c *gin.Context) {
 	var req AddQueryHistoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -67,6 +106,36 @@ func AddQueryHistory(c *gin.Context) {
 	c.JSON(http.StatusCreated, entry)
 }
 
+// recordQueryHistory stores a runQuery run in the history unless
+// auto-logging is turned off. It runs before the response is written, so a
+// client refreshing its history afterwards sees the entry; a storage error
+// is only logged and never fails the query.
+func recordQueryHistory(connId, sql string, start time.Time, rowCount int, errMsg string) {
+	if value, _ := storage.GetPreference(storage.HistoryAutoLogPreference); value == "false" {
+		return
+	}
+
+	name := ""
+	if conn, err := database.GetManager().Get(connId); err == nil {
+		name = conn.Name
+	}
+
+	entry := &storage.QueryHistoryEntry{
+		ID:             uuid.New().String(),
+		ConnectionID:   connId,
+		ConnectionName: name,
+		SQL:            strings.TrimSpace(sql),
+		Duration:       time.Since(start).Seconds() * 1000,
+		RowCount:       rowCount,
+		Success:        errMsg == "",
+		Error:          errMsg,
+		ExecutedAt:     start,
+	}
+	if err := storage.AddQueryHistory(entry); err != nil {
+		log.Printf("query history: failed to record run on %s: %v", connId, err)
+	}
+}
+
 // DeleteQueryHistory removes a query from history
 func DeleteQueryHistory(c *gin.Context) {
 	id := c.Param("id")
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHistoryTime(t *testing.T) {
+	if got, err := parseHistoryTime("", false); got != nil || err != nil {
+		t.Errorf("empty input = %v, %v; want nil, nil", got, err)
+	}
+
+	got, err := parseHistoryTime("2024-03-05T10:00:00Z", true)
+	if err != nil || !got.Equal(time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC 3339 = %v, %v", got, err)
+	}
+
+	from, _ := parseHistoryTime("2024-03-05", false)
+	to, _ := parseHistoryTime("2024-03-05", true)
+	if want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local); !from.Equal(want) {
+		t.Errorf("from date = %v, want %v", from, want)
+	}
+	if want := time.Date(2024, 3, 6, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond); !to.Equal(want) {
+		t.Errorf("to date = %v, want %v", to, want)
+	}
+
+	if _, err := parseHistoryTime("last tuesday", false); err == nil {
+		t.Error("expected error for unparseable time")
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+)
+
+// readOnlyLeadKeywords are the statements a read-only connection may run.
+var readOnlyLeadKeywords = map[string]bool{
+	"SELECT":  true,
+	"WITH":    true,
+	"TABLE":   true,
+	"VALUES":  true,
+	"SHOW":    true,
+	"EXPLAIN": true,
+}
+
+// writeKeywords anywhere in an otherwise-allowed statement make it a write:
+// data-modifying CTEs (WITH x AS (DELETE ...)), SELECT ... INTO, and
+// EXPLAIN ANALYZE of a DML statement, which executes it.
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"TRUNCATE": true,
+	"INTO":     true,
+	"CREATE":   true,
+	"DROP":     true,
+	"ALTER":    true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"COPY":     true,
+}
+
+// sqlToken is one token of a statement with the parenthesis depth it
+// appears at: a bare word (upper-cased, Word set), one of the marks
+// ( ) , . *, or a placeholder for a value, whose Text is ' for a string,
+// " for a quoted identifier, 0 for a number or $ for a parameter.
+type sqlToken struct {
+	Text  string
+	Word  bool
+	Depth int
+}
+
+// sqlKeywords returns the upper-cased bare words of sql, skipping string
+// literals, quoted identifiers, dollar-quoted bodies and comments, so that
+// `SELECT 'DROP TABLE x'` or a column named "delete" isn't mistaken for a
+// write.
+func sqlKeywords(sql string) []string {
+	var words []string
+	for _, t := range sqlTokens(sql) {
+		if t.Word {
+			words = append(words, t.Text)
+		}
+	}
+	return words
+}
+
+// sqlTokens splits sql like sqlKeywords, keeping enough of the rest to
+// tell a clause's structure (see lintStatement). Other operators are
+// dropped.
+func sqlTokens(sql string) []sqlToken {
+	var tokens []sqlToken
+	depth := 0
+	rs := []rune(sql)
+	n := len(rs)
+	for i := 0; i < n; {
+		ch := rs[i]
+		switch {
+		case ch == '-' && i+1 < n && rs[i+1] == '-':
+			for i < n && rs[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < n && rs[i+1] == '*':
+			// Block comments nest in Postgres.
+			i += 2
+			for nest := 1; nest > 0 && i < n; {
+				switch {
+				case i+1 < n && rs[i] == '/' && rs[i+1] == '*':
+					nest++
+					i += 2
+				case i+1 < n && rs[i] == '*' && rs[i+1] == '/':
+					nest--
+					i += 2
+				default:
+					i++
+				}
+			}
+		case ch == '\'' || ch == '"':
+			i++
+			for i < n {
+				if rs[i] == ch {
+					if i+1 < n && rs[i+1] == ch {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, sqlToken{Text: string(ch), Depth: depth})
+		case ch == '$':
+			// $tag$ ... $tag$ (tag may be empty); a bare $1 is a parameter.
+			j := i + 1
+			for j < n && (rs[j] == '_' || unicode.IsLetter(rs[j]) || (j > i+1 && unicode.IsDigit(rs[j]))) {
+				j++
+			}
+			if j < n && rs[j] == '$' {
+				tag := string(rs[i : j+1])
+				rest := string(rs[j+1:])
+				if end := strings.Index(rest, tag); end >= 0 {
+					i = j + 1 + len([]rune(rest[:end])) + len([]rune(tag))
+				} else {
+					i = n
+				}
+				tokens = append(tokens, sqlToken{Text: "'", Depth: depth})
+			} else {
+				for j < n && unicode.IsDigit(rs[j]) {
+					j++
+				}
+				i = j
+				tokens = append(tokens, sqlToken{Text: "$", Depth: depth})
+			}
+		case ch == '_' || unicode.IsLetter(ch):
+			j := i
+			for j < n && (rs[j] == '_' || rs[j] == '$' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{Text: strings.ToUpper(string(rs[i:j])), Word: true, Depth: depth})
+			i = j
+		case unicode.IsDigit(ch):
+			j := i
+			for j < n && (rs[j] == '.' || rs[j] == '_' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{Text: "0", Depth: depth})
+			i = j
+		case ch == ')':
+			depth = max(depth-1, 0)
+			tokens = append(tokens, sqlToken{Text: ")", Depth: depth})
+			i++
+		case strings.ContainsRune("(,.*", ch):
+			tokens = append(tokens, sqlToken{Text: string(ch), Depth: depth})
+			if ch == '(' {
+				depth++
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// isReadOnlyStatement reports whether a single statement is safe to run on
+// a read-only connection. This is deliberately conservative — false
+// positives just mean the user runs it elsewhere — and backed by
+// default_transaction_read_only on the pool for anything it misses
+// (e.g. volatile functions called from a SELECT).
+func isReadOnlyStatement(sql string) bool {
+	words := sqlKeywords(sql)
+	if len(words) == 0 {
+		return true
+	}
+	if !readOnlyLeadKeywords[words[0]] {
+		return false
+	}
+	for i, w := range words {
+		if !writeKeywords[w] {
+			continue
+		}
+		// Row-locking clauses: FOR UPDATE, FOR NO KEY UPDATE.
+		if w == "UPDATE" && i > 0 && (words[i-1] == "FOR" || words[i-1] == "KEY") {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// readOnlyViolation returns the first statement in sql (which may be a
+// multi-statement batch) that a read-only connection must not run.
+func readOnlyViolation(sql string) (string, bool) {
+	for _, stmt := range splitStatements(sql) {
+		if !isReadOnlyStatement(stmt.SQL) {
+			return stmt.SQL, true
+		}
+	}
+	return "", false
+}
+
+// truncateStatement shortens a statement for use in an error message.
+func truncateStatement(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > 120 {
+		return sql[:117] + "..."
+	}
+	return sql
+}
+
+// rejectReadOnlySQL aborts with 403 if connId is read-only and sql contains
+// a write. Returns true when the request was rejected.
+func rejectReadOnlySQL(c *gin.Context, connId, sql string) bool {
+	if !database.GetManager().IsReadOnly(connId) {
+		return false
+	}
+	if stmt, bad := readOnlyViolation(sql); bad {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     fmt.Sprintf("connection is read-only; statement not allowed: %s", truncateStatement(stmt)),
+			"statement": stmt,
+		})
+		return true
+	}
+	return false
+}
+
+// rejectReadOnlyWrite aborts with 403 if connId is read-only. Used by
+// endpoints that always write (row edits, DDL). Returns true when rejected.
+func rejectReadOnlyWrite(c *gin.Context, connId, operation string) bool {
+	if !database.GetManager().IsReadOnly(connId) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": fmt.Sprintf("connection is read-only; %s is not allowed", operation),
+	})
+	return true
+}
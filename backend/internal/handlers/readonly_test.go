@@ -0,0 +1,54 @@
+package handlers
+
+import "testing"
+
+func TestIsReadOnlyStatement(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM users", true},
+		{"select id from t for update", true},
+		{"SELECT id FROM t FOR NO KEY UPDATE", true},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", true},
+		{"-- Active users\nSELECT 1", true},
+		{"SELECT 'DROP TABLE users'", true},
+		{`SELECT "delete" FROM t`, true},
+		{"SELECT $$ insert into x $$", true},
+		{"SELECT $1::int", true},
+		{"EXPLAIN SELECT 1", true},
+		{"SHOW search_path", true},
+		{"TABLE users", true},
+		{"VALUES (1), (2)", true},
+		{"INSERT INTO t VALUES (1)", false},
+		{"UPDATE t SET a = 1", false},
+		{"delete from t", false},
+		{"DROP TABLE t", false},
+		{"CREATE INDEX ON t (a)", false},
+		{"TRUNCATE t", false},
+		{"SET default_transaction_read_only = off", false},
+		{"WITH gone AS (DELETE FROM t RETURNING *) SELECT * FROM gone", false},
+		{"SELECT * INTO copy_of_t FROM t", false},
+		{"EXPLAIN ANALYZE DELETE FROM t", false},
+		{"/* harmless */ DROP TABLE t", false},
+	}
+	for _, tc := range cases {
+		if got := isReadOnlyStatement(tc.sql); got != tc.want {
+			t.Errorf("isReadOnlyStatement(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
+
+func TestReadOnlyViolationFindsHiddenStatement(t *testing.T) {
+	stmt, bad := readOnlyViolation("SELECT 1; DROP TABLE users; SELECT 2")
+	if !bad {
+		t.Fatal("expected the DROP in the batch to be flagged")
+	}
+	if stmt != "DROP TABLE users" {
+		t.Errorf("offending statement = %q, want %q", stmt, "DROP TABLE users")
+	}
+
+	if _, bad := readOnlyViolation("SELECT 1; SELECT 2"); bad {
+		t.Error("all-SELECT batch should pass")
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	defaultRecentTables = 20
+	maxRecentTables     = 100
+)
+
+// recordTableAccess counts a successful DescribeTable, or GetTableData's
+// first page, for the recent tables list. A storage error is only logged and never fails
+// the request.
+func recordTableAccess(connId, schema, table string) {
+	if err := storage.RecordTableAccess(connId, schema, table); err != nil {
+		log.Printf("recent tables: failed to record %s.%s on %s: %v", schema, table, connId, err)
+	}
+}
+
+// ListRecentTables returns the tables opened most recently on the
+// connection, or with ?sort=frequent the most often opened. ?limit= caps
+// the list (default 20, at most 100).
+func ListRecentTables(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	order := c.DefaultQuery("sort", storage.RecentTablesByRecency)
+	if order != storage.RecentTablesByRecency && order != storage.RecentTablesByFrequency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be \"recent\" or \"frequent\""})
+		return
+	}
+	limit := defaultRecentTables
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+		limit = min(n, maxRecentTables)
+	}
+
+	tables, err := storage.GetRecentTables(connId, order, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tables)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// Postgres type categories (pg_type.typcategory) that row edits convert.
+const (
+	typeCategoryArray     = "A"
+	typeCategoryComposite = "C"
+)
+
+// convertRowValues rewrites JSON arrays and objects bound for array and
+// composite columns as Postgres text literals, which pgx sends in text
+// format for Postgres to parse as the column's type, whatever the element
+// or field types are. A composite takes an object keyed by field name or
+// an array of fields in order. bytea values arrive base64-encoded, the
+// form convertValue returns them in, and are decoded to bytes. Everything
+// else, including objects for json/jsonb columns, is passed through as is.
+func convertRowValues(columns []models.ColumnInfo, data map[string]any) (map[string]any, error) {
+	byName := make(map[string]models.ColumnInfo, len(columns))
+	for _, col := range columns {
+		byName[col.Name] = col
+	}
+
+	out := make(map[string]any, len(data))
+	for name, v := range data {
+		out[name] = v
+		col, ok := byName[name]
+		if !ok {
+			continue
+		}
+		var err error
+		switch x := v.(type) {
+		case string:
+			if col.BaseType == "bytea" {
+				out[name], err = base64.StdEncoding.DecodeString(x)
+			}
+		case []any:
+			switch {
+			case col.BaseType == "_bytea":
+				out[name], err = byteaArray(x)
+			case col.TypeCategory == typeCategoryArray:
+				out[name], err = arrayLiteral(x, col.CompositeFields)
+			case col.TypeCategory == typeCategoryComposite:
+				out[name], err = compositeLiteral(x, col.CompositeFields)
+			}
+		case map[string]any:
+			if col.TypeCategory == typeCategoryComposite {
+				out[name], err = compositeLiteral(x, col.CompositeFields)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+	}
+	return out, nil
+}
+
+// byteaArray decodes a one-dimensional JSON array of base64 strings and
+// nulls for a bytea[] column.
+func byteaArray(items []any) ([][]byte, error) {
+	out := make([][]byte, len(items))
+	for i, item := range items {
+		switch x := item.(type) {
+		case nil:
+		case string:
+			b, err := base64.StdEncoding.DecodeString(x)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i+1, err)
+			}
+			out[i] = b
+		default:
+			return nil, fmt.Errorf("element %d: expected a base64 string, got %s", i+1, jsonKind(item))
+		}
+	}
+	return out, nil
+}
+
+// arrayLiteral renders a JSON array as an array literal ({1,"a b",NULL}).
+// Nested arrays become further dimensions; objects become composite
+// literals when the elements are composites (fields set), else JSON text.
+func arrayLiteral(items []any, fields []string) (string, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		switch x := item.(type) {
+		case nil:
+			b.WriteString("NULL")
+		case []any:
+			if len(fields) > 0 {
+				s, err := compositeLiteral(x, fields)
+				if err != nil {
+					return "", fmt.Errorf("element %d: %w", i+1, err)
+				}
+				b.WriteString(quoteLiteralElement(s))
+				continue
+			}
+			s, err := arrayLiteral(x, nil)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		default:
+			s, err := elementText(item, fields)
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i+1, err)
+			}
+			b.WriteString(quoteLiteralElement(s))
+		}
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// compositeLiteral renders a composite value, given as an object keyed by
+// field or an array in field order, as a row literal ("(1,""a b"",)").
+// Missing fields are NULL; unknown ones are an error.
+func compositeLiteral(v any, fields []string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("composite fields unknown")
+	}
+	values := make([]any, len(fields))
+	switch x := v.(type) {
+	case map[string]any:
+		index := make(map[string]int, len(fields))
+		for i, f := range fields {
+			index[f] = i
+		}
+		var unknown []string
+		for k, fv := range x {
+			i, ok := index[k]
+			if !ok {
+				unknown = append(unknown, k)
+				continue
+			}
+			values[i] = fv
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return "", fmt.Errorf("no field %s in (%s)", strings.Join(unknown, ", "), strings.Join(fields, ", "))
+		}
+	case []any:
+		if len(x) > len(fields) {
+			return "", fmt.Errorf("%d values for %d fields", len(x), len(fields))
+		}
+		copy(values, x)
+	default:
+		return "", fmt.Errorf("expected an object or array, got %s", jsonKind(v))
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, fv := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if fv == nil {
+			continue // an empty field is NULL
+		}
+		var s string
+		var err error
+		if arr, ok := fv.([]any); ok {
+			s, err = arrayLiteral(arr, nil)
+		} else {
+			s, err = elementText(fv, nil)
+		}
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", fields[i], err)
+		}
+		b.WriteString(quoteLiteralElement(s))
+	}
+	b.WriteByte(')')
+	return b.String(), nil
+}
+
+// elementText is the text form of one scalar (or, for composite elements,
+// object) inside an array or composite literal. Objects that aren't
+// composites are written as JSON, for json/jsonb elements and fields.
+func elementText(v any, fields []string) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		if x {
+			return "t", nil
+		}
+		return "f", nil
+	case map[string]any:
+		if len(fields) > 0 {
+			return compositeLiteral(x, fields)
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// quoteLiteralElement double-quotes an array element or composite field,
+// backslash-escaping quotes and backslashes, so commas, braces, spaces and
+// the word NULL are taken literally.
+func quoteLiteralElement(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
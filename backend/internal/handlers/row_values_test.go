@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestConvertRowValues(t *testing.T) {
+	columns := []models.ColumnInfo{
+		{Name: "tags", BaseType: "_text", TypeCategory: "A"},
+		{Name: "grid", BaseType: "_int4", TypeCategory: "A"},
+		{Name: "addr", BaseType: "address", TypeCategory: "C", CompositeFields: []string{"street", "city", "zip"}},
+		{Name: "stops", BaseType: "_address", TypeCategory: "A", CompositeFields: []string{"street", "city", "zip"}},
+		{Name: "docs", BaseType: "_jsonb", TypeCategory: "A"},
+		{Name: "meta", BaseType: "jsonb", TypeCategory: "U"},
+	}
+	data := map[string]any{
+		"tags":  []any{"a b", `say "hi"`, nil, "NULL", `back\slash`},
+		"grid":  []any{[]any{1.0, 2.0}, []any{3.0, nil}},
+		"addr":  map[string]any{"street": "1 Main St, Apt 2", "city": "Springfield"},
+		"stops": []any{map[string]any{"city": "A"}, []any{"x", "B", 12345.0}, nil},
+		"docs":  []any{map[string]any{"k": true}},
+		"meta":  map[string]any{"k": 1.0},
+		"other": "unchanged",
+	}
+	got, err := convertRowValues(columns, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"tags":  `{"a b","say \"hi\"",NULL,"NULL","back\\slash"}`,
+		"grid":  `{{"1","2"},{"3",NULL}}`,
+		"addr":  `("1 Main St, Apt 2","Springfield",)`,
+		"stops": `{"(,\"A\",)","(\"x\",\"B\",\"12345\")",NULL}`,
+		"docs":  `{"{\"k\":true}"}`,
+		"meta":  map[string]any{"k": 1.0},
+		"other": "unchanged",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRowValues() =\n%#v\nwant\n%#v", got, want)
+	}
+
+	bad := []map[string]any{
+		{"addr": map[string]any{"country": "X"}},
+		{"addr": []any{"a", "b", "c", "d"}},
+		{"stops": []any{[]any{"a", "b", "c", "d"}}},
+	}
+	for _, d := range bad {
+		if _, err := convertRowValues(columns, d); err == nil {
+			t.Errorf("convertRowValues(%v) succeeded, want error", d)
+		}
+	}
+}
+
+func TestConvertRowValuesBytea(t *testing.T) {
+	columns := []models.ColumnInfo{
+		{Name: "blob", BaseType: "bytea", TypeCategory: "U"},
+		{Name: "blobs", BaseType: "_bytea", TypeCategory: "A"},
+		{Name: "note", BaseType: "text", TypeCategory: "S"},
+	}
+	got, err := convertRowValues(columns, map[string]any{
+		"blob":  "3q2+7w==",
+		"blobs": []any{"AAE=", nil},
+		"note":  "3q2+7w==",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"blob":  []byte{0xde, 0xad, 0xbe, 0xef},
+		"blobs": [][]byte{{0, 1}, nil},
+		"note":  "3q2+7w==",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRowValues() =\n%#v\nwant\n%#v", got, want)
+	}
+
+	for _, d := range []map[string]any{
+		{"blob": "not base64!"},
+		{"blobs": []any{1.0}},
+	} {
+		if _, err := convertRowValues(columns, d); err == nil {
+			t.Errorf("convertRowValues(%v) succeeded, want error", d)
+		}
+	}
+}
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/thelinuxer/pgvoyager/internal/database"
 	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
 	"github.com/gin-gonic/gin"
 )
 
@@ -152,6 +157,47 @@ func ListSchemas(c *gin.Context) {
 	c.JSON(http.StatusOK, schemas)
 }
 
+// tableQuery selects the models.Table fields for ordinary and partitioned
+// tables on a server of the given version. A partitioned parent stores no
+// rows itself (its reltuples is 0), so from Postgres 12, which has
+// pg_partition_tree, its row estimate and size are summed over the leaf
+// partitions; before that they are the parent's own.
+func tableQuery(version int) string {
+	rowCount := "c.reltuples"
+	size := "pg_catalog.pg_table_size(c.oid)"
+	partitionKey := "''"
+	if version >= pg12 {
+		rowCount = `CASE WHEN c.relkind = 'p' THEN (
+				SELECT COALESCE(sum(pc.reltuples) FILTER (WHERE pc.reltuples > 0), 0)
+				FROM pg_catalog.pg_partition_tree(c.oid) pt
+				JOIN pg_catalog.pg_class pc ON pc.oid = pt.relid
+				WHERE pt.isleaf
+			) ELSE c.reltuples END`
+		size = `CASE WHEN c.relkind = 'p' THEN (
+				SELECT COALESCE(sum(pg_catalog.pg_table_size(pt.relid)), 0)::bigint
+				FROM pg_catalog.pg_partition_tree(c.oid) pt
+			) ELSE pg_catalog.pg_table_size(c.oid) END`
+	}
+	if version >= pg10 {
+		partitionKey = "COALESCE(CASE WHEN c.relkind = 'p' THEN pg_catalog.pg_get_partkeydef(c.oid) END, '')"
+	}
+	return `
+		SELECT
+			n.nspname as schema,
+			c.relname as name,
+			pg_catalog.pg_get_userbyid(c.relowner) as owner,
+			` + rowCount + `::bigint as row_count,
+			pg_catalog.pg_size_pretty(` + size + `) as size,
+			EXISTS(SELECT 1 FROM pg_constraint con WHERE con.conrelid = c.oid AND con.contype = 'p') as has_pk,
+			COALESCE(obj_description(c.oid), '') as comment,
+			c.relkind = 'p' as is_partitioned,
+			` + partitionKey + ` as partition_key
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p')
+`
+}
+
 func ListTables(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
@@ -164,18 +210,12 @@ func ListTables(c *gin.Context) {
 
 	schemaFilter := c.Query("schema")
 
-	query := `
-		SELECT
-			n.nspname as schema,
-			c.relname as name,
-			pg_catalog.pg_get_userbyid(c.relowner) as owner,
-			c.reltuples::bigint as row_count,
-			pg_catalog.pg_size_pretty(pg_catalog.pg_table_size(c.oid)) as size,
-			EXISTS(SELECT 1 FROM pg_constraint con WHERE con.conrelid = c.oid AND con.contype = 'p') as has_pk,
-			COALESCE(obj_description(c.oid), '') as comment
-		FROM pg_catalog.pg_class c
-		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
-		WHERE c.relkind = 'r'
+	version, err := serverVersionNum(ctx, pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	query := tableQuery(version) + `
 		  AND n.nspname NOT LIKE 'pg_%'
 		  AND n.nspname != 'information_schema'
 	`
@@ -198,7 +238,7 @@ func ListTables(c *gin.Context) {
 	var tables []models.Table
 	for rows.Next() {
 		var t models.Table
-		if err := rows.Scan(&t.Schema, &t.Name, &t.Owner, &t.RowCount, &t.Size, &t.HasPK, &t.Comment); err != nil {
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Owner, &t.RowCount, &t.Size, &t.HasPK, &t.Comment, &t.IsPartitioned, &t.PartitionKey); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -218,35 +258,121 @@ func GetTableInfo(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
-	table := c.Param("table")
+	t, err := loadTableInfo(ctx, pool, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return
+	}
 
-	query := `
-		SELECT
-			n.nspname as schema,
-			c.relname as name,
-			pg_catalog.pg_get_userbyid(c.relowner) as owner,
-			c.reltuples::bigint as row_count,
-			pg_catalog.pg_size_pretty(pg_catalog.pg_table_size(c.oid)) as size,
-			EXISTS(SELECT 1 FROM pg_constraint con WHERE con.conrelid = c.oid AND con.contype = 'p') as has_pk,
-			COALESCE(obj_description(c.oid), '') as comment
-		FROM pg_catalog.pg_class c
-		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
-		WHERE c.relkind = 'r'
+	c.JSON(http.StatusOK, t)
+}
+
+// loadTableInfo returns one table's summary row; pgx.ErrNoRows when it
+// doesn't exist.
+func loadTableInfo(ctx context.Context, q interface {
+	queryRunner
+	QueryRow(context.Context, string, ...any) pgx.Row
+}, schema, table string) (*models.Table, error) {
+	version, err := serverVersionNum(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	query := tableQuery(version) + `
 		  AND n.nspname = $1
 		  AND c.relname = $2
 	`
 
 	var t models.Table
-	err := pool.QueryRow(ctx, query, schema, table).Scan(
+	err = q.QueryRow(ctx, query, schema, table).Scan(
 		&t.Schema, &t.Name, &t.Owner, &t.RowCount, &t.Size, &t.HasPK, &t.Comment,
+		&t.IsPartitioned, &t.PartitionKey,
 	)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Table not found"})
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTablePartitions returns the partition strategy and key of a
+// partitioned table and lists its direct partitions with their bounds.
+func GetTablePartitions(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, t)
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result := models.TablePartitions{
+		Schema:     c.Param("schema"),
+		Table:      c.Param("table"),
+		Partitions: []models.Partition{},
+	}
+	if !requireServerVersion(ctx, c, pool, pg10, "Table partitioning") {
+		return
+	}
+
+	var oid uint32
+	err := pool.QueryRow(ctx, `
+		SELECT
+			c.oid,
+			CASE pt.partstrat
+				WHEN 'r' THEN 'range'
+				WHEN 'l' THEN 'list'
+				WHEN 'h' THEN 'hash'
+			END as strategy,
+			pg_catalog.pg_get_partkeydef(c.oid) as partition_key
+		FROM pg_catalog.pg_partitioned_table pt
+		JOIN pg_catalog.pg_class c ON c.oid = pt.partrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1
+		  AND c.relname = $2
+	`, result.Schema, result.Table).Scan(&oid, &result.Strategy, &result.PartitionKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Partitioned table not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT
+			n.nspname as schema,
+			c.relname as name,
+			pg_catalog.pg_get_expr(c.relpartbound, c.oid) as bound,
+			c.relkind = 'p' as is_partitioned,
+			c.reltuples::bigint as row_count,
+			pg_catalog.pg_size_pretty(pg_catalog.pg_table_size(c.oid)) as size
+		FROM pg_catalog.pg_inherits i
+		JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE i.inhparent = $1
+		ORDER BY c.relpartbound IS NULL, n.nspname, c.relname
+	`, oid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Partition
+		if err := rows.Scan(&p.Schema, &p.Name, &p.Bound, &p.IsPartitioned, &p.RowCount, &p.Size); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result.Partitions = append(result.Partitions, p)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 func GetTableColumns(c *gin.Context) {
@@ -259,24 +385,38 @@ func GetTableColumns(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
-	table := c.Param("table")
+	byTable, err := loadTableColumns(ctx, pool, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	c.JSON(http.StatusOK, byTable[c.Param("table")])
+}
+
+// loadTableColumns returns the columns of table in schema, keyed by table
+// name; an empty table loads every relation in the schema.
+func loadTableColumns(ctx context.Context, q queryRunner, schema, table string) (map[string][]models.Column, error) {
 	query := `
 		SELECT
+			c.relname as table_name,
 			a.attname as name,
 			a.attnum as position,
 			pg_catalog.format_type(a.atttypid, a.atttypmod) as data_type,
 			t.typname as udt_name,
 			NOT a.attnotnull as is_nullable,
-			pg_catalog.pg_get_expr(d.adbin, d.adrelid) as default_value,
+			CASE WHEN a.attgenerated = '' THEN pg_catalog.pg_get_expr(d.adbin, d.adrelid) END as default_value,
 			COALESCE(pk.is_pk, false) as is_primary_key,
 			COALESCE(fk.is_fk, false) as is_foreign_key,
 			fk.ref_schema,
 			fk.ref_table,
 			fk.ref_column,
 			CASE WHEN a.atttypmod > 0 THEN a.atttypmod - 4 ELSE NULL END as max_length,
-			COALESCE(col_description(c.oid, a.attnum), '') as comment
+			COALESCE(col_description(c.oid, a.attnum), '') as comment,
+			a.attgenerated <> '' as is_generated,
+			CASE WHEN a.attgenerated <> '' THEN pg_catalog.pg_get_expr(d.adbin, d.adrelid) END as generation_expression,
+			a.attidentity <> '' as is_identity,
+			CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END as identity_generation
 		FROM pg_catalog.pg_attribute a
 		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
 		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
@@ -306,31 +446,36 @@ func GetTableColumns(c *gin.Context) {
 			LIMIT 1
 		) fk ON true
 		WHERE n.nspname = $1
-		  AND c.relname = $2
+		  AND ($2::text = '' OR c.relname = $2)
 		  AND a.attnum > 0
 		  AND NOT a.attisdropped
-		ORDER BY a.attnum
+		ORDER BY c.relname, a.attnum
 	`
 
-	rows, err := pool.Query(ctx, query, schema, table)
+	version, err := serverVersionNum(ctx, q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
+	}
+
+	rows, err := q.Query(ctx, columnQueryForVersion(query, version), schema, table)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
-	var columns []models.Column
+	columns := make(map[string][]models.Column)
 	for rows.Next() {
+		var tableName string
 		var col models.Column
 		var refSchema, refTable, refColumn *string
 
 		if err := rows.Scan(
-			&col.Name, &col.Position, &col.DataType, &col.UDTName,
+			&tableName, &col.Name, &col.Position, &col.DataType, &col.UDTName,
 			&col.IsNullable, &col.DefaultValue, &col.IsPrimaryKey, &col.IsForeignKey,
 			&refSchema, &refTable, &refColumn, &col.MaxLength, &col.Comment,
+			&col.IsGenerated, &col.GenerationExpression, &col.IsIdentity, &col.IdentityGeneration,
 		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
 
 		if col.IsForeignKey && refSchema != nil {
@@ -341,10 +486,9 @@ func GetTableColumns(c *gin.Context) {
 			}
 		}
 
-		columns = append(columns, col)
+		columns[tableName] = append(columns[tableName], col)
 	}
-
-	c.JSON(http.StatusOK, columns)
+	return columns, rows.Err()
 }
 
 func GetTableConstraints(c *gin.Context) {
@@ -357,11 +501,21 @@ func GetTableConstraints(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
-	table := c.Param("table")
+	byTable, err := loadTableConstraints(ctx, pool, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
+	c.JSON(http.StatusOK, byTable[c.Param("table")])
+}
+
+// loadTableConstraints returns the constraints of table in schema, keyed by
+// table name; an empty table loads every table in the schema.
+func loadTableConstraints(ctx context.Context, q queryRunner, schema, table string) (map[string][]models.Constraint, error) {
 	query := `
 		SELECT
+			c.relname as table_name,
 			con.conname as name,
 			CASE con.contype
 				WHEN 'p' THEN 'PRIMARY KEY'
@@ -385,30 +539,29 @@ func GetTableConstraints(c *gin.Context) {
 		LEFT JOIN pg_namespace nf ON nf.oid = cf.relnamespace
 		LEFT JOIN pg_attribute af ON af.attrelid = con.confrelid AND af.attnum = ANY(con.confkey)
 		WHERE n.nspname = $1
-		  AND c.relname = $2
-		GROUP BY con.oid, con.conname, con.contype, nf.nspname, cf.relname
-		ORDER BY con.contype, con.conname
+		  AND ($2::text = '' OR c.relname = $2)
+		GROUP BY c.relname, con.oid, con.conname, con.contype, nf.nspname, cf.relname
+		ORDER BY c.relname, con.contype, con.conname
 	`
 
-	rows, err := pool.Query(ctx, query, schema, table)
+	rows, err := q.Query(ctx, query, schema, table)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	var constraints []models.Constraint
+	constraints := make(map[string][]models.Constraint)
 	for rows.Next() {
+		var tableName string
 		var con models.Constraint
 		var refSchema, refTable *string
 		var refColumns []string
 
 		if err := rows.Scan(
-			&con.Name, &con.Type, &con.Columns, &con.Definition,
+			&tableName, &con.Name, &con.Type, &con.Columns, &con.Definition,
 			&refSchema, &refTable, &refColumns,
 		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
 
 		if refSchema != nil {
@@ -417,10 +570,9 @@ func GetTableConstraints(c *gin.Context) {
 			con.RefColumns = refColumns
 		}
 
-		constraints = append(constraints, con)
+		constraints[tableName] = append(constraints[tableName], con)
 	}
-
-	c.JSON(http.StatusOK, constraints)
+	return constraints, rows.Err()
 }
 
 func GetTableIndexes(c *gin.Context) {
@@ -433,11 +585,21 @@ func GetTableIndexes(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
-	table := c.Param("table")
+	byTable, err := loadTableIndexes(ctx, pool, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, byTable[c.Param("table")])
+}
 
+// loadTableIndexes returns the indexes of table in schema, keyed by table
+// name; an empty table loads every table in the schema.
+func loadTableIndexes(ctx context.Context, q queryRunner, schema, table string) (map[string][]models.Index, error) {
 	query := `
 		SELECT
+			t.relname as table_name,
 			i.relname as name,
 			array_agg(a.attname ORDER BY array_position(ix.indkey, a.attnum)) as columns,
 			ix.indisunique as is_unique,
@@ -452,32 +614,30 @@ func GetTableIndexes(c *gin.Context) {
 		JOIN pg_am am ON am.oid = i.relam
 		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
 		WHERE n.nspname = $1
-		  AND t.relname = $2
-		GROUP BY i.oid, i.relname, ix.indisunique, ix.indisprimary, am.amname
-		ORDER BY i.relname
+		  AND ($2::text = '' OR t.relname = $2)
+		GROUP BY t.relname, i.oid, i.relname, ix.indisunique, ix.indisprimary, am.amname
+		ORDER BY t.relname, i.relname
 	`
 
-	rows, err := pool.Query(ctx, query, schema, table)
+	rows, err := q.Query(ctx, query, schema, table)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	var indexes []models.Index
+	indexes := make(map[string][]models.Index)
 	for rows.Next() {
+		var tableName string
 		var idx models.Index
 		if err := rows.Scan(
-			&idx.Name, &idx.Columns, &idx.IsUnique, &idx.IsPrimary,
+			&tableName, &idx.Name, &idx.Columns, &idx.IsUnique, &idx.IsPrimary,
 			&idx.Type, &idx.Size, &idx.Definition,
 		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
-		indexes = append(indexes, idx)
+		indexes[tableName] = append(indexes[tableName], idx)
 	}
-
-	c.JSON(http.StatusOK, indexes)
+	return indexes, rows.Err()
 }
 
 func GetForeignKeys(c *gin.Context) {
@@ -490,9 +650,17 @@ func GetForeignKeys(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
-	table := c.Param("table")
+	fks, err := loadForeignKeys(ctx, pool, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fks)
+}
 
+// loadForeignKeys returns the foreign keys declared on table in schema.
+func loadForeignKeys(ctx context.Context, q queryRunner, schema, table string) ([]models.ForeignKey, error) {
 	query := `
 		SELECT
 			con.conname as name,
@@ -528,10 +696,9 @@ func GetForeignKeys(c *gin.Context) {
 		ORDER BY con.conname
 	`
 
-	rows, err := pool.Query(ctx, query, schema, table)
+	rows, err := q.Query(ctx, query, schema, table)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -542,13 +709,11 @@ func GetForeignKeys(c *gin.Context) {
 			&fk.Name, &fk.Columns, &fk.RefSchema, &fk.RefTable,
 			&fk.RefColumns, &fk.OnUpdate, &fk.OnDelete,
 		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
 		fks = append(fks, fk)
 	}
-
-	c.JSON(http.StatusOK, fks)
+	return fks, rows.Err()
 }
 
 // GetSchemaRelationships returns all foreign key relationships within a schema
@@ -563,8 +728,18 @@ func GetSchemaRelationships(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	schema := c.Param("schema")
+	relationships, err := fetchSchemaRelationships(ctx, pool, c.Param("schema"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relationships)
+}
 
+// fetchSchemaRelationships lists the foreign keys from or to tables in
+// schema.
+func fetchSchemaRelationships(ctx context.Context, q queryRunner, schema string) ([]models.SchemaRelationship, error) {
 	query := `
 		SELECT
 			n.nspname as source_schema,
@@ -601,10 +776,9 @@ func GetSchemaRelationships(c *gin.Context) {
 		ORDER BY c.relname, con.conname
 	`
 
-	rows, err := pool.Query(ctx, query, schema)
+	rows, err := q.Query(ctx, query, schema)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -616,13 +790,11 @@ func GetSchemaRelationships(c *gin.Context) {
 			&rel.TargetSchema, &rel.TargetTable, &rel.TargetColumns,
 			&rel.ConstraintName, &rel.OnUpdate, &rel.OnDelete,
 		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, err
 		}
 		relationships = append(relationships, rel)
 	}
-
-	c.JSON(http.StatusOK, relationships)
+	return relationships, rows.Err()
 }
 
 func ListViews(c *gin.Context) {
@@ -679,6 +851,116 @@ func ListViews(c *gin.Context) {
 	c.JSON(http.StatusOK, views)
 }
 
+func ListMaterializedViews(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schemaFilter := c.Query("schema")
+
+	query := `
+		SELECT
+			n.nspname as schema,
+			c.relname as name,
+			pg_catalog.pg_get_userbyid(c.relowner) as owner,
+			pg_get_viewdef(c.oid, true) as definition,
+			c.relispopulated as is_populated,
+			pg_catalog.pg_size_pretty(pg_catalog.pg_total_relation_size(c.oid)) as size,
+			COALESCE(obj_description(c.oid), '') as comment
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'm'
+		  AND n.nspname NOT LIKE 'pg_%'
+		  AND n.nspname != 'information_schema'
+	`
+
+	args := []interface{}{}
+	if schemaFilter != "" {
+		query += " AND n.nspname = $1"
+		args = append(args, schemaFilter)
+	}
+
+	query += " ORDER BY n.nspname, c.relname"
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	var views []models.MaterializedView
+	for rows.Next() {
+		var v models.MaterializedView
+		if err := rows.Scan(&v.Schema, &v.Name, &v.Owner, &v.Definition, &v.IsPopulated, &v.Size, &v.Comment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		views = append(views, v)
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// RefreshMaterializedView runs REFRESH MATERIALIZED VIEW. With
+// {"concurrently": true} readers aren't blocked, but Postgres then requires
+// a unique index on the view and that it is already populated.
+func RefreshMaterializedView(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, "refreshing materialized views") {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	// Refreshing re-runs the view's query, which can be far slower than
+	// the usual catalog lookups.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	schema := c.Param("schema")
+	name := c.Param("name")
+
+	if !isValidIdentifier(schema) || !isValidIdentifier(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or view name"})
+		return
+	}
+
+	// Optional body
+	var req struct {
+		Concurrently bool `json:"concurrently"`
+	}
+	if err := c.ShouldBindJSON(&req); security.AbortIfBodyTooLarge(c, err) {
+		return
+	}
+
+	query := "REFRESH MATERIALIZED VIEW "
+	if req.Concurrently {
+		query += "CONCURRENTLY "
+	}
+	query += fmt.Sprintf("%s.%s", quoteIdentifier(schema), quoteIdentifier(name))
+
+	start := time.Now()
+	if _, err := pool.Exec(ctx, query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  fmt.Sprintf("Materialized view %s.%s refreshed", schema, name),
+		"duration": time.Since(start).Seconds() * 1000,
+	})
+}
+
 func ListFunctions(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
@@ -691,15 +973,20 @@ func ListFunctions(c *gin.Context) {
 
 	schemaFilter := c.Query("schema")
 
+	// The definition is fetched separately (see loadFunctionDefinitions):
+	// pg_get_functiondef can error for individual functions and inline it
+	// would fail the whole listing.
 	query := `
 		SELECT
+			p.oid,
 			n.nspname as schema,
 			p.proname as name,
 			pg_catalog.pg_get_userbyid(p.proowner) as owner,
 			pg_catalog.pg_get_function_result(p.oid) as return_type,
 			pg_catalog.pg_get_function_arguments(p.oid) as arguments,
 			l.lanname as language,
-			pg_get_functiondef(p.oid) as definition,
+			COALESCE(p.prosrc, '') as source,
+			COALESCE(p.probin, '') as object_file,
 			p.prokind = 'a' as is_aggregate,
 			COALESCE(obj_description(p.oid, 'pg_proc'), '') as comment
 		FROM pg_catalog.pg_proc p
@@ -726,21 +1013,113 @@ func ListFunctions(c *gin.Context) {
 	defer rows.Close()
 
 	var functions []models.Function
+	var oids []uint32
+	var sources, objectFiles []string
 	for rows.Next() {
 		var f models.Function
+		var oid uint32
+		var source, objectFile string
 		if err := rows.Scan(
-			&f.Schema, &f.Name, &f.Owner, &f.ReturnType, &f.Arguments,
-			&f.Language, &f.Definition, &f.IsAggregate, &f.Comment,
+			&oid, &f.Schema, &f.Name, &f.Owner, &f.ReturnType, &f.Arguments,
+			&f.Language, &source, &objectFile, &f.IsAggregate, &f.Comment,
 		); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		functions = append(functions, f)
+		oids = append(oids, oid)
+		sources = append(sources, source)
+		objectFiles = append(objectFiles, objectFile)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows.Close()
+
+	defs, defErrs := loadFunctionDefinitions(ctx, pool, oids)
+	for i := range functions {
+		if def, ok := defs[oids[i]]; ok {
+			functions[i].Definition = def
+			continue
+		}
+		functions[i].Definition, functions[i].DefinitionNote =
+			fallbackFunctionDefinition(&functions[i], sources[i], objectFiles[i], defErrs[oids[i]])
 	}
 
 	c.JSON(http.StatusOK, functions)
 }
 
+// loadFunctionDefinitions runs pg_get_functiondef for every oid in one
+// round trip. If that batch fails — one bad function poisons the whole
+// statement — it retries each oid on its own so only the offending
+// functions miss out. Failures are returned per oid.
+func loadFunctionDefinitions(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}, oids []uint32) (map[uint32]string, map[uint32]error) {
+	defs := make(map[uint32]string, len(oids))
+	errs := make(map[uint32]error)
+	if len(oids) == 0 {
+		return defs, errs
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT f.oid, pg_catalog.pg_get_functiondef(f.oid)
+		FROM unnest($1::oid[]) AS f(oid)
+	`, oids)
+	if err == nil {
+		for rows.Next() {
+			var oid uint32
+			var def string
+			if err = rows.Scan(&oid, &def); err != nil {
+				break
+			}
+			defs[oid] = def
+		}
+		rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err == nil {
+			return defs, errs
+		}
+	}
+
+	clear(defs)
+	for _, oid := range oids {
+		var def string
+		if err := pool.QueryRow(ctx, `SELECT pg_catalog.pg_get_functiondef($1)`, oid).Scan(&def); err != nil {
+			errs[oid] = err
+			continue
+		}
+		defs[oid] = def
+	}
+	return defs, errs
+}
+
+// fallbackFunctionDefinition builds a placeholder definition when
+// pg_get_functiondef fails. C functions show the object file and symbol
+// (probin / prosrc); for other languages the raw body is still useful.
+func fallbackFunctionDefinition(f *models.Function, source, objectFile string, cause error) (definition, note string) {
+	note = "pg_get_functiondef is unavailable for this function"
+	if cause != nil {
+		note += ": " + cause.Error()
+	}
+
+	header := fmt.Sprintf("-- %s\nCREATE OR REPLACE FUNCTION %s.%s(%s)\n RETURNS %s\n LANGUAGE %s\n",
+		note, quoteIdentifier(f.Schema), quoteIdentifier(f.Name), f.Arguments, f.ReturnType, f.Language)
+
+	switch {
+	case objectFile != "":
+		return header + fmt.Sprintf("AS '%s', '%s';", strings.ReplaceAll(objectFile, "'", "''"), strings.ReplaceAll(source, "'", "''")), note
+	case source != "":
+		return header + "AS $function$" + source + "$function$;", note
+	default:
+		return header + "-- source not available", note
+	}
+}
+
 func ListSequences(c *gin.Context) {
 	manager, connId, ok := getPool(c)
 	if !ok {
@@ -895,14 +1274,18 @@ func GetAllColumns(c *gin.Context) {
 			pg_catalog.format_type(a.atttypid, a.atttypmod) as data_type,
 			t.typname as udt_name,
 			NOT a.attnotnull as is_nullable,
-			pg_catalog.pg_get_expr(d.adbin, d.adrelid) as default_value,
+			CASE WHEN a.attgenerated = '' THEN pg_catalog.pg_get_expr(d.adbin, d.adrelid) END as default_value,
 			COALESCE(pk.is_pk, false) as is_primary_key,
 			COALESCE(fk.is_fk, false) as is_foreign_key,
 			fk.ref_schema,
 			fk.ref_table,
 			fk.ref_column,
 			CASE WHEN a.atttypmod > 0 THEN a.atttypmod - 4 ELSE NULL END as max_length,
-			COALESCE(col_description(c.oid, a.attnum), '') as comment
+			COALESCE(col_description(c.oid, a.attnum), '') as comment,
+			a.attgenerated <> '' as is_generated,
+			CASE WHEN a.attgenerated <> '' THEN pg_catalog.pg_get_expr(d.adbin, d.adrelid) END as generation_expression,
+			a.attidentity <> '' as is_identity,
+			CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END as identity_generation
 		FROM pg_catalog.pg_attribute a
 		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
 		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
@@ -938,7 +1321,13 @@ func GetAllColumns(c *gin.Context) {
 		ORDER BY n.nspname, c.relname, a.attnum
 	`
 
-	rows, err := pool.Query(ctx, query)
+	version, err := serverVersionNum(ctx, pool)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := pool.Query(ctx, columnQueryForVersion(query, version))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -958,6 +1347,7 @@ func GetAllColumns(c *gin.Context) {
 			&col.Name, &col.Position, &col.DataType, &col.UDTName,
 			&col.IsNullable, &col.DefaultValue, &col.IsPrimaryKey, &col.IsForeignKey,
 			&refSchema, &refTable, &refColumn, &col.MaxLength, &col.Comment,
+			&col.IsGenerated, &col.GenerationExpression, &col.IsIdentity, &col.IdentityGeneration,
 		); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -990,3 +1380,213 @@ func GetAllColumns(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// triggersQuery selects user-defined triggers; callers append filters on
+// n.nspname / c.relname. tgtype is a bitmask: 1 row-level, 2 BEFORE,
+// 4 INSERT, 8 DELETE, 16 UPDATE, 32 TRUNCATE, 64 INSTEAD OF. Internal
+// triggers (FK enforcement and the like) are excluded.
+const triggersQuery = `
+	SELECT
+		n.nspname as schema,
+		c.relname as table_name,
+		t.tgname as name,
+		CASE
+			WHEN t.tgtype & 2 <> 0 THEN 'BEFORE'
+			WHEN t.tgtype & 64 <> 0 THEN 'INSTEAD OF'
+			ELSE 'AFTER'
+		END as timing,
+		array_remove(ARRAY[
+			CASE WHEN t.tgtype & 4 <> 0 THEN 'INSERT' END,
+			CASE WHEN t.tgtype & 16 <> 0 THEN 'UPDATE' END,
+			CASE WHEN t.tgtype & 8 <> 0 THEN 'DELETE' END,
+			CASE WHEN t.tgtype & 32 <> 0 THEN 'TRUNCATE' END
+		], NULL) as events,
+		CASE WHEN t.tgtype & 1 <> 0 THEN 'ROW' ELSE 'STATEMENT' END as level,
+		pn.nspname || '.' || p.proname as function,
+		t.tgenabled <> 'D' as is_enabled,
+		pg_get_triggerdef(t.oid) as definition
+	FROM pg_catalog.pg_trigger t
+	JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	JOIN pg_catalog.pg_proc p ON p.oid = t.tgfoid
+	JOIN pg_catalog.pg_namespace pn ON pn.oid = p.pronamespace
+	WHERE NOT t.tgisinternal
+	  AND n.nspname NOT LIKE 'pg_%'
+	  AND n.nspname != 'information_schema'
+`
+
+func scanTriggers(ctx context.Context, pool queryRunner, query string, args ...interface{}) ([]models.Trigger, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []models.Trigger
+	for rows.Next() {
+		var t models.Trigger
+		if err := rows.Scan(
+			&t.Schema, &t.Table, &t.Name, &t.Timing, &t.Events,
+			&t.Level, &t.Function, &t.IsEnabled, &t.Definition,
+		); err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+func ListTriggers(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := triggersQuery
+	args := []interface{}{}
+	if schemaFilter := c.Query("schema"); schemaFilter != "" {
+		query += " AND n.nspname = $1"
+		args = append(args, schemaFilter)
+	}
+	query += " ORDER BY n.nspname, c.relname, t.tgname"
+
+	triggers, err := scanTriggers(ctx, pool, query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, triggers)
+}
+
+func GetTableTriggers(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := triggersQuery + " AND n.nspname = $1 AND c.relname = $2 ORDER BY t.tgname"
+	triggers, err := scanTriggers(ctx, pool, query, c.Param("schema"), c.Param("table"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, triggers)
+}
+
+// ListRoles lists database roles with their attributes and the roles each
+// is a member of. Predefined pg_* roles are left out unless
+// includeSystem=true.
+func ListRoles(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			r.rolname as name,
+			r.rolsuper as is_superuser,
+			r.rolcreatedb as can_create_db,
+			r.rolcreaterole as can_create_role,
+			r.rolcanlogin as can_login,
+			r.rolreplication as is_replication,
+			r.rolconnlimit as connection_limit,
+			NULLIF(r.rolvaliduntil, 'infinity') as valid_until,
+			ARRAY(
+				SELECT g.rolname
+				FROM pg_catalog.pg_auth_members m
+				JOIN pg_catalog.pg_roles g ON g.oid = m.roleid
+				WHERE m.member = r.oid
+				ORDER BY g.rolname
+			) as member_of
+		FROM pg_catalog.pg_roles r
+	`
+	if c.Query("includeSystem") != "true" {
+		query += " WHERE r.rolname !~ '^pg_'"
+	}
+	query += " ORDER BY r.rolname"
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var r models.Role
+		if err := rows.Scan(
+			&r.Name, &r.IsSuperuser, &r.CanCreateDB, &r.CanCreateRole, &r.CanLogin,
+			&r.IsReplication, &r.ConnectionLimit, &r.ValidUntil, &r.MemberOf,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		roles = append(roles, r)
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// ListExtensions lists installed extensions with the version the server
+// would install by default, flagging those with an update available.
+func ListExtensions(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			e.extname as name,
+			n.nspname as schema,
+			e.extversion as installed_version,
+			COALESCE(a.default_version, '') as default_version,
+			COALESCE(a.default_version <> e.extversion, false) as update_available,
+			COALESCE(a.comment, '') as comment
+		FROM pg_catalog.pg_extension e
+		JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+		LEFT JOIN pg_catalog.pg_available_extensions a ON a.name = e.extname
+		ORDER BY e.extname
+	`
+
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	extensions := []models.Extension{}
+	for rows.Next() {
+		var e models.Extension
+		if err := rows.Scan(
+			&e.Name, &e.Schema, &e.InstalledVersion, &e.DefaultVersion, &e.UpdateAvailable, &e.Comment,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		extensions = append(extensions, e)
+	}
+
+	c.JSON(http.StatusOK, extensions)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// schemaSnapshot is the part of a schema that a diff compares, keyed by
+// table name.
+type schemaSnapshot struct {
+	tables      []string
+	columns     map[string][]models.Column
+	indexes     map[string][]models.Index
+	constraints map[string][]models.Constraint
+}
+
+// loadSchemaSnapshot introspects the ordinary tables of schema with the
+// same catalog queries the table detail endpoints use.
+func loadSchemaSnapshot(ctx context.Context, q queryRunner, schema string) (*schemaSnapshot, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.relname
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r'
+		  AND n.nspname = $1
+		ORDER BY c.relname
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snap := &schemaSnapshot{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		snap.tables = append(snap.tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if snap.columns, err = loadTableColumns(ctx, q, schema, ""); err != nil {
+		return nil, err
+	}
+	if snap.indexes, err = loadTableIndexes(ctx, q, schema, ""); err != nil {
+		return nil, err
+	}
+	if snap.constraints, err = loadTableConstraints(ctx, q, schema, ""); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// columnDefinition renders the parts of a column a diff compares: type,
+// nullability and default. Position and comment are ignored.
+func columnDefinition(col models.Column) string {
+	def := col.DataType
+	if !col.IsNullable {
+		def += " NOT NULL"
+	}
+	if col.DefaultValue != nil {
+		def += " DEFAULT " + *col.DefaultValue
+	}
+	if col.GenerationExpression != nil {
+		def += " GENERATED ALWAYS AS (" + *col.GenerationExpression + ") STORED"
+	}
+	if col.IsIdentity {
+		def += " GENERATED " + col.IdentityGeneration + " AS IDENTITY"
+	}
+	return def
+}
+
+// diffObjects compares two name-to-definition maps, returning the entries
+// missing from either side or defined differently, ordered by name.
+func diffObjects(a, b map[string]string) []models.ObjectDiff {
+	diffs := []models.ObjectDiff{}
+	for name, defA := range a {
+		defB, ok := b[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, models.ObjectDiff{Name: name, Status: models.DiffOnlyInA, A: defA})
+		case defA != defB:
+			diffs = append(diffs, models.ObjectDiff{Name: name, Status: models.DiffChanged, A: defA, B: defB})
+		}
+	}
+	for name, defB := range b {
+		if _, ok := a[name]; !ok {
+			diffs = append(diffs, models.ObjectDiff{Name: name, Status: models.DiffOnlyInB, B: defB})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// diffTable compares one table present in both snapshots. ok is false when
+// the two sides match.
+func diffTable(a, b *schemaSnapshot, table string) (diff models.TableDiff, ok bool) {
+	columns := func(s *schemaSnapshot) map[string]string {
+		defs := make(map[string]string)
+		for _, col := range s.columns[table] {
+			defs[col.Name] = columnDefinition(col)
+		}
+		return defs
+	}
+	indexes := func(s *schemaSnapshot) map[string]string {
+		defs := make(map[string]string)
+		for _, idx := range s.indexes[table] {
+			defs[idx.Name] = idx.Definition
+		}
+		return defs
+	}
+	constraints := func(s *schemaSnapshot) map[string]string {
+		defs := make(map[string]string)
+		for _, con := range s.constraints[table] {
+			defs[con.Name] = con.Definition
+		}
+		return defs
+	}
+
+	diff = models.TableDiff{
+		Table:       table,
+		Columns:     diffObjects(columns(a), columns(b)),
+		Indexes:     diffObjects(indexes(a), indexes(b)),
+		Constraints: diffObjects(constraints(a), constraints(b)),
+	}
+	return diff, len(diff.Columns)+len(diff.Indexes)+len(diff.Constraints) > 0
+}
+
+// diffSchemas compares two snapshots of the same schema.
+func diffSchemas(a, b *schemaSnapshot) models.SchemaDiff {
+	diff := models.SchemaDiff{
+		TablesOnlyInA: []string{},
+		TablesOnlyInB: []string{},
+		ChangedTables: []models.TableDiff{},
+	}
+
+	inB := make(map[string]bool, len(b.tables))
+	for _, t := range b.tables {
+		inB[t] = true
+	}
+	inA := make(map[string]bool, len(a.tables))
+	for _, t := range a.tables {
+		inA[t] = true
+		if !inB[t] {
+			diff.TablesOnlyInA = append(diff.TablesOnlyInA, t)
+		} else if td, changed := diffTable(a, b, t); changed {
+			diff.ChangedTables = append(diff.ChangedTables, td)
+		}
+	}
+	for _, t := range b.tables {
+		if !inA[t] {
+			diff.TablesOnlyInB = append(diff.TablesOnlyInB, t)
+		}
+	}
+	return diff
+}
+
+// DiffSchemas compares the tables, columns, indexes and constraints of a
+// schema between two connected connections, e.g. staging and production.
+func DiffSchemas(c *gin.Context) {
+	var req models.SchemaDiffRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Schema == "" {
+		req.Schema = "public"
+	}
+
+	manager := database.GetManager()
+	ids := []string{req.ConnectionA, req.ConnectionB}
+	for _, id := range ids {
+		if !manager.IsConnected(id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Not connected: %s", id)})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snapshots := make([]*schemaSnapshot, len(ids))
+	for i, id := range ids {
+		pool, err := manager.GetPool(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		snapshots[i], err = loadSchemaSnapshot(ctx, pool, req.Schema)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%s: %v", id, err)})
+			return
+		}
+	}
+
+	diff := diffSchemas(snapshots[0], snapshots[1])
+	diff.Schema = req.Schema
+	diff.ConnectionA = req.ConnectionA
+	diff.ConnectionB = req.ConnectionB
+	c.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	def := "nextval('users_id_seq'::regclass)"
+	a := &schemaSnapshot{
+		tables: []string{"audit", "users"},
+		columns: map[string][]models.Column{
+			"users": {
+				{Name: "id", DataType: "integer", DefaultValue: &def},
+				{Name: "email", DataType: "character varying(100)", IsNullable: true},
+			},
+		},
+		indexes: map[string][]models.Index{
+			"users": {{Name: "users_email_idx", Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"}},
+		},
+		constraints: map[string][]models.Constraint{
+			"users": {{Name: "users_pkey", Definition: "PRIMARY KEY (id)"}},
+		},
+	}
+	b := &schemaSnapshot{
+		tables: []string{"orders", "users"},
+		columns: map[string][]models.Column{
+			"users": {
+				{Name: "id", DataType: "integer", DefaultValue: &def},
+				{Name: "email", DataType: "character varying(255)"},
+				{Name: "name", DataType: "text", IsNullable: true},
+			},
+		},
+		constraints: map[string][]models.Constraint{
+			"users": {{Name: "users_pkey", Definition: "PRIMARY KEY (id)"}},
+		},
+	}
+
+	diff := diffSchemas(a, b)
+	if len(diff.TablesOnlyInA) != 1 || diff.TablesOnlyInA[0] != "audit" {
+		t.Errorf("TablesOnlyInA = %v, want [audit]", diff.TablesOnlyInA)
+	}
+	if len(diff.TablesOnlyInB) != 1 || diff.TablesOnlyInB[0] != "orders" {
+		t.Errorf("TablesOnlyInB = %v, want [orders]", diff.TablesOnlyInB)
+	}
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("got %d changed tables, want 1", len(diff.ChangedTables))
+	}
+
+	users := diff.ChangedTables[0]
+	wantColumns := []models.ObjectDiff{
+		{Name: "email", Status: models.DiffChanged, A: "character varying(100)", B: "character varying(255) NOT NULL"},
+		{Name: "name", Status: models.DiffOnlyInB, B: "text"},
+	}
+	if len(users.Columns) != len(wantColumns) {
+		t.Fatalf("Columns = %+v, want %+v", users.Columns, wantColumns)
+	}
+	for i, want := range wantColumns {
+		if users.Columns[i] != want {
+			t.Errorf("Columns[%d] = %+v, want %+v", i, users.Columns[i], want)
+		}
+	}
+	if len(users.Indexes) != 1 || users.Indexes[0].Status != models.DiffOnlyInA {
+		t.Errorf("Indexes = %+v, want users_email_idx only in A", users.Indexes)
+	}
+	if len(users.Constraints) != 0 {
+		t.Errorf("Constraints = %+v, want none", users.Constraints)
+	}
+}
+
+func TestDiffSchemasIdentical(t *testing.T) {
+	snap := &schemaSnapshot{
+		tables:  []string{"users"},
+		columns: map[string][]models.Column{"users": {{Name: "id", DataType: "integer"}}},
+	}
+	diff := diffSchemas(snap, snap)
+	if len(diff.TablesOnlyInA)+len(diff.TablesOnlyInB)+len(diff.ChangedTables) != 0 {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+}
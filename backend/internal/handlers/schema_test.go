@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+func TestFallbackFunctionDefinitionCLanguage(t *testing.T) {
+	f := &models.Function{
+		Schema:     "public",
+		Name:       "add_one",
+		ReturnType: "integer",
+		Arguments:  "integer",
+		Language:   "c",
+	}
+	def, note := fallbackFunctionDefinition(f, "add_one", "$libdir/add_one", errors.New("boom"))
+
+	if !strings.Contains(note, "boom") {
+		t.Errorf("note should carry the underlying error, got %q", note)
+	}
+	for _, want := range []string{
+		`CREATE OR REPLACE FUNCTION "public"."add_one"(integer)`,
+		"RETURNS integer",
+		"LANGUAGE c",
+		"AS '$libdir/add_one', 'add_one';",
+	} {
+		if !strings.Contains(def, want) {
+			t.Errorf("definition missing %q:\n%s", want, def)
+		}
+	}
+}
+
+func TestFallbackFunctionDefinitionBody(t *testing.T) {
+	f := &models.Function{Schema: "s", Name: "f", ReturnType: "void", Language: "plpgsql"}
+	def, _ := fallbackFunctionDefinition(f, "BEGIN END", "", nil)
+	if !strings.Contains(def, "AS $function$BEGIN END$function$;") {
+		t.Errorf("expected dollar-quoted body, got:\n%s", def)
+	}
+
+	def, _ = fallbackFunctionDefinition(f, "", "", nil)
+	if !strings.Contains(def, "-- source not available") {
+		t.Errorf("expected placeholder for missing source, got:\n%s", def)
+	}
+}
+
+func TestCommentLiteral(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", "NULL"},
+		{"   ", "NULL"},
+		{"Customer accounts", "E'Customer accounts'"},
+		{`it's a \ test`, `E'it''s a \\ test'`},
+	}
+	for _, tt := range tests {
+		got, err := commentLiteral(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("commentLiteral(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := commentLiteral("a\x00b"); err == nil {
+		t.Error("commentLiteral accepted a NUL byte")
+	}
+}
+
+func TestBuildCreateIndex(t *testing.T) {
+	req := models.CreateIndexRequest{Schema: "public", Table: "events", Columns: []string{"payload"}, Method: "gin", Concurrently: true}
+	got, err := buildCreateIndex(&req)
+	want := `CREATE INDEX CONCURRENTLY "events_payload_idx" ON "public"."events" USING gin ("payload")`
+	if err != nil || got != want {
+		t.Errorf("buildCreateIndex() = %q, %v; want %q", got, err, want)
+	}
+
+	req = models.CreateIndexRequest{Schema: "app", Table: "users", Columns: []string{"org_id", "email"}, Name: "users_email_key", Unique: true}
+	got, err = buildCreateIndex(&req)
+	want = `CREATE UNIQUE INDEX "users_email_key" ON "app"."users" USING btree ("org_id", "email")`
+	if err != nil || got != want {
+		t.Errorf("buildCreateIndex() = %q, %v; want %q", got, err, want)
+	}
+
+	bad := []models.CreateIndexRequest{
+		{Schema: "public", Table: "t; DROP TABLE x", Columns: []string{"a"}},
+		{Schema: "public", Table: "t", Columns: []string{"a)"}},
+		{Schema: "public", Table: "t", Columns: []string{"a"}, Name: "bad name"},
+		{Schema: "public", Table: "t", Columns: []string{"a"}, Method: "gin", Unique: true},
+	}
+	for _, r := range bad {
+		if _, err := buildCreateIndex(&r); err == nil {
+			t.Errorf("buildCreateIndex(%+v) succeeded, want error", r)
+		}
+	}
+}
+
+func TestDefaultIndexName(t *testing.T) {
+	if got := defaultIndexName("t", []string{"a", "b"}); got != "t_a_b_idx" {
+		t.Errorf("defaultIndexName = %q", got)
+	}
+	long := defaultIndexName(strings.Repeat("x", 60), []string{"column"})
+	if len(long) != maxIdentifierLength || !strings.HasSuffix(long, "_idx") {
+		t.Errorf("defaultIndexName(long) = %q (%d bytes)", long, len(long))
+	}
+}
+
+func TestAlterSequenceClauses(t *testing.T) {
+	n := func(v int64) *int64 { return &v }
+	got, err := alterSequenceClauses(&models.AlterSequenceRequest{Restart: n(1000), Increment: n(-2), MaxValue: n(5000)})
+	if want := "INCREMENT BY -2 MAXVALUE 5000 RESTART WITH 1000"; err != nil || got != want {
+		t.Errorf("alterSequenceClauses() = %q, %v; want %q", got, err, want)
+	}
+	if _, err := alterSequenceClauses(&models.AlterSequenceRequest{}); err == nil {
+		t.Error("empty request accepted")
+	}
+	if _, err := alterSequenceClauses(&models.AlterSequenceRequest{Increment: n(0)}); err == nil {
+		t.Error("zero increment accepted")
+	}
+}
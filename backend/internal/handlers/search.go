@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+const (
+	// defaultSearchLimit is the per-table match cap when none is given.
+	defaultSearchLimit = 20
+	// maxSearchTables bounds how many tables go into one UNION ALL.
+	maxSearchTables = 50
+	// searchMaxRowsPreference holds the estimated row count above which a
+	// table is skipped; the request's maxTableRows overrides it.
+	searchMaxRowsPreference   = "search.max_table_rows"
+	defaultSearchMaxTableRows = 1_000_000
+)
+
+// searchTable is a table with at least one text-type column.
+type searchTable struct {
+	schema        string
+	table         string
+	estimatedRows float64 // -1 when the table has never been analyzed
+	populated     bool    // false for a materialized view never refreshed
+	columns       []string
+}
+
+// searchableTables lists the tables in schema the current user can read,
+// with their string-category columns (text, varchar, char, citext, and
+// domains over them) in column order. Partitions are listed individually
+// rather than through their parent.
+func searchableTables(ctx context.Context, q queryRunner, schema string) ([]searchTable, error) {
+	rows, err := q.Query(ctx, `
+		SELECT c.relname, c.reltuples::float8, c.relispopulated, a.attname
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1
+		  AND c.relkind IN ('r', 'm')
+		  AND a.attnum > 0
+		  AND NOT a.attisdropped
+		  AND t.typcategory = 'S'
+		  AND has_table_privilege(c.oid, 'SELECT')
+		ORDER BY c.relname, a.attnum
+	`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []searchTable
+	for rows.Next() {
+		var table, column string
+		var estimated float64
+		var populated bool
+		if err := rows.Scan(&table, &estimated, &populated, &column); err != nil {
+			return nil, err
+		}
+		if n := len(tables); n == 0 || tables[n-1].table != table {
+			tables = append(tables, searchTable{schema: schema, table: table, estimatedRows: estimated, populated: populated})
+		}
+		last := &tables[len(tables)-1]
+		last.columns = append(last.columns, column)
+	}
+	return tables, rows.Err()
+}
+
+// likePattern turns a search term into an ILIKE pattern matching it
+// anywhere, with the term's own wildcards taken literally.
+func likePattern(term string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + escaper.Replace(term) + "%"
+}
+
+// buildSearchQuery builds one UNION ALL branch per table, each returning up
+// to limit rows as (table_index, matched_columns, row). The pattern is $1;
+// table_index is the table's position in tables.
+func buildSearchQuery(tables []searchTable, limit int) (string, error) {
+	branches := make([]string, 0, len(tables))
+	for i, t := range tables {
+		schema, err := dbsafe.QuoteIdent(t.schema)
+		if err != nil {
+			return "", err
+		}
+		table, err := dbsafe.QuoteIdent(t.table)
+		if err != nil {
+			return "", err
+		}
+
+		conds := make([]string, 0, len(t.columns))
+		cases := make([]string, 0, len(t.columns))
+		for _, col := range t.columns {
+			ident, err := dbsafe.QuoteIdent(col)
+			if err != nil {
+				return "", err
+			}
+			name, err := dbsafe.QuoteString(col)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, fmt.Sprintf("t.%s ILIKE $1", ident))
+			cases = append(cases, fmt.Sprintf("CASE WHEN t.%s ILIKE $1 THEN %s END", ident, name))
+		}
+
+		branches = append(branches, fmt.Sprintf(
+			"(SELECT %d AS table_index, array_remove(ARRAY[%s]::text[], NULL) AS matched_columns, to_jsonb(t.*) AS row FROM %s.%s t WHERE %s LIMIT %d)",
+			i, strings.Join(cases, ", "), schema, table, strings.Join(conds, " OR "), limit,
+		))
+	}
+	return strings.Join(branches, "\nUNION ALL\n"), nil
+}
+
+// searchMaxTableRows resolves the skip threshold: request, then
+// preference, then the default.
+func searchMaxTableRows(requested int64) float64 {
+	if requested > 0 {
+		return float64(requested)
+	}
+	if value, _ := storage.GetPreference(searchMaxRowsPreference); value != "" {
+		if n, err := parseSearchMaxTableRows(value); err == nil {
+			return float64(n)
+		}
+	}
+	return defaultSearchMaxTableRows
+}
+
+// parseSearchMaxTableRows reads a searchMaxRowsPreference value, a
+// positive row count.
+func parseSearchMaxTableRows(value string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("must be a positive number of rows")
+	}
+	return n, nil
+}
+
+// searchSkipReason says why t isn't searched, given the row limit and the
+// number of tables already chosen, or "" to search it. A materialized view
+// that was never refreshed can't be read, and a table never analyzed
+// (reltuples -1) has no estimate to hold against the limit.
+func searchSkipReason(t searchTable, maxRows float64, chosen int) string {
+	switch {
+	case !t.populated:
+		return "materialized view has not been populated"
+	case t.estimatedRows < 0:
+		return "row count unknown; run ANALYZE on the table first"
+	case t.estimatedRows > maxRows:
+		return fmt.Sprintf("estimated %.0f rows exceeds the %.0f row limit", t.estimatedRows, maxRows)
+	case chosen >= maxSearchTables:
+		return fmt.Sprintf("only the first %d tables are searched", maxSearchTables)
+	}
+	return ""
+}
+
+// SearchData looks for a term in every text column of every table in a
+// schema and returns the matching rows grouped by table. Tables whose
+// estimated row count (pg_class.reltuples) exceeds the threshold, and any
+// past the first maxSearchTables, are listed as skipped instead of scanned.
+func SearchData(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var req models.SearchRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !isValidIdentifier(req.Schema) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema name"})
+		return
+	}
+	if strings.TrimSpace(req.Term) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search term is required"})
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	maxRows := searchMaxTableRows(req.MaxTableRows)
+
+	start := time.Now()
+	candidates, err := searchableTables(ctx, pool, req.Schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.SearchResult{
+		Tables:  []models.TableSearchResult{},
+		Skipped: []models.SkippedTable{},
+	}
+	var tables []searchTable
+	for _, t := range candidates {
+		if reason := searchSkipReason(t, maxRows, len(tables)); reason != "" {
+			result.Skipped = append(result.Skipped, models.SkippedTable{
+				Schema:        t.schema,
+				Table:         t.table,
+				EstimatedRows: max(t.estimatedRows, 0),
+				Reason:        reason,
+			})
+			continue
+		}
+		tables = append(tables, t)
+	}
+	result.TablesScanned = len(tables)
+
+	if len(tables) > 0 {
+		query, err := buildSearchQuery(tables, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		rows, err := pool.Query(ctx, query, likePattern(req.Term))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+
+		matches := make([][]models.SearchMatch, len(tables))
+		for rows.Next() {
+			var index int
+			var match models.SearchMatch
+			if err := rows.Scan(&index, &match.MatchedColumns, &match.Row); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			matches[index] = append(matches[index], match)
+		}
+		if err := rows.Err(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		for i, t := range tables {
+			if len(matches[i]) == 0 {
+				continue
+			}
+			result.Tables = append(result.Tables, models.TableSearchResult{
+				Schema:  t.schema,
+				Table:   t.table,
+				Matches: matches[i],
+			})
+			result.TotalMatches += len(matches[i])
+		}
+	}
+
+	result.Duration = time.Since(start).Seconds() * 1000
+	c.JSON(http.StatusOK, result)
+}
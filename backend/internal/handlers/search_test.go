@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLikePattern(t *testing.T) {
+	if got, want := likePattern(`50%_off\`), `%50\%\_off\\%`; got != want {
+		t.Errorf("likePattern = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	query, err := buildSearchQuery([]searchTable{
+		{schema: "public", table: "users", columns: []string{"name", "e\"mail"}},
+		{schema: "public", table: "Notes", columns: []string{"body"}},
+	}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`(SELECT 0 AS table_index, array_remove(ARRAY[CASE WHEN t."name" ILIKE $1 THEN E'name' END, CASE WHEN t."e""mail" ILIKE $1 THEN E'e"mail' END]::text[], NULL)`,
+		`FROM "public"."users" t WHERE t."name" ILIKE $1 OR t."e""mail" ILIKE $1 LIMIT 5)`,
+		"\nUNION ALL\n(SELECT 1 AS table_index",
+		`FROM "public"."Notes" t WHERE t."body" ILIKE $1 LIMIT 5)`,
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+}
+
+func TestSearchSkipReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		table  searchTable
+		chosen int
+		want   string
+	}{
+		{"small table", searchTable{populated: true, estimatedRows: 10}, 0, ""},
+		{"over the limit", searchTable{populated: true, estimatedRows: 2000}, 0, "exceeds"},
+		{"never analyzed", searchTable{populated: true, estimatedRows: -1}, 0, "ANALYZE"},
+		{"unpopulated view", searchTable{estimatedRows: 0}, 0, "populated"},
+		{"too many tables", searchTable{populated: true}, maxSearchTables, "first"},
+	}
+	for _, tt := range tests {
+		got := searchSkipReason(tt.table, 1000, tt.chosen)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: reason = %q, want one mentioning %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// SetSequenceValue runs setval on a sequence, e.g. to move it past the
+// largest id after a bulk import, and returns its new state.
+func SetSequenceValue(c *gin.Context) {
+	var req models.SetSequenceValueRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	isCalled := req.IsCalled == nil || *req.IsCalled
+
+	changeSequence(c, "setting sequence values", func(ctx context.Context, tx pgx.Tx, qualified string) error {
+		// setval takes the sequence as regclass, so the name is a parameter.
+		_, err := tx.Exec(ctx, "SELECT setval($1::regclass, $2, $3)", qualified, *req.Value, isCalled)
+		return err
+	})
+}
+
+// AlterSequence restarts a sequence or changes its increment or maximum,
+// and returns its new state.
+func AlterSequence(c *gin.Context) {
+	var req models.AlterSequenceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	clauses, err := alterSequenceClauses(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changeSequence(c, "altering sequences", func(ctx context.Context, tx pgx.Tx, qualified string) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf("ALTER SEQUENCE %s %s", qualified, clauses))
+		return err
+	})
+}
+
+// alterSequenceClauses renders the ALTER SEQUENCE options for a request.
+// Range checks against the sequence's type are left to Postgres.
+func alterSequenceClauses(req *models.AlterSequenceRequest) (string, error) {
+	var clauses []string
+	if req.Increment != nil {
+		if *req.Increment == 0 {
+			return "", errors.New("increment can't be zero")
+		}
+		clauses = append(clauses, fmt.Sprintf("INCREMENT BY %d", *req.Increment))
+	}
+	if req.MaxValue != nil {
+		clauses = append(clauses, fmt.Sprintf("MAXVALUE %d", *req.MaxValue))
+	}
+	if req.Restart != nil {
+		clauses = append(clauses, fmt.Sprintf("RESTART WITH %d", *req.Restart))
+	}
+	if len(clauses) == 0 {
+		return "", errors.New("nothing to change: set restart, increment or maxValue")
+	}
+	return strings.Join(clauses, " "), nil
+}
+
+// changeSequence applies change to the :schema.:name sequence in a
+// transaction and replies with where the sequence stands afterwards.
+func changeSequence(c *gin.Context, operation string, change func(ctx context.Context, tx pgx.Tx, qualified string) error) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	if rejectReadOnlyWrite(c, connId, operation) {
+		return
+	}
+
+	schema := c.Param("schema")
+	name := c.Param("name")
+	if !isValidIdentifier(schema) || !isValidIdentifier(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema or sequence name"})
+		return
+	}
+	qualified := fmt.Sprintf("%s.%s", quoteIdentifier(schema), quoteIdentifier(name))
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := change(ctx, tx, qualified); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	state := models.SequenceState{Schema: schema, Name: name}
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT s.last_value, s.is_called, ps.seqincrement
+		FROM %s s, pg_catalog.pg_sequence ps
+		WHERE ps.seqrelid = $1::regclass
+	`, qualified), qualified).Scan(&state.LastValue, &state.IsCalled, &state.Increment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	state.NextValue = state.LastValue
+	if state.IsCalled {
+		state.NextValue += state.Increment
+	}
+	c.JSON(http.StatusOK, state)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/storage"
+)
+
+// QueryResetSessionPreference set to "false" leaves the session state a
+// query creates (temp tables, SET, prepared statements, cursors, LISTEN,
+// advisory locks) on its pooled connection, for workflows that build on it
+// across runs — though a later run may still get another connection.
+// Anything else has ExecuteQuery discard it.
+const QueryResetSessionPreference = "query.reset_session"
+
+// sessionFunctions change session state from an otherwise read-only
+// SELECT.
+var sessionFunctions = map[string]bool{
+	"SET_CONFIG":                  true,
+	"PG_ADVISORY_LOCK":            true,
+	"PG_ADVISORY_LOCK_SHARED":     true,
+	"PG_TRY_ADVISORY_LOCK":        true,
+	"PG_TRY_ADVISORY_LOCK_SHARED": true,
+}
+
+// mayAlterSession reports whether any of statements could leave state on
+// the session. Only plain reads are known not to: anything a read-only
+// connection would refuse (SET, CREATE TEMP TABLE, PREPARE, ...) might.
+func mayAlterSession(statements []StatementInfo) bool {
+	for _, stmt := range statements {
+		if !isReadOnlyStatement(stmt.SQL) {
+			return true
+		}
+		for _, w := range sqlKeywords(stmt.SQL) {
+			if sessionFunctions[w] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resetSessionEnabled reads QueryResetSessionPreference.
+func resetSessionEnabled() bool {
+	value, _ := storage.GetPreference(QueryResetSessionPreference)
+	return value != "false"
+}
+
+// resetSession runs DISCARD ALL on a connection of connId before it goes
+// back to the pool, so state a query left can't leak into unrelated
+// requests. pgx's statement caches are cleared with it, since their
+// server-side statements are gone. The connection's role is set again;
+// read-only mode and the search path, startup parameters, survive. A
+// connection left in a transaction is skipped (the pool drops it anyway),
+// and one that can't be reset is closed.
+func resetSession(conn *pgxpool.Conn, connId string) {
+	pgConn := conn.Conn().PgConn()
+	if conn.Conn().IsClosed() || pgConn.TxStatus() != 'I' {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := pgConn.Exec(ctx, "DISCARD ALL").ReadAll()
+	if err == nil {
+		err = conn.Conn().DeallocateAll(ctx)
+	}
+	if err == nil {
+		err = database.GetManager().RestoreSessionRole(ctx, connId, conn.Conn())
+	}
+	if err != nil {
+		log.Printf("could not reset session state, closing the connection: %v", err)
+		conn.Conn().Close(ctx)
+	}
+}
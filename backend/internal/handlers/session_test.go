@@ -0,0 +1,28 @@
+package handlers
+
+import "testing"
+
+func TestMayAlterSession(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * FROM users", false},
+		{"WITH x AS (SELECT 1) SELECT * FROM x; SHOW search_path", false},
+		{"EXPLAIN SELECT 1", false},
+		{"SELECT 'set_config' AS name", false},
+		{"SET search_path = app", true},
+		{"SELECT 1; CREATE TEMP TABLE t AS SELECT 1", true},
+		{"SELECT * INTO TEMP t FROM users", true},
+		{"PREPARE q AS SELECT 1", true},
+		{"LISTEN events", true},
+		{"SELECT set_config('work_mem', '64MB', false)", true},
+		{"SELECT pg_advisory_lock(42)", true},
+		{"UPDATE users SET active = true", true},
+	}
+	for _, tt := range tests {
+		if got := mayAlterSession(splitStatements(tt.sql)); got != tt.want {
+			t.Errorf("mayAlterSession(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/dbsafe"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// GetSlowQueries reports the top statements from pg_stat_statements by
+// total or mean execution time, calls or rows (?orderBy=, default total).
+// ?limit= works as for RunAnalysis. A missing or unloaded extension is
+// reported in the response rather than as an error.
+func GetSlowQueries(c *gin.Context) {
+	connId := c.Param("connId")
+	manager := database.GetManager()
+	if !manager.IsConnected(connId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not connected"})
+		return
+	}
+
+	orderBy := c.DefaultQuery("orderBy", "total")
+	switch orderBy {
+	case "total", "mean", "calls", "rows":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "orderBy must be total, mean, calls or rows"})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report := models.SlowQueryReport{OrderBy: orderBy, Queries: []models.SlowQuery{}}
+
+	var schema string
+	err := pool.QueryRow(ctx, `
+		SELECT n.nspname
+		FROM pg_catalog.pg_extension e
+		JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname = 'pg_stat_statements'
+	`).Scan(&schema)
+	if errors.Is(err, pgx.ErrNoRows) {
+		report.Message = "pg_stat_statements is not installed. Add it to shared_preload_libraries, restart the server and run CREATE EXTENSION pg_stat_statements;"
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	view, err := dbsafe.QuoteIdent(schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	view += ".pg_stat_statements"
+
+	// pg_stat_statements 1.8 (Postgres 13) renamed total_time and
+	// mean_time to total_exec_time and mean_exec_time.
+	var execTimeColumns bool
+	err = pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_catalog.pg_attribute
+			WHERE attrelid = to_regclass($1) AND attname = 'total_exec_time'
+		)
+	`, view).Scan(&execTimeColumns)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	totalCol, meanCol := "total_time", "mean_time"
+	if execTimeColumns {
+		totalCol, meanCol = "total_exec_time", "mean_exec_time"
+	}
+	orderCol := map[string]string{
+		"total": totalCol,
+		"mean":  meanCol,
+		"calls": "calls",
+		"rows":  "rows",
+	}[orderBy]
+
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(s.queryid::text, '') as query_id,
+			s.query,
+			COALESCE(r.rolname, '') as username,
+			s.calls,
+			s.%[1]s as total_time,
+			s.%[2]s as mean_time,
+			s.rows,
+			COALESCE(100 * s.%[1]s / NULLIF(sum(s.%[1]s) OVER (), 0), 0) as percent_total
+		FROM %[3]s s
+		LEFT JOIN pg_catalog.pg_roles r ON r.oid = s.userid
+		WHERE s.dbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database())
+		ORDER BY s.%[4]s DESC
+		LIMIT $1
+	`, totalCol, meanCol, view, orderCol)
+
+	rows, err := pool.Query(ctx, query, analysisLimit(c))
+	if err != nil {
+		// Typically the extension exists but the library isn't in
+		// shared_preload_libraries.
+		report.Message = "pg_stat_statements is installed but not usable: " + err.Error()
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var q models.SlowQuery
+		if err := rows.Scan(&q.QueryID, &q.Query, &q.User, &q.Calls, &q.TotalTime, &q.MeanTime, &q.Rows, &q.PercentTotal); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		report.Queries = append(report.Queries, q)
+	}
+	if err := rows.Err(); err != nil {
+		report.Message = "pg_stat_statements is installed but not usable: " + err.Error()
+		report.Queries = []models.SlowQuery{}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	report.Available = true
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+	"github.com/thelinuxer/pgvoyager/internal/security"
+)
+
+// streamBatchSize is how many rows go into each "rows" message.
+const streamBatchSize = 500
+
+var streamUpgrader = security.NewWebSocketUpgrader()
+
+// streamMessage is one frame of a streamed query. Type is "columns" (sent
+// first), "rows" (repeated), then either "done" or "error".
+type streamMessage struct {
+	Type          string              `json:"type"`
+	Columns       []models.ColumnInfo `json:"columns,omitempty"`
+	Rows          []map[string]any    `json:"rows,omitempty"`
+	RowCount      int                 `json:"rowCount,omitempty"`
+	Duration      float64             `json:"duration,omitempty"` // milliseconds
+	Error         string              `json:"error,omitempty"`
+	ErrorPosition int                 `json:"errorPosition,omitempty"`
+	ErrorHint     string              `json:"errorHint,omitempty"`
+	ErrorDetail   string              `json:"errorDetail,omitempty"`
+}
+
+// streamError converts a failed QueryResult into an "error" frame.
+func streamError(r models.QueryResult) streamMessage {
+	return streamMessage{
+		Type:          "error",
+		Duration:      r.Duration,
+		Error:         r.Error,
+		ErrorPosition: r.ErrorPosition,
+		ErrorHint:     r.ErrorHint,
+		ErrorDetail:   r.ErrorDetail,
+	}
+}
+
+// streamRequest is the message that starts a stream. A WebSocket can't
+// carry models.ConfirmProductionHeader, so ConfirmProduction stands in for
+// it: without it a write to a production connection is refused.
+type streamRequest struct {
+	models.QueryRequest
+	ConfirmProduction bool `json:"confirmProduction,omitempty"`
+}
+
+// StreamQuery is the WebSocket counterpart of ExecuteQuery for result sets
+// too big to buffer. The client sends one QueryRequest message; the server
+// replies with column metadata, row batches as pgx yields them, and a
+// final summary. Closing the socket cancels the query.
+func StreamQuery(c *gin.Context) {
+	manager, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	ws, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("query stream upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	var req streamRequest
+	if err := ws.ReadJSON(&req); err != nil || req.SQL == "" {
+		ws.WriteJSON(streamMessage{Type: "error", Error: "expected a query request with sql"})
+		return
+	}
+
+	if database.GetManager().IsReadOnly(connId) {
+		if stmt, bad := readOnlyViolation(req.SQL); bad {
+			ws.WriteJSON(streamMessage{
+				Type:  "error",
+				Error: "connection is read-only; statement not allowed: " + truncateStatement(stmt),
+			})
+			return
+		}
+	}
+	if database.GetManager().IsProduction(connId) && !req.ConfirmProduction {
+		if stmt, bad := readOnlyViolation(req.SQL); bad {
+			ws.WriteJSON(streamMessage{
+				Type:  "error",
+				Error: "connection is tagged production; resend with confirmProduction: true to confirm: " + truncateStatement(stmt),
+			})
+			return
+		}
+	}
+	params, err := coerceParams(req.Params, req.ParamTypes)
+	if err != nil {
+		ws.WriteJSON(streamMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	req.Params = params
+	// With no params pgx uses the simple protocol, which would run every
+	// statement in the string; streaming is for a single big SELECT.
+	if len(splitStatements(req.SQL)) > 1 {
+		ws.WriteJSON(streamMessage{Type: "error", Error: "streaming accepts a single statement"})
+		return
+	}
+
+	pool, _ := manager.GetPool(connId)
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout(connId, 120*time.Second))
+	defer cancel()
+
+	// The client sends nothing after the request, so any read returning is
+	// a close (or a broken socket): stop the query.
+	go func() {
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	sendError := func(err error) {
+		ws.WriteJSON(streamError(buildErrorResult(err, time.Since(start).Seconds()*1000, 0)))
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	defer conn.Release()
+
+	// The rows stay open for the whole stream, so the columns are described
+	// up front from a prepared statement on the same connection rather than
+	// through a second one from the small pool.
+	desc, err := conn.Conn().PgConn().Prepare(ctx, "", req.SQL, nil)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	columns := describeColumns(ctx, conn, desc.Fields)
+
+	rows, err := conn.Query(ctx, req.SQL, req.Params...)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	if err := ws.WriteJSON(streamMessage{Type: "columns", Columns: columns}); err != nil {
+		return
+	}
+
+	total := 0
+	batch := make([]map[string]any, 0, streamBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := ws.WriteJSON(streamMessage{Type: "rows", Rows: batch})
+		batch = make([]map[string]any, 0, streamBatchSize)
+		return err
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			sendError(err)
+			return
+		}
+		row := make(map[string]any, len(fieldDescs))
+		for i, fd := range fieldDescs {
+			row[fd.Name] = convertValue(values[i], fd.DataTypeOID)
+		}
+		batch = append(batch, row)
+		total++
+
+		if len(batch) == streamBatchSize {
+			if err := flush(); err != nil {
+				return
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		sendError(err)
+		return
+	}
+	if err := flush(); err != nil {
+		return
+	}
+
+	ws.WriteJSON(streamMessage{
+		Type:     "done",
+		RowCount: total,
+		Duration: time.Since(start).Seconds() * 1000,
+	})
+	ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+}
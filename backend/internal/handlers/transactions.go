@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/thelinuxer/pgvoyager/internal/database"
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// getTransaction looks up the :txId transaction on the :connId connection,
+// responding 404 when there is none.
+func getTransaction(c *gin.Context, connId string) (*database.Transaction, bool) {
+	t, err := database.GetTransactionManager().Get(connId, c.Param("txId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return t, true
+}
+
+// BeginTransaction opens an interactive transaction that later requests
+// run statements in until it is committed, rolled back, or left idle long
+// enough for the reaper to roll it back.
+func BeginTransaction(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	t, err := database.GetTransactionManager().Begin(ctx, connId)
+	if errors.Is(err, database.ErrTooManyTransactions) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t.Info())
+}
+
+// ListTransactions returns the connection's open interactive transactions.
+func ListTransactions(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, database.GetTransactionManager().List(connId))
+}
+
+// ExecuteInTransaction runs SQL inside an open transaction and returns a
+// QueryResult like ExecuteQuery. A failing statement leaves the
+// transaction open but failed, so only a rollback is useful afterwards.
+func ExecuteInTransaction(c *gin.Context) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.TransactionExecuteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if rejectReadOnlySQL(c, connId, req.SQL) {
+		return
+	}
+	statements := splitStatements(req.SQL)
+	for _, stmt := range statements {
+		if isTransactionControl(stmt.SQL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction control statements are not allowed; use the commit and rollback endpoints"})
+			return
+		}
+	}
+
+	t, ok := getTransaction(c, connId)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout(connId, 120*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	var result models.QueryResult
+	err := database.GetTransactionManager().Run(t, func(tx pgx.Tx) error {
+		result = executeSingleResult(ctx, tx, req.SQL, statements, req.Params, start)
+		return nil
+	})
+	if errors.Is(err, database.ErrTransactionEnded) {
+		recordQueryHistory(connId, req.SQL, start, result.RowCount, err.Error())
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	markDeadline(ctx, &result)
+	recordQueryHistory(connId, req.SQL, start, result.RowCount, result.Error)
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateSavepoint sets a savepoint in an open transaction, which later
+// work can be rolled back to without ending the transaction.
+func CreateSavepoint(c *gin.Context) {
+	savepointRequest(c, database.SavepointCreate)
+}
+
+// ReleaseSavepoint forgets a savepoint and those set after it, keeping
+// their work.
+func ReleaseSavepoint(c *gin.Context) {
+	savepointRequest(c, database.SavepointRelease)
+}
+
+// RollbackToSavepoint undoes the work done since a savepoint, which stays
+// set. It also recovers a transaction that failed after the savepoint.
+func RollbackToSavepoint(c *gin.Context) {
+	savepointRequest(c, database.SavepointRollback)
+}
+
+// savepointRequest runs a savepoint operation and responds with the
+// transaction's info, or the Postgres error with 400.
+func savepointRequest(c *gin.Context, op string) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+
+	var req models.SavepointRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := database.ValidateSavepointName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, ok := getTransaction(c, connId)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := database.GetTransactionManager().Savepoint(ctx, t, op, req.Name)
+	if errors.Is(err, database.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, t.Info())
+}
+
+// CommitTransaction commits an open transaction. A failed commit (a
+// deferred constraint, or a transaction already failed) reports the
+// rollback Postgres did instead.
+func CommitTransaction(c *gin.Context) {
+	endTransactionRequest(c, true)
+}
+
+// RollbackTransaction rolls back an open transaction.
+func RollbackTransaction(c *gin.Context) {
+	endTransactionRequest(c, false)
+}
+
+func endTransactionRequest(c *gin.Context, commit bool) {
+	_, connId, ok := getPool(c)
+	if !ok {
+		return
+	}
+	t, ok := getTransaction(c, connId)
+	if !ok {
+		return
+	}
+
+	err := database.GetTransactionManager().Finish(t, commit)
+	if errors.Is(err, database.ErrTransactionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A failed ROLLBACK closes the connection, which discards the
+	// transaction just the same.
+	resp := models.TransactionEndResponse{ID: t.ID, Outcome: models.TransactionRolledBack}
+	if !commit {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	resp.Outcome = models.TransactionCommitted
+	c.JSON(http.StatusOK, resp)
+}
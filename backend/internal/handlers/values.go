@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// convertValue normalizes a value from rows.Values() into the JSON-friendly
+// form every result path (query, table data, stream, MCP, export) returns.
+// oid is the column's type OID, from its field description.
+//
+//   - numeric → string, keeping its full precision (NaN and infinities
+//     included); float NaN/±Infinity → "NaN", "Infinity", "-Infinity",
+//     which JSON can't otherwise encode.
+//   - date / timestamp / timestamptz → RFC3339 string; ±infinity →
+//     "infinity" / "-infinity".
+//   - pgtype.Time → "HH:MM:SS[.ffffff]" and pgtype.Interval → "HH:MM:SS"
+//     (with months and days when set), instead of opaque JSON objects.
+//   - bytea → base64 string; other []byte (e.g. XML) → string.
+//   - uuid → its canonical string rather than a 16-number array.
+//   - ranges, geometric types, bit strings, macaddr and "char" → their
+//     Postgres text form.
+//   - arrays → JSON arrays with each element converted the same way.
+//
+// Anything else (ints, bools, strings, decoded json/jsonb) is returned
+// as-is.
+func convertValue(v any, oid uint32) any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if oid == pgtype.ByteaOID {
+			return base64.StdEncoding.EncodeToString(x)
+		}
+		return string(x)
+	case [16]byte:
+		return uuid.UUID(x).String()
+	case []any:
+		elemOID := arrayElementOID(oid)
+		out := make([]any, len(x))
+		for i, e := range x {
+			out[i] = convertValue(e, elemOID)
+		}
+		return out
+	case float64:
+		return floatValue(x)
+	case float32:
+		return floatValue(float64(x))
+	case int32:
+		if oid == pgtype.QCharOID {
+			return string(rune(x))
+		}
+		return x
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case pgtype.InfinityModifier:
+		return x.String()
+	case net.HardwareAddr:
+		return x.String()
+	case pgtype.Time:
+		if !x.Valid {
+			return nil
+		}
+		us := x.Microseconds
+		hours := us / 3_600_000_000
+		us -= hours * 3_600_000_000
+		mins := us / 60_000_000
+		us -= mins * 60_000_000
+		secs := us / 1_000_000
+		us -= secs * 1_000_000
+		if us == 0 {
+			return fmt.Sprintf("%02d:%02d:%02d", hours, mins, secs)
+		}
+		return fmt.Sprintf("%02d:%02d:%02d.%06d", hours, mins, secs, us)
+	case pgtype.Interval:
+		if !x.Valid {
+			return nil
+		}
+		// Render as ISO-ish "PnDThh:mm:ss" only when meaningful;
+		// otherwise just the time portion.
+		totalUs := x.Microseconds
+		hours := totalUs / 3_600_000_000
+		totalUs -= hours * 3_600_000_000
+		mins := totalUs / 60_000_000
+		totalUs -= mins * 60_000_000
+		secs := totalUs / 1_000_000
+		if x.Days == 0 && x.Months == 0 {
+			return fmt.Sprintf("%02d:%02d:%02d", hours, mins, secs)
+		}
+		return fmt.Sprintf("%d months %d days %02d:%02d:%02d", x.Months, x.Days, hours, mins, secs)
+	case pgtype.Range[any]:
+		if !x.Valid {
+			return nil
+		}
+		return rangeText(x)
+	case pgtype.Multirange[pgtype.Range[any]]:
+		parts := make([]string, len(x))
+		for i, r := range x {
+			parts[i] = rangeText(r)
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case driver.Valuer:
+		// numeric and the geometric and bit string types render their
+		// Postgres text form.
+		text, err := x.Value()
+		if err != nil {
+			return fmt.Sprint(x)
+		}
+		return text
+	}
+	return v
+}
+
+// arrayElementOID returns the element type of a built-in array type, or 0.
+func arrayElementOID(oid uint32) uint32 {
+	t, ok := builtinTypes.TypeForOID(oid)
+	if !ok {
+		return 0
+	}
+	if codec, ok := t.Codec.(*pgtype.ArrayCodec); ok {
+		return codec.ElementType.OID
+	}
+	return 0
+}
+
+// floatValue spells out the float values JSON has no number for.
+func floatValue(f float64) any {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return f
+}
+
+// rangeText renders a range the way Postgres prints it, e.g. [1,5) or
+// empty. Bound values are converted like any other value.
+func rangeText(r pgtype.Range[any]) string {
+	if r.LowerType == pgtype.Empty {
+		return "empty"
+	}
+	bound := func(v any, t pgtype.BoundType) string {
+		if t == pgtype.Unbounded {
+			return ""
+		}
+		return fmt.Sprint(convertValue(v, 0))
+	}
+
+	var b strings.Builder
+	if r.LowerType == pgtype.Inclusive {
+		b.WriteByte('[')
+	} else {
+		b.WriteByte('(')
+	}
+	b.WriteString(bound(r.Lower, r.LowerType))
+	b.WriteByte(',')
+	b.WriteString(bound(r.Upper, r.UpperType))
+	if r.UpperType == pgtype.Inclusive {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte(')')
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TestConvertValueDecoded runs Postgres text values through pgx's own
+// decoding, as rows.Values() would, and checks the JSON each one becomes.
+func TestConvertValueDecoded(t *testing.T) {
+	cases := []struct {
+		oid  uint32
+		text string
+		want string
+	}{
+		{pgtype.NumericOID, "12345678901234567890.123450", `"12345678901234567890.123450"`},
+		{pgtype.NumericOID, "NaN", `"NaN"`},
+		{pgtype.Float8OID, "NaN", `"NaN"`},
+		{pgtype.Float8OID, "-Infinity", `"-Infinity"`},
+		{pgtype.Float8OID, "1.5", `1.5`},
+		{pgtype.Int8OID, "42", `42`},
+		{pgtype.TimestamptzOID, "2024-03-01 12:30:00.5+00", `"2024-03-01T12:30:00.5Z"`},
+		{pgtype.DateOID, "infinity", `"infinity"`},
+		{pgtype.TimestampOID, "-infinity", `"-infinity"`},
+		{pgtype.ByteaOID, `\xdeadbeef`, `"3q2+7w=="`},
+		{pgtype.XMLOID, "plain text", `"plain text"`},
+		{pgtype.UUIDOID, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", `"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"`},
+		{pgtype.Int4ArrayOID, "{1,NULL,3}", `[1,null,3]`},
+		{pgtype.NumericArrayOID, "{1.10,2}", `["1.10","2"]`},
+		{pgtype.ByteaArrayOID, `{"\\x00ff"}`, `["AP8="]`},
+		{pgtype.Int4rangeOID, "[1,5)", `"[1,5)"`},
+		{pgtype.Int4rangeOID, "empty", `"empty"`},
+		{pgtype.NumrangeOID, "(,2.5]", `"(,2.5]"`},
+		{pgtype.Int4multirangeOID, "{[1,3),[5,7)}", `"{[1,3),[5,7)}"`},
+		{pgtype.PointOID, "(1,2)", `"(1,2)"`},
+		{pgtype.BitOID, "101", `"101"`},
+		{pgtype.MacaddrOID, "08:00:2b:01:02:03", `"08:00:2b:01:02:03"`},
+		{pgtype.QCharOID, "a", `"a"`},
+		{pgtype.JSONBOID, `{"k": [1, "v"]}`, `{"k":[1,"v"]}`},
+	}
+	m := pgtype.NewMap()
+	for _, tc := range cases {
+		typ, _ := m.TypeForOID(tc.oid)
+		v, err := typ.Codec.DecodeValue(m, tc.oid, pgtype.TextFormatCode, []byte(tc.text))
+		if err != nil {
+			t.Fatalf("decoding %s %q: %v", typ.Name, tc.text, err)
+		}
+		got, err := json.Marshal(convertValue(v, tc.oid))
+		if err != nil {
+			t.Errorf("%s %q: %v", typ.Name, tc.text, err)
+			continue
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s %q = %s, want %s", typ.Name, tc.text, got, tc.want)
+		}
+	}
+}
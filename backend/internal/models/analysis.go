@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // AnalysisResult is the complete response from database analysis
 type AnalysisResult struct {
 	Summary    AnalysisSummary    `json:"summary"`
@@ -15,11 +17,23 @@ type AnalysisSummary struct {
 	Ok       int `json:"ok"`
 }
 
-// AnalysisCategory groups related issues
+// AnalysisCategory groups related issues. Each check lists at most the
+// configured limit of issues; TotalCount includes the ones left out.
 type AnalysisCategory struct {
-	Name   string          `json:"name"`
-	Icon   string          `json:"icon"`
-	Issues []AnalysisIssue `json:"issues"`
+	Name       string          `json:"name"`
+	Icon       string          `json:"icon"`
+	Issues     []AnalysisIssue `json:"issues"`
+	Checks     []AnalysisCheck `json:"checks"`
+	TotalCount int             `json:"totalCount"`
+	Shown      int             `json:"shown"`
+}
+
+// AnalysisCheck reports how many issues one check found and how many of
+// them are listed, e.g. "showing 20 of 147 unused indexes".
+type AnalysisCheck struct {
+	Title      string `json:"title"`
+	TotalCount int    `json:"totalCount"`
+	Shown      int    `json:"shown"`
 }
 
 // AnalysisIssue represents a single finding
@@ -41,3 +55,51 @@ type DatabaseStats struct {
 	CacheHitRatio     float64 `json:"cacheHitRatio"`
 	ActiveConnections int     `json:"activeConnections"`
 }
+
+// SlowQueryReport lists the most expensive statements recorded by
+// pg_stat_statements. When the extension isn't usable Available is false
+// and Message says why.
+type SlowQueryReport struct {
+	Available bool        `json:"available"`
+	Message   string      `json:"message,omitempty"`
+	OrderBy   string      `json:"orderBy"`
+	Queries   []SlowQuery `json:"queries"`
+}
+
+// SlowQuery is one normalized statement's cumulative statistics. Times are
+// in milliseconds.
+type SlowQuery struct {
+	QueryID      string  `json:"queryId,omitempty"`
+	Query        string  `json:"query"`
+	User         string  `json:"user"`
+	Calls        int64   `json:"calls"`
+	TotalTime    float64 `json:"totalTime"`
+	MeanTime     float64 `json:"meanTime"`
+	Rows         int64   `json:"rows"`
+	PercentTotal float64 `json:"percentTotal"` // share of all recorded execution time
+}
+
+// SizeHistory is a connection's recorded database and table sizes, oldest
+// sample first.
+type SizeHistory struct {
+	// IntervalSeconds is the time between background samples; 0 when
+	// sampling is off.
+	IntervalSeconds int          `json:"intervalSeconds"`
+	RetentionDays   int          `json:"retentionDays"`
+	Samples         []SizeSample `json:"samples"`
+}
+
+// SizeSample is the database's size at one point in time, with its largest
+// tables (total size, including indexes and TOAST).
+type SizeSample struct {
+	SampledAt     time.Time         `json:"sampledAt"`
+	DatabaseBytes int64             `json:"databaseBytes"`
+	Tables        []TableSizeSample `json:"tables"`
+}
+
+// TableSizeSample is one table's size in a SizeSample.
+type TableSizeSample struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Bytes  int64  `json:"bytes"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// Sources of ColumnStats figures
+const (
+	ColumnStatsFromCatalog = "pg_stats" // planner statistics from ANALYZE
+	ColumnStatsFromSample  = "sample"   // computed over the first SampleSize rows
+)
+
+// ColumnStats profiles one column. Counts taken from pg_stats are
+// estimates scaled by the table's estimated row count; Min and Max are
+// exact and only set for numeric and date/time columns.
+type ColumnStats struct {
+	Schema        string           `json:"schema"`
+	Table         string           `json:"table"`
+	Column        string           `json:"column"`
+	DataType      string           `json:"dataType"`
+	Source        string           `json:"source"`
+	SampleSize    int64            `json:"sampleSize,omitempty"`
+	RowCount      int64            `json:"rowCount"`
+	NullCount     int64            `json:"nullCount"`
+	NullFraction  float64          `json:"nullFraction"`
+	DistinctCount int64            `json:"distinctCount"`
+	Min           *string          `json:"min,omitempty"`
+	Max           *string          `json:"max,omitempty"`
+	MostCommon    []ValueFrequency `json:"mostCommon"`
+}
+
+// ValueFrequency is one of a column's most common values, rendered as
+// text, with the fraction of rows holding it.
+type ValueFrequency struct {
+	Value     string  `json:"value"`
+	Count     int64   `json:"count"`
+	Frequency float64 `json:"frequency"`
+}
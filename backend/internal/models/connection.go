@@ -3,36 +3,159 @@ package models
 import "time"
 
 type Connection struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Host        string    `json:"host"`
-	Port        int       `json:"port"`
-	Database    string    `json:"database"`
-	Username    string    `json:"username"`
-	Password    string    `json:"password,omitempty"`
-	SSLMode     string    `json:"sslMode"`
-	IsConnected bool      `json:"isConnected"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	SSLMode  string `json:"sslMode"`
+	// ConnectionString is a libpq URI (postgres://...) or key/value string
+	// (e.g. "service=prod") used instead of the discrete fields above;
+	// Database, when set, overrides its database. The password in it is
+	// redacted in API responses.
+	ConnectionString string `json:"connectionString,omitempty"`
+	// MaxConnIdleTime is how long, in seconds, an idle pooled connection is
+	// kept before being closed. Zero leaves pgx's default in place.
+	MaxConnIdleTime int `json:"maxConnIdleTime,omitempty"`
+	// MaxConns and MinConns bound the pool size, and MaxConnLifetime (in
+	// seconds) recycles connections after that long. Zero leaves
+	// PgVoyager's defaults: two connections, none kept open, 30 minutes.
+	MaxConns        int `json:"maxConns,omitempty"`
+	MinConns        int `json:"minConns,omitempty"`
+	MaxConnLifetime int `json:"maxConnLifetime,omitempty"`
+	// IsReadOnly rejects writes and DDL through the API and opens every
+	// session with default_transaction_read_only=on.
+	IsReadOnly bool `json:"isReadOnly"`
+	// Environment tags the connection as development, staging or
+	// production (empty when untagged). Writes to a production connection
+	// need the ConfirmProductionHeader.
+	Environment string `json:"environment,omitempty"`
+	// Role, when set, is assumed with SET ROLE on every pooled session, so
+	// a shared login can act with a specific role's privileges.
+	Role string `json:"role,omitempty"`
+	// SearchPath, when set, is a comma-separated list of schemas put in
+	// search_path on every pooled session, so unqualified names resolve
+	// there first.
+	SearchPath string `json:"searchPath,omitempty"`
+	// DefaultQueryTimeoutMs is the deadline, in milliseconds, for queries
+	// and EXPLAINs run against the connection. Zero keeps the built-in
+	// defaults (120s and 60s); the server caps it at an hour.
+	DefaultQueryTimeoutMs int `json:"defaultQueryTimeoutMs,omitempty"`
+	SSLFiles
+	// SSH tunnel through a bastion host; unused when SSHHost is empty.
+	// Auth uses the private key at SSHKeyPath and/or SSHPassword (which
+	// doubles as the key passphrase for encrypted keys).
+	SSHHost     string `json:"sshHost,omitempty"`
+	SSHPort     int    `json:"sshPort,omitempty"`
+	SSHUser     string `json:"sshUser,omitempty"`
+	SSHKeyPath  string `json:"sshKeyPath,omitempty"`
+	SSHPassword string `json:"sshPassword,omitempty"`
+	// IsFavorite pins the connection to the top of the list.
+	IsFavorite  bool `json:"isFavorite"`
+	IsConnected bool `json:"isConnected"`
+	// LatencyMs is the measured round trip to the server; only filled in
+	// by the single-connection endpoint while connected.
+	LatencyMs float64   `json:"latencyMs,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// ConnectionRequest takes either Host, Port and Username (plus optional
+// Password and SSLMode) or a ConnectionString; see
+// database.ValidateConnectionTarget.
 type ConnectionRequest struct {
-	Name     string `json:"name" binding:"required"`
-	Host     string `json:"host" binding:"required"`
-	Port     int    `json:"port" binding:"required"`
-	Database string `json:"database"`
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password"`
-	SSLMode  string `json:"sslMode"`
+	Name             string `json:"name" binding:"required"`
+	Host             string `json:"host"`
+	Port             int    `json:"port"`
+	Database         string `json:"database"`
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	SSLMode          string `json:"sslMode"`
+	ConnectionString string `json:"connectionString"`
+	// MaxConnIdleTime in seconds; zero uses pgx's default
+	MaxConnIdleTime int `json:"maxConnIdleTime" binding:"min=0"`
+	// Pool size and connection lifetime (seconds); zero uses PgVoyager's
+	// defaults (see Connection)
+	MaxConns        int    `json:"maxConns" binding:"min=0,max=1000"`
+	MinConns        int    `json:"minConns" binding:"min=0,max=1000"`
+	MaxConnLifetime int    `json:"maxConnLifetime" binding:"min=0"`
+	IsReadOnly      bool   `json:"isReadOnly"`
+	Environment     string `json:"environment" binding:"omitempty,oneof=development staging production"`
+	Role            string `json:"role"`
+	SearchPath      string `json:"searchPath"`
+	// DefaultQueryTimeoutMs in milliseconds; zero uses the built-in defaults
+	DefaultQueryTimeoutMs int `json:"defaultQueryTimeoutMs" binding:"min=0"`
+	SSLFiles
+	SSHTunnel
+}
+
+// SSLFiles are paths to PEM files for TLS, shared by connections and their
+// create/update and test requests: a client certificate and key for servers
+// that authenticate clients by certificate, and a root certificate to check
+// the server against (sslmode verify-ca or verify-full). A leading ~ is
+// expanded. They go with the discrete host fields; a connection string
+// names its own sslcert, sslkey and sslrootcert.
+type SSLFiles struct {
+	SSLCert     string `json:"sslCert,omitempty"`
+	SSLKey      string `json:"sslKey,omitempty"`
+	SSLRootCert string `json:"sslRootCert,omitempty"`
+}
+
+// SSHTunnel holds the optional bastion settings shared by connection
+// create/update and test requests.
+type SSHTunnel struct {
+	SSHHost     string `json:"sshHost"`
+	SSHPort     int    `json:"sshPort"`
+	SSHUser     string `json:"sshUser"`
+	SSHKeyPath  string `json:"sshKeyPath"`
+	SSHPassword string `json:"sshPassword"`
 }
 
 type TestConnectionRequest struct {
-	Host     string `json:"host" binding:"required"`
-	Port     int    `json:"port" binding:"required"`
-	Database string `json:"database"`
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password"`
-	SSLMode  string `json:"sslMode"`
+	Host             string `json:"host"`
+	Port             int    `json:"port"`
+	Database         string `json:"database"`
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	SSLMode          string `json:"sslMode"`
+	ConnectionString string `json:"connectionString"`
+	Role             string `json:"role"`
+	SearchPath       string `json:"searchPath"`
+	SSLFiles
+	SSHTunnel
+}
+
+// PoolStats is a snapshot of a connection's pgx pool
+type PoolStats struct {
+	TotalConns              int32   `json:"totalConns"`
+	IdleConns               int32   `json:"idleConns"`
+	AcquiredConns           int32   `json:"acquiredConns"`
+	ConstructingConns       int32   `json:"constructingConns"`
+	MaxConns                int32   `json:"maxConns"`
+	AcquireCount            int64   `json:"acquireCount"`
+	EmptyAcquireCount       int64   `json:"emptyAcquireCount"`
+	CanceledAcquireCount    int64   `json:"canceledAcquireCount"`
+	NewConnsCount           int64   `json:"newConnsCount"`
+	MaxIdleDestroyCount     int64   `json:"maxIdleDestroyCount"`
+	MaxLifetimeDestroyCount int64   `json:"maxLifetimeDestroyCount"`
+	MaxConnIdleTime         float64 `json:"maxConnIdleTime"` // seconds, effective value
+	MaxConnLifetime         float64 `json:"maxConnLifetime"` // seconds
+}
+
+// ConnectionHealth reports the background liveness check of a connection.
+// Status is "unknown", "healthy", "reconnected" or "disconnected".
+type ConnectionHealth struct {
+	ConnectionID        string     `json:"connectionId"`
+	Status              string     `json:"status"`
+	IsConnected         bool       `json:"isConnected"`
+	LastPingAt          *time.Time `json:"lastPingAt,omitempty"`
+	LatencyMs           float64    `json:"latencyMs"`
+	LastError           string     `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Reconnects          int        `json:"reconnects"`
+	IntervalSeconds     int        `json:"intervalSeconds"` // 0 when checking is off
 }
 
 type SwitchDatabaseRequest struct {
@@ -50,7 +173,43 @@ type DropDatabaseRequest struct {
 	Force bool `json:"force"`
 }
 
+// EnvironmentProduction is the Connection.Environment that requires
+// confirmed writes.
+const EnvironmentProduction = "production"
+
+// ConfirmProductionHeader must be "true" on writes to a production
+// connection; without it they fail with 428 Precondition Required.
+const ConfirmProductionHeader = "X-Confirm-Production"
+
 // DefaultDatabase is the fallback database name used when a connection is created
 // without specifying one. Postgres always requires a database to authenticate against;
 // `postgres` is the conventional maintenance database guaranteed to exist.
 const DefaultDatabase = "postgres"
+
+// ConnectionInfo describes the server a successful connection test
+// reached.
+type ConnectionInfo struct {
+	ServerVersion    string `json:"serverVersion"`
+	ServerVersionNum int    `json:"serverVersionNum"`
+	Database         string `json:"database"`
+	CurrentUser      string `json:"currentUser"`
+	SSL              bool   `json:"ssl"`
+	// SSLVersion is the negotiated TLS version, e.g. "TLS 1.3".
+	SSLVersion string            `json:"sslVersion,omitempty"`
+	Extensions []ExtensionStatus `json:"extensions"`
+}
+
+// ExtensionStatus says whether an extension is installed in the database,
+// or only available to install on the server.
+type ExtensionStatus struct {
+	Name             string `json:"name"`
+	Installed        bool   `json:"installed"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	Available        bool   `json:"available"`
+}
+
+// FavoriteRequest pins or unpins a connection or saved query; without
+// Favorite the current setting is flipped.
+type FavoriteRequest struct {
+	Favorite *bool `json:"favorite"`
+}
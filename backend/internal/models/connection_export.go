@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// ExportPassphraseHeader carries the passphrase for connection export and
+// import. Exports made with one hold their secrets encrypted; without one
+// the secrets are left out.
+const ExportPassphraseHeader = "X-Export-Passphrase"
+
+// ConnectionExport.Passwords values.
+const (
+	ExportPasswordsExcluded  = "excluded"
+	ExportPasswordsEncrypted = "encrypted"
+)
+
+// ConnectionExport is the file GET /api/connections/export produces and
+// POST /api/connections/import reads. Secrets are never in plaintext: with
+// Passwords "encrypted" each connection's are sealed with AES-256-GCM under
+// a key derived from the passphrase (PBKDF2-SHA256, Iterations rounds over
+// Salt); with "excluded" they are dropped.
+type ConnectionExport struct {
+	Version     int                  `json:"version"`
+	ExportedAt  time.Time            `json:"exportedAt"`
+	Passwords   string               `json:"passwords"`
+	Salt        string               `json:"salt,omitempty"`
+	Iterations  int                  `json:"iterations,omitempty"`
+	Connections []ExportedConnection `json:"connections"`
+}
+
+// ExportedConnection is a saved connection without its ID or state.
+// ConnectionString never carries a password in the clear; Secrets holds
+// the encrypted password, SSH password and full connection string.
+type ExportedConnection struct {
+	Name             string `json:"name"`
+	Host             string `json:"host,omitempty"`
+	Port             int    `json:"port,omitempty"`
+	Database         string `json:"database"`
+	Username         string `json:"username,omitempty"`
+	SSLMode          string `json:"sslMode,omitempty"`
+	ConnectionString string `json:"connectionString,omitempty"`
+	MaxConnIdleTime  int    `json:"maxConnIdleTime,omitempty"`
+	MaxConns         int    `json:"maxConns,omitempty"`
+	MinConns         int    `json:"minConns,omitempty"`
+	MaxConnLifetime  int    `json:"maxConnLifetime,omitempty"`
+	IsReadOnly       bool   `json:"isReadOnly,omitempty"`
+	Environment      string `json:"environment,omitempty"`
+	Role             string `json:"role,omitempty"`
+	SearchPath       string `json:"searchPath,omitempty"`
+	// DefaultQueryTimeoutMs is Connection.DefaultQueryTimeoutMs.
+	DefaultQueryTimeoutMs int `json:"defaultQueryTimeoutMs,omitempty"`
+	SSLFiles
+	SSHHost    string `json:"sshHost,omitempty"`
+	SSHPort    int    `json:"sshPort,omitempty"`
+	SSHUser    string `json:"sshUser,omitempty"`
+	SSHKeyPath string `json:"sshKeyPath,omitempty"`
+	Secrets    string `json:"secrets,omitempty"`
+}
+
+// ConnectionImportResult lists what an import created and what it left
+// out: duplicates of existing connections (same name, host and database)
+// and entries that failed validation.
+type ConnectionImportResult struct {
+	Imported []*Connection          `json:"imported"`
+	Skipped  []ConnectionImportSkip `json:"skipped"`
+}
+
+type ConnectionImportSkip struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
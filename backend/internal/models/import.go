@@ -0,0 +1,42 @@
+package models
+
+// InferTypesRequest carries a CSV sample to derive a table definition from.
+// The first row is a header unless HasHeader is false; Schema and Table
+// name the suggested CREATE TABLE (default public.imported_data).
+type InferTypesRequest struct {
+	CSV       string `json:"csv" binding:"required"`
+	Delimiter string `json:"delimiter,omitempty"` // single character, default ","
+	HasHeader *bool  `json:"hasHeader,omitempty"`
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table,omitempty"`
+}
+
+type InferredColumn struct {
+	Name       string `json:"name"`       // sanitized identifier used in the DDL
+	SourceName string `json:"sourceName"` // header text as given
+	Type       string `json:"type"`
+	Nullable   bool   `json:"nullable"` // some sample value was empty
+}
+
+type InferTypesResponse struct {
+	Columns     []InferredColumn `json:"columns"`
+	SampledRows int              `json:"sampledRows"`
+	CreateTable string           `json:"createTable"`
+}
+
+// ImportLineError reports a record that could not be imported. Line is
+// 1-based within the request body.
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	Success  bool              `json:"success"`
+	Inserted int64             `json:"inserted"`
+	Skipped  int               `json:"skipped"`
+	Errors   []ImportLineError `json:"errors,omitempty"`
+	// ErrorsTruncated is set when more records failed than are listed.
+	ErrorsTruncated bool    `json:"errorsTruncated,omitempty"`
+	Duration        float64 `json:"duration"` // milliseconds
+}
@@ -0,0 +1,29 @@
+package models
+
+// VacuumRequest sets VACUUM's options. QueryID, when set, tags the run so
+// its progress can be polled and POST /query/:connId/cancel can cancel it.
+type VacuumRequest struct {
+	Analyze bool   `json:"analyze"`
+	Full    bool   `json:"full"`
+	QueryID string `json:"queryId,omitempty"`
+}
+
+// AnalyzeRequest optionally tags an ANALYZE run like VacuumRequest.
+type AnalyzeRequest struct {
+	QueryID string `json:"queryId,omitempty"`
+}
+
+// MaintenanceProgress is how far a tagged VACUUM or ANALYZE has got, from
+// Postgres's pg_stat_progress_* views. Command is "vacuum", "vacuum full"
+// or "analyze"; it and Phase are empty between phases (e.g. while a
+// VACUUM ANALYZE switches from one to the other) or before the server
+// reports anything. Blocks count heap blocks processed, or sampled for
+// ANALYZE.
+type MaintenanceProgress struct {
+	QueryID     string  `json:"queryId"`
+	Command     string  `json:"command"`
+	Phase       string  `json:"phase"`
+	BlocksTotal int64   `json:"blocksTotal"`
+	BlocksDone  int64   `json:"blocksDone"`
+	Percent     float64 `json:"percent"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// PreparedTransaction is a two-phase transaction left in pg_prepared_xacts
+// by PREPARE TRANSACTION, waiting for COMMIT/ROLLBACK PREPARED.
+type PreparedTransaction struct {
+	GID         string    `json:"gid"`
+	Transaction string    `json:"transaction"` // xid
+	Prepared    time.Time `json:"prepared"`
+	Owner       string    `json:"owner"`
+	Database    string    `json:"database"`
+	AgeSeconds  float64   `json:"ageSeconds"`
+	// InCurrentDatabase is false for transactions prepared in another
+	// database; those can only be resolved from a connection to it.
+	InCurrentDatabase bool `json:"inCurrentDatabase"`
+}
+
+// Session is one client backend from pg_stat_activity.
+type Session struct {
+	PID             int32      `json:"pid"`
+	User            string     `json:"user"`
+	Database        string     `json:"database"`
+	ApplicationName string     `json:"applicationName"`
+	ClientAddr      string     `json:"clientAddr"`
+	State           string     `json:"state"`
+	Query           string     `json:"query"`
+	WaitEventType   string     `json:"waitEventType"`
+	WaitEvent       string     `json:"waitEvent"`
+	BackendStart    time.Time  `json:"backendStart"`
+	QueryStart      *time.Time `json:"queryStart"`
+	// DurationSeconds is how long the current (or last) query has run.
+	DurationSeconds float64 `json:"durationSeconds"`
+	// IsOwn marks PgVoyager's own connections, which can't be terminated.
+	IsOwn bool `json:"isOwn"`
+}
+
+// TerminateSessionRequest picks pg_terminate_backend (Force) over
+// pg_cancel_backend, which only cancels the running query.
+type TerminateSessionRequest struct {
+	Force bool `json:"force"`
+}
+
+// LockWait is one "blocked PID is waiting on blocking PID" pair. A
+// session blocked by several others appears once per blocker.
+type LockWait struct {
+	BlockedPID   int32  `json:"blockedPid"`
+	BlockedUser  string `json:"blockedUser"`
+	BlockedQuery string `json:"blockedQuery"`
+	// WaitSeconds is how long the blocked query has been waiting.
+	WaitSeconds float64 `json:"waitSeconds"`
+	// BlockingPID is 0 when the blocker is a prepared transaction.
+	BlockingPID   int32  `json:"blockingPid"`
+	BlockingUser  string `json:"blockingUser"`
+	BlockingQuery string `json:"blockingQuery"`
+	BlockingState string `json:"blockingState"`
+	// BlockingXactSeconds is the age of the blocker's transaction.
+	BlockingXactSeconds float64 `json:"blockingXactSeconds"`
+	LockType            string  `json:"lockType"`
+	// Relation is schema.table for relation locks, empty otherwise.
+	Relation string `json:"relation"`
+	// Mode is the lock the blocked session asked for; BlockingModes the
+	// conflicting ones the blocker holds on the same object.
+	Mode          string `json:"mode"`
+	BlockingModes string `json:"blockingModes"`
+}
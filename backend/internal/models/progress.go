@@ -0,0 +1,18 @@
+package models
+
+// ProgressEvent is one frame of the progress WebSocket for a long
+// operation started with an operationId. Type is "progress" (repeated),
+// then "done" or "error". Operation is "import", "export", "vacuum" or
+// "analyze"; Done and Total count Unit ("rows", "bytes" or "blocks"), with
+// Total 0 when it isn't known up front.
+type ProgressEvent struct {
+	Type        string  `json:"type"`
+	OperationID string  `json:"operationId"`
+	Operation   string  `json:"operation"`
+	Phase       string  `json:"phase,omitempty"`
+	Done        int64   `json:"done"`
+	Total       int64   `json:"total,omitempty"`
+	Unit        string  `json:"unit,omitempty"`
+	Percent     float64 `json:"percent,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
@@ -1,11 +1,50 @@
 package models
 
+import "encoding/json"
+
 type QueryRequest struct {
 	SQL    string        `json:"sql" binding:"required"`
 	Params []interface{} `json:"params,omitempty"`
+	// ParamTypes optionally names the type of each of Params by position
+	// (e.g. "uuid", "timestamptz", "numeric"); the value is converted to
+	// it before binding. An empty entry leaves its param as sent.
+	ParamTypes []string `json:"paramTypes,omitempty"`
+	// MultiResult returns a []QueryResult with one entry per row-returning
+	// statement instead of only the last SELECT's result.
+	MultiResult bool `json:"multiResult,omitempty"`
+	// QueryID is a client-chosen handle for cancelling this query via
+	// POST /query/:connId/cancel while it runs.
+	QueryID string `json:"queryId,omitempty"`
+	// Transactional runs the whole script inside BEGIN/COMMIT, rolling
+	// back if any statement fails.
+	Transactional bool `json:"transactional,omitempty"`
+	// DryRun runs the script in a transaction that is always rolled back.
+	DryRun bool `json:"dryRun,omitempty"`
+	// TimeoutMs sets statement_timeout for the run; zero keeps the server's
+	// setting. The request's own deadline still applies.
+	TimeoutMs int `json:"timeoutMs,omitempty" binding:"min=0"`
+}
+
+// Outcomes reported in QueryResult.Transaction.
+const (
+	TransactionCommitted  = "committed"
+	TransactionRolledBack = "rolled_back"
+)
+
+type CancelQueryRequest struct {
+	QueryID string `json:"queryId" binding:"required"`
+}
+
+// ExportRequest is a QueryRequest plus the output format:
+// "csv" (default), "json" or "jsonl".
+type ExportRequest struct {
+	SQL    string        `json:"sql" binding:"required"`
+	Params []interface{} `json:"params,omitempty"`
+	Format string        `json:"format"`
 }
 
 type QueryResult struct {
+	Label         string           `json:"label,omitempty"` // from a leading `-- comment`, multi-result mode only
 	Columns       []ColumnInfo     `json:"columns"`
 	Rows          []map[string]any `json:"rows"`
 	RowCount      int              `json:"rowCount"`
@@ -14,14 +53,37 @@ type QueryResult struct {
 	ErrorPosition int              `json:"errorPosition,omitempty"` // 1-based character position in SQL
 	ErrorHint     string           `json:"errorHint,omitempty"`
 	ErrorDetail   string           `json:"errorDetail,omitempty"`
+	// ErrorKind tells timeouts apart: QueryErrorStatementTimeout when the
+	// requested TimeoutMs fired, QueryErrorDeadline when the server-side
+	// request deadline did.
+	ErrorKind string `json:"errorKind,omitempty"`
+	// Transaction is set on the last result of a transactional or dry run:
+	// TransactionCommitted or TransactionRolledBack.
+	Transaction string `json:"transaction,omitempty"`
 }
 
+// QueryResult.ErrorKind values
+const (
+	QueryErrorStatementTimeout = "statement_timeout"
+	QueryErrorDeadline         = "deadline"
+)
+
 type ColumnInfo struct {
 	Name         string  `json:"name"`
 	DataType     string  `json:"dataType"`
 	IsPrimaryKey bool    `json:"isPrimaryKey"`
 	IsForeignKey bool    `json:"isForeignKey"`
 	FKReference  *FKRef  `json:"fkReference,omitempty"`
+	// BaseType (the pg_type name, through a domain to its base, e.g. int4
+	// or _text) and NotNull are only set for a table's columns.
+	BaseType string `json:"baseType,omitempty"`
+	NotNull  bool   `json:"notNull,omitempty"`
+	// TypeCategory is the base type's pg_type.typcategory (A for arrays,
+	// C for composites) and CompositeFields the attribute names, in order,
+	// of a composite column or array of composites. Table columns only;
+	// used to convert row edits, not sent.
+	TypeCategory    string   `json:"-"`
+	CompositeFields []string `json:"-"`
 }
 
 type TableDataRequest struct {
@@ -32,6 +94,17 @@ type TableDataRequest struct {
 	Filter    string `form:"filter"`
 }
 
+// TableFilter is one condition on GetTableData. Op is one of =, !=, <>, >,
+// <, >=, <=, LIKE, ILIKE, IN (comma-separated Value), IS NULL or IS NOT
+// NULL; Connector ("AND", the default, or "OR") joins it to the previous
+// filter.
+type TableFilter struct {
+	Column    string `json:"column"`
+	Op        string `json:"op"`
+	Value     string `json:"value"`
+	Connector string `json:"connector,omitempty"`
+}
+
 type TableDataResponse struct {
 	Columns    []ColumnInfo     `json:"columns"`
 	Rows       []map[string]any `json:"rows"`
@@ -39,6 +112,12 @@ type TableDataResponse struct {
 	Page       int              `json:"page"`
 	PageSize   int              `json:"pageSize"`
 	TotalPages int              `json:"totalPages"`
+	// FKLabels maps FK column -> value -> a label from the referenced row
+	// (its first text column). Only sent with ?expandFk=true.
+	FKLabels map[string]map[string]string `json:"fkLabels,omitempty"`
+	// NextCursor continues a keyset-paginated read (?cursor=) after the
+	// last row; empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ForeignKeyPreview struct {
@@ -48,14 +127,142 @@ type ForeignKeyPreview struct {
 	Row        map[string]any   `json:"row"`
 }
 
+// ExplainRequest selects the EXPLAIN output format ("text", the default,
+// or "json") and whether to ANALYZE, which actually runs the statement.
+// Analyze defaults to true when omitted, but a statement that may modify
+// data is only analyzed when AnalyzeWrites is set too.
+type ExplainRequest struct {
+	SQL           string        `json:"sql" binding:"required"`
+	Params        []interface{} `json:"params,omitempty"`
+	Format        string        `json:"format,omitempty"`
+	Analyze       *bool         `json:"analyze,omitempty"`
+	AnalyzeWrites bool          `json:"analyzeWrites,omitempty"`
+}
+
+// LintRequest is SQL to check before running it. Params are used only to
+// plan a single statement (for the large SELECT * check).
+type LintRequest struct {
+	SQL    string        `json:"sql" binding:"required"`
+	Params []interface{} `json:"params,omitempty"`
+}
+
+// LintWarning flags one risky statement. Offset is where the statement
+// starts in the submitted SQL.
+type LintWarning struct {
+	Severity  string `json:"severity"` // "critical", "warning"
+	Rule      string `json:"rule"`     // e.g. "delete_without_where", "drop", "select_star_large"
+	Message   string `json:"message"`
+	Statement string `json:"statement"`
+	Offset    int    `json:"offset"`
+}
+
+// LintResult lists the warnings in statement order. RequiresConfirmation
+// is set when any is critical, for the UI to ask before running the SQL.
+type LintResult struct {
+	Warnings             []LintWarning `json:"warnings"`
+	RequiresConfirmation bool          `json:"requiresConfirmation"`
+}
+
+// QueryDiffRequest compares the results of two single-statement queries.
+// Params go to both. Limit lowers the configured per-query row cap.
+type QueryDiffRequest struct {
+	SQLA   string        `json:"sqlA" binding:"required"`
+	SQLB   string        `json:"sqlB" binding:"required"`
+	Params []interface{} `json:"params,omitempty"`
+	Limit  int           `json:"limit,omitempty" binding:"min=0"`
+}
+
+// QueryDiffResult compares two results as multisets of rows: order is
+// ignored, duplicates count. Columns are matched by position and named
+// after query A's. Rows are arrays in column order. When either query
+// returned more than Limit rows only the first Limit were compared, and
+// Truncated is set; Match is then false, since the rest is unknown.
+type QueryDiffResult struct {
+	Columns   []string `json:"columns"`
+	RowCountA int      `json:"rowCountA"`
+	RowCountB int      `json:"rowCountB"`
+	OnlyInA   [][]any  `json:"onlyInA"`
+	OnlyInB   [][]any  `json:"onlyInB"`
+	Match     bool     `json:"match"`
+	Truncated bool     `json:"truncated"`
+	Limit     int      `json:"limit"`
+	Duration  float64  `json:"duration"`
+}
+
 type ExplainResult struct {
-	Plan     string  `json:"plan"`
-	Duration float64 `json:"duration"`
+	Plan     string          `json:"plan,omitempty"`     // text format
+	PlanJSON json.RawMessage `json:"planJson,omitempty"` // json format: the array EXPLAIN returns
+	// Nodes has one entry per plan node in pre-order (json format only),
+	// flagging the ones worth highlighting.
+	Nodes    []PlanNodeDiagnostic `json:"nodes,omitempty"`
+	Duration float64              `json:"duration"`
+	// Warning explains why ANALYZE was left out, when it was.
+	Warning string `json:"warning,omitempty"`
+}
+
+// PlanNodeDiagnostic carries derived metrics for one EXPLAIN plan node.
+// Index is the node's position in a pre-order walk of the plan tree
+// (root = 0, then each child subtree in "Plans" order). Actual-row and
+// timing fields are only set for EXPLAIN ANALYZE.
+type PlanNodeDiagnostic struct {
+	Index         int     `json:"index"`
+	Depth         int     `json:"depth"`
+	NodeType      string  `json:"nodeType"`
+	Relation      string  `json:"relation,omitempty"`
+	EstimatedRows float64 `json:"estimatedRows"`
+	ActualRows    float64 `json:"actualRows,omitempty"` // per loop, like EXPLAIN shows
+	Loops         float64 `json:"loops,omitempty"`
+	// EstimateFactor is max(actual, estimated) / min(actual, estimated),
+	// with both floored at 1 row.
+	EstimateFactor  float64 `json:"estimateFactor,omitempty"`
+	SelfTimeMs      float64 `json:"selfTimeMs,omitempty"` // total over all loops, children excluded
+	SelfTimePercent float64 `json:"selfTimePercent,omitempty"`
+
+	Misestimated bool `json:"misestimated"`
+	Hotspot      bool `json:"hotspot"`
+	LargeSeqScan bool `json:"largeSeqScan"`
 }
 
 // CRUD operations
 type InsertRowRequest struct {
 	Data map[string]any `json:"data" binding:"required"`
+	// OnConflict turns the insert into an upsert
+	OnConflict *OnConflict `json:"onConflict,omitempty"`
+}
+
+// OnConflict is the ON CONFLICT clause of an InsertRowRequest. Columns name
+// the unique constraint to match; Action "nothing" skips the row and
+// "update" overwrites the other columns given in Data.
+type OnConflict struct {
+	Columns []string `json:"columns" binding:"required,min=1"`
+	Action  string   `json:"action" binding:"required,oneof=nothing update"`
+}
+
+// CrudResponse.Outcome values for inserts
+const (
+	InsertOutcomeInserted = "inserted"
+	InsertOutcomeUpdated  = "updated"
+	InsertOutcomeSkipped  = "skipped" // ON CONFLICT DO NOTHING hit a conflict
+)
+
+// BulkInsertRowsRequest inserts many rows in one transaction; every row
+// must have the same set of columns.
+type BulkInsertRowsRequest struct {
+	Rows []map[string]any `json:"rows" binding:"required"`
+}
+
+// FieldError is a value rejected for one column before it reached
+// Postgres.
+type FieldError struct {
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+// FieldValidationError is the 400 response for a row edit with invalid
+// values, listing each one.
+type FieldValidationError struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields"`
 }
 
 type UpdateRowRequest struct {
@@ -67,9 +274,31 @@ type DeleteRowRequest struct {
 	PrimaryKey map[string]any `json:"primaryKey" binding:"required"`
 }
 
+// DeleteByFilterRequest deletes every row matching Filters (the
+// GetTableData filter structure). DryRun only counts them.
+type DeleteByFilterRequest struct {
+	Filters []TableFilter `json:"filters" binding:"required,min=1"`
+	DryRun  bool          `json:"dryRun"`
+}
+
 type CrudResponse struct {
 	Success      bool           `json:"success"`
 	RowsAffected int64          `json:"rowsAffected"`
 	Message      string         `json:"message,omitempty"`
 	InsertedRow  map[string]any `json:"insertedRow,omitempty"`
+	Outcome      string         `json:"outcome,omitempty"` // inserts only
+	// DryRun is set when RowsAffected is what would have been affected
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// FormatRequest is SQL to pretty-print. KeywordCase is upper (the
+// default), lower or preserve; Indent is the spaces per level, default 2.
+type FormatRequest struct {
+	SQL         string `json:"sql" binding:"required"`
+	KeywordCase string `json:"keywordCase,omitempty" binding:"omitempty,oneof=upper lower preserve"`
+	Indent      int    `json:"indent,omitempty" binding:"min=0,max=8"`
+}
+
+type FormatResponse struct {
+	SQL string `json:"sql"`
 }
@@ -3,18 +3,57 @@ package models
 import "time"
 
 type SavedQuery struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	SQL          string    `json:"sql"`
-	ConnectionID string    `json:"connectionId,omitempty"`
-	Description  string    `json:"description,omitempty"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	SQL          string   `json:"sql"`
+	ConnectionID string   `json:"connectionId,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Tags         []string `json:"tags"`
+	Folder       string   `json:"folder,omitempty"`
+	// Parameters declares the :name placeholders in SQL; see
+	// POST /queries/:id/run.
+	Parameters []QueryParam `json:"parameters"`
+	// IsFavorite pins the query to the top of the list.
+	IsFavorite bool      `json:"isFavorite"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// QueryParam is a named placeholder in a saved query. Type, when set, is
+// cast onto the bound value ($1::date); a parameter without a Default is
+// required at run time.
+type QueryParam struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Default any    `json:"default,omitempty"`
+}
+
+// RunSavedQueryRequest runs a saved query against ConnectionID (defaulting
+// to the query's own connection) with values for its parameters.
+type RunSavedQueryRequest struct {
+	ConnectionID string         `json:"connectionId"`
+	Params       map[string]any `json:"params"`
 }
 
 type SavedQueryRequest struct {
-	Name         string `json:"name" binding:"required"`
-	SQL          string `json:"sql" binding:"required"`
-	ConnectionID string `json:"connectionId"`
-	Description  string `json:"description"`
+	Name         string       `json:"name" binding:"required"`
+	SQL          string       `json:"sql" binding:"required"`
+	ConnectionID string       `json:"connectionId"`
+	Description  string       `json:"description"`
+	Tags         []string     `json:"tags"`
+	Folder       string       `json:"folder"`
+	Parameters   []QueryParam `json:"parameters"`
+}
+
+// SavedQueryFilter narrows List; empty fields match everything. Tag
+// matching is case-insensitive, Folder is exact.
+type SavedQueryFilter struct {
+	Tag    string `form:"tag"`
+	Folder string `form:"folder"`
+}
+
+// TagCount is one distinct saved-query tag and how many queries carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Database struct {
 	Name       string `json:"name"`
 	Owner      string `json:"owner"`
@@ -23,6 +25,30 @@ type Table struct {
 	Size         string `json:"size"`
 	HasPK        bool   `json:"hasPk"`
 	Comment      string `json:"comment,omitempty"`
+	// IsPartitioned marks a partitioned parent; PartitionKey is its
+	// pg_get_partkeydef, e.g. "RANGE (created_at)".
+	IsPartitioned bool   `json:"isPartitioned"`
+	PartitionKey  string `json:"partitionKey,omitempty"`
+}
+
+// TablePartitions is the partitioning scheme of a partitioned table and
+// its direct child partitions.
+type TablePartitions struct {
+	Schema       string      `json:"schema"`
+	Table        string      `json:"table"`
+	Strategy     string      `json:"strategy"` // "range", "list", "hash"
+	PartitionKey string      `json:"partitionKey"`
+	Partitions   []Partition `json:"partitions"`
+}
+
+type Partition struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	// Bound is the partition bound, e.g. "FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')" or "DEFAULT"
+	Bound         string `json:"bound"`
+	IsPartitioned bool   `json:"isPartitioned"` // sub-partitioned
+	RowCount      int64  `json:"rowCount"`
+	Size          string `json:"size"`
 }
 
 type Column struct {
@@ -37,6 +63,15 @@ type Column struct {
 	FKReference  *FKRef  `json:"fkReference,omitempty"`
 	MaxLength    *int    `json:"maxLength,omitempty"`
 	Comment      string  `json:"comment,omitempty"`
+	// IsGenerated marks GENERATED ALWAYS AS (expr) STORED columns, which
+	// can't be written; GenerationExpression is expr. Their expression is
+	// not reported as DefaultValue.
+	IsGenerated          bool    `json:"isGenerated"`
+	GenerationExpression *string `json:"generationExpression,omitempty"`
+	// IsIdentity marks identity columns; IdentityGeneration is "ALWAYS" or
+	// "BY DEFAULT".
+	IsIdentity         bool   `json:"isIdentity"`
+	IdentityGeneration string `json:"identityGeneration,omitempty"`
 }
 
 type FKRef struct {
@@ -88,6 +123,45 @@ type SchemaRelationship struct {
 	OnDelete       string   `json:"onDelete"`
 }
 
+// Cardinalities of an ERDEdge, read from the referenced (target) table to
+// the referencing (source) one.
+const (
+	CardinalityOneToOne  = "one-to-one"
+	CardinalityOneToMany = "one-to-many"
+)
+
+// ERDiagram is everything needed to draw a schema's ER diagram: its
+// tables, the tables in other schemas its foreign keys touch, and the
+// foreign keys themselves.
+type ERDiagram struct {
+	Schema string    `json:"schema"`
+	Nodes  []ERDNode `json:"nodes"`
+	Edges  []ERDEdge `json:"edges"`
+}
+
+type ERDNode struct {
+	Schema  string      `json:"schema"`
+	Table   string      `json:"table"`
+	Columns []ERDColumn `json:"columns"`
+}
+
+type ERDColumn struct {
+	Name         string `json:"name"`
+	DataType     string `json:"dataType"`
+	IsNullable   bool   `json:"isNullable"`
+	IsPrimaryKey bool   `json:"isPrimaryKey"`
+	IsForeignKey bool   `json:"isForeignKey"`
+}
+
+// ERDEdge is a foreign key with its cardinality: one-to-one when the
+// referencing columns are unique, else one-to-many. Optional means a
+// referencing column is nullable, so a source row may have no target.
+type ERDEdge struct {
+	SchemaRelationship
+	Cardinality string `json:"cardinality"`
+	Optional    bool   `json:"optional"`
+}
+
 type View struct {
 	Schema     string `json:"schema"`
 	Name       string `json:"name"`
@@ -96,16 +170,29 @@ type View struct {
 	Comment    string `json:"comment,omitempty"`
 }
 
+type MaterializedView struct {
+	Schema      string `json:"schema"`
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Definition  string `json:"definition"`
+	IsPopulated bool   `json:"isPopulated"`
+	Size        string `json:"size"`
+	Comment     string `json:"comment,omitempty"`
+}
+
 type Function struct {
-	Schema       string   `json:"schema"`
-	Name         string   `json:"name"`
-	Owner        string   `json:"owner"`
-	ReturnType   string   `json:"returnType"`
-	Arguments    string   `json:"arguments"`
-	Language     string   `json:"language"`
-	Definition   string   `json:"definition"`
-	IsAggregate  bool     `json:"isAggregate"`
-	Comment      string   `json:"comment,omitempty"`
+	Schema     string `json:"schema"`
+	Name       string `json:"name"`
+	Owner      string `json:"owner"`
+	ReturnType string `json:"returnType"`
+	Arguments  string `json:"arguments"`
+	Language   string `json:"language"`
+	Definition string `json:"definition"`
+	// DefinitionNote explains a placeholder Definition when
+	// pg_get_functiondef failed for this function.
+	DefinitionNote string `json:"definitionNote,omitempty"`
+	IsAggregate    bool   `json:"isAggregate"`
+	Comment        string `json:"comment,omitempty"`
 }
 
 type Sequence struct {
@@ -130,3 +217,151 @@ type CustomType struct {
 	Elements []string `json:"elements,omitempty"` // for enums
 	Comment  string `json:"comment,omitempty"`
 }
+
+// ObjectDependency is one edge in the pg_depend graph, resolved to a
+// human-readable object. Kind is the pg_identify_object type ("table",
+// "view", "function", "trigger", ...).
+type ObjectDependency struct {
+	Kind           string `json:"kind"`
+	Schema         string `json:"schema,omitempty"`
+	Name           string `json:"name"`
+	Identity       string `json:"identity"`
+	DependencyType string `json:"dependencyType"` // "normal", "auto"
+}
+
+// ObjectDependencies lists what an object depends on and what depends on it
+type ObjectDependencies struct {
+	Schema       string             `json:"schema"`
+	Name         string             `json:"name"`
+	DependsOn    []ObjectDependency `json:"dependsOn"`
+	DependedOnBy []ObjectDependency `json:"dependedOnBy"`
+}
+
+// TableDescription bundles the per-table endpoints into one response
+type TableDescription struct {
+	Table       Table        `json:"table"`
+	Columns     []Column     `json:"columns"`
+	Constraints []Constraint `json:"constraints"`
+	Indexes     []Index      `json:"indexes"`
+	ForeignKeys []ForeignKey `json:"foreignKeys"`
+	Triggers    []Trigger    `json:"triggers"`
+}
+
+// ViewDependency is a relation a view reads, or an object depending on a
+// view. Kind is the pg_identify_object type ("table", "view", ...).
+type ViewDependency struct {
+	Kind   string `json:"kind"`
+	Schema string `json:"schema,omitempty"`
+	Name   string `json:"name"`
+}
+
+// ViewDependencies lists the relations a view reads from and the objects
+// that read it
+type ViewDependencies struct {
+	Schema    string           `json:"schema"`
+	Name      string           `json:"name"`
+	ReadsFrom []ViewDependency `json:"readsFrom"`
+	ReadBy    []ViewDependency `json:"readBy"`
+}
+
+type Trigger struct {
+	Schema     string   `json:"schema"`
+	Table      string   `json:"table"`
+	Name       string   `json:"name"`
+	Timing     string   `json:"timing"` // BEFORE, AFTER, INSTEAD OF
+	Events     []string `json:"events"` // INSERT, UPDATE, DELETE, TRUNCATE
+	Level      string   `json:"level"`  // ROW or STATEMENT
+	Function   string   `json:"function"`
+	IsEnabled  bool     `json:"isEnabled"`
+	Definition string   `json:"definition"`
+}
+
+// Role is a database role. Passwords are never read, not even the masked
+// rolpassword pg_roles shows.
+type Role struct {
+	Name            string     `json:"name"`
+	IsSuperuser     bool       `json:"isSuperuser"`
+	CanCreateDB     bool       `json:"canCreateDb"`
+	CanCreateRole   bool       `json:"canCreateRole"`
+	CanLogin        bool       `json:"canLogin"`
+	IsReplication   bool       `json:"isReplication"`
+	ConnectionLimit int        `json:"connectionLimit"` // -1 means no limit
+	ValidUntil      *time.Time `json:"validUntil,omitempty"`
+	MemberOf        []string   `json:"memberOf"`
+}
+
+// Extension is an installed extension. UpdateAvailable is set when the
+// server ships a default version other than the installed one.
+type Extension struct {
+	Name             string `json:"name"`
+	Schema           string `json:"schema"`
+	InstalledVersion string `json:"installedVersion"`
+	DefaultVersion   string `json:"defaultVersion,omitempty"`
+	UpdateAvailable  bool   `json:"updateAvailable"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+// RecentTable is a table the user has opened on a connection, with how
+// often and when last.
+type RecentTable struct {
+	Schema         string    `json:"schema"`
+	Table          string    `json:"table"`
+	AccessCount    int       `json:"accessCount"`
+	LastAccessedAt time.Time `json:"lastAccessedAt"`
+}
+
+// CommentRequest sets an object's comment; an empty comment removes it.
+type CommentRequest struct {
+	Comment *string `json:"comment" binding:"required"`
+}
+
+// RenameColumnRequest renames a table column.
+type RenameColumnRequest struct {
+	NewName string `json:"newName" binding:"required"`
+}
+
+// ChangeColumnTypeRequest changes a column's type. Using is the USING
+// expression converting existing values (e.g. "price::numeric(10,2)"),
+// needed whenever Postgres has no implicit cast to the new type.
+type ChangeColumnTypeRequest struct {
+	Type  string `json:"type" binding:"required"`
+	Using string `json:"using"`
+}
+
+// CreateIndexRequest describes an index to build. Name defaults to
+// <table>_<columns>_idx. Unique needs the btree method. Concurrently
+// builds without blocking writes, but slower and outside a transaction.
+type CreateIndexRequest struct {
+	Schema       string   `json:"schema" binding:"required"`
+	Table        string   `json:"table" binding:"required"`
+	Columns      []string `json:"columns" binding:"required,min=1"`
+	Name         string   `json:"name"`
+	Unique       bool     `json:"unique"`
+	Method       string   `json:"method" binding:"omitempty,oneof=btree gin gist"`
+	Concurrently bool     `json:"concurrently"`
+}
+
+// SetSequenceValueRequest moves a sequence with setval. With IsCalled
+// (the default) the next nextval returns Value + increment, else Value.
+type SetSequenceValueRequest struct {
+	Value    *int64 `json:"value" binding:"required"`
+	IsCalled *bool  `json:"isCalled"`
+}
+
+// AlterSequenceRequest changes a sequence; unset fields are left alone.
+type AlterSequenceRequest struct {
+	Restart   *int64 `json:"restart"`
+	Increment *int64 `json:"increment"`
+	MaxValue  *int64 `json:"maxValue"`
+}
+
+// SequenceState is a sequence's position after a change. NextValue is
+// what the next nextval will return.
+type SequenceState struct {
+	Schema    string `json:"schema"`
+	Name      string `json:"name"`
+	LastValue int64  `json:"lastValue"`
+	IsCalled  bool   `json:"isCalled"`
+	Increment int64  `json:"increment"`
+	NextValue int64  `json:"nextValue"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// SchemaDiffRequest compares Schema (default "public") between two
+// connected connections.
+type SchemaDiffRequest struct {
+	ConnectionA string `json:"connectionA" binding:"required"`
+	ConnectionB string `json:"connectionB" binding:"required"`
+	Schema      string `json:"schema"`
+}
+
+// Status values for ObjectDiff
+const (
+	DiffOnlyInA = "onlyInA"
+	DiffOnlyInB = "onlyInB"
+	DiffChanged = "changed"
+)
+
+// ObjectDiff is a column, index or constraint that differs between the two
+// sides. A and B hold its definition on each side, empty where missing.
+type ObjectDiff struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "onlyInA", "onlyInB", "changed"
+	A      string `json:"a,omitempty"`
+	B      string `json:"b,omitempty"`
+}
+
+// TableDiff lists the differences within a table present on both sides.
+type TableDiff struct {
+	Table       string       `json:"table"`
+	Columns     []ObjectDiff `json:"columns"`
+	Indexes     []ObjectDiff `json:"indexes"`
+	Constraints []ObjectDiff `json:"constraints"`
+}
+
+// SchemaDiff is the structural difference of one schema across two
+// connections. Tables present on both sides with no differences are not
+// listed.
+type SchemaDiff struct {
+	Schema        string      `json:"schema"`
+	ConnectionA   string      `json:"connectionA"`
+	ConnectionB   string      `json:"connectionB"`
+	TablesOnlyInA []string    `json:"tablesOnlyInA"`
+	TablesOnlyInB []string    `json:"tablesOnlyInB"`
+	ChangedTables []TableDiff `json:"changedTables"`
+}
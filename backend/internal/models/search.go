@@ -0,0 +1,41 @@
+package models
+
+// SearchRequest asks for rows in any table of Schema whose text columns
+// contain Term (case-insensitive). Limit caps matches per table;
+// MaxTableRows overrides the configured size above which tables are
+// skipped rather than scanned.
+type SearchRequest struct {
+	Schema       string `json:"schema" binding:"required"`
+	Term         string `json:"term" binding:"required"`
+	Limit        int    `json:"limit" binding:"min=0,max=200"`
+	MaxTableRows int64  `json:"maxTableRows" binding:"min=0"`
+}
+
+// SearchMatch is one matching row and the columns that matched.
+type SearchMatch struct {
+	MatchedColumns []string       `json:"matchedColumns"`
+	Row            map[string]any `json:"row"`
+}
+
+// TableSearchResult groups the matches found in one table.
+type TableSearchResult struct {
+	Schema  string        `json:"schema"`
+	Table   string        `json:"table"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// SkippedTable is a table the search did not scan, and why.
+type SkippedTable struct {
+	Schema        string  `json:"schema"`
+	Table         string  `json:"table"`
+	EstimatedRows float64 `json:"estimatedRows"`
+	Reason        string  `json:"reason"`
+}
+
+type SearchResult struct {
+	Tables        []TableSearchResult `json:"tables"`
+	Skipped       []SkippedTable      `json:"skipped"`
+	TablesScanned int                 `json:"tablesScanned"`
+	TotalMatches  int                 `json:"totalMatches"`
+	Duration      float64             `json:"duration"` // milliseconds
+}
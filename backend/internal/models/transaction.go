@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// TransactionInfo describes an interactive transaction opened with
+// POST /tx/:connId/begin.
+type TransactionInfo struct {
+	ID           string    `json:"id"`
+	ConnectionID string    `json:"connectionId"`
+	StartedAt    time.Time `json:"startedAt"`
+	LastActivity time.Time `json:"lastActivity"`
+	// Failed is set once a statement has errored: Postgres refuses
+	// everything but ROLLBACK from then on.
+	Failed bool `json:"failed"`
+	// IdleTimeoutSeconds is how long the transaction may sit unused before
+	// it is rolled back.
+	IdleTimeoutSeconds int `json:"idleTimeoutSeconds"`
+}
+
+// TransactionExecuteRequest runs SQL inside an interactive transaction.
+type TransactionExecuteRequest struct {
+	SQL    string        `json:"sql" binding:"required"`
+	Params []interface{} `json:"params,omitempty"`
+}
+
+// SavepointRequest names a savepoint to create, release or roll back to
+// in an interactive transaction.
+type SavepointRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// TransactionEndResponse reports how an interactive transaction finished:
+// TransactionCommitted or TransactionRolledBack.
+type TransactionEndResponse struct {
+	ID      string `json:"id"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
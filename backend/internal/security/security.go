@@ -4,20 +4,35 @@
 package security
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
-// MaxRequestBodyBytes caps every request body. Query-execute and DDL endpoints
-// take JSON bodies; 10 MiB is generous for any realistic SQL payload and
-// blocks unbounded-body DoS against ShouldBindJSON callers.
-const MaxRequestBodyBytes = 10 * 1024 * 1024
+// DefaultMaxRequestBodyBytes caps every mutating request body unless
+// PGVOYAGER_MAX_BODY_BYTES overrides it. 32 MiB leaves room for pasted
+// migration scripts and bulk-insert payloads while still blocking
+// unbounded-body DoS against ShouldBindJSON callers.
+const DefaultMaxRequestBodyBytes = 32 * 1024 * 1024
+
+// MaxRequestBodyBytes returns the body cap: PGVOYAGER_MAX_BODY_BYTES when it
+// parses as a positive integer, DefaultMaxRequestBodyBytes otherwise.
+func MaxRequestBodyBytes() int64 {
+	if v := strings.TrimSpace(os.Getenv("PGVOYAGER_MAX_BODY_BYTES")); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultMaxRequestBodyBytes
+}
 
 // devOrigins are the localhost origins allowed when CORS/dev mode is active.
 // Kept in one place so both the CORS middleware and the WebSocket Origin
@@ -131,8 +146,16 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// MaxBodyBytes is a gin middleware that caps request body size. Skips the
-// Claude terminal WebSocket route, which streams indefinitely.
+// MaxBodyBytes is a gin middleware that caps request body size on mutating
+// methods. Skips the Claude terminal WebSocket route, which streams
+// indefinitely.
+//
+// A declared Content-Length over the limit is rejected up front with a 413
+// naming the limit, so oversize pastes fail with an actionable message
+// instead of a JSON parse error on a truncated body. Every other body is
+// wrapped in http.MaxBytesReader and still streams to the handler; one
+// without a declared length (chunked) that runs past the limit fails the
+// handler's read, which answers the same 413 via AbortIfBodyTooLarge.
 func MaxBodyBytes(limit int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// WebSocket upgrades read raw frames after the upgrade; the
@@ -142,11 +165,53 @@ func MaxBodyBytes(limit int64) gin.HandlerFunc {
 			c.Next()
 			return
 		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":    fmt.Sprintf("request body is %d bytes, which exceeds the %d byte limit (set PGVOYAGER_MAX_BODY_BYTES to raise it)", c.Request.ContentLength, limit),
+				"limit":    limit,
+				"received": c.Request.ContentLength,
+			})
+			return
+		}
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 		c.Next()
 	}
 }
 
+// AbortIfBodyTooLarge answers 413 when err came from reading a request
+// body past the MaxBodyBytes limit, reporting whether it did. Handlers
+// call it where a bind or body read fails, before their usual 400.
+func AbortIfBodyTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error": fmt.Sprintf("request body exceeds the %d byte limit (set PGVOYAGER_MAX_BODY_BYTES to raise it)", tooLarge.Limit),
+		"limit": tooLarge.Limit,
+	})
+	return true
+}
+
+// NewWebSocketUpgrader returns a gorilla upgrader that rejects
+// cross-origin upgrades. Gorilla's default CheckOrigin (and a bare
+// `return true`) would let any web page the user visits open the socket —
+// classic cross-site WebSocket hijacking.
+func NewWebSocketUpgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return AllowedOrigin(r.Header.Get("Origin"), r.Host)
+		},
+	}
+}
+
 // hostOnly extracts the hostname portion of a host:port string. Falls back
 // to the whole string when there is no port (SplitHostPort requires a port).
 func hostOnly(hostport string) string {
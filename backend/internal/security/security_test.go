@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -148,6 +149,120 @@ func TestMaxBodyBytesSkipsTerminalWebSocket(t *testing.T) {
 	}
 }
 
+func TestMaxBodyBytesReportsLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MaxBodyBytes(16))
+	r.POST("/echo", func(c *gin.Context) {
+		t.Error("handler should not run for a declared oversize body")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(strings.Repeat("x", 1024)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if !strings.Contains(w.Body.String(), `"limit":16`) {
+		t.Errorf("413 body should report the limit, got %s", w.Body.String())
+	}
+}
+
+func TestMaxBodyBytesRejectsOversizeChunked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MaxBodyBytes(16))
+	r.POST("/echo", func(c *gin.Context) {
+		var req map[string]any
+		if err := c.ShouldBindJSON(&req); err != nil {
+			if AbortIfBodyTooLarge(c, err) {
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+
+	// No declared length, as with a chunked body.
+	req := httptest.NewRequest(http.MethodPost, "/echo", io.NopCloser(strings.NewReader(`{"sql":"`+strings.Repeat("x", 1024)+`"}`)))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if !strings.Contains(w.Body.String(), `"limit":16`) {
+		t.Errorf("413 body should report the limit, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/echo", io.NopCloser(strings.NewReader(`{"a":1}`)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"a":1}` {
+		t.Errorf("small chunked body: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMaxBodyBytesStreamsChunked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(MaxBodyBytes(1024))
+	r.POST("/import", func(c *gin.Context) {
+		first := make([]byte, 5)
+		if _, err := io.ReadFull(c.Request.Body, first); err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, string(first))
+	})
+
+	// The handler must start on the first line while the rest of the
+	// upload is still on its way, rather than after it is buffered.
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("line1\n"))
+	req := httptest.NewRequest(http.MethodPost, "/import", pr)
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler didn't run before the body was complete")
+	}
+	if w.Code != http.StatusOK || w.Body.String() != "line1" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestMaxRequestBodyBytesEnv(t *testing.T) {
+	cases := []struct {
+		env  string
+		want int64
+	}{
+		{"", DefaultMaxRequestBodyBytes},
+		{"1048576", 1048576},
+		{" 2048 ", 2048},
+		{"0", DefaultMaxRequestBodyBytes},
+		{"-5", DefaultMaxRequestBodyBytes},
+		{"lots", DefaultMaxRequestBodyBytes},
+	}
+	for _, tc := range cases {
+		t.Setenv("PGVOYAGER_MAX_BODY_BYTES", tc.env)
+		if got := MaxRequestBodyBytes(); got != tc.want {
+			t.Errorf("PGVOYAGER_MAX_BODY_BYTES=%q: got %d, want %d", tc.env, got, tc.want)
+		}
+	}
+}
+
 func TestOriginGuardRejectsCrossOrigin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
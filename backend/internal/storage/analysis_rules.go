@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AnalysisRule is a user-defined health check. Its SQL returns one row per
+// issue; see handlers.runCustomRule for how columns map onto the issue.
+type AnalysisRule struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Severity            string    `json:"severity"` // "critical", "warning", "info"
+	SQL                 string    `json:"sql"`
+	DescriptionTemplate string    `json:"descriptionTemplate,omitempty"`
+	Enabled             bool      `json:"enabled"`
+	CreatedAt           time.Time `json:"createdAt"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
+
+// ListAnalysisRules returns all custom analysis rules ordered by name
+func ListAnalysisRules() ([]AnalysisRule, error) {
+	return queryAnalysisRules(`
+		SELECT id, name, severity, sql, description_template, enabled, created_at, updated_at
+		FROM custom_analysis_rules
+		ORDER BY name
+	`)
+}
+
+// ListEnabledAnalysisRules returns the rules RunAnalysis should execute
+func ListEnabledAnalysisRules() ([]AnalysisRule, error) {
+	return queryAnalysisRules(`
+		SELECT id, name, severity, sql, description_template, enabled, created_at, updated_at
+		FROM custom_analysis_rules
+		WHERE enabled
+		ORDER BY name
+	`)
+}
+
+func queryAnalysisRules(query string) ([]AnalysisRule, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []AnalysisRule{}
+	for rows.Next() {
+		r, err := scanAnalysisRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// GetAnalysisRule retrieves a single rule by ID
+func GetAnalysisRule(id string) (*AnalysisRule, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRow(`
+		SELECT id, name, severity, sql, description_template, enabled, created_at, updated_at
+		FROM custom_analysis_rules
+		WHERE id = ?
+	`, id)
+	r, err := scanAnalysisRule(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("analysis rule not found: %s", id)
+	}
+	return r, err
+}
+
+// SaveAnalysisRule inserts or replaces a rule
+func SaveAnalysisRule(r *AnalysisRule) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO custom_analysis_rules (id, name, severity, sql, description_template, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = ?, severity = ?, sql = ?, description_template = ?, enabled = ?, updated_at = ?
+	`, r.ID, r.Name, r.Severity, r.SQL, r.DescriptionTemplate, r.Enabled, r.CreatedAt, r.UpdatedAt,
+		r.Name, r.Severity, r.SQL, r.DescriptionTemplate, r.Enabled, r.UpdatedAt)
+	return err
+}
+
+// DeleteAnalysisRule removes a rule
+func DeleteAnalysisRule(id string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec("DELETE FROM custom_analysis_rules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("analysis rule not found: %s", id)
+	}
+	return nil
+}
+
+func scanAnalysisRule(row interface{ Scan(...any) error }) (*AnalysisRule, error) {
+	var r AnalysisRule
+	if err := row.Scan(&r.ID, &r.Name, &r.Severity, &r.SQL, &r.DescriptionTemplate, &r.Enabled, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
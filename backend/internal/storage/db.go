@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"sync"
@@ -46,7 +47,18 @@ func GetDB() (*sql.DB, error) {
 			if _, e := db.Exec(schema); e != nil {
 				return e
 			}
-			return migrateFromJSON(pgvoyagerDir)
+			if e := migrateColumns(); e != nil {
+				return e
+			}
+			if e := migrateFromJSON(pgvoyagerDir); e != nil {
+				return e
+			}
+			// A bad queries.json shouldn't take the rest of storage down
+			// with it; the file stays in place for the next attempt.
+			if e := migrateSavedQueriesFromJSON(pgvoyagerDir); e != nil {
+				log.Printf("could not import saved queries from queries.json: %v", e)
+			}
+			return nil
 		})
 
 		// Defensive belt-and-suspenders: if the file already existed
@@ -60,6 +72,49 @@ func GetDB() (*sql.DB, error) {
 	return db, err
 }
 
+// migrateColumns adds any columnMigrations missing from existing tables.
+func migrateColumns() error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+func columnExists(table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // tightenIfWorldReadable lowers the DB file to 0600 only when it's
 // currently more permissive. No-op on already-tight files so we don't
 // chmod the file on every cold start (which could race with libsqlite's
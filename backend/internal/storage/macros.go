@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MacroStep is a single step of a macro. Exactly one of SavedQueryID or SQL
+// is set: steps either reference a saved query (resolved at run time, so
+// edits to the saved query are picked up) or carry a literal statement.
+type MacroStep struct {
+	Label        string `json:"label,omitempty"`
+	SavedQueryID string `json:"savedQueryId,omitempty"`
+	SQL          string `json:"sql,omitempty"`
+}
+
+// Macro is a named, replayable sequence of query steps
+type Macro struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Steps       []MacroStep `json:"steps"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+}
+
+// ListMacros returns all macros, most recently updated first
+func ListMacros() ([]Macro, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, name, description, steps, created_at, updated_at
+		FROM macros
+		ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	macros := []Macro{}
+	for rows.Next() {
+		m, err := scanMacro(rows)
+		if err != nil {
+			return nil, err
+		}
+		macros = append(macros, *m)
+	}
+	return macros, rows.Err()
+}
+
+// GetMacro retrieves a single macro by ID
+func GetMacro(id string) (*Macro, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRow(`
+		SELECT id, name, description, steps, created_at, updated_at
+		FROM macros
+		WHERE id = ?
+	`, id)
+	m, err := scanMacro(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("macro not found: %s", id)
+	}
+	return m, err
+}
+
+// SaveMacro inserts or replaces a macro
+func SaveMacro(m *Macro) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	steps, err := json.Marshal(m.Steps)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO macros (id, name, description, steps, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = ?, description = ?, steps = ?, updated_at = ?
+	`, m.ID, m.Name, m.Description, string(steps), m.CreatedAt, m.UpdatedAt,
+		m.Name, m.Description, string(steps), m.UpdatedAt)
+	return err
+}
+
+// DeleteMacro removes a macro
+func DeleteMacro(id string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec("DELETE FROM macros WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("macro not found: %s", id)
+	}
+	return nil
+}
+
+func scanMacro(row interface{ Scan(...any) error }) (*Macro, error) {
+	var m Macro
+	var description sql.NullString
+	var steps string
+	if err := row.Scan(&m.ID, &m.Name, &description, &steps, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	m.Description = description.String
+	if err := json.Unmarshal([]byte(steps), &m.Steps); err != nil {
+		return nil, fmt.Errorf("corrupt macro steps for %s: %w", m.ID, err)
+	}
+	return &m, nil
+}
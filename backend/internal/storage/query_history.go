@@ -2,6 +2,9 @@ package storage
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,7 +21,47 @@ type QueryHistoryEntry struct {
 	ExecutedAt     time.Time `json:"executedAt"`
 }
 
-const maxHistoryEntries = 100
+const (
+	// HistoryMaxEntriesPreference caps how many history entries are kept:
+	// a count up to MaxHistoryRetention, or HistoryRetentionUnlimited.
+	HistoryMaxEntriesPreference = "query_history.max_entries"
+	HistoryRetentionUnlimited   = "unlimited"
+	MaxHistoryRetention         = 10000
+	defaultHistoryRetention     = 1000
+	// HistoryAutoLogPreference set to "false" stops ExecuteQuery from
+	// recording its runs; anything else leaves auto-logging on.
+	HistoryAutoLogPreference = "query_history.auto_log"
+	// maxHistoryPageSize bounds a single GetQueryHistory read.
+	maxHistoryPageSize = MaxHistoryRetention
+)
+
+// ParseHistoryRetention validates a HistoryMaxEntriesPreference value and
+// returns the entry cap, with 0 meaning unlimited.
+func ParseHistoryRetention(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, HistoryRetentionUnlimited) {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > MaxHistoryRetention {
+		return 0, fmt.Errorf("must be a number from 1 to %d, or %q", MaxHistoryRetention, HistoryRetentionUnlimited)
+	}
+	return n, nil
+}
+
+// historyRetention reads the configured cap, falling back to the default
+// when the preference is unset or invalid.
+func historyRetention() int {
+	value, err := GetPreference(HistoryMaxEntriesPreference)
+	if err != nil || value == "" {
+		return defaultHistoryRetention
+	}
+	n, err := ParseHistoryRetention(value)
+	if err != nil {
+		return defaultHistoryRetention
+	}
+	return n
+}
 
 // AddQueryHistory adds a query execution to the history
 func AddQueryHistory(entry *QueryHistoryEntry) error {
@@ -35,38 +78,69 @@ func AddQueryHistory(entry *QueryHistoryEntry) error {
 		return err
 	}
 
-	// Clean up old entries beyond max limit
-	return cleanOldHistory(db)
+	// Clean up old entries beyond the configured limit
+	return cleanOldHistory(db, historyRetention())
 }
 
-// GetQueryHistory retrieves query history with optional filtering
-func GetQueryHistory(connectionID string, limit int) ([]QueryHistoryEntry, error) {
+// QueryHistoryFilter narrows GetQueryHistory; zero fields match
+// everything. Search is a case-insensitive substring of the SQL, and From
+// and To bound executed_at inclusively.
+type QueryHistoryFilter struct {
+	ConnectionID string
+	Search       string
+	From         *time.Time
+	To           *time.Time
+	SuccessOnly  bool
+	Limit        int
+}
+
+// likeEscaper escapes LIKE wildcards for use with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetQueryHistory retrieves query history matching filter, newest first
+func GetQueryHistory(filter QueryHistoryFilter) ([]QueryHistoryEntry, error) {
 	db, err := GetDB()
 	if err != nil {
 		return nil, err
 	}
 
-	if limit <= 0 || limit > maxHistoryEntries {
-		limit = maxHistoryEntries
+	limit := filter.Limit
+	if limit <= 0 || limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
 	}
 
-	var rows *sql.Rows
-	if connectionID != "" {
-		rows, err = db.Query(`
-			SELECT id, connection_id, connection_name, sql, duration, row_count, success, error, executed_at
-			FROM query_history
-			WHERE connection_id = ?
-			ORDER BY executed_at DESC
-			LIMIT ?
-		`, connectionID, limit)
-	} else {
-		rows, err = db.Query(`
-			SELECT id, connection_id, connection_name, sql, duration, row_count, success, error, executed_at
-			FROM query_history
-			ORDER BY executed_at DESC
-			LIMIT ?
-		`, limit)
+	query := `
+		SELECT id, connection_id, connection_name, sql, duration, row_count, success, error, executed_at
+		FROM query_history
+		WHERE 1 = 1`
+	var args []any
+	if filter.ConnectionID != "" {
+		query += ` AND connection_id = ?`
+		args = append(args, filter.ConnectionID)
 	}
+	if filter.Search != "" {
+		query += ` AND sql LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(filter.Search)+"%")
+	}
+	// executed_at holds time.Now() in local time, so bounds are converted
+	// to match and compared directly, keeping the index usable.
+	if filter.From != nil {
+		query += ` AND executed_at >= ?`
+		args = append(args, filter.From.In(time.Local))
+	}
+	if filter.To != nil {
+		query += ` AND executed_at <= ?`
+		args = append(args, filter.To.In(time.Local))
+	}
+	if filter.SuccessOnly {
+		query += ` AND success`
+	}
+	query += `
+		ORDER BY executed_at DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +198,11 @@ func ClearQueryHistory(connectionID string) error {
 	return err
 }
 
-func cleanOldHistory(db *sql.DB) error {
+// cleanOldHistory keeps the newest keep entries; zero keeps everything.
+func cleanOldHistory(db *sql.DB, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
 	_, err := db.Exec(`
 		DELETE FROM query_history
 		WHERE id NOT IN (
@@ -132,6 +210,6 @@ func cleanOldHistory(db *sql.DB) error {
 			ORDER BY executed_at DESC
 			LIMIT ?
 		)
-	`, maxHistoryEntries)
+	`, keep)
 	return err
 }
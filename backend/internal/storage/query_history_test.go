@@ -0,0 +1,26 @@
+package storage
+
+import "testing"
+
+func TestParseHistoryRetention(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"500", 500, false},
+		{" 10000 ", 10000, false},
+		{"unlimited", 0, false},
+		{"Unlimited", 0, false},
+		{"0", 0, true},
+		{"-1", 0, true},
+		{"10001", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHistoryRetention(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseHistoryRetention(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// ListSavedQueries returns the saved queries matching filter, favorites
+// first, then by name. Tags are stored as a JSON array and matched case-insensitively.
+func ListSavedQueries(filter models.SavedQueryFilter) ([]*models.SavedQuery, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, name, sql, connection_id, description, tags, folder, parameters, is_favorite, created_at, updated_at
+		FROM saved_queries
+		WHERE 1 = 1`
+	var args []any
+	if filter.Tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM json_each(saved_queries.tags) t WHERE lower(t.value) = lower(?))`
+		args = append(args, filter.Tag)
+	}
+	if filter.Folder != "" {
+		query += ` AND folder = ?`
+		args = append(args, filter.Folder)
+	}
+	query += ` ORDER BY is_favorite DESC, name COLLATE NOCASE, created_at`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queries := []*models.SavedQuery{}
+	for rows.Next() {
+		q, err := scanSavedQuery(rows)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// ListSavedQueryTags returns each distinct tag (compared case-insensitively)
+// with the number of saved queries carrying it, most used first.
+func ListSavedQueryTags() ([]models.TagCount, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT min(t.value), count(DISTINCT q.id)
+		FROM saved_queries q, json_each(q.tags) t
+		GROUP BY lower(t.value)
+		ORDER BY count(DISTINCT q.id) DESC, lower(t.value)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.TagCount{}
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// GetSavedQuery retrieves a single saved query by ID
+func GetSavedQuery(id string) (*models.SavedQuery, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRow(`
+		SELECT id, name, sql, connection_id, description, tags, folder, parameters, is_favorite, created_at, updated_at
+		FROM saved_queries
+		WHERE id = ?
+	`, id)
+	q, err := scanSavedQuery(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("saved query not found: %s", id)
+	}
+	return q, err
+}
+
+// SaveSavedQuery inserts or replaces a saved query
+func SaveSavedQuery(q *models.SavedQuery) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	tags, err := marshalTags(q.Tags)
+	if err != nil {
+		return err
+	}
+	params, err := marshalParams(q.Parameters)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO saved_queries (id, name, sql, connection_id, description, tags, folder, parameters, is_favorite, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = ?, sql = ?, connection_id = ?, description = ?, tags = ?, folder = ?, parameters = ?, is_favorite = ?, updated_at = ?
+	`, q.ID, q.Name, q.SQL, q.ConnectionID, q.Description, tags, q.Folder, params, q.IsFavorite, q.CreatedAt, q.UpdatedAt,
+		q.Name, q.SQL, q.ConnectionID, q.Description, tags, q.Folder, params, q.IsFavorite, q.UpdatedAt)
+	return err
+}
+
+// SetSavedQueryFavorite pins or unpins a saved query. It isn't an edit of
+// the query, so updated_at is left alone.
+func SetSavedQueryFavorite(id string, favorite bool) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec("UPDATE saved_queries SET is_favorite = ? WHERE id = ?", favorite, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("saved query not found: %s", id)
+	}
+	return nil
+}
+
+// DeleteSavedQuery removes a saved query
+func DeleteSavedQuery(id string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec("DELETE FROM saved_queries WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("saved query not found: %s", id)
+	}
+	return nil
+}
+
+func scanSavedQuery(row interface{ Scan(...any) error }) (*models.SavedQuery, error) {
+	var q models.SavedQuery
+	var tags, params string
+	if err := row.Scan(&q.ID, &q.Name, &q.SQL, &q.ConnectionID, &q.Description, &tags, &q.Folder, &params, &q.IsFavorite, &q.CreatedAt, &q.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &q.Tags); err != nil {
+		return nil, fmt.Errorf("corrupt tags for saved query %s: %w", q.ID, err)
+	}
+	if q.Tags == nil {
+		q.Tags = []string{}
+	}
+	if err := json.Unmarshal([]byte(params), &q.Parameters); err != nil {
+		return nil, fmt.Errorf("corrupt parameters for saved query %s: %w", q.ID, err)
+	}
+	if q.Parameters == nil {
+		q.Parameters = []models.QueryParam{}
+	}
+	return &q, nil
+}
+
+func marshalTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	data, err := json.Marshal(tags)
+	return string(data), err
+}
+
+func marshalParams(params []models.QueryParam) (string, error) {
+	if params == nil {
+		params = []models.QueryParam{}
+	}
+	data, err := json.Marshal(params)
+	return string(data), err
+}
+
+// migrateSavedQueriesFromJSON imports the legacy queries.json file. Rows
+// are inserted with INSERT OR IGNORE, so a run interrupted before the
+// rename below simply re-imports on the next start. Saved queries aren't
+// credentials, so the file is kept as queries.json.migrated rather than
+// shredded.
+func migrateSavedQueriesFromJSON(configDir string) error {
+	jsonPath := filepath.Join(configDir, "queries.json")
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var queries []*models.SavedQuery
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return fmt.Errorf("parse %s: %w", jsonPath, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO saved_queries (id, name, sql, connection_id, description, tags, folder, parameters, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, '[]', '', '[]', ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, q := range queries {
+		if _, err := stmt.Exec(q.ID, q.Name, q.SQL, q.ConnectionID, q.Description, q.CreatedAt, q.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return os.Rename(jsonPath, jsonPath+".migrated")
+}
@@ -34,4 +34,95 @@ CREATE TABLE IF NOT EXISTS preferences (
 	value TEXT NOT NULL,
 	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
+
+CREATE TABLE IF NOT EXISTS macros (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT,
+	steps TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS saved_queries (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	sql TEXT NOT NULL,
+	connection_id TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS custom_analysis_rules (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	severity TEXT NOT NULL,
+	sql TEXT NOT NULL,
+	description_template TEXT NOT NULL DEFAULT '',
+	enabled BOOLEAN NOT NULL DEFAULT 1,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS size_samples (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	connection_id TEXT NOT NULL,
+	database_bytes INTEGER NOT NULL,
+	sampled_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_size_samples_connection ON size_samples(connection_id, sampled_at);
+
+CREATE TABLE IF NOT EXISTS table_size_samples (
+	sample_id INTEGER NOT NULL,
+	schema_name TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	total_bytes INTEGER NOT NULL,
+	PRIMARY KEY (sample_id, schema_name, table_name)
+);
+
+CREATE TABLE IF NOT EXISTS table_access (
+	connection_id TEXT NOT NULL,
+	schema_name TEXT NOT NULL,
+	table_name TEXT NOT NULL,
+	access_count INTEGER NOT NULL DEFAULT 0,
+	last_accessed_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (connection_id, schema_name, table_name)
+);
 `
+
+// columnMigration describes a column added after a table first shipped.
+// CREATE TABLE IF NOT EXISTS never alters an existing table, so these are
+// applied with ALTER TABLE on startup when the column is missing.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+var columnMigrations = []columnMigration{
+	{"connections", "max_conn_idle_time", "INTEGER NOT NULL DEFAULT 0"},
+	{"connections", "ssh_host", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssh_port", "INTEGER NOT NULL DEFAULT 0"},
+	{"connections", "ssh_user", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssh_key_path", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssh_password", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "is_read_only", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"connections", "role", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "max_conns", "INTEGER NOT NULL DEFAULT 0"},
+	{"connections", "min_conns", "INTEGER NOT NULL DEFAULT 0"},
+	{"connections", "max_conn_lifetime", "INTEGER NOT NULL DEFAULT 0"},
+	{"connections", "connection_string", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "environment", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssl_cert", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssl_key", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "ssl_root_cert", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "search_path", "TEXT NOT NULL DEFAULT ''"},
+	{"connections", "is_favorite", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"connections", "default_query_timeout_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"saved_queries", "tags", "TEXT NOT NULL DEFAULT '[]'"},
+	{"saved_queries", "folder", "TEXT NOT NULL DEFAULT ''"},
+	{"saved_queries", "parameters", "TEXT NOT NULL DEFAULT '[]'"},
+	{"saved_queries", "is_favorite", "BOOLEAN NOT NULL DEFAULT 0"},
+}
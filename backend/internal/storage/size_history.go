@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+const (
+	// SizeHistoryRetentionPreference holds how many days of size samples
+	// are kept per connection, 1 to MaxSizeHistoryRetentionDays.
+	SizeHistoryRetentionPreference = "size_history.retention_days"
+	MaxSizeHistoryRetentionDays    = 3650
+	defaultSizeHistoryRetention    = 90
+)
+
+// ParseSizeHistoryRetention validates a SizeHistoryRetentionPreference
+// value and returns the number of days.
+func ParseSizeHistoryRetention(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 || n > MaxSizeHistoryRetentionDays {
+		return 0, fmt.Errorf("must be 1 to %d days", MaxSizeHistoryRetentionDays)
+	}
+	return n, nil
+}
+
+// SizeHistoryRetention reads the configured retention in days, falling
+// back to the default when the preference is unset or invalid.
+func SizeHistoryRetention() int {
+	value, err := GetPreference(SizeHistoryRetentionPreference)
+	if err != nil || value == "" {
+		return defaultSizeHistoryRetention
+	}
+	n, err := ParseSizeHistoryRetention(value)
+	if err != nil {
+		return defaultSizeHistoryRetention
+	}
+	return n
+}
+
+// AddSizeSample records a size sample for a connection and drops its
+// samples older than the retention window. Times are stored in UTC so
+// they compare correctly as text.
+func AddSizeSample(connectionID string, sample *models.SizeSample) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO size_samples (connection_id, database_bytes, sampled_at)
+		VALUES (?, ?, ?)
+	`, connectionID, sample.DatabaseBytes, sample.SampledAt.UTC())
+	if err != nil {
+		return err
+	}
+	sampleID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range sample.Tables {
+		_, err := tx.Exec(`
+			INSERT INTO table_size_samples (sample_id, schema_name, table_name, total_bytes)
+			VALUES (?, ?, ?, ?)
+		`, sampleID, t.Schema, t.Table, t.Bytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -SizeHistoryRetention())
+	if err := deleteSizeSamples(tx, `connection_id = ? AND sampled_at < ?`, connectionID, cutoff); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSizeHistory returns a connection's samples taken at or after since,
+// oldest first, each with its tables largest first.
+func GetSizeHistory(connectionID string, since time.Time) ([]models.SizeSample, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT s.id, s.database_bytes, s.sampled_at, t.schema_name, t.table_name, t.total_bytes
+		FROM size_samples s
+		LEFT JOIN table_size_samples t ON t.sample_id = s.id
+		WHERE s.connection_id = ? AND s.sampled_at >= ?
+		ORDER BY s.sampled_at, s.id, t.total_bytes DESC
+	`, connectionID, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []models.SizeSample{}
+	var lastID int64 = -1
+	for rows.Next() {
+		var (
+			id     int64
+			sample models.SizeSample
+			schema sql.NullString
+			table  sql.NullString
+			bytes  sql.NullInt64
+		)
+		if err := rows.Scan(&id, &sample.DatabaseBytes, &sample.SampledAt, &schema, &table, &bytes); err != nil {
+			return nil, err
+		}
+		if id != lastID {
+			sample.Tables = []models.TableSizeSample{}
+			samples = append(samples, sample)
+			lastID = id
+		}
+		if table.Valid {
+			last := &samples[len(samples)-1]
+			last.Tables = append(last.Tables, models.TableSizeSample{Schema: schema.String, Table: table.String, Bytes: bytes.Int64})
+		}
+	}
+	return samples, rows.Err()
+}
+
+// LastSizeSampleAt returns when the connection was last sampled, or the
+// zero time if it never was.
+func LastSizeSampleAt(connectionID string) (time.Time, error) {
+	db, err := GetDB()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var at time.Time
+	err = db.QueryRow(`
+		SELECT sampled_at FROM size_samples
+		WHERE connection_id = ?
+		ORDER BY sampled_at DESC
+		LIMIT 1
+	`, connectionID).Scan(&at)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return at, err
+}
+
+// ClearSizeHistory removes every size sample of a connection.
+func ClearSizeHistory(connectionID string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSizeSamples(tx, `connection_id = ?`, connectionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteSizeSamples deletes the size_samples matching where, and their
+// table sizes.
+func deleteSizeSamples(tx *sql.Tx, where string, args ...any) error {
+	_, err := tx.Exec(`
+		DELETE FROM table_size_samples
+		WHERE sample_id IN (SELECT id FROM size_samples WHERE `+where+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM size_samples WHERE `+where, args...)
+	return err
+}
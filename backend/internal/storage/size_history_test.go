@@ -0,0 +1,24 @@
+package storage
+
+import "testing"
+
+func TestParseSizeHistoryRetention(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"90", 90, false},
+		{" 1 ", 1, false},
+		{"3650", 3650, false},
+		{"0", 0, true},
+		{"3651", 0, true},
+		{"forever", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSizeHistoryRetention(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSizeHistoryRetention(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
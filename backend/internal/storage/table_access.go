@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/thelinuxer/pgvoyager/internal/models"
+)
+
+// Orders for GetRecentTables.
+const (
+	RecentTablesByRecency   = "recent"
+	RecentTablesByFrequency = "frequent"
+)
+
+// RecordTableAccess counts one opening of a table, as a single upsert.
+func RecordTableAccess(connectionID, schema, table string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO table_access (connection_id, schema_name, table_name, access_count, last_accessed_at)
+		VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (connection_id, schema_name, table_name) DO UPDATE SET
+			access_count = access_count + 1,
+			last_accessed_at = excluded.last_accessed_at
+	`, connectionID, schema, table, time.Now().UTC())
+	return err
+}
+
+// GetRecentTables returns up to limit of a connection's opened tables,
+// most recently opened first, or with RecentTablesByFrequency most often
+// opened first.
+func GetRecentTables(connectionID, order string, limit int) ([]models.RecentTable, error) {
+	db, err := GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy := "last_accessed_at DESC"
+	if order == RecentTablesByFrequency {
+		orderBy = "access_count DESC, last_accessed_at DESC"
+	}
+	rows, err := db.Query(`
+		SELECT schema_name, table_name, access_count, last_accessed_at
+		FROM table_access
+		WHERE connection_id = ?
+		ORDER BY `+orderBy+`
+		LIMIT ?
+	`, connectionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []models.RecentTable{}
+	for rows.Next() {
+		var t models.RecentTable
+		if err := rows.Scan(&t.Schema, &t.Table, &t.AccessCount, &t.LastAccessedAt); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// ClearTableAccess forgets a connection's table usage.
+func ClearTableAccess(connectionID string) error {
+	db, err := GetDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DELETE FROM table_access WHERE connection_id = ?", connectionID)
+	return err
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTableAccess(t *testing.T) {
+	// GetDB opens its database once per process, in the config directory.
+	t.Setenv("PGVOYAGER_CONFIG_DIR", t.TempDir())
+	if _, err := GetDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(schema, table string) {
+		t.Helper()
+		if err := RecordTableAccess("c1", schema, table); err != nil {
+			t.Fatal(err)
+		}
+		// last_accessed_at orders the recent list.
+		time.Sleep(2 * time.Millisecond)
+	}
+	record("public", "orders")
+	record("public", "orders")
+	record("public", "orders")
+	record("public", "users")
+	record("audit", "log")
+	if err := RecordTableAccess("c2", "public", "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	recent, err := GetRecentTables("c1", RecentTablesByRecency, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range recent {
+		got = append(got, r.Schema+"."+r.Table)
+	}
+	if want := []string{"audit.log", "public.users", "public.orders"}; !slices.Equal(got, want) {
+		t.Errorf("recent = %v, want %v", got, want)
+	}
+
+	frequent, err := GetRecentTables("c1", RecentTablesByFrequency, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frequent) != 2 || frequent[0].Table != "orders" || frequent[0].AccessCount != 3 || frequent[1].Table != "log" {
+		t.Errorf("frequent = %+v, want orders (3 opens) then log", frequent)
+	}
+
+	if err := ClearTableAccess("c1"); err != nil {
+		t.Fatal(err)
+	}
+	if recent, _ := GetRecentTables("c1", RecentTablesByRecency, 10); len(recent) != 0 {
+		t.Errorf("after clear: %d tables, want 0", len(recent))
+	}
+	if other, _ := GetRecentTables("c2", RecentTablesByRecency, 10); len(other) != 1 {
+		t.Errorf("clearing c1 should keep c2's tables, got %d", len(other))
+	}
+}
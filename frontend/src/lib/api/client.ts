@@ -1,6 +1,7 @@
 import type {
 	Connection,
 	ConnectionRequest,
+	ConnectionInfo,
 	Database,
 	Schema,
 	Table,
@@ -8,12 +9,16 @@ import type {
 	Constraint,
 	Index,
 	ForeignKey,
+	TableDescription,
 	SchemaRelationship,
+	ERDiagram,
 	View,
 	Function,
 	Sequence,
+	SequenceState,
 	CustomType,
 	TableDataResponse,
+	ColumnStats,
 	ForeignKeyPreview,
 	QueryResult,
 	SavedQuery,
@@ -21,8 +26,20 @@ import type {
 	InsertRowRequest,
 	UpdateRowRequest,
 	DeleteRowRequest,
+	DeleteByFilterRequest,
 	CrudResponse,
-	AnalysisResult
+	AnalysisResult,
+	LintResult,
+	QueryDiffResult,
+	SizeHistory,
+	RecentTable,
+	SizeSample,
+	MaintenanceResult,
+	MaintenanceProgress,
+	TransactionInfo,
+	TransactionEndResponse,
+	ConnectionExport,
+	ConnectionImportResult
 } from '$lib/types';
 
 // In production, the frontend is served from the same origin as the API
@@ -91,8 +108,33 @@ export const connectionApi = {
 			method: 'DELETE'
 		}),
 
+	duplicate: (id: string) =>
+		fetchAPI<Connection>(`/connections/${id}/duplicate`, {
+			method: 'POST'
+		}),
+
+	// Without a value the favorite flag is flipped
+	setFavorite: (id: string, favorite?: boolean) =>
+		fetchAPI<Connection>(`/connections/${id}/favorite`, {
+			method: 'POST',
+			body: JSON.stringify(favorite === undefined ? {} : { favorite })
+		}),
+
+	// Without a passphrase the export leaves passwords out
+	exportAll: (passphrase?: string) =>
+		fetchAPI<ConnectionExport>('/connections/export', {
+			headers: passphrase ? { 'X-Export-Passphrase': passphrase } : undefined
+		}),
+
+	importAll: (file: ConnectionExport, passphrase?: string) =>
+		fetchAPI<ConnectionImportResult>('/connections/import', {
+			method: 'POST',
+			headers: passphrase ? { 'X-Export-Passphrase': passphrase } : undefined,
+			body: JSON.stringify(file)
+		}),
+
 	test: (data: Omit<ConnectionRequest, 'name'>) =>
-		fetchAPI<{ success: boolean; message: string }>('/connections/test', {
+		fetchAPI<{ success: boolean; message: string; info?: ConnectionInfo }>('/connections/test', {
 			method: 'POST',
 			body: JSON.stringify(data)
 		}),
@@ -149,9 +191,49 @@ export const schemaApi = {
 	getTableInfo: (connId: string, schema: string, table: string) =>
 		fetchAPI<Table>(`/schema/${connId}/tables/${schema}/${table}`),
 
+	describeTable: (connId: string, schema: string, table: string) =>
+		fetchAPI<TableDescription>(`/schema/${connId}/tables/${schema}/${table}/describe`),
+
 	getTableColumns: (connId: string, schema: string, table: string) =>
 		fetchAPI<Column[]>(`/schema/${connId}/tables/${schema}/${table}/columns`),
 
+	// An empty comment removes it
+	setTableComment: (connId: string, schema: string, table: string, comment: string) =>
+		fetchAPI<{ success: boolean; message: string }>(`/schema/${connId}/tables/${schema}/${table}/comment`, {
+			method: 'PUT',
+			body: JSON.stringify({ comment })
+		}),
+
+	setColumnComment: (connId: string, schema: string, table: string, column: string, comment: string) =>
+		fetchAPI<{ success: boolean; message: string }>(
+			`/schema/${connId}/tables/${schema}/${table}/columns/${column}/comment`,
+			{
+				method: 'PUT',
+				body: JSON.stringify({ comment })
+			}
+		),
+
+	// Both return the table's columns after the change
+	renameColumn: (connId: string, schema: string, table: string, column: string, newName: string) =>
+		fetchAPI<Column[]>(`/schema/${connId}/tables/${schema}/${table}/columns/${column}/rename`, {
+			method: 'POST',
+			body: JSON.stringify({ newName })
+		}),
+
+	// using converts existing values, e.g. 'price::numeric(10,2)'
+	changeColumnType: (
+		connId: string,
+		schema: string,
+		table: string,
+		column: string,
+		type: string,
+		using?: string
+	) =>
+		fetchAPI<Column[]>(`/schema/${connId}/tables/${schema}/${table}/columns/${column}/change-type`, {
+			method: 'POST',
+			body: JSON.stringify({ type, using })
+		}),
+
 	getAllColumns: (connId: string) =>
 		fetchAPI<{ schema: string; table: string; columns: Column[] }[]>(`/schema/${connId}/all-columns`),
 
@@ -161,12 +243,39 @@ export const schemaApi = {
 	getTableIndexes: (connId: string, schema: string, table: string) =>
 		fetchAPI<Index[]>(`/schema/${connId}/tables/${schema}/${table}/indexes`),
 
+	// name defaults to <table>_<columns>_idx; unique needs btree
+	createIndex: (
+		connId: string,
+		data: {
+			schema: string;
+			table: string;
+			columns: string[];
+			name?: string;
+			unique?: boolean;
+			method?: 'btree' | 'gin' | 'gist';
+			concurrently?: boolean;
+		}
+	) =>
+		fetchAPI<MaintenanceResult>(`/schema/${connId}/indexes`, {
+			method: 'POST',
+			body: JSON.stringify(data)
+		}),
+
+	dropIndex: (connId: string, schema: string, name: string, concurrently = false) =>
+		fetchAPI<MaintenanceResult>(
+			`/schema/${connId}/indexes/${schema}/${name}${concurrently ? '?concurrently=true' : ''}`,
+			{ method: 'DELETE' }
+		),
+
 	getForeignKeys: (connId: string, schema: string, table: string) =>
 		fetchAPI<ForeignKey[]>(`/schema/${connId}/tables/${schema}/${table}/foreign-keys`),
 
 	getSchemaRelationships: (connId: string, schema: string) =>
 		fetchAPI<SchemaRelationship[]>(`/schema/${connId}/schemas/${schema}/relationships`),
 
+	getERDiagram: (connId: string, schema: string) =>
+		fetchAPI<ERDiagram>(`/schema/${connId}/erd/${schema}`),
+
 	listViews: (connId: string, schema?: string) => {
 		const params = schema ? `?schema=${encodeURIComponent(schema)}` : '';
 		return fetchAPI<View[]>(`/schema/${connId}/views${params}`);
@@ -182,6 +291,24 @@ export const schemaApi = {
 		return fetchAPI<Sequence[]>(`/schema/${connId}/sequences${params}`);
 	},
 
+	// isCalled defaults to true: the next nextval returns value + increment
+	setSequenceValue: (connId: string, schema: string, name: string, value: number, isCalled?: boolean) =>
+		fetchAPI<SequenceState>(`/schema/${connId}/sequences/${schema}/${name}/setval`, {
+			method: 'POST',
+			body: JSON.stringify({ value, isCalled })
+		}),
+
+	alterSequence: (
+		connId: string,
+		schema: string,
+		name: string,
+		changes: { restart?: number; increment?: number; maxValue?: number }
+	) =>
+		fetchAPI<SequenceState>(`/schema/${connId}/sequences/${schema}/${name}/alter`, {
+			method: 'POST',
+			body: JSON.stringify(changes)
+		}),
+
 	listTypes: (connId: string, schema?: string) => {
 		const params = schema ? `?schema=${encodeURIComponent(schema)}` : '';
 		return fetchAPI<CustomType[]>(`/schema/${connId}/types${params}`);
@@ -201,15 +328,22 @@ export const dataApi = {
 			orderDir?: 'ASC' | 'DESC';
 			filterColumn?: string;
 			filterValue?: string;
+			// Keyset pagination: '' for the first page, then the previous
+			// response's nextCursor. Needs orderBy on a unique NOT NULL column.
+			cursor?: string;
+			// Columns to return, in this order; all of them when omitted
+			columns?: string[];
 		}
 	) => {
 		const params = new URLSearchParams();
 		if (options?.page) params.set('page', String(options.page));
+		if (options?.cursor !== undefined) params.set('cursor', options.cursor);
 		if (options?.pageSize) params.set('pageSize', String(options.pageSize));
 		if (options?.orderBy) params.set('orderBy', options.orderBy);
 		if (options?.orderDir) params.set('orderDir', options.orderDir);
 		if (options?.filterColumn) params.set('filterColumn', options.filterColumn);
 		if (options?.filterValue) params.set('filterValue', options.filterValue);
+		if (options?.columns?.length) params.set('columns', options.columns.join(','));
 
 		const queryString = params.toString();
 		return fetchAPI<TableDataResponse>(
@@ -217,9 +351,41 @@ export const dataApi = {
 		);
 	},
 
+	// A download URL for the whole table with the grid's filters and order;
+	// use it as a link href rather than fetching it
+	exportTableUrl: (
+		connId: string,
+		schema: string,
+		table: string,
+		format: 'csv' | 'json' | 'jsonl' = 'csv',
+		options?: {
+			orderBy?: string;
+			orderDir?: 'ASC' | 'DESC';
+			filterColumn?: string;
+			filterValue?: string;
+		}
+	) => {
+		const params = new URLSearchParams({ format });
+		if (options?.orderBy) params.set('orderBy', options.orderBy);
+		if (options?.orderDir) params.set('orderDir', options.orderDir);
+		if (options?.filterColumn) params.set('filterColumn', options.filterColumn);
+		if (options?.filterValue) params.set('filterValue', options.filterValue);
+		return `${API_BASE}/data/${connId}/tables/${schema}/${table}/export?${params}`;
+	},
+
 	getRowCount: (connId: string, schema: string, table: string) =>
 		fetchAPI<{ count: number }>(`/data/${connId}/tables/${schema}/${table}/count`),
 
+	getRecentTables: (connId: string, sort: 'recent' | 'frequent' = 'recent', limit?: number) =>
+		fetchAPI<RecentTable[]>(
+			`/data/${connId}/recent-tables?sort=${sort}${limit ? `&limit=${limit}` : ''}`
+		),
+
+	getColumnStats: (connId: string, schema: string, table: string, column: string, top?: number) =>
+		fetchAPI<ColumnStats>(
+			`/data/${connId}/tables/${schema}/${table}/columns/${encodeURIComponent(column)}/stats${top ? `?top=${top}` : ''}`
+		),
+
 	getForeignKeyPreview: (connId: string, schema: string, table: string, column: string, value: string) =>
 		fetchAPI<ForeignKeyPreview>(
 			`/data/${connId}/fk-preview/${schema}/${table}/${column}/${encodeURIComponent(value)}`
@@ -244,6 +410,12 @@ export const dataApi = {
 			body: JSON.stringify(data)
 		}),
 
+	deleteRowsByFilter: (connId: string, schema: string, table: string, data: DeleteByFilterRequest) =>
+		fetchAPI<CrudResponse>(`/data/${connId}/tables/${schema}/${table}/rows/delete-by-filter`, {
+			method: 'POST',
+			body: JSON.stringify(data)
+		}),
+
 	dropTable: (connId: string, schema: string, table: string, cascade?: boolean) =>
 		fetchAPI<{ success: boolean; message: string }>(`/data/${connId}/tables/${schema}/${table}`, {
 			method: 'DELETE',
@@ -290,22 +462,115 @@ export const dataApi = {
 
 // Query API
 export const queryApi = {
-	execute: (connId: string, sql: string, params?: unknown[]) =>
+	format: (sql: string, options?: { keywordCase?: 'upper' | 'lower' | 'preserve'; indent?: number }) =>
+		fetchAPI<{ sql: string }>('/query/format', {
+			method: 'POST',
+			body: JSON.stringify({ sql, ...options })
+		}),
+
+	// paramTypes names each param's type by position (e.g. 'uuid',
+	// 'timestamptz'); the server converts the value before binding
+	execute: (
+		connId: string,
+		sql: string,
+		params?: unknown[],
+		timeoutMs?: number,
+		paramTypes?: string[]
+	) =>
 		fetchAPI<QueryResult>(`/query/${connId}/execute`, {
 			method: 'POST',
-			body: JSON.stringify({ sql, params })
+			body: JSON.stringify({ sql, params, timeoutMs, paramTypes })
 		}),
 
 	explain: (connId: string, sql: string, params?: unknown[]) =>
-		fetchAPI<{ plan: string; duration: number }>(`/query/${connId}/explain`, {
+		fetchAPI<{ plan: string; duration: number; warning?: string }>(`/query/${connId}/explain`, {
+			method: 'POST',
+			body: JSON.stringify({ sql, params })
+		}),
+
+	lint: (connId: string, sql: string, params?: unknown[]) =>
+		fetchAPI<LintResult>(`/query/${connId}/lint`, {
+			method: 'POST',
+			body: JSON.stringify({ sql, params })
+		}),
+
+	diff: (connId: string, sqlA: string, sqlB: string, params?: unknown[], limit?: number) =>
+		fetchAPI<QueryDiffResult>(`/query/${connId}/diff`, {
+			method: 'POST',
+			body: JSON.stringify({ sqlA, sqlB, params, limit })
+		})
+};
+
+// Interactive transactions API
+export const txApi = {
+	list: (connId: string) => fetchAPI<TransactionInfo[]>(`/tx/${connId}`),
+
+	begin: (connId: string) =>
+		fetchAPI<TransactionInfo>(`/tx/${connId}/begin`, { method: 'POST' }),
+
+	execute: (connId: string, txId: string, sql: string, params?: unknown[]) =>
+		fetchAPI<QueryResult>(`/tx/${connId}/${txId}/execute`, {
 			method: 'POST',
 			body: JSON.stringify({ sql, params })
+		}),
+
+	commit: (connId: string, txId: string) =>
+		fetchAPI<TransactionEndResponse>(`/tx/${connId}/${txId}/commit`, { method: 'POST' }),
+
+	rollback: (connId: string, txId: string) =>
+		fetchAPI<TransactionEndResponse>(`/tx/${connId}/${txId}/rollback`, { method: 'POST' }),
+
+	savepoint: (connId: string, txId: string, name: string) =>
+		fetchAPI<TransactionInfo>(`/tx/${connId}/${txId}/savepoint`, {
+			method: 'POST',
+			body: JSON.stringify({ name })
+		}),
+
+	releaseSavepoint: (connId: string, txId: string, name: string) =>
+		fetchAPI<TransactionInfo>(`/tx/${connId}/${txId}/release`, {
+			method: 'POST',
+			body: JSON.stringify({ name })
+		}),
+
+	rollbackToSavepoint: (connId: string, txId: string, name: string) =>
+		fetchAPI<TransactionInfo>(`/tx/${connId}/${txId}/rollback-to`, {
+			method: 'POST',
+			body: JSON.stringify({ name })
 		})
 };
 
 // Analysis API
 export const analysisApi = {
-	run: (connId: string) => fetchAPI<AnalysisResult>(`/analysis/${connId}`)
+	run: (connId: string) => fetchAPI<AnalysisResult>(`/analysis/${connId}`),
+
+	sizeHistory: (connId: string, days?: number) =>
+		fetchAPI<SizeHistory>(`/analysis/${connId}/size-history${days ? `?days=${days}` : ''}`),
+
+	sampleSize: (connId: string) =>
+		fetchAPI<SizeSample>(`/analysis/${connId}/size-history/sample`, { method: 'POST' })
+};
+
+// Table maintenance API. Pass a queryId to poll progress while it runs.
+export const maintenanceApi = {
+	vacuum: (
+		connId: string,
+		schema: string,
+		table: string,
+		options: { analyze?: boolean; full?: boolean; queryId?: string } = {}
+	) =>
+		fetchAPI<MaintenanceResult>(
+			`/maintenance/${connId}/tables/${encodeURIComponent(schema)}/${encodeURIComponent(table)}/vacuum`,
+			{ method: 'POST', body: JSON.stringify(options) }
+		),
+
+	analyze: (connId: string, schema: string, table: string, queryId?: string) =>
+		fetchAPI<MaintenanceResult>(
+			`/maintenance/${connId}/tables/${encodeURIComponent(schema)}/${encodeURIComponent(table)}/analyze`,
+			{ method: 'POST', body: JSON.stringify({ queryId }) }
+		),
+
+	progress: (connId: string, queryId: string) =>
+		fetchAPI<MaintenanceProgress>(`/maintenance/${connId}/progress/${encodeURIComponent(queryId)}`)
 };
 
 // Saved Queries API
@@ -326,6 +591,13 @@ export const savedQueryApi = {
 			body: JSON.stringify(data)
 		}),
 
+	// Without a value the favorite flag is flipped
+	setFavorite: (id: string, favorite?: boolean) =>
+		fetchAPI<SavedQuery>(`/queries/${id}/favorite`, {
+			method: 'POST',
+			body: JSON.stringify(favorite === undefined ? {} : { favorite })
+		}),
+
 	delete: (id: string) =>
 		fetchAPI<void>(`/queries/${id}`, {
 			method: 'DELETE'
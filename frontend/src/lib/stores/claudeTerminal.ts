@@ -1,5 +1,5 @@
 import { writable, get } from 'svelte/store';
-import { editorStore, type EditorAction } from './editor';
+import { editorStore, replacePreview, type EditorAction, type DiffLine } from './editor';
 
 // Get API base URL dynamically based on environment
 function getApiBase(): string {
@@ -48,6 +48,7 @@ interface ClaudeTerminalState {
 	// kept in memory only (never persisted to localStorage).
 	token: string | null;
 	connectionId: string | null; // Track which connection this session is for
+	allowWrites: boolean; // execute_query may write; reset on connection switch
 	isConnected: boolean;
 	isConnecting: boolean;
 	error: string | null;
@@ -58,6 +59,7 @@ function createClaudeTerminalStore() {
 		sessionId: null,
 		token: null,
 		connectionId: null,
+		allowWrites: false,
 		isConnected: false,
 		isConnecting: false,
 		error: null
@@ -83,7 +85,7 @@ function createClaudeTerminalStore() {
 			// If different connection, update the session's connection (no restart needed)
 			const updated = await updateSessionConnection(currentState.sessionId, connectionId);
 			if (updated) {
-				update((state) => ({ ...state, connectionId }));
+				update((state) => ({ ...state, connectionId, allowWrites: false }));
 				if (terminal) {
 					terminal.write('\r\n\x1b[33mSwitched to a different database connection.\x1b[0m\r\n');
 				}
@@ -111,7 +113,8 @@ function createClaudeTerminalStore() {
 				...state,
 				sessionId: data.sessionId,
 				token: data.token,
-				connectionId: connectionId
+				connectionId: connectionId,
+				allowWrites: false
 			}));
 			return data.sessionId;
 		} catch (e) {
@@ -145,6 +148,7 @@ function createClaudeTerminalStore() {
 				sessionId: null,
 				token: null,
 				connectionId: null,
+				allowWrites: false,
 				isConnected: false,
 				isConnecting: false,
 				error: null
@@ -181,6 +185,33 @@ function createClaudeTerminalStore() {
 		}
 	}
 
+	// Allow or forbid writes through Claude's execute_query tool
+	async function setAllowWrites(allowWrites: boolean): Promise<boolean> {
+		const { sessionId, token } = get({ subscribe });
+		if (!sessionId) return false;
+		try {
+			const response = await fetch(`${getApiBase()}/api/claude/sessions/${sessionId}/writes`, {
+				method: 'PUT',
+				headers: {
+					'Content-Type': 'application/json',
+					...(token ? { Authorization: `Bearer ${token}` } : {})
+				},
+				body: JSON.stringify({ allowWrites })
+			});
+
+			if (!response.ok) {
+				console.error('Failed to update session writes');
+				return false;
+			}
+
+			update((s) => ({ ...s, allowWrites }));
+			return true;
+		} catch (e) {
+			console.error('Failed to update session writes:', e);
+			return false;
+		}
+	}
+
 	// Check if connection changed and update session if needed
 	async function ensureSessionForConnection(connectionId: string): Promise<boolean> {
 		const state = get({ subscribe });
@@ -190,7 +221,7 @@ function createClaudeTerminalStore() {
 				// Update existing session's connection
 				const updated = await updateSessionConnection(state.sessionId, connectionId);
 				if (updated) {
-					update((s) => ({ ...s, connectionId }));
+					update((s) => ({ ...s, connectionId, allowWrites: false }));
 					return true;
 				}
 			}
@@ -298,6 +329,8 @@ function createClaudeTerminalStore() {
 		action: string;
 		text: string;
 		position?: { line: number; column: number };
+		previewId?: string;
+		diff?: DiffLine[];
 	}
 
 	function handleEditorAction(data: EditorActionData): void {
@@ -308,7 +341,17 @@ function createClaudeTerminalStore() {
 				text: data.text,
 				position: data.position
 			});
+		} else if (data.action === 'replace_preview' && data.previewId) {
+			// Held for review; the backend sends a replace once accepted
+			replacePreview.set({ previewId: data.previewId, text: data.text, diff: data.diff ?? [] });
+		}
+	}
+
+	function answerReplacePreview(previewId: string, accept: boolean): void {
+		if (ws && ws.readyState === WebSocket.OPEN) {
+			ws.send(JSON.stringify({ type: 'replace_confirm', data: { previewId, accept } }));
 		}
+		replacePreview.set(null);
 	}
 
 	return {
@@ -321,7 +364,9 @@ function createClaudeTerminalStore() {
 		disconnect,
 		sendInput,
 		resize,
-		updateEditorState
+		updateEditorState,
+		answerReplacePreview,
+		setAllowWrites
 	};
 }
 
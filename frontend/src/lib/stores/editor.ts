@@ -12,6 +12,21 @@ export interface EditorAction {
 	position?: { line: number; column: number };
 }
 
+export interface DiffLine {
+	op: 'equal' | 'add' | 'remove';
+	text: string;
+}
+
+// A replacement Claude proposed for review; nothing changes until the
+// user accepts it (claudeTerminal.answerReplacePreview).
+export interface ReplacePreview {
+	previewId: string;
+	text: string;
+	diff: DiffLine[];
+}
+
+export const replacePreview = writable<ReplacePreview | null>(null);
+
 function createEditorStore() {
 	const { subscribe, set, update } = writable<EditorState>({
 		content: '',
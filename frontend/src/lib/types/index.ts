@@ -7,6 +7,15 @@ export interface Connection {
 	username: string;
 	password?: string;
 	sslMode: string;
+	// Paths to PEM files on the PgVoyager host; see SSLFiles
+	sslCert?: string;
+	sslKey?: string;
+	sslRootCert?: string;
+	connectionString?: string;
+	environment?: ConnectionEnvironment;
+	searchPath?: string; // comma-separated schemas, e.g. 'app, public'
+	defaultQueryTimeoutMs?: number; // query/EXPLAIN deadline; server default when unset
+	isFavorite: boolean;
 	isConnected: boolean;
 	createdAt: string;
 	updatedAt: string;
@@ -20,8 +29,49 @@ export interface ConnectionRequest {
 	username: string;
 	password: string;
 	sslMode: string;
+	sslCert?: string;
+	sslKey?: string;
+	sslRootCert?: string;
+	connectionString?: string;
+	environment?: ConnectionEnvironment;
+	searchPath?: string;
+	defaultQueryTimeoutMs?: number;
+}
+
+// What a successful connection test found on the server
+export interface ConnectionInfo {
+	serverVersion: string;
+	serverVersionNum: number;
+	database: string;
+	currentUser: string;
+	ssl: boolean;
+	sslVersion?: string; // e.g. 'TLS 1.3'
+	extensions: {
+		name: string; // 'vector' is pgvector
+		installed: boolean;
+		installedVersion?: string;
+		available: boolean;
+	}[];
+}
+
+// The file GET /connections/export produces. Secrets are either left out
+// ('excluded') or sealed per connection with the export passphrase
+// ('encrypted'); the exact shape is opaque to the UI, which only moves it.
+export interface ConnectionExport {
+	version: number;
+	exportedAt: string;
+	passwords: 'excluded' | 'encrypted';
+	connections: Record<string, unknown>[];
 }
 
+export interface ConnectionImportResult {
+	imported: Connection[];
+	skipped: { name: string; reason: string }[];
+}
+
+// Writes to a production connection need the X-Confirm-Production header
+export type ConnectionEnvironment = 'development' | 'staging' | 'production';
+
 export interface Database {
 	name: string;
 	owner: string;
@@ -45,6 +95,8 @@ export interface Table {
 	size: string;
 	hasPk: boolean;
 	comment?: string;
+	isPartitioned: boolean;
+	partitionKey?: string;
 }
 
 export interface Column {
@@ -59,6 +111,10 @@ export interface Column {
 	fkReference?: FKRef;
 	maxLength?: number;
 	comment?: string;
+	isGenerated: boolean;
+	generationExpression?: string;
+	isIdentity: boolean;
+	identityGeneration?: string;
 }
 
 export interface FKRef {
@@ -73,6 +129,8 @@ export interface ColumnInfo {
 	isPrimaryKey: boolean;
 	isForeignKey: boolean;
 	fkReference?: FKRef;
+	baseType?: string; // table columns only, e.g. int4 or _text
+	notNull?: boolean; // table columns only
 }
 
 export interface Constraint {
@@ -105,6 +163,29 @@ export interface ForeignKey {
 	onDelete: string;
 }
 
+export interface Trigger {
+	schema: string;
+	table: string;
+	name: string;
+	timing: string; // BEFORE, AFTER, INSTEAD OF
+	events: string[];
+	level: 'ROW' | 'STATEMENT';
+	function: string;
+	isEnabled: boolean;
+	definition: string;
+}
+
+// TableDescription bundles a table's info, columns, constraints, indexes,
+// foreign keys and triggers in one response
+export interface TableDescription {
+	table: Table;
+	columns: Column[] | null;
+	constraints: Constraint[] | null;
+	indexes: Index[] | null;
+	foreignKeys: ForeignKey[] | null;
+	triggers: Trigger[] | null;
+}
+
 // SchemaRelationship represents a foreign key relationship for ERD visualization
 export interface SchemaRelationship {
 	sourceSchema: string;
@@ -118,6 +199,34 @@ export interface SchemaRelationship {
 	onDelete: string;
 }
 
+// ERDiagram is a schema's tables (plus tables elsewhere its foreign keys
+// touch) and foreign keys, for drawing an ERD in one call
+export interface ERDiagram {
+	schema: string;
+	nodes: ERDNode[];
+	edges: ERDEdge[];
+}
+
+export interface ERDNode {
+	schema: string;
+	table: string;
+	columns: ERDColumn[];
+}
+
+export interface ERDColumn {
+	name: string;
+	dataType: string;
+	isNullable: boolean;
+	isPrimaryKey: boolean;
+	isForeignKey: boolean;
+}
+
+export interface ERDEdge extends SchemaRelationship {
+	// Read from target to source; one-to-one when the source columns are unique
+	cardinality: 'one-to-one' | 'one-to-many';
+	optional: boolean; // a source column is nullable
+}
+
 export interface View {
 	schema: string;
 	name: string;
@@ -152,6 +261,16 @@ export interface Sequence {
 	lastValue?: number;
 }
 
+// Where a sequence stands after setval or ALTER SEQUENCE
+export interface SequenceState {
+	schema: string;
+	name: string;
+	lastValue: number;
+	isCalled: boolean;
+	increment: number;
+	nextValue: number; // what the next nextval returns
+}
+
 export interface CustomType {
 	schema: string;
 	name: string;
@@ -168,6 +287,26 @@ export interface TableDataResponse {
 	page: number;
 	pageSize: number;
 	totalPages: number;
+	fkLabels?: Record<string, Record<string, string>>;
+	nextCursor?: string; // keyset pagination only; absent on the last page
+}
+
+// ColumnStats profiles one column; counts from pg_stats are estimates,
+// sampled ones cover the first sampleSize rows
+export interface ColumnStats {
+	schema: string;
+	table: string;
+	column: string;
+	dataType: string;
+	source: 'pg_stats' | 'sample';
+	sampleSize?: number;
+	rowCount: number;
+	nullCount: number;
+	nullFraction: number;
+	distinctCount: number;
+	min?: string;
+	max?: string;
+	mostCommon: { value: string; count: number; frequency: number }[];
 }
 
 export interface ForeignKeyPreview {
@@ -186,6 +325,49 @@ export interface QueryResult {
 	errorPosition?: number; // 1-based character position in SQL
 	errorHint?: string;
 	errorDetail?: string;
+	// 'statement_timeout' when the requested timeoutMs fired,
+	// 'deadline' when the server's own request limit did
+	errorKind?: 'statement_timeout' | 'deadline';
+}
+
+export interface LintWarning {
+	severity: 'critical' | 'warning';
+	rule: string; // e.g. 'delete_without_where', 'drop', 'select_star_large'
+	message: string;
+	statement: string;
+	offset: number; // where the statement starts in the linted SQL
+}
+
+export interface LintResult {
+	warnings: LintWarning[];
+	requiresConfirmation: boolean; // any warning is critical
+}
+
+export interface QueryDiffResult {
+	columns: string[];
+	rowCountA: number;
+	rowCountB: number;
+	onlyInA: unknown[][];
+	onlyInB: unknown[][];
+	match: boolean; // false when truncated, even with no differences seen
+	truncated: boolean;
+	limit: number;
+	duration: number;
+}
+
+export interface TransactionInfo {
+	id: string;
+	connectionId: string;
+	startedAt: string;
+	lastActivity: string;
+	failed: boolean;
+	idleTimeoutSeconds: number;
+}
+
+export interface TransactionEndResponse {
+	id: string;
+	outcome: 'committed' | 'rolled_back';
+	error?: string;
 }
 
 export interface SavedQuery {
@@ -194,6 +376,7 @@ export interface SavedQuery {
 	sql: string;
 	connectionId?: string;
 	description?: string;
+	isFavorite: boolean;
 	createdAt: string;
 	updatedAt: string;
 }
@@ -208,6 +391,8 @@ export interface SavedQueryRequest {
 // CRUD operations
 export interface InsertRowRequest {
 	data: Record<string, unknown>;
+	// Upsert: match on these columns, then skip or update the existing row
+	onConflict?: { columns: string[]; action: 'nothing' | 'update' };
 }
 
 export interface UpdateRowRequest {
@@ -219,11 +404,25 @@ export interface DeleteRowRequest {
 	primaryKey: Record<string, unknown>;
 }
 
+export interface TableFilter {
+	column: string;
+	op: string;
+	value: string;
+	connector?: 'AND' | 'OR';
+}
+
+export interface DeleteByFilterRequest {
+	filters: TableFilter[];
+	dryRun?: boolean;
+}
+
 export interface CrudResponse {
 	success: boolean;
 	rowsAffected: number;
 	message?: string;
 	insertedRow?: Record<string, unknown>;
+	outcome?: 'inserted' | 'updated' | 'skipped';
+	dryRun?: boolean;
 }
 
 export type TabType = 'table' | 'query' | 'view' | 'function' | 'sequence' | 'type' | 'erd' | 'analysis';
@@ -266,6 +465,61 @@ export interface DatabaseStats {
 	activeConnections: number;
 }
 
+export interface RecentTable {
+	schema: string;
+	table: string;
+	accessCount: number;
+	lastAccessedAt: string;
+}
+
+export interface SizeHistory {
+	intervalSeconds: number; // 0 when background sampling is off
+	retentionDays: number;
+	samples: SizeSample[];
+}
+
+export interface SizeSample {
+	sampledAt: string;
+	databaseBytes: number;
+	tables: TableSizeSample[]; // largest first
+}
+
+export interface TableSizeSample {
+	schema: string;
+	table: string;
+	bytes: number;
+}
+
+export interface MaintenanceResult {
+	success: boolean;
+	message: string;
+	command: string;
+	duration: number;
+}
+
+export interface MaintenanceProgress {
+	queryId: string;
+	command: '' | 'vacuum' | 'vacuum full' | 'analyze'; // empty between phases
+	phase: string;
+	blocksTotal: number;
+	blocksDone: number;
+	percent: number;
+}
+
+// A frame from /ws/progress/:operationId for an import, export or
+// maintenance run started with ?operationId=
+export interface ProgressEvent {
+	type: 'progress' | 'done' | 'error';
+	operationId: string;
+	operation: 'import' | 'export' | 'vacuum' | 'analyze';
+	phase?: string;
+	done: number;
+	total?: number; // absent when unknown
+	unit?: 'rows' | 'bytes' | 'blocks';
+	percent?: number;
+	error?: string;
+}
+
 // ERD navigation location
 export interface ERDLocation {
 	schema: string;